@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -12,6 +14,7 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	"github.com/remaimber-it/backend/internal/api"
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
 	"github.com/remaimber-it/backend/internal/grader"
 	"github.com/remaimber-it/backend/internal/infrastructure/config"
 	"github.com/remaimber-it/backend/internal/service"
@@ -29,19 +32,48 @@ import (
 
 func main() {
 	cfg := config.Load()
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	logger := slog.New(api.NewRequestIDLogHandler(slog.NewJSONHandler(os.Stdout, nil)))
 
 	// ── Dependencies ────────────────────────────────────────────────
-	db, err := store.NewSQLite("remaimber.db")
+	db, err := openStore(cfg)
 	if err != nil {
 		logger.Error("failed to open database", "error", err)
 		os.Exit(1)
 	}
 	defer db.Close()
+	db.SetPassThreshold(cfg.MasteryPassThreshold)
+	if cfg.AuditLogMaxEntries > 0 {
+		db = store.NewAuditingStore(db, cfg.AuditLogMaxEntries, logger)
+	}
+
+	gradingSvc := newGradingService(cfg, db, logger)
+	gradingSvc.SetGradingTimeout(cfg.GradingTimeout)
+	gradingSvc.SetStuckThreshold(cfg.GradingStuckThreshold)
+	gradingSvc.SetVerboseGradeLogging(cfg.LogGradeAnswers)
+	gradingSvc.SetStrictGradeParsing(cfg.StrictGradeParsing)
+	gradingSvc.SetScoringCurve(questionbank.ScoringCurve(cfg.ScoringCurve))
+	// Release the grading state of sessions abandoned without completing;
+	// they are reported by GET /sessions/incomplete after the same age.
+	sweepCtx, stopSweep := context.WithCancel(context.Background())
+	defer stopSweep()
+	go gradingSvc.SweepIdleSessions(sweepCtx, 10*time.Minute, cfg.StaleSessionAge)
+	if cfg.MasteryHalfLife > 0 {
+		go gradingSvc.SweepMasteryDecay(sweepCtx, 24*time.Hour, cfg.MasteryHalfLife)
+	}
 
-	llm := grader.NewOllamaGrader(cfg.LLMURL, cfg.LLMModel)
-	gradingSvc := service.NewGradingService(db, llm, llm, logger) // llm implements both Grader and Generator
 	handler := api.NewHandler(db, gradingSvc, logger)
+	handler.SetMaxSessionDuration(cfg.MaxSessionDurationMin)
+	handler.SetStaleSessionAge(cfg.StaleSessionAge)
+	handler.SetAdminToken(cfg.AdminToken)
+	handler.SetImportConcurrency(cfg.ImportConcurrency)
+	handler.SetGradePreviewTimeout(cfg.GradePreviewTimeout)
+	handler.SetMasteryScope(store.MasteryScope(cfg.MasteryScope))
+	handler.SetAnswerRateLimit(cfg.AnswerRateLimit)
+	if n, err := handler.ResumePendingAnswers(context.Background()); err != nil {
+		logger.Error("failed to resume pending answers", "error", err)
+	} else if n > 0 {
+		logger.Info("resumed grading of pending answers", "count", n)
+	}
 
 	// ── Routes ──────────────────────────────────────────────────────
 	mux := http.NewServeMux()
@@ -56,8 +88,13 @@ func main() {
 	// Swagger UI served at /swagger/
 	mux.Handle("GET /swagger/", httpSwagger.WrapHandler)
 
-	// ── Middleware chain: Logging → CORS → mux ──────────────────────
-	logged := api.Logging(logger)(api.CORS(mux))
+	// ── Middleware chain: RequestID → Logging → CORS → API key → mux ─
+	// CORS answers preflights before the API key check, since browsers
+	// send them without credentials.
+	corsOptions := api.DefaultCORSOptions
+	corsOptions.AllowedOrigins = cfg.CORSAllowedOrigins
+	authed := api.RequireAPIKey(cfg.APIKey, cfg.AdminToken)(mux)
+	logged := api.RequestID(api.Logging(logger)(api.CORSWithOptions(corsOptions)(authed)))
 
 	// ── Server ──────────────────────────────────────────────────────
 	server := &http.Server{
@@ -78,6 +115,7 @@ func main() {
 		defer cancel()
 
 		logger.Info("shutting down server")
+		stopSweep()
 		if err := server.Shutdown(ctx); err != nil {
 			logger.Error("server forced to shutdown", "error", err)
 		}
@@ -93,3 +131,37 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// openStore opens the store selected by cfg.StoreDriver.
+func openStore(cfg *config.Config) (store.Store, error) {
+	switch cfg.StoreDriver {
+	case "sqlite":
+		return store.NewSQLite("remaimber.db")
+	case "postgres":
+		if cfg.DatabaseURL == "" {
+			return nil, errors.New("DATABASE_URL is required when STORE_DRIVER=postgres")
+		}
+		return store.NewPostgres(cfg.DatabaseURL)
+	default:
+		return nil, fmt.Errorf("unknown STORE_DRIVER %q (want sqlite or postgres)", cfg.StoreDriver)
+	}
+}
+
+// newGradingService grades with the configured LLM, or offline with the
+// stub grader when cfg.UseStubGrader reports so. The stub cannot generate
+// questions.
+func newGradingService(cfg *config.Config, db store.Store, logger *slog.Logger) *service.GradingService {
+	if cfg.UseStubGrader() {
+		logger.Warn("no LLM configured: grading with the offline stub grader")
+		return service.NewGradingService(db, grader.NewStubGrader(), nil, logger, cfg.LLMMaxConcurrency)
+	}
+
+	llm := grader.NewOllamaGrader(cfg.LLMURL, cfg.LLMModel)
+	llm.SetSimilarityThreshold(cfg.SimilarityThreshold)
+	llm.SetShuffleKeyPoints(cfg.ShuffleKeyPoints, 0)
+	llm.SetStreaming(cfg.StreamGrading)
+	llm.SetStrictParsing(cfg.StrictGradeParsing)
+	llm.SetSampling(cfg.LLMTemperature, cfg.LLMMaxTokens, cfg.LLMTopP)
+	llm.SetBankTypeTimeouts(cfg.LLMTimeoutTheory, cfg.LLMTimeoutCode, cfg.LLMTimeoutCLI)
+	return service.NewGradingService(db, llm, llm, logger, cfg.LLMMaxConcurrency) // llm implements both Grader and Generator
+}
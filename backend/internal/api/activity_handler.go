@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	practicesession "github.com/remaimber-it/backend/internal/domain/practice_session"
+)
+
+const (
+	defaultActivityDays = 365
+	maxActivityDays     = 731
+)
+
+// ── Response types ──────────────────────────────────────────────────────────
+
+type ActivityDay struct {
+	Date              string `json:"date" example:"2025-01-15"`
+	Sessions          int    `json:"sessions" example:"2"`
+	CompletedSessions int    `json:"completed_sessions" example:"1"`
+	QuestionsAnswered int    `json:"questions_answered" example:"14"`
+}
+
+type ActivityResponse struct {
+	From          string        `json:"from" example:"2024-01-16"`
+	To            string        `json:"to" example:"2025-01-15"`
+	CurrentStreak int           `json:"current_streak" example:"4"`  // consecutive active days ending today or yesterday
+	LongestStreak int           `json:"longest_streak" example:"12"` // over all history, not only from..to
+	Days          []ActivityDay `json:"days"`                        // every day from..to, oldest first
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// getActivity reports daily practice activity and streaks.
+// @Summary      Get practice activity
+// @Description  Returns, for every UTC day from `from` to `to`, the sessions started and the questions answered in them, plus the current and longest practice streaks. A day is active when a session started on it was completed. Defaults to the last 365 days; the range may span at most 731 days.
+// @Tags         Stats
+// @Produce      json
+// @Param        from  query     string  false  "First day, YYYY-MM-DD (default 364 days before to)"
+// @Param        to    query     string  false  "Last day, YYYY-MM-DD (default today)"
+// @Success      200   {object}  ActivityResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /stats/activity [get]
+func (h *Handler) getActivity(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	to, ok := parseActivityDate(w, r, "to", practicesession.Day(now))
+	if !ok {
+		return
+	}
+	from, ok := parseActivityDate(w, r, "from", to.AddDate(0, 0, 1-defaultActivityDays))
+	if !ok {
+		return
+	}
+	if from.After(to) {
+		respondError(w, http.StatusBadRequest, "from must not be after to")
+		return
+	}
+	if to.Sub(from) >= maxActivityDays*24*time.Hour {
+		respondError(w, http.StatusBadRequest, "the range may span at most "+strconv.Itoa(maxActivityDays)+" days")
+		return
+	}
+
+	activity, err := h.store.ListSessionActivity(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list session activity", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load activity")
+		return
+	}
+
+	resp := ActivityResponse{From: from.Format(time.DateOnly), To: to.Format(time.DateOnly), Days: []ActivityDay{}}
+	index := make(map[time.Time]int)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		index[d] = len(resp.Days)
+		resp.Days = append(resp.Days, ActivityDay{Date: d.Format(time.DateOnly)})
+	}
+
+	var activeDays []time.Time
+	for _, a := range activity {
+		day := practicesession.Day(a.StartedAt)
+		completed := a.Status == practicesession.SessionStatusCompleted
+		if completed {
+			activeDays = append(activeDays, day)
+		}
+		i, ok := index[day]
+		if !ok {
+			continue
+		}
+		resp.Days[i].Sessions++
+		resp.Days[i].QuestionsAnswered += a.Answered
+		if completed {
+			resp.Days[i].CompletedSessions++
+		}
+	}
+	resp.CurrentStreak, resp.LongestStreak = practicesession.Streaks(activeDays, now)
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// parseActivityDate reads an optional YYYY-MM-DD query parameter as a UTC
+// day, or returns def when it is absent. On an invalid value it writes a
+// 400 response and returns false.
+func parseActivityDate(w http.ResponseWriter, r *http.Request, param string, def time.Time) (time.Time, bool) {
+	v := r.URL.Query().Get(param)
+	if v == "" {
+		return def, true
+	}
+	day, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, param+" must be a date in YYYY-MM-DD format")
+		return time.Time{}, false
+	}
+	return day, true
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+const (
+	defaultGradeFailuresLimit = 50
+	maxGradeFailuresLimit     = 500
+
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type GradeFailureResponse struct {
+	SessionID   string `json:"session_id" example:"s1e2s3s4i5o6n7i8"`
+	QuestionID  string `json:"question_id" example:"q1w2e3r4t5y6u7i8"`
+	UserAnswer  string `json:"user_answer" example:"A goroutine is a lightweight thread."`
+	Reason      string `json:"reason" example:"failed to parse grading response: unexpected end of JSON input"`
+	RawResponse string `json:"raw_response,omitempty" example:"{\"score\": 80, \"covered\": ["`
+	FailedAt    string `json:"failed_at,omitempty" example:"2025-01-15T10:30:00Z"`
+}
+
+type AuditEntryResponse struct {
+	ID         int64     `json:"id" example:"1234"`
+	EntityType string    `json:"entity_type" example:"bank"` // folder, category, bank or question
+	EntityID   string    `json:"entity_id" example:"x9y8z7w6v5u4t3s2"`
+	Action     string    `json:"action" example:"delete"`         // create, update, delete, restore or purge
+	Actor      string    `json:"actor,omitempty" example:"admin"` // omitted when unknown
+	At         time.Time `json:"at" example:"2025-01-15T10:30:00Z"`
+}
+
+type IntegrityResponse struct {
+	Total   int                `json:"total" example:"3"`
+	Orphans []OrphanCountEntry `json:"orphans"` // one entry per checked table, including those without orphans
+}
+
+type OrphanCountEntry struct {
+	Table  string `json:"table" example:"grades"`
+	Parent string `json:"parent" example:"sessions"` // the table the missing parent rows belong in
+	Count  int    `json:"count" example:"3"`
+}
+
+// ── Middleware ──────────────────────────────────────────────────────────────
+
+// requireAdmin only lets requests through that carry the admin token as a
+// bearer token. Without a configured token admin endpoints are disabled.
+// Changes made by admin requests are attributed to "admin" in the audit log.
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.adminToken == "" {
+			respondError(w, http.StatusForbidden, "admin endpoints are disabled")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !tokenMatches(token, h.adminToken) {
+			respondError(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+		next(w, r.WithContext(store.WithAuditActor(r.Context(), "admin")))
+	}
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// listGradeFailures returns recent failed gradings.
+// @Summary      List grade failures
+// @Description  Returns the most recent failed gradings, newest first, with the failure reason and the raw model response when it could not be parsed. Requires the admin token as a bearer token.
+// @Tags         Admin
+// @Produce      json
+// @Param        limit  query     int     false  "Maximum number of failures (default 50, max 500)"
+// @Success      200    {array}   GradeFailureResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      401    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /admin/grade-failures [get]
+func (h *Handler) listGradeFailures(w http.ResponseWriter, r *http.Request) {
+	limit := defaultGradeFailuresLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxGradeFailuresLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxGradeFailuresLimit))
+			return
+		}
+		limit = n
+	}
+
+	failures, err := h.store.ListGradeFailures(r.Context(), limit)
+	if err != nil {
+		h.logger.Error("failed to list grade failures", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load grade failures")
+		return
+	}
+
+	response := make([]GradeFailureResponse, len(failures))
+	for i, f := range failures {
+		response[i] = GradeFailureResponse{
+			SessionID:   f.SessionID,
+			QuestionID:  f.QuestionID,
+			UserAnswer:  f.UserAnswer,
+			Reason:      f.Reason,
+			RawResponse: f.RawResponse,
+		}
+		if !f.FailedAt.IsZero() {
+			response[i].FailedAt = f.FailedAt.Format(time.RFC3339)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// listAuditLog returns recent changes to folders, categories, banks and
+// questions.
+// @Summary      List audit log entries
+// @Description  Returns the most recent creates, updates and deletes of folders, categories, banks and questions, newest first. The log is append-only and keeps the newest AUDIT_LOG_MAX_ENTRIES entries (10000 unless configured; 0 disables it). Practice sessions and grades are not logged. Requires the admin token as a bearer token.
+// @Tags         Admin
+// @Produce      json
+// @Param        limit  query     int     false  "Maximum number of entries (default 50, max 500)"
+// @Success      200    {array}   AuditEntryResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      401    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /admin/audit [get]
+func (h *Handler) listAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAuditLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxAuditLimit))
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.store.ListAuditEntries(r.Context(), limit)
+	if err != nil {
+		h.logger.Error("failed to list audit log", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load audit log")
+		return
+	}
+
+	response := make([]AuditEntryResponse, len(entries))
+	for i, e := range entries {
+		response[i] = AuditEntryResponse{
+			ID:         e.ID,
+			EntityType: e.EntityType,
+			EntityID:   e.EntityID,
+			Action:     e.Action,
+			Actor:      e.Actor,
+			At:         e.At,
+		}
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// checkIntegrity reports orphaned rows.
+// @Summary      Check database integrity
+// @Description  Counts rows whose parent row no longer exists, such as questions of a deleted bank or grades of a deleted session, per table. Nothing is changed. Requires the admin token as a bearer token.
+// @Tags         Admin
+// @Produce      json
+// @Success      200  {object}  IntegrityResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/integrity [get]
+func (h *Handler) checkIntegrity(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.store.FindOrphans(r.Context())
+	if err != nil {
+		h.logger.Error("failed to check integrity", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to check integrity")
+		return
+	}
+	respondJSON(w, http.StatusOK, newIntegrityResponse(counts))
+}
+
+// cleanupIntegrity deletes orphaned rows.
+// @Summary      Remove orphaned rows
+// @Description  Deletes every row GET /admin/integrity counts in one transaction, along with rows orphaned by those deletions, and returns the number of rows removed per table. Requires the admin token as a bearer token.
+// @Tags         Admin
+// @Produce      json
+// @Success      200  {object}  IntegrityResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/integrity/cleanup [post]
+func (h *Handler) cleanupIntegrity(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.store.DeleteOrphans(r.Context())
+	if err != nil {
+		h.logger.Error("failed to remove orphaned rows", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to remove orphaned rows")
+		return
+	}
+	resp := newIntegrityResponse(counts)
+	if resp.Total > 0 {
+		h.logger.Info("removed orphaned rows", "total", resp.Total)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func newIntegrityResponse(counts []store.OrphanCount) IntegrityResponse {
+	resp := IntegrityResponse{Orphans: make([]OrphanCountEntry, len(counts))}
+	for i, c := range counts {
+		resp.Orphans[i] = OrphanCountEntry{Table: c.Table, Parent: c.Parent, Count: c.Count}
+		resp.Total += c.Count
+	}
+	return resp
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/store"
 )
 
 // ── Request / Response types ────────────────────────────────────────────────
@@ -14,6 +17,25 @@ type CreateBankRequest struct {
 	CategoryID *string `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
 	BankType   string  `json:"bank_type,omitempty" example:"theory"`
 	Language   *string `json:"language,omitempty" example:"go"`
+
+	// Grading mode: "llm" (default) or "exact". Exact matching ignores case
+	// and treats runs of whitespace as one space unless the corresponding
+	// *_sensitive flag is set; alternative answers are accepted too.
+	GradingMode              string `json:"grading_mode,omitempty" example:"llm"`
+	ExactCaseSensitive       bool   `json:"exact_case_sensitive,omitempty" example:"false"`
+	ExactWhitespaceSensitive bool   `json:"exact_whitespace_sensitive,omitempty" example:"false"`
+
+	// Scoring curve applied to grades: "linear" or "sqrt". Omitted uses the
+	// server-wide SCORING_CURVE.
+	ScoringCurve string `json:"scoring_curve,omitempty" example:"sqrt"`
+
+	// Serve questions in the order they were added instead of shuffling,
+	// for banks that follow a sequence. Sessions may override it.
+	PreserveOrder bool `json:"preserve_order,omitempty" example:"false"`
+
+	// Default grading criteria for questions without their own rubric. The
+	// expected answer is then shown to the grader as a reference only.
+	Rubric *string `json:"rubric,omitempty" example:"- Names the race condition\n- Proposes a fix"`
 }
 
 func (r *CreateBankRequest) Validate() error {
@@ -23,69 +45,116 @@ func (r *CreateBankRequest) Validate() error {
 	if r.CategoryID == nil || *r.CategoryID == "" {
 		return errors.New("category_id is required")
 	}
-	bt := questionbank.BankType(r.BankType)
-	if r.BankType != "" && bt != questionbank.BankTypeTheory && bt != questionbank.BankTypeCode && bt != questionbank.BankTypeCLI {
+	if r.BankType != "" && !questionbank.BankType(r.BankType).IsValid() {
 		return errors.New("invalid bank_type: must be theory, code, or cli")
 	}
+	if r.Language != nil {
+		bankType := questionbank.BankType(r.BankType)
+		if bankType == "" {
+			bankType = questionbank.BankTypeTheory
+		}
+		if err := bankType.ValidateLanguage(*r.Language); err != nil {
+			return err
+		}
+		if *r.Language == "" {
+			r.Language = nil
+		}
+	}
+	if r.GradingMode != "" && !questionbank.GradingMode(r.GradingMode).IsValid() {
+		return errors.New("invalid grading_mode: must be llm or exact")
+	}
+	if r.ScoringCurve != "" && !questionbank.ScoringCurve(r.ScoringCurve).IsValid() {
+		return errInvalidScoringCurve
+	}
 	return nil
 }
 
+var errInvalidScoringCurve = errors.New("invalid scoring_curve: must be linear or sqrt")
+
 type CreateBankResponse struct {
-	ID            string  `json:"id" example:"x9y8z7w6v5u4t3s2"`
-	Subject       string  `json:"subject" example:"Go concurrency patterns"`
-	CategoryID    *string `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
-	BankType      string  `json:"bank_type" example:"theory"`
-	Language      *string `json:"language,omitempty" example:"go"`
-	Mastery       int     `json:"mastery" example:"0"`
-	QuestionCount int     `json:"question_count" example:"5"`
+	ID              string  `json:"id" example:"x9y8z7w6v5u4t3s2"`
+	Subject         string  `json:"subject" example:"Go concurrency patterns"`
+	CategoryID      *string `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
+	BankType        string  `json:"bank_type" example:"theory"`
+	Language        *string `json:"language,omitempty" example:"go"`
+	GradingMode     string  `json:"grading_mode,omitempty" example:"llm"`
+	Mastery         int     `json:"mastery" example:"0"`
+	QuestionCount   int     `json:"question_count" example:"5"`
+	UnansweredCount int     `json:"unanswered_count" example:"2"` // questions never answered
+	Version         int     `json:"version" example:"3"`
 }
 
 // BankResponse is used when banks appear nested inside a category response.
 type BankResponse struct {
-	ID         string  `json:"id" example:"x9y8z7w6v5u4t3s2"`
-	Subject    string  `json:"subject" example:"Go concurrency patterns"`
-	CategoryID *string `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
-	BankType   string  `json:"bank_type" example:"theory"`
-	Language   *string `json:"language,omitempty" example:"go"`
-	Mastery    int     `json:"mastery" example:"42"`
+	ID              string  `json:"id" example:"x9y8z7w6v5u4t3s2"`
+	Subject         string  `json:"subject" example:"Go concurrency patterns"`
+	CategoryID      *string `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
+	BankType        string  `json:"bank_type" example:"theory"`
+	Language        *string `json:"language,omitempty" example:"go"`
+	Mastery         int     `json:"mastery" example:"42"` // question mastery averaged with difficulty weights
+	UnansweredCount int     `json:"unanswered_count" example:"2"`
+	Version         int     `json:"version" example:"3"`
 }
 
 type GetBankResponse struct {
-	ID         string             `json:"id" example:"x9y8z7w6v5u4t3s2"`
-	Subject    string             `json:"subject" example:"Go concurrency patterns"`
-	CategoryID *string            `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
-	BankType   string             `json:"bank_type" example:"theory"`
-	Language   *string            `json:"language,omitempty" example:"go"`
-	Mastery    int                `json:"mastery" example:"42"`
-	Questions  []QuestionResponse `json:"questions"`
+	ID                       string             `json:"id" example:"x9y8z7w6v5u4t3s2"`
+	Subject                  string             `json:"subject" example:"Go concurrency patterns"`
+	CategoryID               *string            `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
+	BankType                 string             `json:"bank_type" example:"theory"`
+	Language                 *string            `json:"language,omitempty" example:"go"`
+	GradingMode              string             `json:"grading_mode" example:"llm"`
+	ExactCaseSensitive       bool               `json:"exact_case_sensitive" example:"false"`
+	ExactWhitespaceSensitive bool               `json:"exact_whitespace_sensitive" example:"false"`
+	ScoringCurve             string             `json:"scoring_curve,omitempty" example:"sqrt"` // empty when the server default applies
+	PreserveOrder            bool               `json:"preserve_order" example:"false"`
+	Mastery                  int                `json:"mastery" example:"42"`        // question mastery averaged with difficulty weights
+	MasteryScope             string             `json:"mastery_scope" example:"all"` // "all" counts unanswered questions as 0; "answered" leaves them out
+	UnansweredCount          int                `json:"unanswered_count" example:"2"`
+	Tags                     []string           `json:"tags" example:"concurrency,go"`
+	Rubric                   *string            `json:"rubric,omitempty" example:"- Names the race condition\n- Proposes a fix"`
+	Version                  int                `json:"version" example:"3"`
+	Questions                []QuestionResponse `json:"questions"`
 }
 
 type QuestionResponse struct {
-	ID             string  `json:"id" example:"q1w2e3r4t5y6u7i8"`
-	Subject        string  `json:"subject" example:"What is a goroutine?"`
-	ExpectedAnswer string  `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
-	GradingPrompt  *string `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
-	Mastery        int     `json:"mastery" example:"75"`
-	TimesAnswered  int     `json:"times_answered" example:"3"`
-	TimesCorrect   int     `json:"times_correct" example:"2"`
+	ID              string     `json:"id" example:"q1w2e3r4t5y6u7i8"`
+	Subject         string     `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer  string     `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	ExpectedAnswers []string   `json:"expected_answers"` // every acceptable answer, expected_answer first
+	GradingPrompt   *string    `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
+	Rubric          *string    `json:"rubric,omitempty" example:"- Names the race condition\n- Proposes a fix"`
+	GradingMode     *string    `json:"grading_mode,omitempty" example:"exact"`
+	Difficulty      string     `json:"difficulty" example:"medium"`
+	Tags            []string   `json:"tags" example:"channels,goroutines"`
+	Mastery         int        `json:"mastery" example:"75"`
+	TimesAnswered   int        `json:"times_answered" example:"3"`
+	TimesCorrect    int        `json:"times_correct" example:"2"`
+	PassThreshold   int        `json:"pass_threshold" example:"70"` // minimum score counted in times_correct
+	LatestScore     int        `json:"latest_score" example:"90"`
+	LastAnsweredAt  *time.Time `json:"last_answered_at,omitempty" example:"2025-01-01T12:00:00Z"` // omitted when never answered
 }
 
 type UpdateBankCategoryRequest struct {
 	CategoryID *string `json:"category_id" example:"a1b2c3d4e5f6g7h8"`
+	Version    *int    `json:"version,omitempty" example:"3"` // expected current version, required unless If-Match is sent
 }
 
 type BankStatsResponse struct {
 	BankID         string                  `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
-	Mastery        int                     `json:"mastery" example:"42"`
+	Mastery        int                     `json:"mastery" example:"42"`        // question mastery averaged with difficulty weights
+	MasteryScope   string                  `json:"mastery_scope" example:"all"` // "all" counts unanswered questions as 0; "answered" leaves them out
 	TotalQuestions int                     `json:"total_questions" example:"10"`
+	PassThreshold  int                     `json:"pass_threshold" example:"70"` // minimum score counted in times_correct
 	QuestionStats  []QuestionStatsResponse `json:"question_stats"`
 }
 
 type QuestionStatsResponse struct {
-	QuestionID    string `json:"question_id" example:"q1w2e3r4t5y6u7i8"`
-	TimesAnswered int    `json:"times_answered" example:"3"`
-	TimesCorrect  int    `json:"times_correct" example:"2"`
-	Mastery       int    `json:"mastery" example:"75"`
+	QuestionID     string     `json:"question_id" example:"q1w2e3r4t5y6u7i8"`
+	TimesAnswered  int        `json:"times_answered" example:"3"`
+	TimesCorrect   int        `json:"times_correct" example:"2"`
+	Mastery        int        `json:"mastery" example:"75"`
+	LatestScore    int        `json:"latest_score" example:"90"`
+	LastAnsweredAt *time.Time `json:"last_answered_at,omitempty" example:"2025-01-01T12:00:00Z"` // omitted when never answered
 }
 
 // ── Handlers ────────────────────────────────────────────────────────────────
@@ -105,7 +174,7 @@ type QuestionStatsResponse struct {
 func (h *Handler) createBank(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req CreateBankRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
 		return
 	}
 
@@ -120,6 +189,16 @@ func (h *Handler) createBank(w http.ResponseWriter, r *http.Request) {
 	}
 
 	bank := questionbank.NewWithOptions(req.Subject, req.CategoryID, bankType, req.Language)
+	if req.GradingMode != "" {
+		bank.GradingMode = questionbank.GradingMode(req.GradingMode)
+	}
+	bank.ExactMatch = questionbank.ExactMatchOptions{
+		CaseSensitive:       req.ExactCaseSensitive,
+		WhitespaceSensitive: req.ExactWhitespaceSensitive,
+	}
+	bank.ScoringCurve = questionbank.ScoringCurve(req.ScoringCurve)
+	bank.PreserveOrder = req.PreserveOrder
+	bank.Rubric = req.Rubric
 
 	if err := h.store.SaveBank(ctx, bank); err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to save bank")
@@ -127,12 +206,14 @@ func (h *Handler) createBank(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondJSON(w, http.StatusCreated, CreateBankResponse{
-		ID:         bank.ID,
-		Subject:    bank.Subject,
-		CategoryID: bank.CategoryID,
-		BankType:   string(bank.BankType),
-		Language:   bank.Language,
-		Mastery:    0,
+		ID:          bank.ID,
+		Subject:     bank.Subject,
+		CategoryID:  bank.CategoryID,
+		BankType:    string(bank.BankType),
+		Language:    bank.Language,
+		GradingMode: string(bank.GradingMode),
+		Mastery:     0,
+		Version:     bank.Version,
 	})
 }
 
@@ -146,23 +227,30 @@ func (h *Handler) createBank(w http.ResponseWriter, r *http.Request) {
 // @Router       /banks [get]
 func (h *Handler) listBanks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	banks, err := h.store.ListBanksWithCounts(ctx)
+	banks, err := h.store.ListBanksWithMastery(ctx, h.masteryScope)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to load banks")
 		return
 	}
 
+	bankIDs := make([]string, len(banks))
+	for i, bank := range banks {
+		bankIDs[i] = bank.ID
+	}
+	unansweredMap, _ := h.store.GetUnansweredCountBatch(ctx, bankIDs)
+
 	response := make([]CreateBankResponse, len(banks))
 	for i, bank := range banks {
-		mastery, _ := h.store.GetBankMastery(ctx, bank.ID)
 		response[i] = CreateBankResponse{
-			ID:            bank.ID,
-			Subject:       bank.Subject,
-			CategoryID:    bank.CategoryID,
-			BankType:      string(bank.BankType),
-			Language:      bank.Language,
-			Mastery:       mastery,
-			QuestionCount: bank.QuestionCount,
+			ID:              bank.ID,
+			Subject:         bank.Subject,
+			CategoryID:      bank.CategoryID,
+			BankType:        string(bank.BankType),
+			Language:        bank.Language,
+			Mastery:         bank.Mastery,
+			QuestionCount:   bank.QuestionCount,
+			UnansweredCount: unansweredMap[bank.ID],
+			Version:         bank.Version,
 		}
 	}
 
@@ -171,18 +259,25 @@ func (h *Handler) listBanks(w http.ResponseWriter, r *http.Request) {
 
 // getBank returns a single bank with its questions.
 // @Summary      Get a question bank
-// @Description  Returns a question bank with all its questions and their stats.
+// @Description  Returns a question bank with all its questions and their stats. See GET /banks/{bankID}/stats for mastery_scope.
 // @Tags         Banks
 // @Produce      json
-// @Param        bankID  path      string  true  "Bank ID"
-// @Success      200     {object}  GetBankResponse
-// @Failure      404     {object}  map[string]string
-// @Failure      500     {object}  map[string]string
+// @Param        bankID         path      string  true   "Bank ID"
+// @Param        mastery_scope  query     string  false  "Questions counted in mastery: all (unanswered as 0) or answered; defaults to MASTERY_SCOPE"
+// @Success      200            {object}  GetBankResponse
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Failure      500            {object}  map[string]string
 // @Router       /banks/{bankID} [get]
 func (h *Handler) getBank(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	bankID := r.PathValue("bankID")
 
+	scope, ok := h.parseMasteryScope(w, r)
+	if !ok {
+		return
+	}
+
 	bank, err := h.store.GetBank(ctx, bankID)
 	if h.handleStoreError(w, err, "bank") {
 		return
@@ -199,42 +294,65 @@ func (h *Handler) getBank(w http.ResponseWriter, r *http.Request) {
 	questions := make([]QuestionResponse, len(bank.Questions))
 	for i, q := range bank.Questions {
 		qStats := statsMap[q.ID]
-		var mastery, timesAnswered, timesCorrect int
+		var mastery, timesAnswered, timesCorrect, latestScore int
 		if qStats != nil {
 			mastery = qStats.Mastery
 			timesAnswered = qStats.TimesAnswered
 			timesCorrect = qStats.TimesCorrect
+			latestScore = qStats.LatestScore
 		}
 		questions[i] = QuestionResponse{
-			ID:             q.ID,
-			Subject:        q.Subject,
-			ExpectedAnswer: q.ExpectedAnswer,
-			GradingPrompt:  q.GradingPrompt,
-			Mastery:        mastery,
-			TimesAnswered:  timesAnswered,
-			TimesCorrect:   timesCorrect,
+			ID:              q.ID,
+			Subject:         q.Subject,
+			ExpectedAnswer:  q.ExpectedAnswer,
+			ExpectedAnswers: q.ExpectedAnswers(),
+			GradingPrompt:   q.GradingPrompt,
+			Rubric:          q.Rubric,
+			GradingMode:     gradingModeString(q.GradingMode),
+			Difficulty:      string(q.Difficulty.OrDefault()),
+			Tags:            q.Tags,
+			Mastery:         mastery,
+			TimesAnswered:   timesAnswered,
+			TimesCorrect:    timesCorrect,
+			PassThreshold:   h.store.PassThreshold(),
+			LatestScore:     latestScore,
+			LastAnsweredAt:  lastAnsweredAt(qStats),
 		}
 	}
 
-	bankMastery, _ := h.store.GetBankMastery(ctx, bankID)
+	bankMastery, _ := h.store.GetBankMasteryScoped(ctx, bankID, scope)
+	unansweredMap, _ := h.store.GetUnansweredCountBatch(ctx, []string{bankID})
 
+	setETag(w, bank.Version)
 	respondJSON(w, http.StatusOK, GetBankResponse{
-		ID:         bank.ID,
-		Subject:    bank.Subject,
-		CategoryID: bank.CategoryID,
-		BankType:   string(bank.BankType),
-		Language:   bank.Language,
-		Mastery:    bankMastery,
-		Questions:  questions,
+		ID:                       bank.ID,
+		Subject:                  bank.Subject,
+		CategoryID:               bank.CategoryID,
+		BankType:                 string(bank.BankType),
+		Language:                 bank.Language,
+		GradingMode:              string(bank.GradingMode),
+		ExactCaseSensitive:       bank.ExactMatch.CaseSensitive,
+		ExactWhitespaceSensitive: bank.ExactMatch.WhitespaceSensitive,
+		ScoringCurve:             string(bank.ScoringCurve),
+		PreserveOrder:            bank.PreserveOrder,
+		Rubric:                   bank.Rubric,
+		Mastery:                  bankMastery,
+		MasteryScope:             string(scope),
+		UnansweredCount:          unansweredMap[bankID],
+		Tags:                     bank.Tags,
+		Questions:                questions,
+		Version:                  bank.Version,
 	})
 }
 
-// deleteBank removes a question bank and its questions.
+// deleteBank moves a question bank to the trash, or removes it for good.
 // @Summary      Delete a question bank
-// @Description  Delete a bank and cascade-delete all its questions and stats.
+// @Description  Move a bank and its questions to the trash, keeping their stats; see GET /trash and POST /banks/{bankID}/restore. With permanent=true, delete the bank, trashed or not, and cascade-delete all its questions and stats instead.
 // @Tags         Banks
-// @Param        bankID  path  string  true  "Bank ID"
+// @Param        bankID     path   string  true   "Bank ID"
+// @Param        permanent  query  bool    false  "Delete for good instead of moving to the trash"
 // @Success      204
+// @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /banks/{bankID} [delete]
@@ -242,7 +360,15 @@ func (h *Handler) deleteBank(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	bankID := r.PathValue("bankID")
 
-	if h.handleStoreError(w, h.store.DeleteBank(ctx, bankID), "bank") {
+	permanent, ok := parsePermanent(w, r)
+	if !ok {
+		return
+	}
+	remove := h.store.DeleteBank
+	if permanent {
+		remove = h.store.PurgeBank
+	}
+	if h.handleStoreError(w, remove(ctx, bankID), "bank") {
 		return
 	}
 
@@ -260,13 +386,20 @@ func (h *Handler) deleteBank(w http.ResponseWriter, r *http.Request) {
 // @Success      200     {object}  CreateBankResponse
 // @Failure      400     {object}  map[string]string
 // @Failure      404     {object}  map[string]string
+// @Failure      409     {object}  map[string]string  "bank was modified by another request"
+// @Failure      412     {object}  map[string]string  "If-Match is a weak entity tag"
+// @Failure      428     {object}  map[string]string  "no version given"
 // @Router       /banks/{bankID}/category [patch]
 func (h *Handler) updateBankCategory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	bankID := r.PathValue("bankID")
 
 	var req UpdateBankCategoryRequest
-	if !decodeJSON(w, r, &req) {
+	if !decodeJSON(w, r, &req, strictFields) {
+		return
+	}
+	version, ok := expectedVersion(w, r, req.Version)
+	if !ok {
 		return
 	}
 
@@ -277,37 +410,87 @@ func (h *Handler) updateBankCategory(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if h.handleStoreError(w, h.store.UpdateBankCategory(ctx, bankID, req.CategoryID), "bank") {
+	if h.handleStoreError(w, h.store.UpdateBankCategory(ctx, bankID, req.CategoryID, version), "bank") {
 		return
 	}
 
 	bank, _ := h.store.GetBank(ctx, bankID)
-	mastery, _ := h.store.GetBankMastery(ctx, bankID)
+	mastery, _ := h.store.GetBankMasteryScoped(ctx, bankID, h.masteryScope)
+	unansweredMap, _ := h.store.GetUnansweredCountBatch(ctx, []string{bankID})
 
+	setETag(w, bank.Version)
 	respondJSON(w, http.StatusOK, CreateBankResponse{
-		ID:         bank.ID,
-		Subject:    bank.Subject,
-		CategoryID: bank.CategoryID,
-		BankType:   string(bank.BankType),
-		Language:   bank.Language,
-		Mastery:    mastery,
+		ID:              bank.ID,
+		Subject:         bank.Subject,
+		CategoryID:      bank.CategoryID,
+		BankType:        string(bank.BankType),
+		Language:        bank.Language,
+		Mastery:         mastery,
+		QuestionCount:   len(bank.Questions),
+		UnansweredCount: unansweredMap[bankID],
+		Version:         bank.Version,
 	})
 }
 
 // getBankStats returns mastery statistics for a bank.
 // @Summary      Get bank stats
-// @Description  Returns mastery and per-question statistics for a bank.
+// @Description  Returns mastery and per-question statistics for a bank. Bank mastery averages question mastery weighted by difficulty (easy 1, medium 2, hard 3). With mastery_scope=all never-answered questions count as 0, so adding questions lowers mastery; with mastery_scope=answered they are left out, and a bank with no answers has mastery 0. The default comes from MASTERY_SCOPE.
 // @Tags         Banks
 // @Produce      json
-// @Param        bankID  path      string  true  "Bank ID"
-// @Success      200     {object}  BankStatsResponse
-// @Failure      404     {object}  map[string]string
-// @Failure      500     {object}  map[string]string
+// @Param        bankID         path      string  true   "Bank ID"
+// @Param        mastery_scope  query     string  false  "Questions counted in mastery: all (unanswered as 0) or answered"
+// @Success      200            {object}  BankStatsResponse
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Failure      500            {object}  map[string]string
 // @Router       /banks/{bankID}/stats [get]
 func (h *Handler) getBankStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	bankID := r.PathValue("bankID")
 
+	scope, ok := h.parseMasteryScope(w, r)
+	if !ok {
+		return
+	}
+
+	h.respondBankStats(w, ctx, bankID, scope)
+}
+
+// resetBankStats zeroes the stats of every question in a bank.
+// @Summary      Reset a bank's stats
+// @Description  Zeroes mastery, answer counts and latest scores of every question in the bank and returns the fresh stats as GET /banks/{bankID}/stats does. Past grades and sessions are kept.
+// @Tags         Banks
+// @Produce      json
+// @Param        bankID         path      string  true   "Bank ID"
+// @Param        mastery_scope  query     string  false  "Questions counted in mastery: all (unanswered as 0) or answered"
+// @Success      200            {object}  BankStatsResponse
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Failure      500            {object}  map[string]string
+// @Router       /banks/{bankID}/stats [delete]
+func (h *Handler) resetBankStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+
+	scope, ok := h.parseMasteryScope(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.GetBank(ctx, bankID); h.handleStoreError(w, err, "bank") {
+		return
+	}
+	if err := h.store.ResetBankStats(ctx, bankID); err != nil {
+		h.logger.Error("failed to reset bank stats", "bank_id", bankID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to reset stats")
+		return
+	}
+
+	h.respondBankStats(w, ctx, bankID, scope)
+}
+
+// respondBankStats writes a bank's mastery and per-question stats.
+func (h *Handler) respondBankStats(w http.ResponseWriter, ctx context.Context, bankID string, scope store.MasteryScope) {
 	bank, err := h.store.GetBank(ctx, bankID)
 	if h.handleStoreError(w, err, "bank") {
 		return
@@ -322,19 +505,23 @@ func (h *Handler) getBankStats(w http.ResponseWriter, r *http.Request) {
 	questionStats := make([]QuestionStatsResponse, len(stats))
 	for i, s := range stats {
 		questionStats[i] = QuestionStatsResponse{
-			QuestionID:    s.QuestionID,
-			TimesAnswered: s.TimesAnswered,
-			TimesCorrect:  s.TimesCorrect,
-			Mastery:       s.Mastery,
+			QuestionID:     s.QuestionID,
+			TimesAnswered:  s.TimesAnswered,
+			TimesCorrect:   s.TimesCorrect,
+			Mastery:        s.Mastery,
+			LatestScore:    s.LatestScore,
+			LastAnsweredAt: lastAnsweredAt(&s),
 		}
 	}
 
-	mastery, _ := h.store.GetBankMastery(ctx, bankID)
+	mastery, _ := h.store.GetBankMasteryScoped(ctx, bankID, scope)
 
 	respondJSON(w, http.StatusOK, BankStatsResponse{
 		BankID:         bankID,
 		Mastery:        mastery,
+		MasteryScope:   string(scope),
 		TotalQuestions: len(bank.Questions),
+		PassThreshold:  h.store.PassThreshold(),
 		QuestionStats:  questionStats,
 	})
 }
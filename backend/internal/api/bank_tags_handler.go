@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+// maxBulkTagBanks caps the number of banks one bulk-tag request may touch.
+const maxBulkTagBanks = 500
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type BulkTagBanksRequest struct {
+	BankIDs []string `json:"bank_ids" example:"x9y8z7w6v5u4t3s2,a1b2c3d4e5f6g7h8"`
+	Add     []string `json:"add,omitempty" example:"go,concurrency"`
+	Remove  []string `json:"remove,omitempty" example:"draft"`
+}
+
+// Validate checks the request and normalizes its tags in place.
+func (r *BulkTagBanksRequest) Validate() error {
+	if len(r.BankIDs) == 0 {
+		return errors.New("bank_ids is required")
+	}
+	if len(r.BankIDs) > maxBulkTagBanks {
+		return fmt.Errorf("bank_ids cannot contain more than %d banks", maxBulkTagBanks)
+	}
+	for _, id := range r.BankIDs {
+		if id == "" {
+			return errors.New("bank_ids cannot contain empty IDs")
+		}
+	}
+	if len(r.Add) == 0 && len(r.Remove) == 0 {
+		return errors.New("at least one of add or remove is required")
+	}
+
+	var err error
+	if r.Add, err = normalizeTags(r.Add); err != nil {
+		return err
+	}
+	if r.Remove, err = normalizeTags(r.Remove); err != nil {
+		return err
+	}
+	for _, tag := range r.Add {
+		for _, other := range r.Remove {
+			if tag == other {
+				return fmt.Errorf("tag %q cannot be both added and removed", tag)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeTags normalizes and validates tags, dropping duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = questionbank.NormalizeTag(tag)
+		if err := questionbank.ValidateTag(tag); err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+type BulkTagBanksResponse struct {
+	TagsAdded   int             `json:"tags_added" example:"3"`   // bank/tag pairs that did not exist before
+	TagsRemoved int             `json:"tags_removed" example:"1"` // bank/tag pairs that existed before
+	Banks       []BankTagsEntry `json:"banks"`
+}
+
+type BankTagsEntry struct {
+	BankID string   `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	Tags   []string `json:"tags" example:"concurrency,go"`
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// bulkTagBanks adds and removes tags on several banks at once.
+// @Summary      Bulk-tag banks
+// @Description  Add and remove tags on a set of banks in one transaction. Tags are trimmed and lowercased; adding a tag a bank already has, or removing one it lacks, is a no-op. If any bank ID is unknown nothing is changed and the unknown IDs are returned in missing_bank_ids.
+// @Tags         Banks
+// @Accept       json
+// @Produce      json
+// @Param        body  body      BulkTagBanksRequest   true  "Banks and tags"
+// @Success      200   {object}  BulkTagBanksResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]interface{}  "unknown bank IDs"
+// @Failure      500   {object}  map[string]string
+// @Router       /banks/tags [post]
+func (h *Handler) bulkTagBanks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req BulkTagBanksRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	banks, err := h.store.ListBanks(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list banks")
+		return
+	}
+	known := make(map[string]bool, len(banks))
+	for _, bank := range banks {
+		known[bank.ID] = true
+	}
+	var missing []string
+	for _, id := range req.BankIDs {
+		if !known[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		respondJSON(w, http.StatusNotFound, map[string]any{
+			"error":            "banks not found",
+			"missing_bank_ids": missing,
+		})
+		return
+	}
+
+	update, err := h.store.UpdateBankTags(ctx, req.BankIDs, req.Add, req.Remove)
+	if errors.Is(err, store.ErrNotFound) {
+		// A bank was deleted between the check above and the update.
+		respondError(w, http.StatusNotFound, "bank not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to update tags")
+		return
+	}
+
+	resp := BulkTagBanksResponse{
+		TagsAdded:   update.Added,
+		TagsRemoved: update.Removed,
+		Banks:       make([]BankTagsEntry, 0, len(update.Tags)),
+	}
+	for _, id := range req.BankIDs {
+		tags, ok := update.Tags[id]
+		if !ok {
+			continue
+		}
+		resp.Banks = append(resp.Banks, BankTagsEntry{BankID: id, Tags: tags})
+		delete(update.Tags, id) // list repeated IDs once
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
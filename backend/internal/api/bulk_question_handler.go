@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+// BulkAddQuestionsResponse reports the outcome of a bulk upload. Rows are
+// numbered from 1 in upload order, not counting a CSV header.
+type BulkAddQuestionsResponse struct {
+	Created int            `json:"created" example:"48"`
+	Skipped int            `json:"skipped" example:"2"`
+	Errors  []BulkRowError `json:"errors"`
+	Error   string         `json:"error,omitempty" example:"2 invalid rows; nothing was imported"` // set when strict mode rejects the upload
+}
+
+type BulkRowError struct {
+	Row   int    `json:"row" example:"3"`
+	Error string `json:"error" example:"expected_answer is required"`
+}
+
+// csvQuestionColumns are the columns a CSV upload may have. Subject and
+// expected_answer are required.
+var csvQuestionColumns = []string{"subject", "expected_answer", "difficulty", "grading_prompt", "rubric"}
+
+// parseQuestionsCSV reads a CSV body with a header row naming its columns.
+// A row with the wrong number of fields becomes an invalid request, so it
+// is reported like any other row error.
+func parseQuestionsCSV(body io.Reader) ([]AddQuestionRequest, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, col := range csvQuestionColumns {
+			known = known || name == col
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown csv column %q: want %s", name, strings.Join(csvQuestionColumns, ", "))
+		}
+		index[name] = i
+	}
+	for _, required := range csvQuestionColumns[:2] {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("csv header must include %q", required)
+		}
+	}
+
+	var rows []AddQuestionRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		field := func(name string) (string, bool) {
+			i, ok := index[name]
+			if !ok || i >= len(record) {
+				return "", false
+			}
+			return strings.TrimSpace(record[i]), true
+		}
+		var row AddQuestionRequest
+		row.Subject, _ = field("subject")
+		row.ExpectedAnswer, _ = field("expected_answer")
+		if v, ok := field("difficulty"); ok && v != "" {
+			row.Difficulty = &v
+		}
+		if v, ok := field("grading_prompt"); ok && v != "" {
+			row.GradingPrompt = &v
+		}
+		if v, ok := field("rubric"); ok && v != "" {
+			row.Rubric = &v
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// addQuestionsBulk adds many questions to a bank at once.
+// @Summary      Bulk-add questions
+// @Description  Add questions from a JSON array of question objects, or from a text/csv body whose header names its columns: subject and expected_answer, optionally difficulty, grading_prompt and rubric. Valid rows are saved in one transaction and invalid rows are skipped and reported. With strict=true any invalid row rejects the whole upload with 400. Banks in the system "Deleted" folder reject uploads with 409.
+// @Tags         Questions
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Param        bankID  path      string                 true   "Bank ID"
+// @Param        strict  query     bool                   false  "Reject the upload if any row is invalid"
+// @Param        body    body      []AddQuestionRequest   true   "Questions"
+// @Success      201     {object}  BulkAddQuestionsResponse
+// @Failure      400     {object}  BulkAddQuestionsResponse
+// @Failure      404     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Failure      413     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /banks/{bankID}/questions/bulk [post]
+func (h *Handler) addQuestionsBulk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+
+	strict := false
+	if v := r.URL.Query().Get("strict"); v != "" {
+		if v != "true" && v != "false" {
+			respondError(w, http.StatusBadRequest, "strict must be true or false")
+			return
+		}
+		strict = v == "true"
+	}
+
+	bank, err := h.store.GetBank(ctx, bankID)
+	if h.handleStoreError(w, err, "bank") {
+		return
+	}
+	trashed, err := h.isBankTrashed(ctx, bank)
+	if h.handleStoreError(w, err, "bank") {
+		return
+	}
+	if trashed {
+		respondError(w, http.StatusConflict, "cannot add questions to a bank in the Deleted folder")
+		return
+	}
+
+	var rows []AddQuestionRequest
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		rows, err = parseQuestionsCSV(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else if !decodeJSON(w, r, &rows, strictFields) {
+		return
+	}
+	if len(rows) == 0 {
+		respondError(w, http.StatusBadRequest, "no questions found")
+		return
+	}
+
+	resp := BulkAddQuestionsResponse{Errors: []BulkRowError{}}
+	var questions []questionbank.Question
+	for i, row := range rows {
+		if err := row.Validate(); err != nil {
+			resp.Errors = append(resp.Errors, BulkRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		expected, alternatives := splitExpectedAnswers(row.ExpectedAnswer, row.ExpectedAnswers)
+		if err := bank.AddQuestionWithGradingPrompt(row.Subject, expected, row.GradingPrompt); err != nil {
+			resp.Errors = append(resp.Errors, BulkRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		q := bank.Questions[len(bank.Questions)-1]
+		q.AlternativeAnswers = alternatives
+		q.GradingMode = parseGradingMode(row.GradingMode)
+		q.Rubric = row.Rubric
+		if row.Difficulty != nil {
+			q.Difficulty = questionbank.Difficulty(*row.Difficulty)
+		}
+		questions = append(questions, q)
+	}
+	resp.Skipped = len(resp.Errors)
+
+	if strict && resp.Skipped > 0 {
+		resp.Error = fmt.Sprintf("%d invalid rows; nothing was imported", resp.Skipped)
+		respondJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	if len(questions) > 0 {
+		if err := h.store.AddQuestions(ctx, bankID, questions); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to save questions")
+			return
+		}
+	}
+	resp.Created = len(questions)
+	respondJSON(w, http.StatusCreated, resp)
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/remaimber-it/backend/internal/domain/category"
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/store"
 )
 
 // ── Request / Response types ────────────────────────────────────────────────
@@ -27,6 +29,7 @@ type CategoryResponse struct {
 	FolderID  *string `json:"folder_id,omitempty" example:"f1o2l3d4e5r6i7d8"`
 	Mastery   int     `json:"mastery" example:"42"`
 	SortOrder int     `json:"sort_order" example:"0"`
+	Version   int     `json:"version" example:"3"`
 }
 
 type GetCategoryResponse struct {
@@ -34,11 +37,13 @@ type GetCategoryResponse struct {
 	Name     string         `json:"name" example:"Golang"`
 	FolderID *string        `json:"folder_id,omitempty" example:"f1o2l3d4e5r6i7d8"`
 	Mastery  int            `json:"mastery" example:"42"`
+	Version  int            `json:"version" example:"3"`
 	Banks    []BankResponse `json:"banks"`
 }
 
 type UpdateCategoryRequest struct {
-	Name string `json:"name" example:"Rust"`
+	Name    string `json:"name" example:"Rust"`
+	Version *int   `json:"version,omitempty" example:"3"` // expected current version, required unless If-Match is sent
 }
 
 func (r *UpdateCategoryRequest) Validate() error {
@@ -48,8 +53,32 @@ func (r *UpdateCategoryRequest) Validate() error {
 	return nil
 }
 
+// PatchCategoryRequest updates any subset of a category's fields.
+// Omitted fields keep their current value; folder_id may be null to unfile.
+type PatchCategoryRequest struct {
+	Name     *string        `json:"name,omitempty" example:"Rust"`
+	FolderID optionalString `json:"folder_id" swaggertype:"string" example:"f1o2l3d4e5r6i7d8"`
+	Version  *int           `json:"version,omitempty" example:"3"` // expected current version, required unless If-Match is sent
+}
+
+func (r *PatchCategoryRequest) Validate() error {
+	if r.Name == nil && !r.FolderID.Set {
+		return errors.New("at least one field is required")
+	}
+	if r.Name != nil && *r.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+	return nil
+}
+
 type UpdateCategoryFolderRequest struct {
 	FolderID *string `json:"folder_id" example:"f1o2l3d4e5r6i7d8"`
+	Version  *int    `json:"version,omitempty" example:"3"` // expected current version, required unless If-Match is sent
+}
+
+type CloneCategoryRequest struct {
+	Name     *string        `json:"name,omitempty" example:"Golang (copy)"`                    // defaults to the source name with " (copy)"
+	FolderID optionalString `json:"folder_id" swaggertype:"string" example:"f1o2l3d4e5r6i7d8"` // omit to keep the source folder, null to unfile
 }
 
 type ReorderCategoriesRequest struct {
@@ -59,6 +88,7 @@ type ReorderCategoriesRequest struct {
 type CategoryStatsResponse struct {
 	CategoryID string `json:"category_id" example:"a1b2c3d4e5f6g7h8"`
 	Mastery    int    `json:"mastery" example:"42"`
+	Weighting  string `json:"weighting" example:"questions"`
 }
 
 // ── Handlers ────────────────────────────────────────────────────────────────
@@ -78,7 +108,7 @@ type CategoryStatsResponse struct {
 func (h *Handler) createCategory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req CreateCategoryRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
 		return
 	}
 
@@ -106,6 +136,7 @@ func (h *Handler) createCategory(w http.ResponseWriter, r *http.Request) {
 		FolderID:  cat.FolderID,
 		Mastery:   0,
 		SortOrder: cat.SortOrder,
+		Version:   cat.Version,
 	})
 }
 
@@ -119,26 +150,21 @@ func (h *Handler) createCategory(w http.ResponseWriter, r *http.Request) {
 // @Router       /categories [get]
 func (h *Handler) listCategories(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	categories, err := h.store.ListCategories(ctx)
+	categories, err := h.store.ListCategoriesWithMastery(ctx)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to load categories")
 		return
 	}
 
-	ids := make([]string, len(categories))
-	for i, cat := range categories {
-		ids[i] = cat.ID
-	}
-	masteryMap, _ := h.store.GetCategoryMasteryBatch(ctx, ids)
-
 	response := make([]CategoryResponse, len(categories))
 	for i, cat := range categories {
 		response[i] = CategoryResponse{
 			ID:        cat.ID,
 			Name:      cat.Name,
 			FolderID:  cat.FolderID,
-			Mastery:   masteryMap[cat.ID],
+			Mastery:   cat.Mastery,
 			SortOrder: cat.SortOrder,
+			Version:   cat.Version,
 		}
 	}
 
@@ -164,7 +190,7 @@ func (h *Handler) getCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	banks, err := h.store.ListBanksByCategory(ctx, categoryID)
+	banks, err := h.store.ListBanksByCategoryWithMastery(ctx, categoryID, h.masteryScope)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to load banks")
 		return
@@ -174,28 +200,32 @@ func (h *Handler) getCategory(w http.ResponseWriter, r *http.Request) {
 	for i, bank := range banks {
 		bankIDs[i] = bank.ID
 	}
-	bankMasteryMap, _ := h.store.GetBankMasteryBatch(ctx, bankIDs)
+	unansweredMap, _ := h.store.GetUnansweredCountBatch(ctx, bankIDs)
 
 	bankResponses := make([]BankResponse, len(banks))
 	for i, bank := range banks {
 		bankResponses[i] = BankResponse{
-			ID:         bank.ID,
-			Subject:    bank.Subject,
-			CategoryID: bank.CategoryID,
-			BankType:   string(bank.BankType),
-			Language:   bank.Language,
-			Mastery:    bankMasteryMap[bank.ID],
+			ID:              bank.ID,
+			Subject:         bank.Subject,
+			CategoryID:      bank.CategoryID,
+			BankType:        bank.BankType,
+			Language:        bank.Language,
+			Mastery:         bank.Mastery,
+			UnansweredCount: unansweredMap[bank.ID],
+			Version:         bank.Version,
 		}
 	}
 
 	categoryMastery, _ := h.store.GetCategoryMastery(ctx, categoryID)
 
+	setETag(w, cat.Version)
 	respondJSON(w, http.StatusOK, GetCategoryResponse{
 		ID:       cat.ID,
 		Name:     cat.Name,
 		FolderID: cat.FolderID,
 		Mastery:  categoryMastery,
 		Banks:    bankResponses,
+		Version:  cat.Version,
 	})
 }
 
@@ -210,13 +240,20 @@ func (h *Handler) getCategory(w http.ResponseWriter, r *http.Request) {
 // @Success      200         {object}  CategoryResponse
 // @Failure      400         {object}  map[string]string
 // @Failure      404         {object}  map[string]string
+// @Failure      409         {object}  map[string]string  "category was modified by another request"
+// @Failure      412         {object}  map[string]string  "If-Match is a weak entity tag"
+// @Failure      428         {object}  map[string]string  "no version given"
 // @Router       /categories/{categoryID} [put]
 func (h *Handler) updateCategory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	categoryID := r.PathValue("categoryID")
 
 	var req UpdateCategoryRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+	version, ok := expectedVersion(w, r, req.Version)
+	if !ok {
 		return
 	}
 
@@ -231,20 +268,100 @@ func (h *Handler) updateCategory(w http.ResponseWriter, r *http.Request) {
 		Name:      req.Name,
 		FolderID:  existing.FolderID,
 		SortOrder: existing.SortOrder,
+		Version:   version,
+	}
+
+	if h.handleStoreError(w, h.store.UpdateCategory(ctx, cat), "category") {
+		return
+	}
+	cat, err = h.store.GetCategory(ctx, categoryID)
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
+
+	mastery, _ := h.store.GetCategoryMastery(ctx, categoryID)
+
+	setETag(w, cat.Version)
+	respondJSON(w, http.StatusOK, CategoryResponse{
+		ID:        cat.ID,
+		Name:      cat.Name,
+		FolderID:  cat.FolderID,
+		Mastery:   mastery,
+		SortOrder: cat.SortOrder,
+		Version:   cat.Version,
+	})
+}
+
+// patchCategory applies a partial update to a category.
+// @Summary      Partially update a category
+// @Description  Update any subset of a category's fields (name, folder_id). Omitted fields are left unchanged; folder_id may be null to unfile the category.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Param        categoryID  path      string                true  "Category ID"
+// @Param        body        body      PatchCategoryRequest  true  "Fields to update"
+// @Success      200         {object}  CategoryResponse
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string  "category or folder not found"
+// @Failure      409         {object}  map[string]string  "category was modified by another request"
+// @Failure      412         {object}  map[string]string  "If-Match is a weak entity tag"
+// @Failure      428         {object}  map[string]string  "no version given"
+// @Router       /categories/{categoryID} [patch]
+func (h *Handler) patchCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	categoryID := r.PathValue("categoryID")
+
+	var req PatchCategoryRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+	version, ok := expectedVersion(w, r, req.Version)
+	if !ok {
+		return
 	}
 
+	cat, err := h.store.GetCategory(ctx, categoryID)
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
+
+	if req.Name != nil {
+		cat.Name = *req.Name
+	}
+
+	if req.FolderID.Set {
+		folderID := req.FolderID.Value
+		if folderID != nil && *folderID == "" {
+			folderID = nil
+		}
+		if folderID != nil {
+			_, err := h.store.GetFolder(ctx, *folderID)
+			if h.handleStoreError(w, err, "folder") {
+				return
+			}
+		}
+		cat.SetFolder(folderID)
+	}
+
+	cat.Version = version
 	if h.handleStoreError(w, h.store.UpdateCategory(ctx, cat), "category") {
 		return
 	}
+	cat, err = h.store.GetCategory(ctx, categoryID)
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
 
 	mastery, _ := h.store.GetCategoryMastery(ctx, categoryID)
 
+	setETag(w, cat.Version)
 	respondJSON(w, http.StatusOK, CategoryResponse{
 		ID:        cat.ID,
 		Name:      cat.Name,
 		FolderID:  cat.FolderID,
 		Mastery:   mastery,
 		SortOrder: cat.SortOrder,
+		Version:   cat.Version,
 	})
 }
 
@@ -259,13 +376,20 @@ func (h *Handler) updateCategory(w http.ResponseWriter, r *http.Request) {
 // @Success      200         {object}  CategoryResponse
 // @Failure      400         {object}  map[string]string
 // @Failure      404         {object}  map[string]string
+// @Failure      409         {object}  map[string]string  "category was modified by another request"
+// @Failure      412         {object}  map[string]string  "If-Match is a weak entity tag"
+// @Failure      428         {object}  map[string]string  "no version given"
 // @Router       /categories/{categoryID}/folder [patch]
 func (h *Handler) updateCategoryFolder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	categoryID := r.PathValue("categoryID")
 
 	var req UpdateCategoryFolderRequest
-	if !decodeJSON(w, r, &req) {
+	if !decodeJSON(w, r, &req, strictFields) {
+		return
+	}
+	version, ok := expectedVersion(w, r, req.Version)
+	if !ok {
 		return
 	}
 
@@ -277,7 +401,7 @@ func (h *Handler) updateCategoryFolder(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if h.handleStoreError(w, h.store.UpdateCategoryFolder(ctx, categoryID, req.FolderID), "category") {
+	if h.handleStoreError(w, h.store.UpdateCategoryFolder(ctx, categoryID, req.FolderID, version), "category") {
 		return
 	}
 
@@ -287,12 +411,109 @@ func (h *Handler) updateCategoryFolder(w http.ResponseWriter, r *http.Request) {
 	}
 	mastery, _ := h.store.GetCategoryMastery(ctx, categoryID)
 
+	setETag(w, cat.Version)
 	respondJSON(w, http.StatusOK, CategoryResponse{
 		ID:        cat.ID,
 		Name:      cat.Name,
 		FolderID:  cat.FolderID,
 		Mastery:   mastery,
 		SortOrder: cat.SortOrder,
+		Version:   cat.Version,
+	})
+}
+
+// cloneCategory deep-copies a category with its banks and questions.
+// @Summary      Clone a category
+// @Description  Copy a category and all its banks and questions under new IDs, optionally into a different folder. Stats are not copied, so every question in the copy starts unanswered.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Param        categoryID  path      string                true   "Category ID"
+// @Param        body        body      CloneCategoryRequest  false  "Name and target folder of the copy"
+// @Success      201         {object}  GetCategoryResponse
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string  "category or folder not found"
+// @Failure      500         {object}  map[string]string
+// @Router       /categories/{categoryID}/clone [post]
+func (h *Handler) cloneCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	categoryID := r.PathValue("categoryID")
+
+	var req CloneCategoryRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req, strictFields) {
+		return
+	}
+
+	source, err := h.store.GetCategory(ctx, categoryID)
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
+
+	name := source.Name + " (copy)"
+	if req.Name != nil {
+		if *req.Name == "" {
+			respondError(w, http.StatusBadRequest, "name must not be empty")
+			return
+		}
+		name = *req.Name
+	}
+
+	folderID := source.FolderID
+	if req.FolderID.Set {
+		folderID = req.FolderID.Value
+		if folderID != nil && *folderID == "" {
+			folderID = nil
+		}
+		if folderID != nil {
+			_, err := h.store.GetFolder(ctx, *folderID)
+			if h.handleStoreError(w, err, "folder") {
+				return
+			}
+		}
+	}
+
+	clone := category.New(name)
+	clone.SetFolder(folderID)
+
+	banks, err := h.store.ListBanksByCategory(ctx, categoryID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load banks")
+		return
+	}
+	clonedBanks := make([]*questionbank.QuestionBank, len(banks))
+	for i, b := range banks {
+		bank, err := h.store.GetBank(ctx, b.ID)
+		if h.handleStoreError(w, err, "bank") {
+			return
+		}
+		clonedBanks[i] = bank.Clone(&clone.ID)
+	}
+
+	if err := h.store.SaveCategoryWithBanks(ctx, clone, clonedBanks); err != nil {
+		h.logger.Error("failed to clone category", "category_id", categoryID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to clone category")
+		return
+	}
+
+	bankResponses := make([]BankResponse, len(clonedBanks))
+	for i, bank := range clonedBanks {
+		bankResponses[i] = BankResponse{
+			ID:              bank.ID,
+			Subject:         bank.Subject,
+			CategoryID:      bank.CategoryID,
+			BankType:        string(bank.BankType),
+			Language:        bank.Language,
+			UnansweredCount: len(bank.Questions),
+			Version:         bank.Version,
+		}
+	}
+
+	respondJSON(w, http.StatusCreated, GetCategoryResponse{
+		ID:       clone.ID,
+		Name:     clone.Name,
+		FolderID: clone.FolderID,
+		Banks:    bankResponses,
+		Version:  clone.Version,
 	})
 }
 
@@ -300,7 +521,7 @@ func (h *Handler) updateCategoryFolder(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) reorderCategories(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req ReorderCategoriesRequest
-	if !decodeJSON(w, r, &req) {
+	if !decodeJSON(w, r, &req, strictFields) {
 		return
 	}
 	if len(req.IDs) == 0 {
@@ -316,10 +537,12 @@ func (h *Handler) reorderCategories(w http.ResponseWriter, r *http.Request) {
 
 // deleteCategory removes a category and all its banks.
 // @Summary      Delete a category
-// @Description  Delete a category and cascade-delete all its banks and questions.
+// @Description  Delete a category and cascade-delete all its banks and questions. With reassign_to, the banks are moved to that category first and nothing but the category itself is deleted.
 // @Tags         Categories
-// @Param        categoryID  path  string  true  "Category ID"
+// @Param        categoryID   path   string  true   "Category ID"
+// @Param        reassign_to  query  string  false  "Category to move the banks to instead of deleting them"
 // @Success      204
+// @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /categories/{categoryID} [delete]
@@ -327,7 +550,29 @@ func (h *Handler) deleteCategory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	categoryID := r.PathValue("categoryID")
 
-	if h.handleStoreError(w, h.store.DeleteCategory(ctx, categoryID), "category") {
+	targetID := r.URL.Query().Get("reassign_to")
+	if targetID == "" {
+		if h.handleStoreError(w, h.store.DeleteCategory(ctx, categoryID), "category") {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if targetID == categoryID {
+		respondError(w, http.StatusBadRequest, "reassign_to must differ from the deleted category")
+		return
+	}
+	_, err := h.store.GetCategory(ctx, targetID)
+	if errors.Is(err, store.ErrNotFound) {
+		respondError(w, http.StatusBadRequest, "reassign_to category not found")
+		return
+	}
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
+
+	if h.handleStoreError(w, h.store.DeleteCategoryReassigning(ctx, categoryID, targetID), "category") {
 		return
 	}
 
@@ -363,17 +608,20 @@ func (h *Handler) listBanksByCategory(w http.ResponseWriter, r *http.Request) {
 	for i, bank := range banks {
 		bankIDs[i] = bank.ID
 	}
-	masteryMap, _ := h.store.GetBankMasteryBatch(ctx, bankIDs)
+	masteryMap, _ := h.store.GetBankMasteryBatch(ctx, bankIDs, h.masteryScope)
+	unansweredMap, _ := h.store.GetUnansweredCountBatch(ctx, bankIDs)
 
 	response := make([]BankResponse, len(banks))
 	for i, bank := range banks {
 		response[i] = BankResponse{
-			ID:         bank.ID,
-			Subject:    bank.Subject,
-			CategoryID: bank.CategoryID,
-			BankType:   string(bank.BankType),
-			Language:   bank.Language,
-			Mastery:    masteryMap[bank.ID],
+			ID:              bank.ID,
+			Subject:         bank.Subject,
+			CategoryID:      bank.CategoryID,
+			BankType:        string(bank.BankType),
+			Language:        bank.Language,
+			Mastery:         masteryMap[bank.ID],
+			UnansweredCount: unansweredMap[bank.ID],
+			Version:         bank.Version,
 		}
 	}
 
@@ -382,11 +630,13 @@ func (h *Handler) listBanksByCategory(w http.ResponseWriter, r *http.Request) {
 
 // getCategoryStats returns mastery stats for a category.
 // @Summary      Get category stats
-// @Description  Returns the aggregate mastery score for a category.
+// @Description  Returns the aggregate mastery score for a category. By default every question counts, weighted by difficulty as in bank mastery; weighting=attempts weights each answered question by how often it was answered, counting at most 3 answers so heavily practiced questions do not dominate.
 // @Tags         Categories
 // @Produce      json
-// @Param        categoryID  path      string  true  "Category ID"
+// @Param        categoryID  path      string  true   "Category ID"
+// @Param        weighting   query     string  false  "questions (default) or attempts"
 // @Success      200         {object}  CategoryStatsResponse
+// @Failure      400         {object}  map[string]string
 // @Failure      404         {object}  map[string]string
 // @Failure      500         {object}  map[string]string
 // @Router       /categories/{categoryID}/stats [get]
@@ -394,12 +644,17 @@ func (h *Handler) getCategoryStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	categoryID := r.PathValue("categoryID")
 
+	weighting, ok := parseMasteryWeighting(w, r)
+	if !ok {
+		return
+	}
+
 	_, err := h.store.GetCategory(ctx, categoryID)
 	if h.handleStoreError(w, err, "category") {
 		return
 	}
 
-	mastery, err := h.store.GetCategoryMastery(ctx, categoryID)
+	mastery, err := h.store.GetCategoryMasteryWeighted(ctx, categoryID, weighting)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to get stats")
 		return
@@ -408,5 +663,6 @@ func (h *Handler) getCategoryStats(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, CategoryStatsResponse{
 		CategoryID: categoryID,
 		Mastery:    mastery,
+		Weighting:  string(weighting),
 	})
 }
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+// defaultWeakestLimit and maxWeakestLimit bound how many questions a
+// category's weakest list returns.
+const (
+	defaultWeakestLimit = 20
+	maxWeakestLimit     = 200
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type WeakQuestionResponse struct {
+	ID             string `json:"id" example:"q1w2e3r4t5y6u7i8"`
+	Subject        string `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer string `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	BankID         string `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	BankSubject    string `json:"bank_subject" example:"Go concurrency patterns"`
+	Mastery        int    `json:"mastery" example:"20"`
+	TimesAnswered  int    `json:"times_answered" example:"3"`
+}
+
+type WeakestQuestionsResponse struct {
+	CategoryID string                 `json:"category_id" example:"a1b2c3d4e5f6g7h8"`
+	Questions  []WeakQuestionResponse `json:"questions"`
+}
+
+type CreateWeakestSessionRequest struct {
+	Limit          *int `json:"limit,omitempty" example:"20"`
+	MaxDurationMin *int `json:"max_duration_min,omitempty" example:"15"`
+}
+
+func (r *CreateWeakestSessionRequest) Validate() error {
+	if r.Limit != nil && (*r.Limit < 1 || *r.Limit > maxWeakestLimit) {
+		return errWeakestLimit
+	}
+	return nil
+}
+
+var errWeakestLimit = errors.New("limit must be between 1 and 200")
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// getWeakestInCategory lists a category's weakest questions across its banks.
+// @Summary      List a category's weakest questions
+// @Description  Returns questions from every bank in the category, never-answered first, then by ascending mastery, each with the bank it belongs to. Start a session over the same list with POST /categories/{categoryID}/weakest/session.
+// @Tags         Categories
+// @Produce      json
+// @Param        categoryID  path      string  true   "Category ID"
+// @Param        limit       query     int     false  "Maximum number of questions (default 20, max 200)"
+// @Success      200         {object}  WeakestQuestionsResponse
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /categories/{categoryID}/weakest [get]
+func (h *Handler) getWeakestInCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := r.PathValue("categoryID")
+
+	limit := defaultWeakestLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxWeakestLimit {
+			respondError(w, http.StatusBadRequest, errWeakestLimit.Error())
+			return
+		}
+		limit = n
+	}
+
+	questions, ok := h.weakestInCategory(w, r, categoryID, limit)
+	if !ok {
+		return
+	}
+
+	resp := WeakestQuestionsResponse{CategoryID: categoryID, Questions: make([]WeakQuestionResponse, len(questions))}
+	for i, q := range questions {
+		resp.Questions[i] = WeakQuestionResponse{
+			ID:             q.ID,
+			Subject:        q.Subject,
+			ExpectedAnswer: q.ExpectedAnswer,
+			BankID:         q.BankID,
+			BankSubject:    q.BankSubject,
+			Mastery:        q.Mastery,
+			TimesAnswered:  q.TimesAnswered,
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// createWeakestSession starts a session over a category's weakest questions.
+// @Summary      Practice a category's weakest questions
+// @Description  Create a multi-bank practice session from the questions GET /categories/{categoryID}/weakest returns, in the same order.
+// @Tags         Categories
+// @Accept       json
+// @Produce      json
+// @Param        categoryID  path      string                       true   "Category ID"
+// @Param        body        body      CreateWeakestSessionRequest  false  "Session options"
+// @Success      201         {object}  object
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /categories/{categoryID}/weakest/session [post]
+func (h *Handler) createWeakestSession(w http.ResponseWriter, r *http.Request) {
+	var req CreateWeakestSessionRequest
+	if r.ContentLength != 0 && !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+	if !h.checkSessionDuration(w, req.MaxDurationMin) {
+		return
+	}
+
+	limit := defaultWeakestLimit
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+
+	questions, ok := h.weakestInCategory(w, r, r.PathValue("categoryID"), limit)
+	if !ok {
+		return
+	}
+	if len(questions) == 0 {
+		respondError(w, http.StatusBadRequest, "no questions found in category")
+		return
+	}
+
+	h.startMultiBankSession(w, r.Context(), questions, req.MaxDurationMin)
+}
+
+// weakestInCategory loads a category's weakest questions. On failure it
+// writes the error response and returns false.
+func (h *Handler) weakestInCategory(w http.ResponseWriter, r *http.Request, categoryID string, limit int) ([]store.QuestionWithBank, bool) {
+	ctx := r.Context()
+	if _, err := h.store.GetCategory(ctx, categoryID); h.handleStoreError(w, err, "category") {
+		return nil, false
+	}
+
+	questions, err := h.store.GetWeakestQuestionsInCategory(ctx, categoryID, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get questions")
+		return nil, false
+	}
+	return questions, true
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// exportFormat selects how GET /export and GET /folders/{folderID}/export
+// render their data.
+type exportFormat string
+
+const (
+	exportFormatJSON     exportFormat = "json"     // re-importable backup (default)
+	exportFormatMarkdown exportFormat = "markdown" // readable document for sharing
+	exportFormatAnki     exportFormat = "anki"     // tab-separated notes for Anki's text import
+)
+
+// parseExportFormat reads the optional "format" query parameter. On an
+// unknown value it writes a 400 response and returns false.
+func parseExportFormat(w http.ResponseWriter, r *http.Request) (exportFormat, bool) {
+	switch format := exportFormat(r.URL.Query().Get("format")); format {
+	case "":
+		return exportFormatJSON, true
+	case exportFormatJSON, exportFormatMarkdown, exportFormatAnki:
+		return format, true
+	default:
+		respondError(w, http.StatusBadRequest, "format must be 'json', 'markdown' or 'anki'")
+		return "", false
+	}
+}
+
+// writeExport sends data as a download named name plus the format's
+// extension.
+func (h *Handler) writeExport(w http.ResponseWriter, data ExportData, format exportFormat, name string) {
+	var contentType, ext string
+	var write func(io.Writer, ExportData) error
+	switch format {
+	case exportFormatMarkdown:
+		contentType, ext, write = "text/markdown; charset=utf-8", ".md", writeExportMarkdown
+	case exportFormatAnki:
+		contentType, ext, write = "text/plain; charset=utf-8", ".txt", writeExportAnki
+	default:
+		contentType, ext = "application/json", ".json"
+		write = func(w io.Writer, data ExportData) error { return json.NewEncoder(w).Encode(data) }
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+name+ext)
+	if err := write(w, data); err != nil {
+		h.logger.Error("failed to write export", "format", format, "error", err)
+	}
+}
+
+// writeExportMarkdown renders data as a Markdown document: one heading per
+// folder, category and bank, then each question with its expected answer.
+// Answers of code banks are fenced in the bank's language.
+func writeExportMarkdown(w io.Writer, data ExportData) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# remAIber export\n\n_Exported %s_\n", data.ExportedAt)
+
+	writeCategories := func(categories []ExportCategory) {
+		for _, cat := range categories {
+			fmt.Fprintf(bw, "\n### %s\n", markdownLine(cat.Name))
+			for _, bank := range cat.Banks {
+				fmt.Fprintf(bw, "\n#### %s\n", markdownLine(bank.Subject))
+				for i, q := range bank.Questions {
+					fmt.Fprintf(bw, "\n**Q%d.** %s\n\n", i+1, q.Subject)
+					writeMarkdownAnswer(bw, bank, q.ExpectedAnswer)
+				}
+			}
+		}
+	}
+	for _, f := range data.Folders {
+		fmt.Fprintf(bw, "\n## %s\n", markdownLine(f.Name))
+		writeCategories(f.Categories)
+	}
+	if len(data.Categories) > 0 {
+		bw.WriteString("\n## Unfiled\n")
+		writeCategories(data.Categories)
+	}
+	return bw.Flush()
+}
+
+// writeMarkdownAnswer writes an expected answer, fenced for code banks and
+// quoted otherwise.
+func writeMarkdownAnswer(w *bufio.Writer, bank ExportBank, answer string) {
+	bankType := questionbank.BankType(bank.BankType)
+	if bankType == questionbank.BankTypeCode || bankType == questionbank.BankTypeCLI {
+		lang := ""
+		if bank.Language != nil {
+			lang = *bank.Language
+		} else if bankType == questionbank.BankTypeCLI {
+			lang = "shell"
+		}
+		// A fence longer than any backtick run in the answer cannot be
+		// closed early by it.
+		fence := "```"
+		for strings.Contains(answer, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(w, "%s%s\n%s\n%s\n", fence, lang, strings.TrimRight(answer, "\n"), fence)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(answer, "\n"), "\n") {
+		fmt.Fprintf(w, "> %s\n", line)
+	}
+}
+
+// markdownLine flattens a name onto one line so it stays a single heading.
+func markdownLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writeExportAnki renders data as tab-separated Anki notes, front = question
+// subject and back = expected answer. The header lines tell Anki's importer
+// the separator and that fields are HTML, so line breaks survive as <br>.
+func writeExportAnki(w io.Writer, data ExportData) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("#separator:tab\n#html:true\n")
+
+	writeCategories := func(categories []ExportCategory) {
+		for _, cat := range categories {
+			for _, bank := range cat.Banks {
+				for _, q := range bank.Questions {
+					fmt.Fprintf(bw, "%s\t%s\n", ankiField(q.Subject), ankiField(q.ExpectedAnswer))
+				}
+			}
+		}
+	}
+	for _, f := range data.Folders {
+		writeCategories(f.Categories)
+	}
+	writeCategories(data.Categories)
+	return bw.Flush()
+}
+
+// ankiField escapes s for an HTML field of a tab-separated note.
+func ankiField(s string) string {
+	s = html.EscapeString(strings.TrimRight(s, "\n"))
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return strings.ReplaceAll(s, "\t", "    ")
+}
@@ -3,27 +3,59 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/remaimber-it/backend/internal/domain/category"
 	"github.com/remaimber-it/backend/internal/domain/folder"
 	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/store"
+	"github.com/remaimber-it/backend/internal/worker"
 )
 
+// ExportVersion is the version of the export format written by /export.
+// Built-in templates carry the same version so they stay importable.
+// 2.0 added per-question stats (?include_stats=true); 1.x payloads still
+// import unchanged.
+const ExportVersion = "2.0"
+
 // ── Request / Response types ────────────────────────────────────────────────
 
 type ExportQuestion struct {
-	Subject        string  `json:"subject" example:"What is a goroutine?"`
-	ExpectedAnswer string  `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
-	GradingPrompt  *string `json:"grading_prompt,omitempty"`
+	Subject            string               `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer     string               `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	AlternativeAnswers []string             `json:"alternative_answers,omitempty"` // other acceptable answers
+	GradingPrompt      *string              `json:"grading_prompt,omitempty"`
+	Rubric             *string              `json:"rubric,omitempty"`
+	GradingMode        *string              `json:"grading_mode,omitempty" example:"exact"`
+	Difficulty         string               `json:"difficulty,omitempty" example:"hard"`
+	Stats              *ExportQuestionStats `json:"question_stats,omitempty"` // only with include_stats=true, for answered questions
+}
+
+// ExportQuestionStats is a question's practice history in an export.
+type ExportQuestionStats struct {
+	TimesAnswered  int    `json:"times_answered" example:"4"`
+	TimesCorrect   int    `json:"times_correct" example:"3"`
+	TotalScore     int    `json:"total_score" example:"290"`
+	LatestScore    int    `json:"latest_score" example:"85"`
+	Mastery        int    `json:"mastery" example:"79"`
+	LastAnsweredAt string `json:"last_answered_at,omitempty" example:"2025-01-15T10:30:00Z"`
 }
 
 type ExportBank struct {
-	Subject   string           `json:"subject" example:"Go concurrency patterns"`
-	BankType  string           `json:"bank_type" example:"theory"`
-	Language  *string          `json:"language,omitempty" example:"go"`
-	Questions []ExportQuestion `json:"questions"`
+	Subject                  string           `json:"subject" example:"Go concurrency patterns"`
+	BankType                 string           `json:"bank_type" example:"theory"`
+	Language                 *string          `json:"language,omitempty" example:"go"`
+	GradingMode              string           `json:"grading_mode,omitempty" example:"llm"`
+	ExactCaseSensitive       bool             `json:"exact_case_sensitive,omitempty"`
+	ExactWhitespaceSensitive bool             `json:"exact_whitespace_sensitive,omitempty"`
+	ScoringCurve             string           `json:"scoring_curve,omitempty" example:"sqrt"`
+	PreserveOrder            bool             `json:"preserve_order,omitempty"`
+	Rubric                   *string          `json:"rubric,omitempty"`
+	CreatedAt                string           `json:"created_at,omitempty" example:"2025-01-15T10:30:00Z"` // kept on import with preserve_timestamps=true
+	Questions                []ExportQuestion `json:"questions"`
 }
 
 type ExportCategory struct {
@@ -37,34 +69,81 @@ type ExportFolder struct {
 }
 
 type ExportData struct {
-	Version    string           `json:"version" example:"1.1"`
+	Version    string           `json:"version" example:"2.0"`
 	ExportedAt string           `json:"exported_at" example:"2025-01-15T10:30:00Z"`
 	Folders    []ExportFolder   `json:"folders,omitempty"`
 	Categories []ExportCategory `json:"categories"` // Categories without a folder
 }
 
+// ExportLine is a single question in the newline-delimited JSON export.
+type ExportLine struct {
+	Category       string `json:"category" example:"Golang"`
+	Bank           string `json:"bank" example:"Go concurrency patterns"`
+	Subject        string `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer string `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	Mastery        int    `json:"mastery" example:"75"`
+}
+
 type ImportResult struct {
 	FoldersCreated    int `json:"folders_created" example:"3"`
 	CategoriesCreated int `json:"categories_created" example:"2"`
 	BanksCreated      int `json:"banks_created" example:"5"`
 	QuestionsCreated  int `json:"questions_created" example:"42"`
+	StatsRestored     int `json:"stats_restored" example:"17"`
+
+	// Entities matched by mode=merge instead of created
+	FoldersMerged    int `json:"folders_merged" example:"1"`
+	CategoriesMerged int `json:"categories_merged" example:"2"`
+	BanksMerged      int `json:"banks_merged" example:"4"`
+	QuestionsSkipped int `json:"questions_skipped" example:"38"` // already in a merged bank
+
+	Errors []ImportBankError `json:"errors,omitempty"` // banks skipped by strict=true
+}
+
+// ImportBankError explains why a bank was not imported.
+type ImportBankError struct {
+	Category string `json:"category" example:"Golang"`
+	Bank     string `json:"bank" example:"Go concurrency patterns"`
+	Error    string `json:"error" example:"invalid bank_type \"thoery\": must be theory, code, or cli"`
+}
+
+// importOptions are the query options of POST /import.
+type importOptions struct {
+	preserveTimestamps bool // keep the exported created_at of banks
+	strict             bool // skip banks with an invalid bank_type or language instead of coercing them
+	merge              bool // reuse folders, categories and banks with the same name; skip known questions
 }
 
 // ── Handlers ────────────────────────────────────────────────────────────────
 
 // exportAll exports all data as a JSON file.
 // @Summary      Export all data
-// @Description  Export all folders, categories, banks, and questions as a downloadable JSON file. The system "Deleted" folder and its contents are excluded.
+// @Description  Export all folders, categories, banks, and questions as a downloadable JSON file. The system "Deleted" folder and its contents are excluded. With include_stats=true, answered questions also carry their question_stats so a backup restores mastery.
+// @Description  format=markdown instead returns a readable .md document with a heading per folder, category and bank, and format=anki a tab-separated .txt of question/answer notes for Anki's text import. Only JSON can be imported back.
 // @Tags         Import/Export
 // @Produce      json
-// @Success      200  {object}  ExportData
-// @Failure      500  {object}  map[string]string
+// @Produce      text/markdown
+// @Produce      text/plain
+// @Param        format         query     string  false  "json (default), markdown or anki"
+// @Param        include_stats  query     bool    false  "Include per-question practice stats (JSON only)"
+// @Success      200            {object}  ExportData
+// @Failure      400            {object}  map[string]string
+// @Failure      500            {object}  map[string]string
 // @Router       /export [get]
 func (h *Handler) exportAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	format, ok := parseExportFormat(w, r)
+	if !ok {
+		return
+	}
+	includeStats, ok := parseBoolQuery(w, r, "include_stats")
+	if !ok {
+		return
+	}
+
 	exportData := ExportData{
-		Version:    "1.1",
+		Version:    ExportVersion,
 		ExportedAt: time.Now().UTC().Format(time.RFC3339),
 		Folders:    make([]ExportFolder, 0),
 		Categories: make([]ExportCategory, 0),
@@ -96,18 +175,10 @@ func (h *Handler) exportAll(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		exportFolder := ExportFolder{
-			Name:       f.Name,
-			Categories: make([]ExportCategory, 0),
-		}
-
 		for _, cat := range categories {
 			categoriesInFolders[cat.ID] = true
-			exportCat := h.buildExportCategory(ctx, cat)
-			exportFolder.Categories = append(exportFolder.Categories, exportCat)
 		}
-
-		exportData.Folders = append(exportData.Folders, exportFolder)
+		exportData.Folders = append(exportData.Folders, h.buildExportFolder(ctx, f, categories, includeStats))
 	}
 
 	// Export categories that are NOT in any folder
@@ -121,19 +192,97 @@ func (h *Handler) exportAll(w http.ResponseWriter, r *http.Request) {
 		if categoriesInFolders[cat.ID] {
 			continue
 		}
-		exportCat := h.buildExportCategory(ctx, cat)
+		exportCat := h.buildExportCategory(ctx, cat, includeStats)
 		exportData.Categories = append(exportData.Categories, exportCat)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Disposition", "attachment; filename=remaimber-export.json")
-	if err := json.NewEncoder(w).Encode(exportData); err != nil {
-		h.logger.Error("failed to encode export", "error", err)
+	h.writeExport(w, exportData, format, "remaimber-export")
+}
+
+// exportFolder exports a single folder as a JSON file.
+// @Summary      Export a folder
+// @Description  Export one folder with its categories, banks, and questions as a downloadable JSON file in the same format as /export, so it can be re-created with /import. The system "Deleted" folder cannot be exported. format=markdown and format=anki work as on /export.
+// @Tags         Import/Export
+// @Produce      json
+// @Produce      text/markdown
+// @Produce      text/plain
+// @Param        folderID       path      string  true   "Folder ID"
+// @Param        format         query     string  false  "json (default), markdown or anki"
+// @Param        include_stats  query     bool    false  "Include per-question practice stats (JSON only)"
+// @Success      200            {object}  ExportData
+// @Failure      400            {object}  map[string]string
+// @Failure      403            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Failure      500            {object}  map[string]string
+// @Router       /folders/{folderID}/export [get]
+func (h *Handler) exportFolder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	folderID := r.PathValue("folderID")
+
+	format, ok := parseExportFormat(w, r)
+	if !ok {
+		return
 	}
+	includeStats, ok := parseBoolQuery(w, r, "include_stats")
+	if !ok {
+		return
+	}
+
+	f, err := h.store.GetFolder(ctx, folderID)
+	if h.handleStoreError(w, err, "folder") {
+		return
+	}
+	if f.IsSystem {
+		respondError(w, http.StatusForbidden, "cannot export system folder")
+		return
+	}
+
+	categories, err := h.store.ListCategoriesByFolder(ctx, f.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load categories")
+		return
+	}
+
+	exportData := ExportData{
+		Version:    ExportVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Folders:    []ExportFolder{h.buildExportFolder(ctx, f, categories, includeStats)},
+		Categories: make([]ExportCategory, 0),
+	}
+
+	h.writeExport(w, exportData, format, "remaimber-folder-export")
+}
+
+// parseBoolQuery reads an optional boolean query parameter, false when
+// absent. On a malformed value it writes a 400 response and returns false.
+func parseBoolQuery(w http.ResponseWriter, r *http.Request, name string) (bool, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, name+" must be true or false")
+		return false, false
+	}
+	return b, true
+}
+
+// buildExportFolder creates an ExportFolder from a folder and its categories.
+func (h *Handler) buildExportFolder(ctx context.Context, f *folder.Folder, categories []*category.Category, includeStats bool) ExportFolder {
+	exportFolder := ExportFolder{
+		Name:       f.Name,
+		Categories: make([]ExportCategory, 0, len(categories)),
+	}
+	for _, cat := range categories {
+		exportFolder.Categories = append(exportFolder.Categories, h.buildExportCategory(ctx, cat, includeStats))
+	}
+	return exportFolder
 }
 
 // buildExportCategory creates an ExportCategory from a category entity.
-func (h *Handler) buildExportCategory(ctx context.Context, cat *category.Category) ExportCategory {
+// With includeStats, answered questions carry their practice stats.
+func (h *Handler) buildExportCategory(ctx context.Context, cat *category.Category, includeStats bool) ExportCategory {
 	banks, err := h.store.ListBanksByCategory(ctx, cat.ID)
 	if err != nil {
 		h.logger.Error("failed to list banks for category", "category_id", cat.ID, "error", err)
@@ -153,17 +302,36 @@ func (h *Handler) buildExportCategory(ctx context.Context, cat *category.Categor
 		}
 
 		exportBank := ExportBank{
-			Subject:   fullBank.Subject,
-			BankType:  string(fullBank.BankType),
-			Language:  fullBank.Language,
-			Questions: make([]ExportQuestion, len(fullBank.Questions)),
+			Subject:                  fullBank.Subject,
+			BankType:                 string(fullBank.BankType),
+			Language:                 fullBank.Language,
+			GradingMode:              string(fullBank.GradingMode),
+			ExactCaseSensitive:       fullBank.ExactMatch.CaseSensitive,
+			ExactWhitespaceSensitive: fullBank.ExactMatch.WhitespaceSensitive,
+			ScoringCurve:             string(fullBank.ScoringCurve),
+			PreserveOrder:            fullBank.PreserveOrder,
+			Rubric:                   fullBank.Rubric,
+			Questions:                make([]ExportQuestion, len(fullBank.Questions)),
+		}
+		if !fullBank.CreatedAt.IsZero() {
+			exportBank.CreatedAt = fullBank.CreatedAt.UTC().Format(time.RFC3339Nano)
+		}
+
+		var stats map[string]*ExportQuestionStats
+		if includeStats {
+			stats = h.exportQuestionStats(ctx, fullBank.ID)
 		}
 
 		for i, q := range fullBank.Questions {
 			exportBank.Questions[i] = ExportQuestion{
-				Subject:        q.Subject,
-				ExpectedAnswer: q.ExpectedAnswer,
-				GradingPrompt:  q.GradingPrompt,
+				Subject:            q.Subject,
+				ExpectedAnswer:     q.ExpectedAnswer,
+				AlternativeAnswers: q.AlternativeAnswers,
+				GradingPrompt:      q.GradingPrompt,
+				Rubric:             q.Rubric,
+				GradingMode:        gradingModeString(q.GradingMode),
+				Difficulty:         string(q.Difficulty.OrDefault()),
+				Stats:              stats[q.ID],
 			}
 		}
 
@@ -173,19 +341,127 @@ func (h *Handler) buildExportCategory(ctx context.Context, cat *category.Categor
 	return exportCat
 }
 
+// exportQuestionStats returns the stats of a bank's answered questions,
+// keyed by question ID. On failure it logs and exports the bank without
+// stats.
+func (h *Handler) exportQuestionStats(ctx context.Context, bankID string) map[string]*ExportQuestionStats {
+	stats, err := h.store.GetQuestionStatsByBank(ctx, bankID)
+	if err != nil {
+		h.logger.Error("failed to get question stats", "bank_id", bankID, "error", err)
+		return nil
+	}
+	byQuestion := make(map[string]*ExportQuestionStats, len(stats))
+	for _, s := range stats {
+		if s.TimesAnswered == 0 {
+			continue
+		}
+		es := &ExportQuestionStats{
+			TimesAnswered: s.TimesAnswered,
+			TimesCorrect:  s.TimesCorrect,
+			TotalScore:    s.TotalScore,
+			LatestScore:   s.LatestScore,
+			Mastery:       s.Mastery,
+		}
+		if !s.LastAnswered.IsZero() {
+			es.LastAnsweredAt = s.LastAnswered.UTC().Format(time.RFC3339Nano)
+		}
+		byQuestion[s.QuestionID] = es
+	}
+	return byQuestion
+}
+
+// exportNDJSON streams every question as newline-delimited JSON.
+// @Summary      Export questions as NDJSON
+// @Description  Stream one JSON object per question ({category, bank, subject, expected_answer, mastery}), one per line. Questions of banks without a category have an empty category. Questions in the system "Deleted" folder are excluded.
+// @Tags         Import/Export
+// @Produce      application/x-ndjson
+// @Success      200  {object}  ExportLine
+// @Failure      500  {object}  map[string]string
+// @Router       /export/ndjson [get]
+func (h *Handler) exportNDJSON(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	folders, err := h.store.ListFolders(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load folders")
+		return
+	}
+	systemFolders := make(map[string]bool)
+	for _, f := range folders {
+		if f.IsSystem {
+			systemFolders[f.ID] = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", "attachment; filename=remaimber-export.ndjson")
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	// Headers are already sent once the first line is written, so failures
+	// past this point can only be logged.
+	lastBankID := ""
+	err = h.store.StreamQuestionStats(ctx, store.QuestionStatsFilter{}, func(row store.QuestionStatsRow) error {
+		if systemFolders[row.FolderID] {
+			return nil
+		}
+		// Flush once per bank rather than once per line.
+		if row.BankID != lastBankID && lastBankID != "" && flusher != nil {
+			flusher.Flush()
+		}
+		lastBankID = row.BankID
+		return enc.Encode(ExportLine{
+			Category:       row.Category,
+			Bank:           row.Bank,
+			Subject:        row.Question,
+			ExpectedAnswer: row.ExpectedAnswer,
+			Mastery:        row.Mastery,
+		})
+	})
+	if err != nil {
+		h.logger.Error("failed to write ndjson export", "error", err)
+	}
+}
+
 // importAll imports data from a previously exported JSON payload.
 // @Summary      Import data
-// @Description  Import folders, categories, banks, and questions from a JSON export. New IDs are generated for all entities.
+// @Description  Import folders, categories, banks, and questions from a JSON export (version 1.x or 2.0). New IDs are generated for all entities. Imported banks are created now unless preserve_timestamps=true, which keeps their exported created_at to restore a backup faithfully. Questions exported with question_stats get those stats back.
+// @Description  By default a missing or unknown bank_type is imported as theory and any language is kept. With strict=true such banks are skipped instead and listed in errors, along with banks whose language is not a known code language.
+// @Description  mode=merge syncs into existing data instead of creating copies: folders, categories (within the same folder) and banks (within the same category) with the same name are reused, and only questions whose subject the bank does not already have are added. Settings of merged banks are left as they are.
 // @Tags         Import/Export
 // @Accept       json
 // @Produce      json
-// @Param        body  body      ExportData    true  "Export data to import"
-// @Success      201   {object}  ImportResult
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Param        preserve_timestamps  query     bool        false  "Keep the exported creation times of banks"
+// @Param        strict               query     bool        false  "Skip and report banks with an invalid bank_type or language"
+// @Param        mode                 query     string      false  "create (default) or merge"
+// @Param        body                 body      ExportData  true   "Export data to import"
+// @Success      201                  {object}  ImportResult
+// @Failure      400                  {object}  map[string]string
+// @Failure      500                  {object}  map[string]string
 // @Router       /import [post]
 func (h *Handler) importAll(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+
+	var opts importOptions
+	var ok bool
+	if opts.preserveTimestamps, ok = parseBoolQuery(w, r, "preserve_timestamps"); !ok {
+		return
+	}
+	if opts.strict, ok = parseBoolQuery(w, r, "strict"); !ok {
+		return
+	}
+	switch r.URL.Query().Get("mode") {
+	case "", "create":
+	case "merge":
+		opts.merge = true
+	default:
+		respondError(w, http.StatusBadRequest, "mode must be 'create' or 'merge'")
+		return
+	}
+
+	// Not strictFields: exports from newer versions may carry fields this
+	// one does not know yet.
 	var importData ExportData
 	if !decodeJSON(w, r, &importData) {
 		return
@@ -195,54 +471,173 @@ func (h *Handler) importAll(w http.ResponseWriter, r *http.Request) {
 
 	// Import folders and their categories
 	for _, f := range importData.Folders {
-		newFolder := folder.New(f.Name)
-		if err := h.store.SaveFolder(ctx, newFolder); err != nil {
-			h.logger.Error("failed to create folder", "name", f.Name, "error", err)
+		folderID, ok := h.importFolder(ctx, f.Name, opts, &result)
+		if !ok {
 			continue
 		}
-		result.FoldersCreated++
 
 		for _, cat := range f.Categories {
-			newCat := category.NewWithFolder(cat.Name, newFolder.ID)
-			if err := h.store.SaveCategory(ctx, newCat); err != nil {
-				h.logger.Error("failed to create category", "name", cat.Name, "error", err)
+			categoryID, ok := h.importCategory(ctx, cat.Name, &folderID, opts, &result)
+			if !ok {
 				continue
 			}
-			result.CategoriesCreated++
-
-			h.importBanks(ctx, cat.Banks, newCat.ID, &result)
+			h.importBanks(ctx, cat, categoryID, opts, &result)
 		}
 	}
 
 	// Import unfiled categories (backward compatible with v1.0 exports)
 	for _, cat := range importData.Categories {
-		newCat := category.New(cat.Name)
-		if err := h.store.SaveCategory(ctx, newCat); err != nil {
-			h.logger.Error("failed to create category", "name", cat.Name, "error", err)
+		categoryID, ok := h.importCategory(ctx, cat.Name, nil, opts, &result)
+		if !ok {
 			continue
 		}
-		result.CategoriesCreated++
-
-		h.importBanks(ctx, cat.Banks, newCat.ID, &result)
+		h.importBanks(ctx, cat, categoryID, opts, &result)
 	}
 
 	respondJSON(w, http.StatusCreated, result)
 }
 
-// importBanks imports banks and their questions into a category.
-func (h *Handler) importBanks(ctx context.Context, banks []ExportBank, categoryID string, result *ImportResult) {
-	for _, bank := range banks {
-		var bankType questionbank.BankType
-		switch questionbank.BankType(bank.BankType) {
-		case questionbank.BankTypeCode:
-			bankType = questionbank.BankTypeCode
-		case questionbank.BankTypeCLI:
-			bankType = questionbank.BankTypeCLI
-		default:
+// importFolder returns the ID of the folder to import into: a new one, or
+// in merge mode an existing non-system folder with the same name. It
+// returns false if the folder could not be looked up or created.
+func (h *Handler) importFolder(ctx context.Context, name string, opts importOptions, result *ImportResult) (string, bool) {
+	if opts.merge {
+		folders, err := h.store.ListFolders(ctx)
+		if err != nil {
+			h.logger.Error("failed to list folders", "error", err)
+			return "", false
+		}
+		for _, f := range folders {
+			if !f.IsSystem && f.Name == name {
+				result.FoldersMerged++
+				return f.ID, true
+			}
+		}
+	}
+
+	newFolder := folder.New(name)
+	if err := h.store.SaveFolder(ctx, newFolder); err != nil {
+		h.logger.Error("failed to create folder", "name", name, "error", err)
+		return "", false
+	}
+	result.FoldersCreated++
+	return newFolder.ID, true
+}
+
+// importCategory returns the ID of the category to import into, in folderID
+// or unfiled when folderID is nil: a new one, or in merge mode an existing
+// category with the same name in the same place. It returns false if the
+// category could not be looked up or created.
+func (h *Handler) importCategory(ctx context.Context, name string, folderID *string, opts importOptions, result *ImportResult) (string, bool) {
+	if opts.merge {
+		var categories []*category.Category
+		var err error
+		if folderID != nil {
+			categories, err = h.store.ListCategoriesByFolder(ctx, *folderID)
+		} else {
+			categories, err = h.store.ListCategories(ctx)
+		}
+		if err != nil {
+			h.logger.Error("failed to list categories", "error", err)
+			return "", false
+		}
+		for _, cat := range categories {
+			if cat.Name == name && (folderID != nil || cat.FolderID == nil) {
+				result.CategoriesMerged++
+				return cat.ID, true
+			}
+		}
+	}
+
+	newCat := category.New(name)
+	if folderID != nil {
+		newCat = category.NewWithFolder(name, *folderID)
+	}
+	if err := h.store.SaveCategory(ctx, newCat); err != nil {
+		h.logger.Error("failed to create category", "name", name, "error", err)
+		return "", false
+	}
+	result.CategoriesCreated++
+	return newCat.ID, true
+}
+
+// importBanks imports a category's banks and their questions into the
+// category stored as categoryID. Banks are created in order; then up to
+// h.importConcurrency of them are filled with questions at once. Each
+// bank's questions are saved one by one, in order, because insertion order
+// is the order a bank lists its questions in. In merge mode, banks already
+// in the category only receive the questions they do not have yet.
+func (h *Handler) importBanks(ctx context.Context, cat ExportCategory, categoryID string, opts importOptions, result *ImportResult) {
+	type pendingBank struct {
+		bank      *questionbank.QuestionBank
+		questions []ExportQuestion
+	}
+	var pending []pendingBank
+
+	existing := make(map[string]string) // subject → bank ID, in merge mode
+	if opts.merge {
+		banks, err := h.store.ListBanksByCategory(ctx, categoryID)
+		if err != nil {
+			h.logger.Error("failed to list banks for category", "category_id", categoryID, "error", err)
+			return
+		}
+		for _, b := range banks {
+			if _, dup := existing[b.Subject]; !dup {
+				existing[b.Subject] = b.ID
+			}
+		}
+	}
+
+	for _, bank := range cat.Banks {
+		bankType := questionbank.BankType(bank.BankType)
+		if opts.strict {
+			if err := validateImportBank(bank); err != nil {
+				result.Errors = append(result.Errors, ImportBankError{Category: cat.Name, Bank: bank.Subject, Error: err.Error()})
+				continue
+			}
+		} else if !bankType.IsValid() {
 			bankType = questionbank.BankTypeTheory
 		}
+		// Without strict, a language the bank type cannot have is dropped.
+		language := bank.Language
+		if language != nil && (*language == "" || bankType.ValidateLanguage(*language) != nil) {
+			language = nil
+		}
 
-		newBank := questionbank.NewWithOptions(bank.Subject, &categoryID, bankType, bank.Language)
+		if bankID, ok := existing[bank.Subject]; ok {
+			// Each existing bank is merged into at most once, so no two
+			// pending entries fill the same bank concurrently.
+			delete(existing, bank.Subject)
+			existingBank, err := h.store.GetBank(ctx, bankID)
+			if err != nil {
+				h.logger.Error("failed to get bank", "bank_id", bankID, "error", err)
+				continue
+			}
+			questions, skipped := newQuestions(existingBank, bank.Questions)
+			result.BanksMerged++
+			result.QuestionsSkipped += skipped
+			pending = append(pending, pendingBank{existingBank, questions})
+			continue
+		}
+
+		newBank := questionbank.NewWithOptions(bank.Subject, &categoryID, bankType, language)
+		if mode := questionbank.GradingMode(bank.GradingMode); mode.IsValid() {
+			newBank.GradingMode = mode
+		}
+		newBank.ExactMatch = questionbank.ExactMatchOptions{
+			CaseSensitive:       bank.ExactCaseSensitive,
+			WhitespaceSensitive: bank.ExactWhitespaceSensitive,
+		}
+		if curve := questionbank.ScoringCurve(bank.ScoringCurve); curve.IsValid() {
+			newBank.ScoringCurve = curve
+		}
+		newBank.PreserveOrder = bank.PreserveOrder
+		newBank.Rubric = bank.Rubric
+		if opts.preserveTimestamps && bank.CreatedAt != "" {
+			if createdAt, err := time.Parse(time.RFC3339Nano, bank.CreatedAt); err == nil {
+				newBank.CreatedAt = createdAt
+			}
+		}
 
 		if err := h.store.SaveBank(ctx, newBank); err != nil {
 			h.logger.Error("failed to create bank", "subject", bank.Subject, "error", err)
@@ -250,17 +645,98 @@ func (h *Handler) importBanks(ctx context.Context, banks []ExportBank, categoryI
 		}
 		result.BanksCreated++
 
-		for _, q := range bank.Questions {
-			if err := newBank.AddQuestionWithGradingPrompt(q.Subject, q.ExpectedAnswer, q.GradingPrompt); err != nil {
-				h.logger.Error("failed to add question", "error", err)
-				continue
-			}
-			newQuestion := newBank.Questions[len(newBank.Questions)-1]
-			if err := h.store.AddQuestion(ctx, newBank.ID, newQuestion); err != nil {
-				h.logger.Error("failed to save question", "error", err)
+		pending = append(pending, pendingBank{newBank, bank.Questions})
+	}
+
+	created := make([]int, len(pending))
+	restored := make([]int, len(pending))
+	worker.NewPool(h.importConcurrency).Run(len(pending), func(i int) {
+		created[i], restored[i] = h.importQuestions(ctx, pending[i].bank, pending[i].questions)
+	})
+	for i := range pending {
+		result.QuestionsCreated += created[i]
+		result.StatsRestored += restored[i]
+	}
+}
+
+// newQuestions returns the questions whose subject bank does not have yet,
+// keeping only the first of any repeated subject, and how many it dropped.
+func newQuestions(bank *questionbank.QuestionBank, questions []ExportQuestion) ([]ExportQuestion, int) {
+	known := make(map[string]bool, len(bank.Questions))
+	for _, q := range bank.Questions {
+		known[q.Subject] = true
+	}
+	var fresh []ExportQuestion
+	for _, q := range questions {
+		if known[q.Subject] {
+			continue
+		}
+		known[q.Subject] = true
+		fresh = append(fresh, q)
+	}
+	return fresh, len(questions) - len(fresh)
+}
+
+// validateImportBank checks the fields strict imports refuse to coerce.
+func validateImportBank(bank ExportBank) error {
+	if !questionbank.BankType(bank.BankType).IsValid() {
+		return fmt.Errorf("invalid bank_type %q: must be theory, code, or cli", bank.BankType)
+	}
+	if bank.Language != nil {
+		return questionbank.BankType(bank.BankType).ValidateLanguage(*bank.Language)
+	}
+	return nil
+}
+
+// importQuestions adds questions to bank in order and returns how many
+// were saved and how many of those got their exported stats back.
+func (h *Handler) importQuestions(ctx context.Context, bank *questionbank.QuestionBank, questions []ExportQuestion) (saved, restored int) {
+	for _, q := range questions {
+		if err := bank.AddQuestionWithGradingPrompt(q.Subject, q.ExpectedAnswer, q.GradingPrompt); err != nil {
+			h.logger.Error("failed to add question", "error", err)
+			continue
+		}
+		newQuestion := bank.Questions[len(bank.Questions)-1]
+		if validateGradingMode(q.GradingMode) == nil {
+			newQuestion.GradingMode = parseGradingMode(q.GradingMode)
+		}
+		if d := questionbank.Difficulty(q.Difficulty); d.IsValid() {
+			newQuestion.Difficulty = d
+		}
+		newQuestion.Rubric = q.Rubric
+		newQuestion.AlternativeAnswers = q.AlternativeAnswers
+		if err := h.store.AddQuestion(ctx, bank.ID, newQuestion); err != nil {
+			h.logger.Error("failed to save question", "error", err)
+			continue
+		}
+		saved++
+
+		if q.Stats != nil && q.Stats.TimesAnswered > 0 {
+			if err := h.store.RestoreQuestionStats(ctx, importedStats(newQuestion.ID, q.Stats)); err != nil {
+				h.logger.Error("failed to restore question stats", "question_id", newQuestion.ID, "error", err)
 				continue
 			}
-			result.QuestionsCreated++
+			restored++
+		}
+	}
+	return saved, restored
+}
+
+// importedStats converts exported stats for the question now stored as
+// questionID. An unparseable last_answered_at is dropped.
+func importedStats(questionID string, s *ExportQuestionStats) questionbank.QuestionStats {
+	stats := questionbank.QuestionStats{
+		QuestionID:    questionID,
+		TimesAnswered: s.TimesAnswered,
+		TimesCorrect:  min(max(s.TimesCorrect, 0), s.TimesAnswered),
+		TotalScore:    max(s.TotalScore, 0),
+		LatestScore:   min(max(s.LatestScore, 0), 100),
+		Mastery:       min(max(s.Mastery, 0), 100),
+	}
+	if s.LastAnsweredAt != "" {
+		if t, err := time.Parse(time.RFC3339Nano, s.LastAnsweredAt); err == nil {
+			stats.LastAnswered = t
 		}
 	}
+	return stats
 }
@@ -26,6 +26,7 @@ type FolderResponse struct {
 	Name     string `json:"name" example:"Programming"`
 	IsSystem bool   `json:"is_system" example:"false"`
 	Mastery  int    `json:"mastery" example:"42"`
+	Version  int    `json:"version" example:"3"`
 }
 
 type GetFolderResponse struct {
@@ -33,11 +34,13 @@ type GetFolderResponse struct {
 	Name       string             `json:"name" example:"Programming"`
 	IsSystem   bool               `json:"is_system" example:"false"`
 	Mastery    int                `json:"mastery" example:"42"`
+	Version    int                `json:"version" example:"3"`
 	Categories []CategoryResponse `json:"categories"`
 }
 
 type UpdateFolderRequest struct {
-	Name string `json:"name" example:"DevOps"`
+	Name    string `json:"name" example:"DevOps"`
+	Version *int   `json:"version,omitempty" example:"3"` // expected current version, required unless If-Match is sent
 }
 
 func (r *UpdateFolderRequest) Validate() error {
@@ -48,8 +51,9 @@ func (r *UpdateFolderRequest) Validate() error {
 }
 
 type FolderStatsResponse struct {
-	FolderID string `json:"folder_id" example:"f1o2l3d4e5r6i7d8"`
-	Mastery  int    `json:"mastery" example:"42"`
+	FolderID  string `json:"folder_id" example:"f1o2l3d4e5r6i7d8"`
+	Mastery   int    `json:"mastery" example:"42"`
+	Weighting string `json:"weighting" example:"questions"`
 }
 
 // ── Handlers ────────────────────────────────────────────────────────────────
@@ -68,7 +72,7 @@ type FolderStatsResponse struct {
 func (h *Handler) createFolder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req CreateFolderRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
 		return
 	}
 
@@ -83,6 +87,7 @@ func (h *Handler) createFolder(w http.ResponseWriter, r *http.Request) {
 		Name:     f.Name,
 		IsSystem: false,
 		Mastery:  0,
+		Version:  f.Version,
 	})
 }
 
@@ -115,6 +120,7 @@ func (h *Handler) listFolders(w http.ResponseWriter, r *http.Request) {
 			Name:     f.Name,
 			IsSystem: f.IsSystem,
 			Mastery:  masteryMap[f.ID],
+			Version:  f.Version,
 		}
 	}
 
@@ -158,17 +164,20 @@ func (h *Handler) getFolder(w http.ResponseWriter, r *http.Request) {
 			ID:      cat.ID,
 			Name:    cat.Name,
 			Mastery: catMasteryMap[cat.ID],
+			Version: cat.Version,
 		}
 	}
 
 	folderMastery, _ := h.store.GetFolderMastery(ctx, folderID)
 
+	setETag(w, f.Version)
 	respondJSON(w, http.StatusOK, GetFolderResponse{
 		ID:         f.ID,
 		Name:       f.Name,
 		IsSystem:   f.IsSystem,
 		Mastery:    folderMastery,
 		Categories: catResponses,
+		Version:    f.Version,
 	})
 }
 
@@ -184,19 +193,27 @@ func (h *Handler) getFolder(w http.ResponseWriter, r *http.Request) {
 // @Failure      400       {object}  map[string]string
 // @Failure      403       {object}  map[string]string  "cannot rename system folder"
 // @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string  "folder was modified by another request"
+// @Failure      412       {object}  map[string]string  "If-Match is a weak entity tag"
+// @Failure      428       {object}  map[string]string  "no version given"
 // @Router       /folders/{folderID} [put]
 func (h *Handler) updateFolder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	folderID := r.PathValue("folderID")
 
 	var req UpdateFolderRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+	version, ok := expectedVersion(w, r, req.Version)
+	if !ok {
 		return
 	}
 
 	f := &folder.Folder{
-		ID:   folderID,
-		Name: req.Name,
+		ID:      folderID,
+		Name:    req.Name,
+		Version: version,
 	}
 
 	err := h.store.UpdateFolder(ctx, f)
@@ -208,13 +225,20 @@ func (h *Handler) updateFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	f, err = h.store.GetFolder(ctx, folderID)
+	if h.handleStoreError(w, err, "folder") {
+		return
+	}
 	mastery, _ := h.store.GetFolderMastery(ctx, folderID)
 
+	setETag(w, f.Version)
+
 	respondJSON(w, http.StatusOK, FolderResponse{
 		ID:       f.ID,
 		Name:     f.Name,
 		IsSystem: f.IsSystem,
 		Mastery:  mastery,
+		Version:  f.Version,
 	})
 }
 
@@ -275,6 +299,7 @@ func (h *Handler) listCategoriesByFolder(w http.ResponseWriter, r *http.Request)
 			ID:      cat.ID,
 			Name:    cat.Name,
 			Mastery: masteryMap[cat.ID],
+			Version: cat.Version,
 		}
 	}
 
@@ -283,31 +308,39 @@ func (h *Handler) listCategoriesByFolder(w http.ResponseWriter, r *http.Request)
 
 // getFolderStats returns mastery stats for a folder.
 // @Summary      Get folder stats
-// @Description  Returns the aggregate mastery score for a folder.
+// @Description  Returns the aggregate mastery score for a folder. By default every question counts once; weighting=attempts weights each answered question by how often it was answered, counting at most 3 answers so heavily practiced questions do not dominate.
 // @Tags         Folders
 // @Produce      json
-// @Param        folderID  path      string  true  "Folder ID"
-// @Success      200       {object}  FolderStatsResponse
-// @Failure      404       {object}  map[string]string
-// @Failure      500       {object}  map[string]string
+// @Param        folderID   path      string  true   "Folder ID"
+// @Param        weighting  query     string  false  "questions (default) or attempts"
+// @Success      200        {object}  FolderStatsResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
 // @Router       /folders/{folderID}/stats [get]
 func (h *Handler) getFolderStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	folderID := r.PathValue("folderID")
 
+	weighting, ok := parseMasteryWeighting(w, r)
+	if !ok {
+		return
+	}
+
 	_, err := h.store.GetFolder(ctx, folderID)
 	if h.handleStoreError(w, err, "folder") {
 		return
 	}
 
-	mastery, err := h.store.GetFolderMastery(ctx, folderID)
+	mastery, err := h.store.GetFolderMasteryWeighted(ctx, folderID, weighting)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to get stats")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, FolderStatsResponse{
-		FolderID: folderID,
-		Mastery:  mastery,
+		FolderID:  folderID,
+		Mastery:   mastery,
+		Weighting: string(weighting),
 	})
 }
@@ -50,7 +50,7 @@ func (h *Handler) generateQuestions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req GenerateQuestionsRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
 		return
 	}
 
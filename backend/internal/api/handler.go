@@ -3,8 +3,13 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/remaimber-it/backend/internal/service"
 	"github.com/remaimber-it/backend/internal/store"
@@ -13,6 +18,14 @@ import (
 // maxRequestBodySize is the upper limit for JSON request bodies (1 MB).
 const maxRequestBodySize = 1 << 20
 
+// defaultStaleSessionAge is used until SetStaleSessionAge is called.
+const defaultStaleSessionAge = 24 * time.Hour
+
+// defaultGradePreviewTimeout bounds POST /grade/preview until
+// SetGradePreviewTimeout is called. It stays under the server's write
+// timeout so the timeout error can still be sent.
+const defaultGradePreviewTimeout = 25 * time.Second
+
 // Handler holds all dependencies needed by HTTP handlers.
 // Instead of relying on package-level globals, every handler method
 // receives its dependencies through this struct.
@@ -20,6 +33,14 @@ type Handler struct {
 	store   store.Store
 	grading *service.GradingService
 	logger  *slog.Logger
+
+	maxSessionDurationMin int                // 0 = no cap on max_duration_min
+	staleSessionAge       time.Duration      // default age for GET /sessions/incomplete
+	adminToken            string             // bearer token for /admin routes; empty disables them
+	importConcurrency     int                // banks filled at once by POST /import
+	gradePreviewTimeout   time.Duration      // model time allowed to POST /grade/preview
+	masteryScope          store.MasteryScope // scope of every reported bank mastery unless a request chooses
+	answerLimiter         *rateLimiter       // limits POST /sessions/{sessionID}/answers per client; nil = unlimited
 }
 
 // NewHandler creates a Handler with the given dependencies.
@@ -27,12 +48,75 @@ type Handler struct {
 // can be injected.
 func NewHandler(s store.Store, gs *service.GradingService, logger *slog.Logger) *Handler {
 	return &Handler{
-		store:   s,
-		grading: gs,
-		logger:  logger,
+		store:               s,
+		grading:             gs,
+		logger:              logger,
+		staleSessionAge:     defaultStaleSessionAge,
+		importConcurrency:   1,
+		gradePreviewTimeout: defaultGradePreviewTimeout,
+		masteryScope:        store.MasteryScopeAll,
+	}
+}
+
+// SetMaxSessionDuration caps the max_duration_min a session may request.
+// Requests above the cap are rejected rather than clamped, so the client
+// never silently gets a shorter timer than it asked for. Zero or a negative
+// value removes the cap.
+func (h *Handler) SetMaxSessionDuration(minutes int) {
+	h.maxSessionDurationMin = max(minutes, 0)
+}
+
+// SetStaleSessionAge sets how long a session may stay active before
+// GET /sessions/incomplete reports it by default. Non-positive values are
+// ignored.
+func (h *Handler) SetStaleSessionAge(age time.Duration) {
+	if age > 0 {
+		h.staleSessionAge = age
 	}
 }
 
+// SetAdminToken sets the bearer token required by /admin routes. An empty
+// token disables them.
+func (h *Handler) SetAdminToken(token string) {
+	h.adminToken = token
+}
+
+// SetImportConcurrency sets how many banks POST /import fills with
+// questions at once. Values below 1 import sequentially.
+func (h *Handler) SetImportConcurrency(n int) {
+	h.importConcurrency = max(n, 1)
+}
+
+// SetGradePreviewTimeout sets how long POST /grade/preview waits for the
+// model. Non-positive values are ignored.
+func (h *Handler) SetGradePreviewTimeout(d time.Duration) {
+	if d > 0 {
+		h.gradePreviewTimeout = d
+	}
+}
+
+// SetMasteryScope sets which questions count towards every bank mastery
+// the API reports: bank lists, the library tree and bank details. GET
+// /banks/{bankID} and GET /banks/{bankID}/stats may override it with the
+// mastery_scope query parameter. Category and folder mastery are
+// unaffected. Unknown scopes are ignored.
+func (h *Handler) SetMasteryScope(scope store.MasteryScope) {
+	if scope.IsValid() {
+		h.masteryScope = scope
+	}
+}
+
+// SetAnswerRateLimit limits each client, identified by API key or IP, to
+// perMinute answer submissions per minute. Zero or a negative value removes
+// the limit.
+func (h *Handler) SetAnswerRateLimit(perMinute int) {
+	if perMinute <= 0 {
+		h.answerLimiter = nil
+		return
+	}
+	h.answerLimiter = newRateLimiter(perMinute)
+}
+
 // respondJSON writes a JSON response with the given status code.
 func respondJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -55,40 +139,171 @@ func (h *Handler) handleStoreError(w http.ResponseWriter, err error, entity stri
 		respondError(w, http.StatusNotFound, entity+" not found")
 		return true
 	}
+	if errors.Is(err, store.ErrVersionConflict) {
+		respondError(w, http.StatusConflict, entity+" was modified by another request")
+		return true
+	}
 	h.logger.Error("store error", "error", err, "entity", entity)
 	respondError(w, http.StatusInternalServerError, "internal error")
 	return true
 }
 
+// expectedVersion returns the version a client based its update on. The
+// If-Match header takes precedence over the body's version field; If-Match: *
+// returns 0, which skips the version check. An update with neither gets a 428
+// response, a weak entity tag (which If-Match never matches) a 412 and a
+// malformed value a 400; expectedVersion then returns false.
+func expectedVersion(w http.ResponseWriter, r *http.Request, body *int) (int, bool) {
+	if v := strings.TrimSpace(r.Header.Get("If-Match")); v != "" {
+		if v == "*" {
+			return 0, true
+		}
+		if strings.HasPrefix(v, "W/") {
+			respondError(w, http.StatusPreconditionFailed, "If-Match requires a strong entity tag")
+			return 0, false
+		}
+		n, err := strconv.Atoi(strings.Trim(v, `"`))
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "If-Match must be an entity version")
+			return 0, false
+		}
+		return n, true
+	}
+	if body != nil {
+		if *body < 1 {
+			respondError(w, http.StatusBadRequest, "version must be positive")
+			return 0, false
+		}
+		return *body, true
+	}
+	respondError(w, http.StatusPreconditionRequired, "updates require a version: send If-Match or version")
+	return 0, false
+}
+
+// setETag advertises an entity's version so clients can send it back in
+// If-Match on their next update.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// parseMasteryWeighting reads the optional "weighting" query parameter.
+// On an unknown value it writes a 400 response and returns false.
+func parseMasteryWeighting(w http.ResponseWriter, r *http.Request) (store.MasteryWeighting, bool) {
+	weighting := store.MasteryWeighting(r.URL.Query().Get("weighting"))
+	if weighting == "" {
+		return store.MasteryWeightingQuestions, true
+	}
+	if !weighting.IsValid() {
+		respondError(w, http.StatusBadRequest, "weighting must be 'questions' or 'attempts'")
+		return "", false
+	}
+	return weighting, true
+}
+
+// parseMasteryScope reads the optional "mastery_scope" query parameter,
+// falling back to the handler's configured scope. On an unknown value it
+// writes a 400 response and returns false.
+func (h *Handler) parseMasteryScope(w http.ResponseWriter, r *http.Request) (store.MasteryScope, bool) {
+	scope := store.MasteryScope(r.URL.Query().Get("mastery_scope"))
+	if scope == "" {
+		return h.masteryScope, true
+	}
+	if !scope.IsValid() {
+		respondError(w, http.StatusBadRequest, "mastery_scope must be 'all' or 'answered'")
+		return "", false
+	}
+	return scope, true
+}
+
+// optionalString is a JSON field that distinguishes "absent" from "null".
+// Set is true whenever the key appears in the payload; Value is nil when the
+// key was explicitly null. Used by PATCH endpoints for clearable fields.
+type optionalString struct {
+	Set   bool
+	Value *string
+}
+
+func (o *optionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
+
 // Validatable is implemented by request types that can validate themselves.
 type Validatable interface {
 	Validate() error
 }
 
+// decodeOption configures the json.Decoder used by decodeJSON.
+type decodeOption func(*json.Decoder)
+
+// strictFields rejects bodies containing fields dst does not declare, so a
+// typo like "catgory_id" fails loudly instead of being ignored. Use it on
+// create/update endpoints; leave it off where payloads may carry fields
+// from newer versions (e.g. import).
+func strictFields(d *json.Decoder) { d.DisallowUnknownFields() }
+
 // decodeJSON reads a JSON request body into dst.
 // The body is capped at maxRequestBodySize to prevent abuse.
 // Returns true on success. On failure it writes a 400 response and returns false.
-func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any, opts ...decodeOption) bool {
 	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 
-	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+	dec := json.NewDecoder(r.Body)
+	for _, opt := range opts {
+		opt(dec)
+	}
+	if err := dec.Decode(dst); err != nil {
 		// MaxBytesReader returns a specific error when the limit is exceeded.
 		var maxBytesErr *http.MaxBytesError
 		if errors.As(err, &maxBytesErr) {
 			respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
 			return false
 		}
-		respondError(w, http.StatusBadRequest, "invalid json")
+		respondError(w, http.StatusBadRequest, describeJSONError(err))
 		return false
 	}
 	return true
 }
 
+// describeJSONError turns a decoding error into a client-facing message,
+// pointing at the offending byte offset and field where possible.
+func describeJSONError(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("invalid json at offset %d: %s", syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("invalid json at offset %d: expected %s, got %s", typeErr.Offset, typeErr.Type, typeErr.Value)
+		}
+		return fmt.Sprintf("invalid json at offset %d: field %q must be %s, got %s", typeErr.Offset, typeErr.Field, typeErr.Type, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for DisallowUnknownFields.
+		return "unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	case errors.Is(err, io.EOF):
+		return "invalid json: request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "invalid json: unexpected end of input"
+	default:
+		return "invalid json"
+	}
+}
+
 // decodeAndValidate decodes a JSON request body and validates it.
 // If dst implements Validatable, Validate() is called automatically.
 // Returns true on success. On failure it writes a 400 response and returns false.
-func decodeAndValidate(w http.ResponseWriter, r *http.Request, dst Validatable) bool {
-	if !decodeJSON(w, r, dst) {
+func decodeAndValidate(w http.ResponseWriter, r *http.Request, dst Validatable, opts ...decodeOption) bool {
+	if !decodeJSON(w, r, dst, opts...) {
 		return false
 	}
 	if err := dst.Validate(); err != nil {
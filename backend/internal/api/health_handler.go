@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/remaimber-it/backend/internal/service"
+)
+
+// ── Response types ──────────────────────────────────────────────────────────
+
+type GradingHealthResponse struct {
+	Status            string                 `json:"status" example:"ok"` // "ok" or "stuck"
+	InFlight          int                    `json:"in_flight" example:"2"`
+	TrackedSessions   int                    `json:"tracked_sessions" example:"5"` // sessions still tracked for grading
+	StuckThresholdSec int                    `json:"stuck_threshold_sec" example:"240"`
+	StuckSessions     []StuckSessionResponse `json:"stuck_sessions"`
+}
+
+type StuckSessionResponse struct {
+	SessionID     string `json:"session_id" example:"s1e2s3s4i5o6n7i8"`
+	RunningForSec int    `json:"running_for_sec" example:"600"`
+}
+
+type LLMHealthResponse struct {
+	Status    string `json:"status" example:"ok"` // "ok" or "unreachable"
+	Model     string `json:"model" example:"qwen2.5:7b"`
+	Endpoint  string `json:"endpoint" example:"http://localhost:1234"`
+	Reachable bool   `json:"reachable" example:"true"`
+	LatencyMs int64  `json:"latency_ms" example:"12"`
+	Error     string `json:"error,omitempty" example:"LLM returned status 500"`
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// getGradingHealth reports whether the grading pipeline is making progress.
+// @Summary      Grading pipeline health
+// @Description  Returns the number of in-flight gradings and any sessions whose oldest grading has run longer than the stuck threshold. Responds 503 when a session is stuck.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  GradingHealthResponse
+// @Failure      503  {object}  GradingHealthResponse
+// @Router       /health/grading [get]
+func (h *Handler) getGradingHealth(w http.ResponseWriter, r *http.Request) {
+	health := h.grading.Health()
+	respondJSON(w, gradingHealthStatus(health), newGradingHealthResponse(health))
+}
+
+// getLLMHealth probes the grading model's server.
+// @Summary      LLM health
+// @Description  Checks that the grading model's server is reachable, giving up after 2 seconds, and reports the configured model and endpoint. Responds 503 when it is unreachable.
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  LLMHealthResponse
+// @Failure      503  {object}  LLMHealthResponse
+// @Router       /health/llm [get]
+func (h *Handler) getLLMHealth(w http.ResponseWriter, r *http.Request) {
+	health := h.grading.PingGrader(r.Context())
+
+	resp := LLMHealthResponse{
+		Status:    "ok",
+		Model:     health.Model,
+		Endpoint:  health.Endpoint,
+		Reachable: health.Err == nil,
+		LatencyMs: health.Latency.Milliseconds(),
+	}
+	status := http.StatusOK
+	if health.Err != nil {
+		resp.Status = "unreachable"
+		resp.Error = health.Err.Error()
+		status = http.StatusServiceUnavailable
+	}
+	respondJSON(w, status, resp)
+}
+
+func gradingHealthStatus(health service.GradingHealth) int {
+	if len(health.StuckSessions) > 0 {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func newGradingHealthResponse(health service.GradingHealth) GradingHealthResponse {
+	resp := GradingHealthResponse{
+		Status:            "ok",
+		InFlight:          health.InFlight,
+		TrackedSessions:   health.TrackedSessions,
+		StuckThresholdSec: int(health.StuckThreshold.Seconds()),
+		StuckSessions:     make([]StuckSessionResponse, len(health.StuckSessions)),
+	}
+	if len(health.StuckSessions) > 0 {
+		resp.Status = "stuck"
+	}
+	for i, s := range health.StuckSessions {
+		resp.StuckSessions[i] = StuckSessionResponse{
+			SessionID:     s.SessionID,
+			RunningForSec: int(s.RunningFor.Seconds()),
+		}
+	}
+	return resp
+}
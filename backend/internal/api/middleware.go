@@ -1,25 +1,72 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/remaimber-it/backend/internal/id"
 )
 
-// CORS adds permissive cross-origin headers for local / Tauri development.
+// CORSOptions lists what cross-origin requests may use.
+type CORSOptions struct {
+	AllowedOrigins []string // "*" allows any origin
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
+// DefaultCORSOptions allows any origin, every method the API routes use,
+// JSON bodies, and the Authorization header needed by admin routes.
+var DefaultCORSOptions = CORSOptions{
+	AllowedOrigins: []string{"*"},
+	AllowedMethods: RouteMethods(),
+	AllowedHeaders: []string{"Content-Type", "Authorization"},
+}
+
+// CORS adds permissive cross-origin headers for local / Tauri development,
+// using DefaultCORSOptions.
 func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	return CORSWithOptions(DefaultCORSOptions)(next)
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// CORSWithOptions returns CORS middleware allowing the given methods and
+// headers from the allowed origins. A request from another origin gets no
+// CORS headers, so browsers refuse it. OPTIONS preflight requests are
+// answered with 204 No Content and never reach the wrapped handler.
+func CORSWithOptions(opts CORSOptions) func(http.Handler) http.Handler {
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
 
-		next.ServeHTTP(w, r)
-	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowOrigin := "*"
+			if !anyOrigin {
+				// The response depends on Origin, so caches must key on it.
+				w.Header().Add("Vary", "Origin")
+				allowOrigin = r.Header.Get("Origin")
+				if !slices.Contains(opts.AllowedOrigins, allowOrigin) {
+					allowOrigin = ""
+				}
+			}
+			if allowOrigin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // responseWriter wraps http.ResponseWriter to capture the status code.
@@ -37,8 +84,92 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// apiKeyKey is the context key of the bearer token RequireAPIKey accepted.
+type apiKeyKey struct{}
+
+// RequireAPIKey returns middleware that rejects requests with 401 unless
+// they carry key as a bearer token. The admin token, when set, is accepted
+// too, since admin routes take it in the same Authorization header. The
+// health checks under /health and the Swagger UI under /swagger/ stay open,
+// so probes and docs work without the key. An empty key disables the check.
+// The accepted token is stored in the request context for rateLimitKey.
+func RequireAPIKey(key, adminToken string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if key == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" || strings.HasPrefix(r.URL.Path, "/health/") || strings.HasPrefix(r.URL.Path, "/swagger/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || !(tokenMatches(token, key) || adminToken != "" && tokenMatches(token, adminToken)) {
+				respondError(w, http.StatusUnauthorized, "invalid or missing API key")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyKey{}, token)))
+		})
+	}
+}
+
+// tokenMatches compares a bearer token in constant time.
+func tokenMatches(token, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
+// RequestIDHeader is the response header carrying a request's ID.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID gives every request a fresh ID, stored in its context (see
+// RequestIDFromContext) and echoed in the X-Request-ID response header, so
+// logs of one request, including grading it starts, can be tied together.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := id.GenerateID()
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID)))
+	})
+}
+
+// RequestIDFromContext returns the ID RequestID gave the request, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// requestIDLogHandler adds a request_id attribute to records logged with
+// the context of a request that has one.
+type requestIDLogHandler struct {
+	slog.Handler
+}
+
+// NewRequestIDLogHandler wraps h so that records logged with a request's
+// context, e.g. logger.InfoContext(r.Context(), ...), carry its request ID.
+func NewRequestIDLogHandler(h slog.Handler) slog.Handler {
+	return requestIDLogHandler{h}
+}
+
+func (h requestIDLogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h requestIDLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDLogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDLogHandler) WithGroup(name string) slog.Handler {
+	return requestIDLogHandler{h.Handler.WithGroup(name)}
+}
+
 // Logging returns middleware that logs every request with method, path,
-// status code, and duration.
+// status code, and duration. Behind RequestID, the record also carries the
+// request ID.
 func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -47,7 +178,7 @@ func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
 
 			next.ServeHTTP(rw, r)
 
-			logger.Info("request",
+			logger.InfoContext(r.Context(), "request",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", rw.statusCode,
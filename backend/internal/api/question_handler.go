@@ -1,53 +1,371 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/remaimber-it/backend/internal/domain/category"
 	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/id"
+	"github.com/remaimber-it/backend/internal/store"
 )
 
 // ── Request / Response types ────────────────────────────────────────────────
 
 type AddQuestionRequest struct {
-	Subject        string  `json:"subject" example:"What is a goroutine?"`
-	ExpectedAnswer string  `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
-	GradingPrompt  *string `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
+	Subject         string   `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer  string   `json:"expected_answer,omitempty" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	ExpectedAnswers []string `json:"expected_answers,omitempty" example:"SIGTERM,SIGINT"` // several equally correct answers, primary first; replaces expected_answer
+	GradingPrompt   *string  `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
+	Rubric          *string  `json:"rubric,omitempty" example:"- Names the race condition\n- Proposes a fix"`
+	GradingMode     *string  `json:"grading_mode,omitempty" example:"exact"` // overrides the bank's grading mode
+	Difficulty      *string  `json:"difficulty,omitempty" example:"hard"`    // easy, medium (default) or hard
 }
 
 func (r *AddQuestionRequest) Validate() error {
 	if r.Subject == "" {
 		return errors.New("subject is required")
 	}
-	if r.ExpectedAnswer == "" {
+	if err := validateExpectedAnswers(r.ExpectedAnswer, r.ExpectedAnswers); err != nil {
+		return err
+	}
+	if r.Difficulty != nil && !questionbank.Difficulty(*r.Difficulty).IsValid() {
+		return errInvalidDifficulty
+	}
+	return validateGradingMode(r.GradingMode)
+}
+
+var errInvalidDifficulty = errors.New("invalid difficulty: must be easy, medium, or hard")
+
+// validateExpectedAnswers checks that a question request gives its answer
+// either as the single expected_answer or as the expected_answers list.
+func validateExpectedAnswers(single string, list []string) error {
+	if single != "" && len(list) > 0 {
+		return errors.New("give either expected_answer or expected_answers, not both")
+	}
+	if single == "" && len(list) == 0 {
 		return errors.New("expected_answer is required")
 	}
+	for _, answer := range list {
+		if strings.TrimSpace(answer) == "" {
+			return errors.New("expected_answers must not contain empty answers")
+		}
+	}
 	return nil
 }
 
+// splitExpectedAnswers returns a question's primary expected answer and its
+// alternatives from a request's expected_answer or expected_answers. A
+// single answer is a list of one.
+func splitExpectedAnswers(single string, list []string) (string, []string) {
+	if len(list) == 0 {
+		return single, nil
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list[0], list[1:]
+}
+
+// PatchQuestionRequest updates a question's difficulty.
+type PatchQuestionRequest struct {
+	Difficulty *string `json:"difficulty" example:"hard"`
+}
+
+func (r *PatchQuestionRequest) Validate() error {
+	if r.Difficulty == nil {
+		return errors.New("difficulty is required")
+	}
+	if !questionbank.Difficulty(*r.Difficulty).IsValid() {
+		return errInvalidDifficulty
+	}
+	return nil
+}
+
+// MoveQuestionRequest moves or copies a question to another bank.
+type MoveQuestionRequest struct {
+	TargetBankID string `json:"target_bank_id" example:"x9y8z7w6v5u4t3s2"`
+	Copy         bool   `json:"copy" example:"false"` // copy with fresh stats instead of moving
+}
+
+func (r *MoveQuestionRequest) Validate() error {
+	if r.TargetBankID == "" {
+		return errors.New("target_bank_id is required")
+	}
+	return nil
+}
+
+// validateGradingMode checks an optional per-question grading mode override.
+func validateGradingMode(mode *string) error {
+	if mode != nil && !questionbank.GradingMode(*mode).IsValid() {
+		return errors.New("invalid grading_mode: must be llm or exact")
+	}
+	return nil
+}
+
+// parseGradingMode converts an optional grading mode string to its domain type.
+func parseGradingMode(mode *string) *questionbank.GradingMode {
+	if mode == nil {
+		return nil
+	}
+	m := questionbank.GradingMode(*mode)
+	return &m
+}
+
+// lastAnsweredAt returns when stats were last graded, or nil when that is
+// unknown, for responses.
+func lastAnsweredAt(stats *questionbank.QuestionStats) *time.Time {
+	if stats == nil || stats.LastAnswered.IsZero() {
+		return nil
+	}
+	t := stats.LastAnswered
+	return &t
+}
+
+// gradingModeString converts an optional domain grading mode to a string for responses.
+func gradingModeString(mode *questionbank.GradingMode) *string {
+	if mode == nil {
+		return nil
+	}
+	s := string(*mode)
+	return &s
+}
+
 type AddQuestionResponse struct {
-	ID             string  `json:"id" example:"q1w2e3r4t5y6u7i8"`
-	Subject        string  `json:"subject" example:"What is a goroutine?"`
-	ExpectedAnswer string  `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
-	GradingPrompt  *string `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
-	Mastery        int     `json:"mastery" example:"0"`
-	TimesAnswered  int     `json:"times_answered" example:"0"`
-	TimesCorrect   int     `json:"times_correct" example:"0"`
+	ID              string   `json:"id" example:"q1w2e3r4t5y6u7i8"`
+	Subject         string   `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer  string   `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	ExpectedAnswers []string `json:"expected_answers"` // every acceptable answer, expected_answer first
+	GradingPrompt   *string  `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
+	Rubric          *string  `json:"rubric,omitempty" example:"- Names the race condition\n- Proposes a fix"`
+	GradingMode     *string  `json:"grading_mode,omitempty" example:"exact"`
+	Difficulty      string   `json:"difficulty" example:"medium"`
+	Mastery         int      `json:"mastery" example:"0"`
+	TimesAnswered   int      `json:"times_answered" example:"0"`
+	TimesCorrect    int      `json:"times_correct" example:"0"`
+	PassThreshold   int      `json:"pass_threshold" example:"70"` // minimum score counted in times_correct
+}
+
+type QuestionDetailResponse struct {
+	ID              string     `json:"id" example:"q1w2e3r4t5y6u7i8"`
+	BankID          string     `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	Subject         string     `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer  string     `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	ExpectedAnswers []string   `json:"expected_answers"` // every acceptable answer, expected_answer first
+	GradingPrompt   *string    `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
+	Rubric          *string    `json:"rubric,omitempty" example:"- Names the race condition\n- Proposes a fix"`
+	GradingMode     *string    `json:"grading_mode,omitempty" example:"exact"`
+	Difficulty      string     `json:"difficulty" example:"medium"`
+	Tags            []string   `json:"tags" example:"channels,goroutines"`
+	Mastery         int        `json:"mastery" example:"75"`
+	TimesAnswered   int        `json:"times_answered" example:"4"`
+	TimesCorrect    int        `json:"times_correct" example:"3"`
+	PassThreshold   int        `json:"pass_threshold" example:"70"` // minimum score counted in times_correct and streak
+	Accuracy        int        `json:"accuracy" example:"75"`
+	LatestScore     int        `json:"latest_score" example:"90"`
+	LastAnsweredAt  *time.Time `json:"last_answered_at,omitempty" example:"2025-01-01T12:00:00Z"` // omitted when never answered
+	Streak          int        `json:"streak" example:"2"`
 }
 
 // ── Handlers ────────────────────────────────────────────────────────────────
 
+// getQuestion returns a single question with its full stats.
+// @Summary      Get a question
+// @Description  Returns a question from a bank along with its difficulty, mastery, answer counts, accuracy, and current correct-answer streak.
+// @Tags         Questions
+// @Produce      json
+// @Param        bankID      path      string  true  "Bank ID"
+// @Param        questionID  path      string  true  "Question ID"
+// @Success      200         {object}  QuestionDetailResponse
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /banks/{bankID}/questions/{questionID} [get]
+func (h *Handler) getQuestion(w http.ResponseWriter, r *http.Request) {
+	h.respondQuestionDetail(w, r.Context(), r.PathValue("bankID"), r.PathValue("questionID"))
+}
+
+// patchQuestion changes a question's difficulty.
+// @Summary      Set a question's difficulty
+// @Description  Set the difficulty (easy, medium or hard) of a question. Difficulty weights the question in its bank's mastery: easy counts 1, medium 2 and hard 3.
+// @Tags         Questions
+// @Accept       json
+// @Produce      json
+// @Param        bankID      path      string                true  "Bank ID"
+// @Param        questionID  path      string                true  "Question ID"
+// @Param        body        body      PatchQuestionRequest  true  "New difficulty"
+// @Success      200         {object}  QuestionDetailResponse
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /banks/{bankID}/questions/{questionID} [patch]
+func (h *Handler) patchQuestion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+	questionID := r.PathValue("questionID")
+
+	var req PatchQuestionRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	if _, err := h.store.GetQuestion(ctx, bankID, questionID); h.handleStoreError(w, err, "question") {
+		return
+	}
+	err := h.store.UpdateQuestionDifficulty(ctx, questionID, questionbank.Difficulty(*req.Difficulty))
+	if h.handleStoreError(w, err, "question") {
+		return
+	}
+
+	h.respondQuestionDetail(w, ctx, bankID, questionID)
+}
+
+// resetQuestionStats zeroes a question's stats.
+// @Summary      Reset a question's stats
+// @Description  Zeroes the question's mastery, answer counts and latest score, e.g. after its expected answer changed, and returns the question with its fresh stats. Past grades are kept and still appear in its history.
+// @Tags         Questions
+// @Produce      json
+// @Param        bankID      path      string  true  "Bank ID"
+// @Param        questionID  path      string  true  "Question ID"
+// @Success      200         {object}  QuestionDetailResponse
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /banks/{bankID}/questions/{questionID}/stats [delete]
+func (h *Handler) resetQuestionStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+	questionID := r.PathValue("questionID")
+
+	if _, err := h.store.GetQuestion(ctx, bankID, questionID); h.handleStoreError(w, err, "question") {
+		return
+	}
+	if err := h.store.ResetQuestionStats(ctx, questionID); err != nil {
+		h.logger.Error("failed to reset question stats", "question_id", questionID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to reset stats")
+		return
+	}
+
+	h.respondQuestionDetail(w, ctx, bankID, questionID)
+}
+
+// moveQuestion moves or copies a question to another bank.
+// @Summary      Move or copy a question
+// @Description  Move a question to the end of another bank, keeping its ID, stats and history, or with copy=true add a copy with a new ID and no stats. Questions can only move between code banks or between non-code banks. Returns the question in the target bank.
+// @Tags         Questions
+// @Accept       json
+// @Produce      json
+// @Param        bankID      path      string               true  "Bank ID"
+// @Param        questionID  path      string               true  "Question ID"
+// @Param        body        body      MoveQuestionRequest  true  "Target bank"
+// @Success      200         {object}  QuestionDetailResponse
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      409         {object}  map[string]string  "target bank is in the Deleted folder"
+// @Failure      500         {object}  map[string]string
+// @Router       /banks/{bankID}/questions/{questionID}/move [post]
+func (h *Handler) moveQuestion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+	questionID := r.PathValue("questionID")
+
+	var req MoveQuestionRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	if _, err := h.store.GetQuestion(ctx, bankID, questionID); h.handleStoreError(w, err, "question") {
+		return
+	}
+	source, err := h.store.GetBank(ctx, bankID)
+	if h.handleStoreError(w, err, "bank") {
+		return
+	}
+	target, err := h.store.GetBank(ctx, req.TargetBankID)
+	if h.handleStoreError(w, err, "target bank") {
+		return
+	}
+	if !req.Copy && target.ID == source.ID {
+		respondError(w, http.StatusBadRequest, "question is already in the target bank")
+		return
+	}
+	// Code questions are graded as code, so they only fit code banks.
+	if (source.BankType == questionbank.BankTypeCode) != (target.BankType == questionbank.BankTypeCode) {
+		respondError(w, http.StatusBadRequest, "cannot move a question from a "+string(source.BankType)+" bank to a "+string(target.BankType)+" bank")
+		return
+	}
+	trashed, err := h.isBankTrashed(ctx, target)
+	if h.handleStoreError(w, err, "target bank") {
+		return
+	}
+	if trashed {
+		respondError(w, http.StatusConflict, "cannot add questions to a bank in the Deleted folder")
+		return
+	}
+
+	resultID := questionID
+	if req.Copy {
+		resultID = id.GenerateID()
+		err = h.store.CopyQuestion(ctx, questionID, target.ID, resultID)
+	} else {
+		err = h.store.MoveQuestion(ctx, questionID, target.ID)
+	}
+	if h.handleStoreError(w, err, "question") {
+		return
+	}
+
+	h.respondQuestionDetail(w, ctx, target.ID, resultID)
+}
+
+// respondQuestionDetail writes a question of bankID with its stats.
+func (h *Handler) respondQuestionDetail(w http.ResponseWriter, ctx context.Context, bankID, questionID string) {
+	q, err := h.store.GetQuestion(ctx, bankID, questionID)
+	if h.handleStoreError(w, err, "question") {
+		return
+	}
+
+	stats, err := h.store.GetQuestionStats(ctx, questionID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get stats")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, QuestionDetailResponse{
+		ID:              q.ID,
+		BankID:          bankID,
+		Subject:         q.Subject,
+		ExpectedAnswer:  q.ExpectedAnswer,
+		ExpectedAnswers: q.ExpectedAnswers(),
+		GradingPrompt:   q.GradingPrompt,
+		Rubric:          q.Rubric,
+		GradingMode:     gradingModeString(q.GradingMode),
+		Difficulty:      string(q.Difficulty.OrDefault()),
+		Tags:            q.Tags,
+		Mastery:         stats.Mastery,
+		TimesAnswered:   stats.TimesAnswered,
+		TimesCorrect:    stats.TimesCorrect,
+		PassThreshold:   h.store.PassThreshold(),
+		Accuracy:        stats.Accuracy(),
+		LatestScore:     stats.LatestScore,
+		LastAnsweredAt:  lastAnsweredAt(stats),
+		Streak:          stats.Streak,
+	})
+}
+
 // addQuestion adds a new question to a bank.
 // @Summary      Add a question
-// @Description  Add a new question with an expected answer to a question bank.
+// @Description  Add a new question with an expected answer to a question bank. A question with several equally correct answers lists them in expected_answers instead, primary first; answers are graded against whichever matches best. A question whose subject nearly repeats one already in the bank (at least 80% of their distinct words in common, ignoring case, punctuation and word order) is rejected with 409 and the ID of that question, unless force=true. Banks in the system "Deleted" folder reject new questions with 409.
 // @Tags         Questions
 // @Accept       json
 // @Produce      json
 // @Param        bankID  path      string              true  "Bank ID"
+// @Param        force   query     bool                false "Add the question even if it nearly repeats one in the bank"
 // @Param        body    body      AddQuestionRequest   true  "Question to add"
 // @Success      201     {object}  AddQuestionResponse
 // @Failure      400     {object}  map[string]string
 // @Failure      404     {object}  map[string]string
+// @Failure      409     {object}  map[string]string  "bank is in the Deleted folder, or a near-duplicate question exists"
 // @Failure      500     {object}  map[string]string
 // @Router       /banks/{bankID}/questions [post]
 func (h *Handler) addQuestion(w http.ResponseWriter, r *http.Request) {
@@ -59,86 +377,161 @@ func (h *Handler) addQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	trashed, err := h.isBankTrashed(ctx, bank)
+	if h.handleStoreError(w, err, "bank") {
+		return
+	}
+	if trashed {
+		respondError(w, http.StatusConflict, "cannot add questions to a bank in the Deleted folder")
+		return
+	}
+
+	force, ok := parseBoolQuery(w, r, "force")
+	if !ok {
+		return
+	}
 	var req AddQuestionRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
 		return
 	}
 
-	if err := bank.AddQuestionWithGradingPrompt(req.Subject, req.ExpectedAnswer, req.GradingPrompt); err != nil {
+	if !force {
+		if dup := bank.FindNearDuplicate(req.Subject, questionbank.DuplicateSubjectThreshold); dup != nil {
+			respondJSON(w, http.StatusConflict, map[string]string{
+				"error":       "a question with a similar subject already exists in this bank; add it with force=true to keep both",
+				"question_id": dup.ID,
+				"subject":     dup.Subject,
+			})
+			return
+		}
+	}
+
+	expected, alternatives := splitExpectedAnswers(req.ExpectedAnswer, req.ExpectedAnswers)
+	if err := bank.AddQuestionWithGradingPrompt(req.Subject, expected, req.GradingPrompt); err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	newQuestion := bank.Questions[len(bank.Questions)-1]
+	newQuestion.AlternativeAnswers = alternatives
+	newQuestion.GradingMode = parseGradingMode(req.GradingMode)
+	newQuestion.Rubric = req.Rubric
+	if req.Difficulty != nil {
+		newQuestion.Difficulty = questionbank.Difficulty(*req.Difficulty)
+	}
 	if err := h.store.AddQuestion(ctx, bankID, newQuestion); err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to save question")
 		return
 	}
 
 	respondJSON(w, http.StatusCreated, AddQuestionResponse{
-		ID:             newQuestion.ID,
-		Subject:        newQuestion.Subject,
-		ExpectedAnswer: newQuestion.ExpectedAnswer,
-		GradingPrompt:  newQuestion.GradingPrompt,
-		Mastery:        0,
-		TimesAnswered:  0,
-		TimesCorrect:   0,
+		ID:              newQuestion.ID,
+		Subject:         newQuestion.Subject,
+		ExpectedAnswer:  newQuestion.ExpectedAnswer,
+		ExpectedAnswers: newQuestion.ExpectedAnswers(),
+		GradingPrompt:   newQuestion.GradingPrompt,
+		Rubric:          newQuestion.Rubric,
+		GradingMode:     req.GradingMode,
+		Difficulty:      string(newQuestion.Difficulty),
+		Mastery:         0,
+		TimesAnswered:   0,
+		TimesCorrect:    0,
+		PassThreshold:   h.store.PassThreshold(),
 	})
 }
 
+// isBankTrashed reports whether bank belongs to a category inside the system
+// "Deleted" folder. Such banks are read-only until restored.
+func (h *Handler) isBankTrashed(ctx context.Context, bank *questionbank.QuestionBank) (bool, error) {
+	if bank.CategoryID == nil {
+		return false, nil
+	}
+	cat, err := h.store.GetCategory(ctx, *bank.CategoryID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return h.isCategoryTrashed(ctx, cat)
+}
+
+// isCategoryTrashed reports whether cat is inside the system "Deleted"
+// folder.
+func (h *Handler) isCategoryTrashed(ctx context.Context, cat *category.Category) (bool, error) {
+	if cat.FolderID == nil {
+		return false, nil
+	}
+	f, err := h.store.GetFolder(ctx, *cat.FolderID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return f.IsSystem, nil
+}
+
 // ── Update Question ──────────────────────────────────────────────────────────
 
 type UpdateQuestionRequest struct {
-	Subject        string  `json:"subject"`
-	ExpectedAnswer string  `json:"expected_answer"`
-	GradingPrompt  *string `json:"grading_prompt,omitempty"`
+	Subject         string   `json:"subject"`
+	ExpectedAnswer  string   `json:"expected_answer,omitempty"`
+	ExpectedAnswers []string `json:"expected_answers,omitempty"` // replaces expected_answer, see AddQuestionRequest
+	GradingPrompt   *string  `json:"grading_prompt,omitempty"`
+	Rubric          *string  `json:"rubric,omitempty"`
+	GradingMode     *string  `json:"grading_mode,omitempty"`
 }
 
 func (r *UpdateQuestionRequest) Validate() error {
 	if r.Subject == "" {
 		return errors.New("subject is required")
 	}
-	if r.ExpectedAnswer == "" {
-		return errors.New("expected_answer is required")
+	if err := validateExpectedAnswers(r.ExpectedAnswer, r.ExpectedAnswers); err != nil {
+		return err
 	}
-	return nil
-}
-
-type UpdateQuestionResponse struct {
-	ID             string  `json:"id"`
-	Subject        string  `json:"subject"`
-	ExpectedAnswer string  `json:"expected_answer"`
-	GradingPrompt  *string `json:"grading_prompt,omitempty"`
+	return validateGradingMode(r.GradingMode)
 }
 
 // updateQuestion updates an existing question's content.
 // @Summary      Update a question
-// @Description  Update the subject, expected answer, and grading prompt of a question.
+// @Description  Update the subject, expected answers, grading prompt, and rubric of a question. Its difficulty and stats are kept. Returns the question with its current stats.
 // @Tags         Questions
 // @Accept       json
 // @Produce      json
 // @Param        bankID      path      string                true  "Bank ID"
 // @Param        questionID  path      string                true  "Question ID"
 // @Param        body        body      UpdateQuestionRequest  true  "Updated question data"
-// @Success      200         {object}  UpdateQuestionResponse
+// @Success      200         {object}  QuestionDetailResponse
 // @Failure      400         {object}  map[string]string
 // @Failure      404         {object}  map[string]string
 // @Failure      500         {object}  map[string]string
 // @Router       /banks/{bankID}/questions/{questionID} [put]
 func (h *Handler) updateQuestion(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	bankID := r.PathValue("bankID")
 	questionID := r.PathValue("questionID")
 
 	var req UpdateQuestionRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	// Scope the update to bankID: a question ID alone would let a request
+	// through any bank's path edit it.
+	if _, err := h.store.GetQuestion(ctx, bankID, questionID); h.handleStoreError(w, err, "question") {
 		return
 	}
 
+	expected, alternatives := splitExpectedAnswers(req.ExpectedAnswer, req.ExpectedAnswers)
 	updated := questionbank.Question{
-		ID:             questionID,
-		Subject:        req.Subject,
-		ExpectedAnswer: req.ExpectedAnswer,
-		GradingPrompt:  req.GradingPrompt,
+		ID:                 questionID,
+		Subject:            req.Subject,
+		ExpectedAnswer:     expected,
+		AlternativeAnswers: alternatives,
+		GradingPrompt:      req.GradingPrompt,
+		Rubric:             req.Rubric,
+		GradingMode:        parseGradingMode(req.GradingMode),
 	}
 
 	if err := h.store.UpdateQuestion(ctx, updated); err != nil {
@@ -149,21 +542,18 @@ func (h *Handler) updateQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, UpdateQuestionResponse{
-		ID:             updated.ID,
-		Subject:        updated.Subject,
-		ExpectedAnswer: updated.ExpectedAnswer,
-		GradingPrompt:  updated.GradingPrompt,
-	})
+	h.respondQuestionDetail(w, ctx, bankID, questionID)
 }
 
-// deleteQuestion removes a question from a bank.
+// deleteQuestion moves a question to the trash, or removes it for good.
 // @Summary      Delete a question
-// @Description  Delete a question and its statistics.
+// @Description  Move a question to the trash, keeping its statistics; see GET /trash. With permanent=true, delete the question, trashed or not, and its statistics instead.
 // @Tags         Questions
-// @Param        bankID      path  string  true  "Bank ID"
-// @Param        questionID  path  string  true  "Question ID"
+// @Param        bankID      path   string  true   "Bank ID"
+// @Param        questionID  path   string  true   "Question ID"
+// @Param        permanent   query  bool    false  "Delete for good instead of moving to the trash"
 // @Success      204
+// @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /banks/{bankID}/questions/{questionID} [delete]
@@ -171,7 +561,15 @@ func (h *Handler) deleteQuestion(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	questionID := r.PathValue("questionID")
 
-	if h.handleStoreError(w, h.store.DeleteQuestion(ctx, questionID), "question") {
+	permanent, ok := parsePermanent(w, r)
+	if !ok {
+		return
+	}
+	remove := h.store.DeleteQuestion
+	if permanent {
+		remove = h.store.PurgeQuestion
+	}
+	if h.handleStoreError(w, remove(ctx, questionID), "question") {
 		return
 	}
 
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+const (
+	defaultQuestionHistoryLimit = 20
+	maxQuestionHistoryLimit     = 200
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type QuestionHistoryEntry struct {
+	SessionID  string     `json:"session_id" example:"s1e2s3s4i5o6n7i8"`
+	Score      int        `json:"score" example:"80"`
+	Covered    []string   `json:"covered" example:"goroutines are lightweight"`
+	Missed     []string   `json:"missed" example:"managed by Go runtime"`
+	UserAnswer string     `json:"user_answer" example:"A goroutine is a lightweight thread."`
+	Status     string     `json:"status" example:"success"`                           // "success" or "failed"
+	GradedAt   *time.Time `json:"graded_at,omitempty" example:"2025-01-15T10:30:00Z"` // omitted for grades saved before grading times were recorded
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// getQuestionHistory lists a question's grades across sessions.
+// @Summary      Get a question's answer history
+// @Description  Returns the grades of a question across all its sessions, newest first, so you can see how your answers changed over time. Grades of restarted sessions are not kept.
+// @Tags         Questions
+// @Produce      json
+// @Param        bankID      path      string  true   "Bank ID"
+// @Param        questionID  path      string  true   "Question ID"
+// @Param        limit       query     int     false  "Maximum number of grades (default 20, max 200)"
+// @Success      200         {array}   QuestionHistoryEntry
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /banks/{bankID}/questions/{questionID}/history [get]
+func (h *Handler) getQuestionHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	questionID := r.PathValue("questionID")
+
+	limit := defaultQuestionHistoryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxQuestionHistoryLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxQuestionHistoryLimit))
+			return
+		}
+		limit = n
+	}
+
+	if _, err := h.store.GetQuestion(ctx, r.PathValue("bankID"), questionID); h.handleStoreError(w, err, "question") {
+		return
+	}
+
+	grades, err := h.store.GetGradesByQuestion(ctx, questionID, limit)
+	if err != nil {
+		h.logger.Error("failed to load question history", "question_id", questionID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load history")
+		return
+	}
+
+	history := make([]QuestionHistoryEntry, len(grades))
+	for i, g := range grades {
+		status := "success"
+		if g.Status == store.GradeStatusFailed {
+			status = "failed"
+		}
+		history[i] = QuestionHistoryEntry{
+			SessionID:  g.SessionID,
+			Score:      g.Score,
+			Covered:    g.Covered,
+			Missed:     g.Missed,
+			UserAnswer: g.UserAnswer,
+			Status:     status,
+		}
+		if !g.GradedAt.IsZero() {
+			gradedAt := g.GradedAt
+			history[i].GradedAt = &gradedAt
+		}
+	}
+	respondJSON(w, http.StatusOK, history)
+}
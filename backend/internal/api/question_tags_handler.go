@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type SetQuestionTagsRequest struct {
+	Tags []string `json:"tags" example:"channels,goroutines"`
+}
+
+// Validate normalizes the request's tags in place.
+func (r *SetQuestionTagsRequest) Validate() error {
+	var err error
+	r.Tags, err = normalizeTags(r.Tags)
+	return err
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// setQuestionTags replaces a question's tags.
+// @Summary      Set a question's tags
+// @Description  Replace the tags of a question. Tags are trimmed and lowercased and duplicates are dropped; an empty list removes all tags. Sessions can be limited to questions with given tags.
+// @Tags         Questions
+// @Accept       json
+// @Produce      json
+// @Param        bankID      path      string                  true  "Bank ID"
+// @Param        questionID  path      string                  true  "Question ID"
+// @Param        body        body      SetQuestionTagsRequest  true  "New tags"
+// @Success      200         {object}  QuestionDetailResponse
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /banks/{bankID}/questions/{questionID}/tags [put]
+func (h *Handler) setQuestionTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+	questionID := r.PathValue("questionID")
+
+	var req SetQuestionTagsRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	err := h.store.SetQuestionTags(ctx, bankID, questionID, req.Tags)
+	if h.handleStoreError(w, err, "question") {
+		return
+	}
+
+	h.respondQuestionDetail(w, ctx, bankID, questionID)
+}
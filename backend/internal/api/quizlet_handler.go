@@ -0,0 +1,171 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type ImportQuizletRequest struct {
+	Subject    string `json:"subject" example:"Spanish verbs"`
+	CategoryID string `json:"category_id" example:"a1b2c3d4e5f6g7h8"`
+	Text       string `json:"text" example:"hablar\tto speak\ncomer\tto eat"`
+
+	// Separators as chosen in Quizlet's export dialog. They default to a tab
+	// between term and definition and a newline between cards.
+	TermSeparator *string `json:"term_separator,omitempty" example:","`
+	CardSeparator *string `json:"card_separator,omitempty" example:";"`
+}
+
+func (r *ImportQuizletRequest) Validate() error {
+	if r.Subject == "" {
+		return errors.New("subject is required")
+	}
+	if r.CategoryID == "" {
+		return errors.New("category_id is required")
+	}
+	if strings.TrimSpace(r.Text) == "" {
+		return errors.New("text is required")
+	}
+	if r.TermSeparator != nil && *r.TermSeparator == "" {
+		return errors.New("term_separator cannot be empty")
+	}
+	if r.CardSeparator != nil && *r.CardSeparator == "" {
+		return errors.New("card_separator cannot be empty")
+	}
+	if r.termSeparator() == r.cardSeparator() {
+		return errors.New("term_separator and card_separator must differ")
+	}
+	return nil
+}
+
+func (r *ImportQuizletRequest) termSeparator() string {
+	if r.TermSeparator != nil {
+		return *r.TermSeparator
+	}
+	return "\t"
+}
+
+func (r *ImportQuizletRequest) cardSeparator() string {
+	if r.CardSeparator != nil {
+		return *r.CardSeparator
+	}
+	return "\n"
+}
+
+type ImportQuizletResponse struct {
+	BankID           string `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	Subject          string `json:"subject" example:"Spanish verbs"`
+	QuestionsCreated int    `json:"questions_created" example:"42"`
+	CardsSkipped     int    `json:"cards_skipped" example:"1"` // cards missing a term or a definition
+}
+
+// quizletCard is one term/definition pair of a Quizlet export.
+type quizletCard struct {
+	Term       string
+	Definition string
+}
+
+// parseQuizlet splits a Quizlet export into cards. Each card is split at
+// the first term separator. When cards are separated by newlines, a line
+// without a term separator continues the previous card's definition, so
+// multi-line definitions survive. Blank cards, such as those left by
+// trailing separators, are dropped; the skipped count is the number of
+// remaining cards without a term or a definition.
+func parseQuizlet(text, termSep, cardSep string) (cards []quizletCard, skipped int) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var chunks []string
+	for _, chunk := range strings.Split(text, cardSep) {
+		if cardSep == "\n" && len(chunks) > 0 && !strings.Contains(chunk, termSep) {
+			if strings.TrimSpace(chunk) != "" {
+				chunks[len(chunks)-1] += "\n" + chunk
+			}
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+		term, definition, _ := strings.Cut(chunk, termSep)
+		card := quizletCard{
+			Term:       strings.TrimSpace(term),
+			Definition: strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(definition), strings.TrimSpace(termSep))),
+		}
+		if card.Term == "" || card.Definition == "" {
+			skipped++
+			continue
+		}
+		cards = append(cards, card)
+	}
+	return cards, skipped
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// importQuizlet creates a bank from a Quizlet text export.
+// @Summary      Import a Quizlet set
+// @Description  Create a theory bank in a category from text exported by Quizlet. Terms become question subjects and definitions their expected answers. Separators default to a tab between term and definition and a newline between cards; with newline-separated cards, lines without a term separator continue the previous definition. Blank cards and trailing separators are ignored, and cards missing a term or definition are skipped. Categories in the system "Deleted" folder reject imports with 409.
+// @Tags         Import/Export
+// @Accept       json
+// @Produce      json
+// @Param        body  body      ImportQuizletRequest   true  "Quizlet export"
+// @Success      201   {object}  ImportQuizletResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string  "category not found"
+// @Failure      409   {object}  map[string]string  "category is in the Deleted folder"
+// @Failure      500   {object}  map[string]string
+// @Router       /import/quizlet [post]
+func (h *Handler) importQuizlet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req ImportQuizletRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	cards, skipped := parseQuizlet(req.Text, req.termSeparator(), req.cardSeparator())
+	if len(cards) == 0 {
+		respondError(w, http.StatusBadRequest, "no cards found: check the separators")
+		return
+	}
+
+	cat, err := h.store.GetCategory(ctx, req.CategoryID)
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
+	trashed, err := h.isCategoryTrashed(ctx, cat)
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
+	if trashed {
+		respondError(w, http.StatusConflict, "cannot import into a category in the Deleted folder")
+		return
+	}
+
+	bank := questionbank.NewWithCategory(req.Subject, req.CategoryID)
+	for _, card := range cards {
+		if err := bank.AddQuestion(card.Term, card.Definition); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.store.SaveBankWithQuestions(ctx, bank); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to save bank")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, ImportQuizletResponse{
+		BankID:           bank.ID,
+		Subject:          bank.Subject,
+		QuestionsCreated: len(cards),
+		CardsSkipped:     skipped,
+	})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiterSweepInterval is how often a rateLimiter drops idle buckets.
+const rateLimiterSweepInterval = time.Minute
+
+// rateLimiter is an in-memory token bucket per client key. Each bucket
+// holds up to burst tokens and refills at rate tokens per second.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newRateLimiter allows perMinute requests per minute per key, in bursts of
+// up to perMinute.
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// take takes up to n whole tokens from key's bucket at now and returns how
+// many it took. When it takes none it also returns how long until the
+// bucket has one.
+func (l *rateLimiter) take(key string, n int, now time.Time) (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refilled(b, now)
+	b.updated = now
+	if taken := min(n, int(b.tokens)); taken > 0 {
+		b.tokens -= float64(taken)
+		return taken, 0
+	}
+	return 0, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// refilled returns b's tokens at now.
+func (l *rateLimiter) refilled(b *tokenBucket, now time.Time) float64 {
+	return min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+}
+
+// sweep drops, at most once per rateLimiterSweepInterval, the buckets that
+// have refilled completely: they behave exactly like a new bucket, so
+// keeping them would only leak memory. l.mu must be held.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimiterSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refilled(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitKey identifies the client of r: the bearer token RequireAPIKey
+// validated for it, otherwise its IP address. An unchecked token is
+// ignored, or every made-up one would get a bucket of its own.
+func rateLimitKey(r *http.Request) string {
+	if token, _ := r.Context().Value(apiKeyKey{}).(string); token != "" {
+		return "key:" + token
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimitAnswers rejects answer submissions beyond the limit set with
+// SetAnswerRateLimit, since every answer costs a model call.
+func (h *Handler) rateLimitAnswers(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.allowAnswers(w, r, 1) > 0 {
+			next(w, r)
+		}
+	}
+}
+
+// allowAnswers counts up to n answers from r's client against the answer
+// rate limit and returns how many are within it; every answer beyond that
+// must be turned away. When none is, it writes a 429 response with a
+// Retry-After header.
+func (h *Handler) allowAnswers(w http.ResponseWriter, r *http.Request, n int) int {
+	l := h.answerLimiter
+	if l == nil {
+		return n
+	}
+	allowed, wait := l.take(rateLimitKey(r), n, time.Now())
+	if allowed == 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondError(w, http.StatusTooManyRequests, "too many answers submitted, try again later")
+	}
+	return allowed
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultReviewLogLimit = 50
+	maxReviewLogLimit     = 500
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type BankReviewResponse struct {
+	BankID     string    `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	ReviewedAt time.Time `json:"reviewed_at" example:"2025-01-15T10:30:00Z"`
+}
+
+type BankReviewEntry struct {
+	ReviewedAt time.Time `json:"reviewed_at" example:"2025-01-15T10:30:00Z"`
+}
+
+type BankReviewLogResponse struct {
+	BankID  string            `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	Reviews []BankReviewEntry `json:"reviews"`
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// logBankReview records that a bank was reviewed without a graded session.
+// @Summary      Mark a bank as reviewed
+// @Description  Record a lightweight review of the bank at the current time, e.g. after reading through its questions without answering them. Nothing is graded and no stats change.
+// @Tags         Banks
+// @Produce      json
+// @Param        bankID  path      string  true  "Bank ID"
+// @Success      201     {object}  BankReviewResponse
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /banks/{bankID}/review-log [post]
+func (h *Handler) logBankReview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+
+	if _, err := h.store.GetBank(ctx, bankID); h.handleStoreError(w, err, "bank") {
+		return
+	}
+
+	now := time.Now()
+	if err := h.store.LogBankReview(ctx, bankID, now); err != nil {
+		h.logger.Error("failed to log bank review", "bank_id", bankID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to log review")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, BankReviewResponse{BankID: bankID, ReviewedAt: now.UTC()})
+}
+
+// getBankReviewLog lists a bank's logged reviews.
+// @Summary      Get a bank's review log
+// @Description  Returns the reviews recorded with POST /banks/{bankID}/review-log, newest first.
+// @Tags         Banks
+// @Produce      json
+// @Param        bankID  path      string  true   "Bank ID"
+// @Param        limit   query     int     false  "Maximum number of reviews (default 50, max 500)"
+// @Success      200     {object}  BankReviewLogResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /banks/{bankID}/review-log [get]
+func (h *Handler) getBankReviewLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+
+	limit := defaultReviewLogLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxReviewLogLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxReviewLogLimit))
+			return
+		}
+		limit = n
+	}
+
+	if _, err := h.store.GetBank(ctx, bankID); h.handleStoreError(w, err, "bank") {
+		return
+	}
+
+	reviews, err := h.store.ListBankReviews(ctx, bankID, limit)
+	if err != nil {
+		h.logger.Error("failed to list bank reviews", "bank_id", bankID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load review log")
+		return
+	}
+
+	resp := BankReviewLogResponse{BankID: bankID, Reviews: make([]BankReviewEntry, len(reviews))}
+	for i, at := range reviews {
+		resp.Reviews[i] = BankReviewEntry{ReviewedAt: at.UTC()}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
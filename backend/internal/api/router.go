@@ -1,9 +1,37 @@
 package api
 
-import "net/http"
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// routeMux is the part of http.ServeMux RegisterRoutes uses.
+type routeMux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// methodRecorder collects the methods of the patterns registered on it.
+type methodRecorder map[string]bool
+
+func (m methodRecorder) HandleFunc(pattern string, _ func(http.ResponseWriter, *http.Request)) {
+	if method, _, ok := strings.Cut(pattern, " "); ok {
+		m[method] = true
+	}
+}
+
+// RouteMethods returns the HTTP methods RegisterRoutes registers routes
+// for, plus OPTIONS for CORS preflight requests, sorted.
+func RouteMethods() []string {
+	methods := methodRecorder{http.MethodOptions: true}
+	RegisterRoutes(methods, &Handler{})
+	return slices.Sorted(maps.Keys(methods))
+}
 
 // RegisterRoutes wires all HTTP routes to the handler methods.
-func RegisterRoutes(mux *http.ServeMux, h *Handler) {
+func RegisterRoutes(mux routeMux, h *Handler) {
 	// Folders
 	mux.HandleFunc("POST /folders", h.createFolder)
 	mux.HandleFunc("GET /folders", h.listFolders)
@@ -12,50 +40,108 @@ func RegisterRoutes(mux *http.ServeMux, h *Handler) {
 	mux.HandleFunc("DELETE /folders/{folderID}", h.deleteFolder)
 	mux.HandleFunc("GET /folders/{folderID}/categories", h.listCategoriesByFolder)
 	mux.HandleFunc("GET /folders/{folderID}/stats", h.getFolderStats)
+	mux.HandleFunc("GET /folders/{folderID}/export", h.exportFolder)
 
 	// Categories
 	mux.HandleFunc("POST /categories", h.createCategory)
 	mux.HandleFunc("GET /categories", h.listCategories)
 	mux.HandleFunc("GET /categories/{categoryID}", h.getCategory)
 	mux.HandleFunc("PUT /categories/{categoryID}", h.updateCategory)
+	mux.HandleFunc("PATCH /categories/{categoryID}", h.patchCategory)
 	mux.HandleFunc("DELETE /categories/{categoryID}", h.deleteCategory)
 	mux.HandleFunc("PATCH /categories/{categoryID}/folder", h.updateCategoryFolder)
+	mux.HandleFunc("POST /categories/{categoryID}/clone", h.cloneCategory)
 	mux.HandleFunc("PATCH /categories/reorder", h.reorderCategories)
 	mux.HandleFunc("GET /categories/{categoryID}/banks", h.listBanksByCategory)
 	mux.HandleFunc("GET /categories/{categoryID}/stats", h.getCategoryStats)
+	mux.HandleFunc("GET /categories/{categoryID}/weakest", h.getWeakestInCategory)
+	mux.HandleFunc("POST /categories/{categoryID}/weakest/session", h.createWeakestSession)
 
 	// Banks
 	mux.HandleFunc("POST /banks", h.createBank)
 	mux.HandleFunc("GET /banks", h.listBanks)
+	mux.HandleFunc("POST /banks/tags", h.bulkTagBanks)
 	mux.HandleFunc("GET /banks/{bankID}", h.getBank)
 	mux.HandleFunc("DELETE /banks/{bankID}", h.deleteBank)
+	mux.HandleFunc("POST /banks/{bankID}/restore", h.restoreBank)
 	mux.HandleFunc("PATCH /banks/{bankID}/category", h.updateBankCategory)
 	mux.HandleFunc("GET /banks/{bankID}/stats", h.getBankStats)
+	mux.HandleFunc("DELETE /banks/{bankID}/stats", h.resetBankStats)
+	mux.HandleFunc("GET /banks/{bankID}/weak-preview", h.getWeakPreview)
+	mux.HandleFunc("GET /banks/{bankID}/sessions", h.listBankSessions)
+	mux.HandleFunc("GET /banks/{bankID}/session-stats", h.getBankSessionStats)
+	mux.HandleFunc("POST /banks/{bankID}/review-log", h.logBankReview)
+	mux.HandleFunc("GET /banks/{bankID}/review-log", h.getBankReviewLog)
+	mux.HandleFunc("POST /banks/{bankID}/grade-preview", h.previewBankGrade)
+	mux.HandleFunc("POST /banks/{bankID}/grading-prompt/preview", h.previewGradingPrompt)
 
 	// Questions
 	mux.HandleFunc("POST /banks/{bankID}/questions", h.addQuestion)
+	mux.HandleFunc("POST /banks/{bankID}/questions/bulk", h.addQuestionsBulk)
+	mux.HandleFunc("GET /banks/{bankID}/questions/{questionID}", h.getQuestion)
 	mux.HandleFunc("PUT /banks/{bankID}/questions/{questionID}", h.updateQuestion)
+	mux.HandleFunc("PATCH /banks/{bankID}/questions/{questionID}", h.patchQuestion)
 	mux.HandleFunc("DELETE /banks/{bankID}/questions/{questionID}", h.deleteQuestion)
+	mux.HandleFunc("GET /banks/{bankID}/questions/{questionID}/history", h.getQuestionHistory)
+	mux.HandleFunc("DELETE /banks/{bankID}/questions/{questionID}/stats", h.resetQuestionStats)
+	mux.HandleFunc("POST /banks/{bankID}/questions/{questionID}/move", h.moveQuestion)
+	mux.HandleFunc("PUT /banks/{bankID}/questions/{questionID}/tags", h.setQuestionTags)
 
 	// Sessions
 	mux.HandleFunc("POST /sessions", h.createSession)
 	mux.HandleFunc("POST /sessions/quick", h.createQuickSession)
+	mux.HandleFunc("POST /sessions/preview", h.previewSession)
+	mux.HandleFunc("POST /sessions/estimate", h.estimateSession)
+	mux.HandleFunc("GET /sessions/incomplete", h.listIncompleteSessions)
 	mux.HandleFunc("GET /sessions/{sessionID}", h.getSession)
-	mux.HandleFunc("POST /sessions/{sessionID}/answers", h.submitAnswer)
+	mux.HandleFunc("POST /sessions/{sessionID}/answers", h.rateLimitAnswers(h.submitAnswer))
+	mux.HandleFunc("POST /sessions/{sessionID}/answers/batch", h.submitAnswers)
 	mux.HandleFunc("POST /sessions/{sessionID}/complete", h.completeSession)
+	mux.HandleFunc("POST /sessions/{sessionID}/restart", h.restartSession)
+	mux.HandleFunc("GET /sessions/{sessionID}/grades", h.getSessionGrades)
 
 	// Stats
 	mux.HandleFunc("GET /stats", h.getOverallStats)
+	mux.HandleFunc("GET /stats/export.csv", h.exportStatsCSV)
+	mux.HandleFunc("GET /stats/overview", h.getStatsOverview)
+	mux.HandleFunc("GET /stats/by-type", h.getStatsByType)
+	mux.HandleFunc("GET /stats/activity", h.getActivity)
+
+	// Search
+	mux.HandleFunc("GET /search", h.searchLibrary)
+
+	// Trash
+	mux.HandleFunc("GET /trash", h.listTrash)
+
+	// Health
+	mux.HandleFunc("GET /health/grading", h.getGradingHealth)
+	mux.HandleFunc("GET /health/llm", h.getLLMHealth)
+
+	// Tree
+	mux.HandleFunc("GET /tree", h.getTree)
+
+	// Templates
+	mux.HandleFunc("GET /templates", h.listTemplates)
+	mux.HandleFunc("POST /templates/{templateID}/install", h.installTemplate)
 
 	// Export/Import
 	mux.HandleFunc("GET /export", h.exportAll)
+	mux.HandleFunc("GET /export/ndjson", h.exportNDJSON)
 	mux.HandleFunc("POST /import", h.importAll)
+	mux.HandleFunc("POST /import/quizlet", h.importQuizlet)
 
 	// Simulate
 	mux.HandleFunc("POST /simulate/grade", h.simulateGrade)
+	mux.HandleFunc("POST /grade/preview", h.dryRunGrade)
 
 	// Generate
 	mux.HandleFunc("POST /generate/questions", h.generateQuestions)
+
+	// Admin
+	mux.HandleFunc("GET /admin/grade-failures", h.requireAdmin(h.listGradeFailures))
+	mux.HandleFunc("GET /admin/audit", h.requireAdmin(h.listAuditLog))
+	mux.HandleFunc("GET /admin/integrity", h.requireAdmin(h.checkIntegrity))
+	mux.HandleFunc("POST /admin/integrity/cleanup", h.requireAdmin(h.cleanupIntegrity))
 }
 
 // ── Shared response types ───────────────────────────────────────────────────
@@ -67,9 +153,15 @@ type ErrorResponse struct {
 
 // GradeDetails appears in session completion responses.
 type GradeDetails struct {
-	Score      int      `json:"score" example:"80"`
-	Covered    []string `json:"covered" example:"goroutines are lightweight"`
-	Missed     []string `json:"missed" example:"managed by Go runtime"`
-	UserAnswer string   `json:"user_answer" example:"A goroutine is a lightweight thread."`
-	Status     string   `json:"status" example:"success"` // "success", "failed", or "not_answered"
+	Score          int        `json:"score" example:"80"`
+	Covered        []string   `json:"covered" example:"goroutines are lightweight"`
+	Missed         []string   `json:"missed" example:"managed by Go runtime"`
+	CoveredIndices []int      `json:"covered_indices" example:"0"` // indices into the expected answer's key points
+	MissedIndices  []int      `json:"missed_indices" example:"1"`
+	UserAnswer     string     `json:"user_answer" example:"A goroutine is a lightweight thread."`
+	Status         string     `json:"status" example:"success"`                           // "success", "failed", "pending", or "not_answered"
+	Model          string     `json:"model,omitempty" example:"qwen3:8b"`                 // model that graded the answer, or "exact"; omitted for failures and older grades
+	GradedAt       *time.Time `json:"graded_at,omitempty" example:"2025-01-15T10:30:00Z"` // omitted until graded and for older grades
+	DurationMs     *int64     `json:"duration_ms,omitempty" example:"2350"`               // grading latency; set along with model
+	MatchedAnswer  *int       `json:"matched_answer,omitempty" example:"1"`               // index into the question's expected_answers graded against; omitted unless it has several
 }
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchResponse struct {
+	Query   string             `json:"query" example:"goroutine"`
+	Results []SearchBankResult `json:"results"`
+}
+
+type SearchBankResult struct {
+	BankID      string        `json:"bank_id" example:"abc123"`
+	BankSubject string        `json:"bank_subject" example:"Go concurrency"`
+	BankType    string        `json:"bank_type" example:"theory"`
+	Matches     []SearchMatch `json:"matches"`
+}
+
+type SearchMatch struct {
+	QuestionID string `json:"question_id,omitempty" example:"q1"`
+	Field      string `json:"field" example:"subject"`
+	Snippet    string `json:"snippet" example:"What is a <mark>goroutine</mark>?"`
+	Exact      bool   `json:"exact" example:"true"`
+}
+
+// searchLibrary runs a full-text search over questions and banks.
+// @Summary      Search questions and banks
+// @Description  Searches question subjects, expected answers and bank subjects. Matching is case- and accent-insensitive; exact phrase matches rank first. Results are grouped by bank in rank order and snippets wrap matched terms in <mark></mark>.
+// @Tags         Search
+// @Produce      json
+// @Param        q          query     string  true   "Search text"
+// @Param        bank_type  query     string  false  "Only search banks of this type (theory, code, cli)"
+// @Param        limit      query     int     false  "Maximum number of matches (1-100, default 20)"
+// @Success      200        {object}  SearchResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /search [get]
+func (h *Handler) searchLibrary(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	text := strings.TrimSpace(params.Get("q"))
+	if text == "" {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	bankType := params.Get("bank_type")
+	if bt := questionbank.BankType(bankType); bankType != "" && bt != questionbank.BankTypeTheory && bt != questionbank.BankTypeCode && bt != questionbank.BankTypeCLI {
+		respondError(w, http.StatusBadRequest, "invalid bank_type: must be theory, code, or cli")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := params.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+		limit = n
+	}
+
+	hits, err := h.store.Search(r.Context(), store.SearchQuery{Text: text, BankType: bankType, Limit: limit})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to search")
+		return
+	}
+
+	resp := SearchResponse{Query: text, Results: []SearchBankResult{}}
+	index := make(map[string]int)
+	for _, hit := range hits {
+		i, ok := index[hit.BankID]
+		if !ok {
+			i = len(resp.Results)
+			index[hit.BankID] = i
+			resp.Results = append(resp.Results, SearchBankResult{
+				BankID:      hit.BankID,
+				BankSubject: hit.BankSubject,
+				BankType:    hit.BankType,
+			})
+		}
+		resp.Results[i].Matches = append(resp.Results[i].Matches, SearchMatch{
+			QuestionID: hit.QuestionID,
+			Field:      hit.Field,
+			Snippet:    hit.Snippet,
+			Exact:      hit.Exact,
+		})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
+// defaultTimePerQuestion is assumed for banks without timed sessions.
+const defaultTimePerQuestion = 90 * time.Second
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type SessionEstimateResponse struct {
+	QuestionCount      int    `json:"question_count" example:"8"`
+	EstimatedSeconds   int    `json:"estimated_seconds" example:"720"`
+	EstimatedMin       int    `json:"estimated_min" example:"12"` // rounded up
+	SecondsPerQuestion int    `json:"seconds_per_question" example:"90"`
+	Source             string `json:"source" example:"history"`       // "history" or "default"
+	SampleAnswers      int    `json:"sample_answers" example:"42"`    // past answers the history average is based on
+	CappedByTimeLimit  bool   `json:"capped_by_time_limit,omitempty"` // the estimate exceeded max_duration_min and was cut to it
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// estimateSession estimates how long a session would take.
+// @Summary      Estimate a session's duration
+// @Description  Run the session question selection without creating a session and estimate how long answering the questions takes. The time per question is averaged over the bank's past sessions, from each session's start to its last grade; without such history a default of 90 seconds is used. With max_duration_min the estimate never exceeds the time limit.
+// @Tags         Sessions
+// @Accept       json
+// @Produce      json
+// @Param        body  body      CreateSessionRequest  true  "Session configuration"
+// @Success      200   {object}  SessionEstimateResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string  "bank not found"
+// @Failure      500   {object}  map[string]string
+// @Router       /sessions/estimate [post]
+func (h *Handler) estimateSession(w http.ResponseWriter, r *http.Request) {
+	var req CreateSessionRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	session, bank, ok := h.buildSession(w, r, &req)
+	if !ok {
+		return
+	}
+
+	perQuestion, answers, err := h.store.AverageTimePerQuestion(r.Context(), bank.ID)
+	if err != nil {
+		h.logger.Error("failed to load session timings", "bank_id", bank.ID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to estimate session")
+		return
+	}
+	source := "history"
+	if answers == 0 {
+		perQuestion = defaultTimePerQuestion
+		source = "default"
+	}
+
+	estimate := perQuestion * time.Duration(len(session.Questions))
+	resp := SessionEstimateResponse{
+		QuestionCount:      len(session.Questions),
+		SecondsPerQuestion: int(math.Round(perQuestion.Seconds())),
+		Source:             source,
+		SampleAnswers:      answers,
+	}
+	if session.MaxDuration != nil {
+		if limit := time.Duration(*session.MaxDuration) * time.Minute; estimate > limit {
+			estimate = limit
+			resp.CappedByTimeLimit = true
+		}
+	}
+	resp.EstimatedSeconds = int(math.Round(estimate.Seconds()))
+	resp.EstimatedMin = (resp.EstimatedSeconds + 59) / 60
+
+	respondJSON(w, http.StatusOK, resp)
+}
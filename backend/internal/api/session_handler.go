@@ -1,12 +1,19 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	practicesession "github.com/remaimber-it/backend/internal/domain/practice_session"
 	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/grader"
 	"github.com/remaimber-it/backend/internal/service"
 	"github.com/remaimber-it/backend/internal/store"
 )
@@ -18,16 +25,36 @@ type CreateSessionRequest struct {
 	MaxQuestions   *int     `json:"max_questions,omitempty" example:"10"`
 	MaxDurationMin *int     `json:"max_duration_min,omitempty" example:"15"`
 	FocusOnWeak    bool     `json:"focus_on_weak" example:"false"`
+	PrioritizeNew  bool     `json:"prioritize_new" example:"false"`          // never-answered questions first, then weakest
+	PreserveOrder  *bool    `json:"preserve_order,omitempty" example:"true"` // serve questions in bank order; omitted uses the bank's setting
 	QuestionIDs    []string `json:"question_ids,omitempty"`
+	Tags           []string `json:"tags,omitempty" example:"channels,goroutines"`      // only questions with these tags
+	TagMatch       string   `json:"tag_match,omitempty" example:"any" enums:"any,all"` // "any" (default) or "all" of tags
 }
 
+// Validate checks the request and normalizes its tags in place.
 func (r *CreateSessionRequest) Validate() error {
 	if r.BankID == "" {
 		return errors.New("bank_id is required")
 	}
-	return nil
+	switch r.TagMatch {
+	case "":
+		r.TagMatch = tagMatchAny
+	case tagMatchAny, tagMatchAll:
+	default:
+		return errors.New("tag_match must be any or all")
+	}
+	var err error
+	r.Tags, err = normalizeTags(r.Tags)
+	return err
 }
 
+// Tag match modes of CreateSessionRequest.
+const (
+	tagMatchAny = "any"
+	tagMatchAll = "all"
+)
+
 type CreateQuickSessionRequest struct {
 	BankIDs        []string `json:"bank_ids"`
 	MaxPerBank     *int     `json:"max_per_bank,omitempty" example:"5"`
@@ -58,16 +85,54 @@ type SessionQuestion struct {
 }
 
 type CreateSessionResponse struct {
-	ID             string            `json:"id" example:"s1e2s3s4i5o6n7id"`
-	Status         string            `json:"status" example:"active"`
-	Questions      []SessionQuestion `json:"questions"`
-	MaxDurationMin *int              `json:"max_duration_min,omitempty" example:"15"`
-	FocusOnWeak    bool              `json:"focus_on_weak" example:"false"`
+	ID             string             `json:"id" example:"s1e2s3s4i5o6n7id"`
+	Status         string             `json:"status" example:"active"`
+	Questions      []SessionQuestion  `json:"questions"`
+	MaxDurationMin *int               `json:"max_duration_min,omitempty" example:"15"`
+	ExpiresAt      *time.Time         `json:"expires_at,omitempty" example:"2025-01-01T12:15:00Z"` // answers after this are rejected
+	FocusOnWeak    bool               `json:"focus_on_weak" example:"false"`
+	Answered       []AnsweredQuestion `json:"answered"` // questions not listed have not been answered yet
+}
+
+// AnsweredQuestion is a question of a session that already has an answer,
+// either graded or still being graded.
+type AnsweredQuestion struct {
+	QuestionID string `json:"question_id" example:"q1w2e3r4t5y6u7i8"`
+	Status     string `json:"status" example:"graded" enums:"graded,pending"`
+}
+
+// PreviewSessionResponse lists the questions a session config selects.
+// Unless the selection is deterministic (weak-first, preserve_order or
+// specific IDs), a session created from the same config draws its own
+// shuffle; pass QuestionIDs as question_ids to create exactly this session.
+type PreviewSessionResponse struct {
+	QuestionCount int      `json:"question_count" example:"10"`
+	QuestionIDs   []string `json:"question_ids"` // in session order; send back as question_ids to keep it
 }
 
 type SubmitAnswerRequest struct {
+	QuestionID     string `json:"question_id" example:"q1w2e3r4t5y6u7i8"`
+	Answer         string `json:"answer" example:"A goroutine is a lightweight concurrent unit of execution."`
+	SelfCovered    []int  `json:"self_covered,omitempty" example:"0"`         // key point indices the user believes they covered; the model then only verifies them
+	AnswerLanguage string `json:"answer_language,omitempty" example:"French"` // language of the answer when it differs from the expected answer's, or "auto"
+}
+
+// maxBatchAnswers is the most answers POST /sessions/{sessionID}/answers/batch
+// accepts at once.
+const maxBatchAnswers = 50
+
+// BatchSubmitAnswersResponse reports, in request order, which answers of a
+// batch were queued for grading.
+type BatchSubmitAnswersResponse struct {
+	Accepted int                 `json:"accepted" example:"19"`
+	Rejected int                 `json:"rejected" example:"1"`
+	Results  []BatchAnswerResult `json:"results"`
+}
+
+type BatchAnswerResult struct {
 	QuestionID string `json:"question_id" example:"q1w2e3r4t5y6u7i8"`
-	Answer     string `json:"answer" example:"A goroutine is a lightweight concurrent unit of execution."`
+	Status     string `json:"status" example:"accepted"`                               // "accepted" or "rejected"
+	Error      string `json:"error,omitempty" example:"question not found in session"` // why the answer was rejected
 }
 
 func (r *SubmitAnswerRequest) Validate() error {
@@ -77,6 +142,27 @@ func (r *SubmitAnswerRequest) Validate() error {
 	if r.Answer == "" {
 		return errors.New("answer is required")
 	}
+	for _, i := range r.SelfCovered {
+		if i < 0 {
+			return errors.New("self_covered indices must not be negative")
+		}
+	}
+	return validateAnswerLanguage(&r.AnswerLanguage)
+}
+
+// maxAnswerLanguageLength is the longest answer_language accepted.
+const maxAnswerLanguageLength = 50
+
+// validateAnswerLanguage trims an answer_language in place and checks it.
+// The value goes into the grading prompt, so it must be a short name.
+func validateAnswerLanguage(language *string) error {
+	*language = strings.TrimSpace(*language)
+	if utf8.RuneCountInString(*language) > maxAnswerLanguageLength {
+		return fmt.Errorf("answer_language cannot be longer than %d characters", maxAnswerLanguageLength)
+	}
+	if strings.ContainsAny(*language, "\r\n") {
+		return errors.New("answer_language must be a single line")
+	}
 	return nil
 }
 
@@ -89,13 +175,15 @@ type CompleteSessionResponse struct {
 	TotalScore int            `json:"total_score" example:"150"`
 	MaxScore   int            `json:"max_score" example:"300"`
 	Results    []GradeDetails `json:"results"`
+	Pending    []string       `json:"pending"`            // question IDs still being graded
+	Progress   map[string]int `json:"progress,omitempty"` // bytes of model output streamed so far, by pending question ID
 }
 
 // ── Handlers ────────────────────────────────────────────────────────────────
 
 // createSession starts a new practice session.
 // @Summary      Create a practice session
-// @Description  Create a practice session from a question bank. Optionally limit question count, set a timer, focus on weak questions, or pick specific question IDs.
+// @Description  Create a practice session from a question bank. Optionally limit question count, set a timer, focus on weak questions (or never-answered ones first with prioritize_new), or pick specific question IDs. Questions are shuffled unless the bank has preserve_order set, which serves them in the order they were added; preserve_order in the request overrides the bank, and weak-first ordering takes precedence over both. A max_duration_min above the server's MAX_SESSION_DURATION_MIN is rejected with 400.
 // @Tags         Sessions
 // @Accept       json
 // @Produce      json
@@ -108,18 +196,126 @@ type CompleteSessionResponse struct {
 func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req CreateSessionRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	session, bank, ok := h.buildSession(w, r, &req)
+	if !ok {
+		return
+	}
+
+	if err := h.store.SaveSession(ctx, session); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to save session")
+		return
+	}
+
+	h.grading.TrackSession(session.ID)
+
+	// Build a lookup for per-question grading prompts
+	questionGradingPrompts := make(map[string]*string)
+	for _, bq := range bank.Questions {
+		questionGradingPrompts[bq.ID] = bq.GradingPrompt
+	}
+
+	questions := make([]SessionQuestion, len(session.Questions))
+	for i, q := range session.Questions {
+		questions[i] = SessionQuestion{
+			ID:             q.ID,
+			Subject:        q.Subject,
+			ExpectedAnswer: q.ExpectedAnswer,
+			GradingPrompt:  questionGradingPrompts[q.ID],
+		}
+	}
+
+	response := CreateSessionResponse{
+		ID:          session.ID,
+		Status:      string(session.Status),
+		Questions:   questions,
+		FocusOnWeak: session.FocusOnWeak,
+		Answered:    []AnsweredQuestion{},
+	}
+
+	if req.MaxDurationMin != nil && *req.MaxDurationMin > 0 {
+		response.MaxDurationMin = req.MaxDurationMin
+	}
+	if expiresAt, ok := session.ExpiresAt(); ok {
+		response.ExpiresAt = &expiresAt
+	}
+
+	respondJSON(w, http.StatusCreated, response)
+}
+
+// previewSession reports which questions a session config would select.
+// @Summary      Preview a practice session
+// @Description  Run the session question selection (max questions, focus on weak, specific IDs) without creating or persisting a session. Shuffled selections are drawn again by POST /sessions, so the same config may pick other questions there; to create the previewed session, send the returned question_ids as question_ids.
+// @Tags         Sessions
+// @Accept       json
+// @Produce      json
+// @Param        body  body      CreateSessionRequest  true  "Session configuration"
+// @Success      200   {object}  PreviewSessionResponse
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string  "bank not found"
+// @Failure      500   {object}  map[string]string
+// @Router       /sessions/preview [post]
+func (h *Handler) previewSession(w http.ResponseWriter, r *http.Request) {
+	var req CreateSessionRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	session, _, ok := h.buildSession(w, r, &req)
+	if !ok {
 		return
 	}
 
+	questionIDs := make([]string, len(session.Questions))
+	for i, q := range session.Questions {
+		questionIDs[i] = q.ID
+	}
+
+	respondJSON(w, http.StatusOK, PreviewSessionResponse{
+		QuestionCount: len(questionIDs),
+		QuestionIDs:   questionIDs,
+	})
+}
+
+// buildSession runs the question selection for a session request without
+// persisting anything. On failure it writes the error response and returns
+// false, so callers can simply return.
+func (h *Handler) buildSession(w http.ResponseWriter, r *http.Request, req *CreateSessionRequest) (*practicesession.PracticeSession, *questionbank.QuestionBank, bool) {
+	ctx := r.Context()
+
 	bank, err := h.store.GetBank(ctx, req.BankID)
 	if h.handleStoreError(w, err, "bank") {
-		return
+		return nil, nil, false
 	}
 
 	if len(bank.Questions) == 0 {
 		respondError(w, http.StatusBadRequest, "bank has no questions")
-		return
+		return nil, nil, false
+	}
+
+	// pool holds the questions the session may draw from; bank stays whole.
+	pool := bank
+	if len(req.Tags) > 0 {
+		matchAll := req.TagMatch == tagMatchAll
+		filtered := *bank
+		filtered.Questions = nil
+		for _, q := range bank.Questions {
+			if q.HasTags(req.Tags, matchAll) {
+				filtered.Questions = append(filtered.Questions, q)
+			}
+		}
+		if len(filtered.Questions) == 0 {
+			respondError(w, http.StatusBadRequest, "no questions match the given tags")
+			return nil, nil, false
+		}
+		pool = &filtered
+	}
+
+	if !h.checkSessionDuration(w, req.MaxDurationMin) {
+		return nil, nil, false
 	}
 
 	config := practicesession.DefaultConfig()
@@ -133,13 +329,17 @@ func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
 		config.MaxDuration = &duration
 	}
 
-	config.FocusOnWeak = req.FocusOnWeak
+	// Prioritizing new questions is a variant of weak-focus ordering.
+	config.FocusOnWeak = req.FocusOnWeak || req.PrioritizeNew
 
-	var session *practicesession.PracticeSession
+	config.PreserveOrder = bank.PreserveOrder
+	if req.PreserveOrder != nil {
+		config.PreserveOrder = *req.PreserveOrder
+	}
 
 	if len(req.QuestionIDs) > 0 {
 		questionMap := make(map[string]questionbank.Question)
-		for _, q := range bank.Questions {
+		for _, q := range pool.Questions {
 			questionMap[q.ID] = q
 		}
 
@@ -152,58 +352,53 @@ func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
 
 		if len(specificQuestions) == 0 {
 			respondError(w, http.StatusBadRequest, "no valid questions found")
-			return
+			return nil, nil, false
 		}
 
-		session = practicesession.NewWithSpecificQuestions(bank, specificQuestions, config)
-	} else {
-		var orderedQuestions []questionbank.Question
-		if req.FocusOnWeak {
-			orderedQuestions, err = h.store.GetQuestionsOrderedByMastery(ctx, req.BankID, true)
-			if err != nil {
-				respondError(w, http.StatusInternalServerError, "failed to get question order")
-				return
-			}
-		}
-
-		session = practicesession.NewWithConfig(bank, config, orderedQuestions)
+		return practicesession.NewWithSpecificQuestions(pool, specificQuestions, config), bank, true
 	}
 
-	if err := h.store.SaveSession(ctx, session); err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to save session")
-		return
-	}
-
-	h.grading.TrackSession(session.ID)
-
-	// Build a lookup for per-question grading prompts
-	questionGradingPrompts := make(map[string]*string)
-	for _, bq := range bank.Questions {
-		questionGradingPrompts[bq.ID] = bq.GradingPrompt
+	var orderedQuestions []questionbank.Question
+	if req.PrioritizeNew {
+		orderedQuestions, err = h.store.GetQuestionsUnansweredFirst(ctx, req.BankID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to get question order")
+			return nil, nil, false
+		}
+	} else if req.FocusOnWeak {
+		orderedQuestions, err = h.store.GetQuestionsOrderedByMastery(ctx, req.BankID, true)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to get question order")
+			return nil, nil, false
+		}
 	}
 
-	questions := make([]SessionQuestion, len(session.Questions))
-	for i, q := range session.Questions {
-		questions[i] = SessionQuestion{
-			ID:             q.ID,
-			Subject:        q.Subject,
-			ExpectedAnswer: q.ExpectedAnswer,
-			GradingPrompt:  questionGradingPrompts[q.ID],
+	if pool != bank && orderedQuestions != nil {
+		allowed := make(map[string]bool, len(pool.Questions))
+		for _, q := range pool.Questions {
+			allowed[q.ID] = true
+		}
+		kept := orderedQuestions[:0]
+		for _, q := range orderedQuestions {
+			if allowed[q.ID] {
+				kept = append(kept, q)
+			}
 		}
+		orderedQuestions = kept
 	}
 
-	response := CreateSessionResponse{
-		ID:          session.ID,
-		Status:      string(session.Status),
-		Questions:   questions,
-		FocusOnWeak: session.FocusOnWeak,
-	}
+	return practicesession.NewWithConfig(pool, config, orderedQuestions), bank, true
+}
 
-	if req.MaxDurationMin != nil && *req.MaxDurationMin > 0 {
-		response.MaxDurationMin = req.MaxDurationMin
+// checkSessionDuration rejects a requested session duration above the
+// configured cap. On failure it writes a 400 response and returns false.
+func (h *Handler) checkSessionDuration(w http.ResponseWriter, minutes *int) bool {
+	if h.maxSessionDurationMin > 0 && minutes != nil && *minutes > h.maxSessionDurationMin {
+		respondError(w, http.StatusBadRequest,
+			fmt.Sprintf("max_duration_min must not exceed %d", h.maxSessionDurationMin))
+		return false
 	}
-
-	respondJSON(w, http.StatusCreated, response)
+	return true
 }
 
 // createQuickSession starts a multi-bank practice session focusing on weak questions.
@@ -220,7 +415,11 @@ func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) createQuickSession(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var req CreateQuickSessionRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	if !h.checkSessionDuration(w, req.MaxDurationMin) {
 		return
 	}
 
@@ -241,12 +440,21 @@ func (h *Handler) createQuickSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.startMultiBankSession(w, ctx, questionsWithBank, req.MaxDurationMin)
+}
+
+// startMultiBankSession creates, saves and responds with a session over
+// questions drawn from several banks, in the order given.
+func (h *Handler) startMultiBankSession(w http.ResponseWriter, ctx context.Context, questionsWithBank []store.QuestionWithBank, maxDurationMin *int) {
 	// Fetch bank metadata for response
 	bankCache := make(map[string]*questionbank.QuestionBank)
-	for _, bankID := range req.BankIDs {
-		bank, err := h.store.GetBank(ctx, bankID)
+	for _, qwb := range questionsWithBank {
+		if _, ok := bankCache[qwb.BankID]; ok {
+			continue
+		}
+		bank, err := h.store.GetBank(ctx, qwb.BankID)
 		if err == nil {
-			bankCache[bankID] = bank
+			bankCache[qwb.BankID] = bank
 		}
 	}
 
@@ -264,8 +472,8 @@ func (h *Handler) createQuickSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	config := practicesession.DefaultConfig()
-	if req.MaxDurationMin != nil && *req.MaxDurationMin > 0 {
-		duration := time.Duration(*req.MaxDurationMin) * time.Minute
+	if maxDurationMin != nil && *maxDurationMin > 0 {
+		duration := time.Duration(*maxDurationMin) * time.Minute
 		config.MaxDuration = &duration
 	}
 
@@ -306,8 +514,11 @@ func (h *Handler) createQuickSession(w http.ResponseWriter, r *http.Request) {
 		"is_multi_bank": true,
 	}
 
-	if req.MaxDurationMin != nil && *req.MaxDurationMin > 0 {
-		response["max_duration_min"] = *req.MaxDurationMin
+	if maxDurationMin != nil && *maxDurationMin > 0 {
+		response["max_duration_min"] = *maxDurationMin
+	}
+	if expiresAt, ok := session.ExpiresAt(); ok {
+		response["expires_at"] = expiresAt
 	}
 
 	respondJSON(w, http.StatusCreated, response)
@@ -315,12 +526,13 @@ func (h *Handler) createQuickSession(w http.ResponseWriter, r *http.Request) {
 
 // getSession returns a session and its questions.
 // @Summary      Get a session
-// @Description  Returns a practice session with its questions.
+// @Description  Returns a practice session with its questions in their original order, whether it focuses on weak questions, and its time limit with the time it expires. answered lists the questions already answered, graded or with grading still pending; questions not listed have not been answered yet.
 // @Tags         Sessions
 // @Produce      json
 // @Param        sessionID  path      string  true  "Session ID"
 // @Success      200        {object}  CreateSessionResponse
 // @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
 // @Router       /sessions/{sessionID} [get]
 func (h *Handler) getSession(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -331,6 +543,23 @@ func (h *Handler) getSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response, err := h.sessionResponse(ctx, session)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load grades")
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// sessionResponse describes a stored session and its questions in order,
+// along with the questions that were already answered so a client can pick
+// up where it left off.
+func (h *Handler) sessionResponse(ctx context.Context, session *practicesession.PracticeSession) (CreateSessionResponse, error) {
+	answered, err := h.answeredQuestions(ctx, session)
+	if err != nil {
+		return CreateSessionResponse{}, err
+	}
+
 	bank, _ := h.store.GetBank(ctx, session.QuestionBankId)
 	questionGradingPrompts := make(map[string]*string)
 	if bank != nil {
@@ -349,16 +578,282 @@ func (h *Handler) getSession(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	respondJSON(w, http.StatusOK, CreateSessionResponse{
-		ID:        session.ID,
-		Status:    string(session.Status),
-		Questions: questions,
-	})
+	response := CreateSessionResponse{
+		ID:             session.ID,
+		Status:         string(session.Status),
+		Questions:      questions,
+		MaxDurationMin: session.MaxDuration,
+		FocusOnWeak:    session.FocusOnWeak,
+		Answered:       answered,
+	}
+	if expiresAt, ok := session.ExpiresAt(); ok {
+		response.ExpiresAt = &expiresAt
+	}
+	return response, nil
+}
+
+// answeredQuestions lists the session's questions that have a stored grade
+// or a grading in flight, in session order.
+func (h *Handler) answeredQuestions(ctx context.Context, session *practicesession.PracticeSession) ([]AnsweredQuestion, error) {
+	grades, err := h.store.GetGrades(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	graded := make(map[string]bool, len(grades))
+	for _, g := range grades {
+		graded[g.QuestionID] = true
+	}
+	inFlight := h.grading.PendingQuestions(session.ID)
+
+	answered := []AnsweredQuestion{}
+	for _, q := range session.Questions {
+		if graded[q.ID] {
+			answered = append(answered, AnsweredQuestion{QuestionID: q.ID, Status: "graded"})
+		} else if _, ok := inFlight[q.ID]; ok {
+			answered = append(answered, AnsweredQuestion{QuestionID: q.ID, Status: "pending"})
+		}
+	}
+	return answered, nil
+}
+
+type IncompleteSessionsResponse struct {
+	OlderThanMin int                         `json:"older_than_min" example:"1440"`
+	Sessions     []IncompleteSessionResponse `json:"sessions"`
+}
+
+type IncompleteSessionResponse struct {
+	ID             string     `json:"id" example:"s1e2s3s4i5o6n7id"`
+	BankID         string     `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`                  // "multi" for quick sessions
+	StartedAt      *time.Time `json:"started_at,omitempty" example:"2025-01-01T12:00:00Z"` // omitted for sessions saved before start times were recorded
+	MaxDurationMin *int       `json:"max_duration_min,omitempty" example:"15"`
+	QuestionCount  int        `json:"question_count" example:"10"`
+	AnsweredCount  int        `json:"answered_count" example:"3"`
+}
+
+type BankSessionResponse struct {
+	ID             string     `json:"id" example:"s1e2s3s4i5o6n7id"`
+	Status         string     `json:"status" example:"completed"`                          // "active" or "completed"
+	CreatedAt      *time.Time `json:"created_at,omitempty" example:"2025-01-01T12:00:00Z"` // omitted for sessions saved before start times were recorded
+	QuestionCount  int        `json:"question_count" example:"10"`
+	AnsweredCount  int        `json:"answered_count" example:"8"`
+	TotalScore     int        `json:"total_score" example:"640"`
+	MaxScore       int        `json:"max_score" example:"1000"`
+	GradingPending bool       `json:"grading_pending" example:"false"` // answers still being graded; total_score will change
+}
+
+type BankSessionsResponse struct {
+	BankID   string                `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	Sessions []BankSessionResponse `json:"sessions"`
+}
+
+type BankSessionStatsResponse struct {
+	BankID        string     `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	SessionCount  int        `json:"session_count" example:"6"`                                // completed sessions
+	AverageScore  int        `json:"average_score" example:"72"`                               // mean score percentage of completed sessions
+	BestScore     int        `json:"best_score" example:"95"`                                  // highest score percentage of a completed session
+	LastSessionAt *time.Time `json:"last_session_at,omitempty" example:"2025-01-01T12:00:00Z"` // start of the latest completed session; omitted when unknown
+}
+
+// listBankSessions lists a bank's practice sessions.
+// @Summary      List a bank's sessions
+// @Description  Returns summaries of every session started from the bank, newest first, active and completed alike. Quick sessions spanning several banks are not included. Fetch a session's answers with GET /sessions/{sessionID}/grades.
+// @Tags         Banks
+// @Produce      json
+// @Param        bankID  path      string  true  "Bank ID"
+// @Success      200     {object}  BankSessionsResponse
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /banks/{bankID}/sessions [get]
+func (h *Handler) listBankSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+
+	if _, err := h.store.GetBank(ctx, bankID); h.handleStoreError(w, err, "bank") {
+		return
+	}
+
+	sessions, err := h.store.ListSessionsByBank(ctx, bankID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+
+	resp := BankSessionsResponse{BankID: bankID, Sessions: make([]BankSessionResponse, len(sessions))}
+	for i, session := range sessions {
+		resp.Sessions[i] = BankSessionResponse{
+			ID:             session.ID,
+			Status:         string(session.Status),
+			QuestionCount:  session.QuestionCount,
+			AnsweredCount:  session.AnsweredCount,
+			TotalScore:     session.TotalScore,
+			MaxScore:       session.QuestionCount * 100,
+			GradingPending: len(h.grading.PendingQuestions(session.ID)) > 0,
+		}
+		if !session.CreatedAt.IsZero() {
+			createdAt := session.CreatedAt
+			resp.Sessions[i].CreatedAt = &createdAt
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// getBankSessionStats summarizes a bank's completed sessions.
+// @Summary      Get a bank's session statistics
+// @Description  Returns how many sessions were completed from the bank, their average and best score as a percentage of the maximum, and when the latest one started. Active sessions are not counted. Unlike GET /banks/{bankID}/stats, this summarizes whole sessions rather than per-question mastery.
+// @Tags         Banks
+// @Produce      json
+// @Param        bankID  path      string  true  "Bank ID"
+// @Success      200     {object}  BankSessionStatsResponse
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /banks/{bankID}/session-stats [get]
+func (h *Handler) getBankSessionStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+
+	if _, err := h.store.GetBank(ctx, bankID); h.handleStoreError(w, err, "bank") {
+		return
+	}
+
+	sessions, err := h.store.ListSessionsByBank(ctx, bankID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+
+	resp := BankSessionStatsResponse{BankID: bankID}
+	totalPercent := 0
+	for _, session := range sessions {
+		if session.Status != practicesession.SessionStatusCompleted || session.QuestionCount == 0 {
+			continue
+		}
+		percent := session.TotalScore / session.QuestionCount
+		resp.SessionCount++
+		totalPercent += percent
+		resp.BestScore = max(resp.BestScore, percent)
+		// Sessions come newest first, so the first dated one is the latest.
+		if resp.LastSessionAt == nil && !session.CreatedAt.IsZero() {
+			createdAt := session.CreatedAt
+			resp.LastSessionAt = &createdAt
+		}
+	}
+	if resp.SessionCount > 0 {
+		resp.AverageScore = totalPercent / resp.SessionCount
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// listIncompleteSessions lists sessions that were started but never completed.
+// @Summary      List incomplete sessions
+// @Description  Returns active sessions started more than older_than_min minutes ago, oldest first, so they can be completed or cleaned up. The default age is the server's STALE_SESSION_AGE (24h unless configured). Sessions saved before start times were recorded are always included.
+// @Tags         Sessions
+// @Produce      json
+// @Param        older_than_min  query     int  false  "Minimum age in minutes"
+// @Success      200             {object}  IncompleteSessionsResponse
+// @Failure      400             {object}  map[string]string
+// @Failure      500             {object}  map[string]string
+// @Router       /sessions/incomplete [get]
+func (h *Handler) listIncompleteSessions(w http.ResponseWriter, r *http.Request) {
+	age := h.staleSessionAge
+	if raw := r.URL.Query().Get("older_than_min"); raw != "" {
+		mins, err := strconv.Atoi(raw)
+		if err != nil || mins < 0 {
+			respondError(w, http.StatusBadRequest, "older_than_min must be a non-negative integer")
+			return
+		}
+		age = time.Duration(mins) * time.Minute
+	}
+
+	sessions, err := h.store.ListIncompleteSessions(r.Context(), time.Now().Add(-age))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list incomplete sessions")
+		return
+	}
+
+	resp := IncompleteSessionsResponse{
+		OlderThanMin: int(age / time.Minute),
+		Sessions:     make([]IncompleteSessionResponse, len(sessions)),
+	}
+	for i, session := range sessions {
+		resp.Sessions[i] = IncompleteSessionResponse{
+			ID:             session.ID,
+			BankID:         session.BankID,
+			MaxDurationMin: session.MaxDuration,
+			QuestionCount:  session.QuestionCount,
+			AnsweredCount:  session.AnsweredCount,
+		}
+		if !session.StartedAt.IsZero() {
+			startedAt := session.StartedAt
+			resp.Sessions[i].StartedAt = &startedAt
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// restartSession clears an active session's answers and re-orders its questions.
+// @Summary      Restart a session
+// @Description  Deletes the session's grades and puts its questions in a fresh random order; sessions created with focus_on_weak are re-ordered weakest first by current mastery, with ties shuffled. Only active sessions can be restarted: a completed session is a finished record, so start a new session (optionally with the same question_ids) instead. Question stats already updated by the cleared grades are kept, and the time limit, if any, restarts from now. Rejected with 409 while answers are still being graded.
+// @Tags         Sessions
+// @Produce      json
+// @Param        sessionID  path      string  true  "Session ID"
+// @Success      200        {object}  CreateSessionResponse
+// @Failure      404        {object}  map[string]string
+// @Failure      409        {object}  map[string]string  "session already completed or grading in progress"
+// @Failure      500        {object}  map[string]string
+// @Router       /sessions/{sessionID}/restart [post]
+func (h *Handler) restartSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := r.PathValue("sessionID")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if h.handleStoreError(w, err, "session") {
+		return
+	}
+	if !session.IsActive() {
+		respondError(w, http.StatusConflict, "session is already completed")
+		return
+	}
+	if len(h.grading.PendingQuestions(sessionID)) > 0 {
+		respondError(w, http.StatusConflict, "answers are still being graded; wait for grading to finish before restarting")
+		return
+	}
+
+	session.Reshuffle()
+	if session.FocusOnWeak {
+		mastery := make(map[string]int, len(session.Questions))
+		for _, q := range session.Questions {
+			stats, err := h.store.GetQuestionStats(ctx, q.ID)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, "failed to fetch question stats")
+				return
+			}
+			mastery[q.ID] = stats.Mastery
+		}
+		sort.SliceStable(session.Questions, func(i, j int) bool {
+			return mastery[session.Questions[i].ID] < mastery[session.Questions[j].ID]
+		})
+	}
+
+	err = h.store.RestartSession(ctx, session)
+	if errors.Is(err, store.ErrSessionCompleted) {
+		respondError(w, http.StatusConflict, "session is already completed")
+		return
+	}
+	if h.handleStoreError(w, err, "session") {
+		return
+	}
+
+	response, err := h.sessionResponse(ctx, session)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load grades")
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
 }
 
 // submitAnswer submits an answer for async LLM grading.
 // @Summary      Submit an answer
-// @Description  Submit a user answer for a question in the session. The answer is graded asynchronously by an LLM.
+// @Description  Submit a user answer for a question in the session. The answer is graded asynchronously by an LLM. When self_covered lists the key points the user believes they covered, the model verifies that marking instead of grading from scratch; the stored grade is always the model's.
 // @Tags         Sessions
 // @Accept       json
 // @Produce      json
@@ -367,7 +862,8 @@ func (h *Handler) getSession(w http.ResponseWriter, r *http.Request) {
 // @Success      200        {object}  SubmitAnswerResponse
 // @Failure      400        {object}  map[string]string
 // @Failure      404        {object}  map[string]string
-// @Failure      409        {object}  map[string]string  "session already completed"
+// @Failure      409        {object}  map[string]string  "session already completed, or its time limit expired (status time_expired)"
+// @Failure      429        {object}  map[string]string  "answer rate limit exceeded; see Retry-After"
 // @Router       /sessions/{sessionID}/answers [post]
 func (h *Handler) submitAnswer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -377,46 +873,252 @@ func (h *Handler) submitAnswer(w http.ResponseWriter, r *http.Request) {
 	if h.handleStoreError(w, err, "session") {
 		return
 	}
-
-	if !session.IsActive() {
-		respondError(w, http.StatusConflict, "session is already completed")
+	if !checkAcceptsAnswers(w, session) {
 		return
 	}
 
 	var req SubmitAnswerRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	question := sessionQuestion(session, req.QuestionID)
+	if question == nil {
+		respondError(w, http.StatusNotFound, "question not found in session")
+		return
+	}
+	if err := validateSelfCovered(question, req.SelfCovered); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.grading.SubmitAnswer(ctx, h.gradeRequest(ctx, session, question, req)); err != nil {
+		h.logger.ErrorContext(ctx, "failed to save answer", "session_id", sessionID, "question_id", question.ID, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to save answer")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SubmitAnswerResponse{
+		Status: "submitted",
+	})
+}
+
+// submitAnswers submits several answers for async grading in one call.
+// @Summary      Submit answers in a batch
+// @Description  Submit up to 50 answers at once; each is graded asynchronously as if submitted on its own. Answers are checked one by one: an invalid answer, a question not in the session, or a second answer to a question already in the batch is rejected without affecting the others. Each answer counts against the answer rate limit: answers beyond what the limit still allows are rejected, and the batch gets 429 only when it allows none.
+// @Tags         Sessions
+// @Accept       json
+// @Produce      json
+// @Param        sessionID  path      string                 true  "Session ID"
+// @Param        body       body      []SubmitAnswerRequest  true  "Answers to submit"
+// @Success      200        {object}  BatchSubmitAnswersResponse
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      409        {object}  map[string]string  "session already completed, or its time limit expired (status time_expired)"
+// @Failure      429        {object}  map[string]string  "answer rate limit exceeded; see Retry-After"
+// @Router       /sessions/{sessionID}/answers/batch [post]
+func (h *Handler) submitAnswers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := r.PathValue("sessionID")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if h.handleStoreError(w, err, "session") {
 		return
 	}
+	if !checkAcceptsAnswers(w, session) {
+		return
+	}
+
+	var reqs []SubmitAnswerRequest
+	if !decodeJSON(w, r, &reqs, strictFields) {
+		return
+	}
+	if len(reqs) == 0 {
+		respondError(w, http.StatusBadRequest, "at least one answer is required")
+		return
+	}
+	if len(reqs) > maxBatchAnswers {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("a batch cannot contain more than %d answers", maxBatchAnswers))
+		return
+	}
+
+	resp := BatchSubmitAnswersResponse{Results: make([]BatchAnswerResult, len(reqs))}
+	var accepted []service.GradeRequest
+	var acceptedAt []int // index in resp.Results of each accepted request
+	seen := make(map[string]bool, len(reqs))
+	for i, req := range reqs {
+		resp.Results[i] = BatchAnswerResult{QuestionID: req.QuestionID, Status: "accepted"}
+		err := req.Validate()
+		var question *questionbank.Question
+		if err == nil {
+			if question = sessionQuestion(session, req.QuestionID); question == nil {
+				err = errors.New("question not found in session")
+			} else if seen[question.ID] {
+				err = errors.New("question already answered earlier in this batch")
+			} else {
+				err = validateSelfCovered(question, req.SelfCovered)
+			}
+		}
+		if err != nil {
+			resp.Results[i].Status, resp.Results[i].Error = "rejected", err.Error()
+			resp.Rejected++
+			continue
+		}
+		seen[question.ID] = true
+		accepted = append(accepted, h.gradeRequest(ctx, session, question, req))
+		acceptedAt = append(acceptedAt, i)
+	}
+
+	allowed := len(accepted)
+	if allowed > 0 {
+		if allowed = h.allowAnswers(w, r, allowed); allowed == 0 {
+			return
+		}
+	}
+	for j, gradeReq := range accepted {
+		i := acceptedAt[j]
+		if j >= allowed {
+			resp.Results[i].Status, resp.Results[i].Error = "rejected", "answer rate limit exceeded"
+			resp.Rejected++
+			continue
+		}
+		if err := h.grading.SubmitAnswer(ctx, gradeReq); err != nil {
+			h.logger.ErrorContext(ctx, "failed to save answer", "session_id", sessionID, "question_id", gradeReq.QuestionID, "error", err)
+			resp.Results[i].Status, resp.Results[i].Error = "rejected", "failed to save answer"
+			resp.Rejected++
+			continue
+		}
+		resp.Accepted++
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ResumePendingAnswers submits for grading every answer that was persisted
+// but not graded, e.g. because the server stopped while grading it, and
+// returns how many it submitted. Call it once at startup. Active sessions
+// are tracked again, so completing them waits for these gradings. Answers
+// that cannot be graded any more are settled instead of being left pending:
+// those of deleted sessions are dropped, those to questions no longer in
+// their session are recorded as failed grades.
+func (h *Handler) ResumePendingAnswers(ctx context.Context) (int, error) {
+	answers, err := h.store.ListPendingAnswers(ctx)
+	if err != nil {
+		return 0, err
+	}
 
-	var question *questionbank.Question
+	sessions := make(map[string]*practicesession.PracticeSession)
+	resumed := 0
+	for _, a := range answers {
+		session, ok := sessions[a.SessionID]
+		if !ok {
+			session, err = h.store.GetSession(ctx, a.SessionID)
+			if errors.Is(err, store.ErrNotFound) {
+				h.logger.Warn("dropping pending answer: session not found", "session_id", a.SessionID, "question_id", a.QuestionID)
+				if err := h.store.DeletePendingAnswer(ctx, a.SessionID, a.QuestionID); err != nil {
+					h.logger.Error("failed to drop pending answer", "session_id", a.SessionID, "question_id", a.QuestionID, "error", err)
+				}
+				continue
+			}
+			if err != nil {
+				h.logger.Warn("cannot resume pending answer", "session_id", a.SessionID, "question_id", a.QuestionID, "error", err)
+				continue
+			}
+			sessions[a.SessionID] = session
+			if session.IsActive() {
+				h.grading.TrackSession(session.ID)
+			}
+		}
+		question := sessionQuestion(session, a.QuestionID)
+		if question == nil {
+			h.logger.Warn("cannot resume pending answer: question not in session", "session_id", a.SessionID, "question_id", a.QuestionID)
+			if err := h.store.SaveGradeFailure(ctx, a.SessionID, a.QuestionID, a.UserAnswer, "question no longer in session", ""); err != nil {
+				h.logger.Error("failed to save grade failure", "session_id", a.SessionID, "question_id", a.QuestionID, "error", err)
+			}
+			continue
+		}
+
+		h.grading.SubmitGrading(h.gradeRequest(ctx, session, question, SubmitAnswerRequest{
+			QuestionID:     a.QuestionID,
+			Answer:         a.UserAnswer,
+			SelfCovered:    a.SelfCovered,
+			AnswerLanguage: a.AnswerLanguage,
+		}))
+		resumed++
+	}
+	return resumed, nil
+}
+
+// checkAcceptsAnswers writes a 409 response and returns false when session
+// is completed or past its time limit.
+func checkAcceptsAnswers(w http.ResponseWriter, session *practicesession.PracticeSession) bool {
+	if !session.IsActive() {
+		respondError(w, http.StatusConflict, "session is already completed")
+		return false
+	}
+	if session.IsExpired(time.Now()) {
+		respondJSON(w, http.StatusConflict, map[string]string{
+			"error":  "session time limit has expired",
+			"status": "time_expired",
+		})
+		return false
+	}
+	return true
+}
+
+// sessionQuestion returns the session's question with the given ID, or nil.
+func sessionQuestion(session *practicesession.PracticeSession, questionID string) *questionbank.Question {
 	for _, q := range session.Questions {
-		if q.ID == req.QuestionID {
-			question = &q
-			break
+		if q.ID == questionID {
+			return &q
 		}
 	}
+	return nil
+}
 
-	if question == nil {
-		respondError(w, http.StatusNotFound, "question not found in session")
-		return
+// validateSelfCovered checks that self-marked key point indices exist in
+// the question's expected answer.
+func validateSelfCovered(question *questionbank.Question, selfCovered []int) error {
+	if selfCovered == nil {
+		return nil
+	}
+	points := len(grader.KeyPoints(question.ExpectedAnswer))
+	for _, i := range selfCovered {
+		if i >= points {
+			return fmt.Errorf("self_covered index %d is out of range: the question has %d key points", i, points)
+		}
 	}
+	return nil
+}
 
+// gradeRequest builds the grading request for an answer to question, with
+// the grading settings of the bank the question comes from.
+func (h *Handler) gradeRequest(ctx context.Context, session *practicesession.PracticeSession, question *questionbank.Question, req SubmitAnswerRequest) service.GradeRequest {
 	// For multi-bank sessions, look up the bank per question
 	var bankID string
 	if session.QuestionBankId == "multi" {
-		bankID, _ = h.store.GetSessionQuestionBankID(ctx, sessionID, req.QuestionID)
+		bankID, _ = h.store.GetSessionQuestionBankID(ctx, session.ID, question.ID)
 	} else {
 		bankID = session.QuestionBankId
 	}
 
 	bank, _ := h.store.GetBank(ctx, bankID)
 	var gradingPrompt *string
+	var rubric *string
+	var alternatives []string
 	var bankType string = "theory"
+	gradingMode := questionbank.GradingModeLLM
+	var exactMatch questionbank.ExactMatchOptions
+	var scoringCurve questionbank.ScoringCurve
 	if bank != nil {
 		bankType = string(bank.BankType)
+		gradingMode = bank.GradingModeFor(*question)
+		rubric = bank.RubricFor(*question)
 		for _, bq := range bank.Questions {
 			if bq.ID == question.ID {
 				gradingPrompt = bq.GradingPrompt
+				gradingMode = bank.GradingModeFor(bq)
+				rubric = bank.RubricFor(bq)
+				alternatives = bq.AlternativeAnswers
 				break
 			}
 		}
@@ -424,30 +1126,38 @@ func (h *Handler) submitAnswer(w http.ResponseWriter, r *http.Request) {
 		if gradingPrompt == nil {
 			gradingPrompt = bank.GradingPrompt
 		}
+		exactMatch = bank.ExactMatch
+		scoringCurve = bank.ScoringCurve
 	}
 
-	h.grading.SubmitGrading(service.GradeRequest{
-		SessionID:      sessionID,
-		QuestionID:     question.ID,
-		Question:       question.Subject,
-		ExpectedAnswer: question.ExpectedAnswer,
-		UserAnswer:     req.Answer,
-		GradingPrompt:  gradingPrompt,
-		BankType:       bankType,
-	})
-
-	respondJSON(w, http.StatusOK, SubmitAnswerResponse{
-		Status: "submitted",
-	})
+	return service.GradeRequest{
+		SessionID:          session.ID,
+		QuestionID:         question.ID,
+		Question:           question.Subject,
+		ExpectedAnswer:     question.ExpectedAnswer,
+		AlternativeAnswers: alternatives,
+		UserAnswer:         req.Answer,
+		GradingPrompt:      gradingPrompt,
+		Rubric:             rubric,
+		BankType:           bankType,
+		GradingMode:        string(gradingMode),
+		ExactMatch:         exactMatch,
+		SelfCovered:        req.SelfCovered,
+		ScoringCurve:       scoringCurve,
+		AnswerLanguage:     req.AnswerLanguage,
+		RequestID:          RequestIDFromContext(ctx),
+	}
 }
 
 // completeSession finalises a session and returns grading results.
 // @Summary      Complete a session
-// @Description  Mark the session as completed, wait for all pending grading to finish, and return results.
+// @Description  Mark the session as completed and return results. By default it waits for all pending grading to finish, and cancels it, recording the answers as failed, if the client disconnects first; with wait=false it returns immediately with the grades so far and the IDs of questions still being graded, which can then be polled via GET /sessions/{sessionID}/grades.
 // @Tags         Sessions
 // @Produce      json
-// @Param        sessionID  path      string  true  "Session ID"
+// @Param        sessionID  path      string  true   "Session ID"
+// @Param        wait       query     bool    false  "Wait for pending grading (default true)"
 // @Success      200        {object}  CompleteSessionResponse
+// @Failure      400        {object}  map[string]string
 // @Failure      404        {object}  map[string]string
 // @Failure      409        {object}  map[string]string  "session already completed"
 // @Failure      500        {object}  map[string]string
@@ -456,6 +1166,16 @@ func (h *Handler) completeSession(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	sessionID := r.PathValue("sessionID")
 
+	wait := true
+	if v := r.URL.Query().Get("wait"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "wait must be true or false")
+			return
+		}
+		wait = b
+	}
+
 	session, err := h.store.GetSession(ctx, sessionID)
 	if h.handleStoreError(w, err, "session") {
 		return
@@ -472,21 +1192,68 @@ func (h *Handler) completeSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Wait for all grading goroutines to finish
-	h.grading.WaitForSession(sessionID)
+	if wait {
+		// Wait for all grading goroutines to finish, or cancel them if the
+		// client disconnects first.
+		h.grading.WaitForSession(ctx, sessionID)
+	} else {
+		// Still release the session's grading state once it drains.
+		go h.grading.WaitForSession(context.Background(), sessionID)
+	}
 
-	grades, err := h.store.GetGrades(ctx, sessionID)
+	response, err := h.sessionResults(ctx, session)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to load grades")
 		return
 	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// getSessionGrades returns the grades recorded for a session so far.
+// @Summary      Get session grades
+// @Description  Returns the session's results without waiting for grading. Questions still being graded have status "pending" and are listed in pending; poll until it is empty.
+// @Tags         Sessions
+// @Produce      json
+// @Param        sessionID  path      string  true  "Session ID"
+// @Success      200        {object}  CompleteSessionResponse
+// @Failure      404        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Router       /sessions/{sessionID}/grades [get]
+func (h *Handler) getSessionGrades(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sessionID := r.PathValue("sessionID")
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if h.handleStoreError(w, err, "session") {
+		return
+	}
+
+	response, err := h.sessionResults(ctx, session)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load grades")
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// sessionResults builds per-question results from the stored grades.
+// Questions whose grading is still in flight are reported as pending.
+func (h *Handler) sessionResults(ctx context.Context, session *practicesession.PracticeSession) (CompleteSessionResponse, error) {
+	grades, err := h.store.GetGrades(ctx, session.ID)
+	if err != nil {
+		return CompleteSessionResponse{}, err
+	}
 
 	gradedQuestions := make(map[string]store.StoredGrade)
 	for _, g := range grades {
 		gradedQuestions[g.QuestionID] = g
 	}
 
+	inFlight := h.grading.PendingQuestions(session.ID)
+	progress := make(map[string]int)
+
 	results := make([]GradeDetails, len(session.Questions))
+	pending := []string{}
 	totalScore := 0
 
 	for i, q := range session.Questions {
@@ -496,30 +1263,66 @@ func (h *Handler) completeSession(w http.ResponseWriter, r *http.Request) {
 				status = "failed"
 			}
 			results[i] = GradeDetails{
-				Score:      grade.Score,
-				Covered:    grade.Covered,
-				Missed:     grade.Missed,
-				UserAnswer: grade.UserAnswer,
-				Status:     status,
+				Score:          grade.Score,
+				Covered:        grade.Covered,
+				Missed:         grade.Missed,
+				CoveredIndices: grade.CoveredIndices,
+				MissedIndices:  grade.MissedIndices,
+				UserAnswer:     grade.UserAnswer,
+				Status:         status,
 			}
+			setGradeProvenance(&results[i], grade)
 			totalScore += grade.Score
+		} else if received, ok := inFlight[q.ID]; ok {
+			pending = append(pending, q.ID)
+			if received > 0 {
+				progress[q.ID] = received
+			}
+			results[i] = GradeDetails{
+				Score:          0,
+				Covered:        []string{},
+				Missed:         []string{},
+				CoveredIndices: []int{},
+				MissedIndices:  []int{},
+				UserAnswer:     "",
+				Status:         "pending",
+			}
 		} else {
 			results[i] = GradeDetails{
-				Score:      0,
-				Covered:    []string{},
-				Missed:     []string{"Not answered"},
-				UserAnswer: "",
-				Status:     "not_answered",
+				Score:          0,
+				Covered:        []string{},
+				Missed:         []string{"Not answered"},
+				CoveredIndices: []int{},
+				MissedIndices:  []int{},
+				UserAnswer:     "",
+				Status:         "not_answered",
 			}
 		}
 	}
 
 	maxScore := len(session.Questions) * 100
 
-	respondJSON(w, http.StatusOK, CompleteSessionResponse{
-		SessionID:  sessionID,
+	return CompleteSessionResponse{
+		SessionID:  session.ID,
 		TotalScore: totalScore,
 		MaxScore:   maxScore,
 		Results:    results,
-	})
+		Pending:    pending,
+		Progress:   progress,
+	}, nil
+}
+
+// setGradeProvenance fills in when and by which model grade was graded,
+// for grades that recorded it, and which expected answer it matched.
+func setGradeProvenance(d *GradeDetails, grade store.StoredGrade) {
+	d.MatchedAnswer = grade.MatchedAnswer
+	if !grade.GradedAt.IsZero() {
+		gradedAt := grade.GradedAt
+		d.GradedAt = &gradedAt
+	}
+	if grade.Model != "" {
+		d.Model = grade.Model
+		ms := grade.Duration.Milliseconds()
+		d.DurationMs = &ms
+	}
 }
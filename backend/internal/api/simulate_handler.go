@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/grader"
 	"github.com/remaimber-it/backend/internal/service"
 )
 
@@ -15,6 +18,8 @@ type SimulateGradeRequest struct {
 	UserAnswer     string  `json:"user_answer" example:"A goroutine is a concurrent unit of execution."`
 	BankType       string  `json:"bank_type" example:"theory"`
 	GradingPrompt  *string `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
+	Rubric         *string `json:"rubric,omitempty" example:"- Names the race condition\n- Proposes a fix"` // grading criteria; expected_answer becomes a reference solution
+	AnswerLanguage string  `json:"answer_language,omitempty" example:"French"`                              // language of user_answer when it differs from expected_answer's, or "auto"
 }
 
 func (r *SimulateGradeRequest) Validate() error {
@@ -28,15 +33,80 @@ func (r *SimulateGradeRequest) Validate() error {
 		return errors.New("user_answer is required")
 	}
 	// bank_type defaults to "theory" if empty
+	return validateAnswerLanguage(&r.AnswerLanguage)
+}
+
+// DryRunGradeRequest is a sample answer to grade with a draft grading prompt.
+type DryRunGradeRequest struct {
+	Question       string  `json:"question" example:"What is a goroutine?"`
+	ExpectedAnswer string  `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	UserAnswer     string  `json:"user_answer" example:"A goroutine is a concurrent unit of execution."`
+	BankType       string  `json:"bank_type,omitempty" example:"theory"` // "theory" (default), "code" or "cli"
+	GradingPrompt  *string `json:"grading_prompt,omitempty" example:"Be strict about mentioning the Go scheduler."`
+}
+
+func (r *DryRunGradeRequest) Validate() error {
+	if r.Question == "" {
+		return errors.New("question is required")
+	}
+	if r.ExpectedAnswer == "" {
+		return errors.New("expected_answer is required")
+	}
+	if r.UserAnswer == "" {
+		return errors.New("user_answer is required")
+	}
+	switch questionbank.BankType(r.BankType) {
+	case "":
+		r.BankType = string(questionbank.BankTypeTheory)
+	case questionbank.BankTypeTheory, questionbank.BankTypeCode, questionbank.BankTypeCLI:
+	default:
+		return errors.New("bank_type must be theory, code or cli")
+	}
 	return nil
 }
 
-type SimulateGradeResponse struct {
+type DryRunGradeResponse struct {
 	Score   int      `json:"score" example:"80"`
 	Covered []string `json:"covered" example:"lightweight thread,concurrent execution"`
 	Missed  []string `json:"missed" example:"managed by Go runtime"`
 }
 
+type GradePreviewRequest struct {
+	Question       string `json:"question" example:"What is a goroutine?"`
+	ExpectedAnswer string `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	Answer         string `json:"answer" example:"A goroutine is a concurrent unit of execution."`
+}
+
+func (r *GradePreviewRequest) Validate() error {
+	if r.Question == "" {
+		return errors.New("question is required")
+	}
+	if r.ExpectedAnswer == "" {
+		return errors.New("expected_answer is required")
+	}
+	if r.Answer == "" {
+		return errors.New("answer is required")
+	}
+	return nil
+}
+
+type GradingPromptPreviewRequest struct {
+	GradePreviewRequest
+	GradingPrompt *string `json:"grading_prompt,omitempty" example:"Accept answers that omit the scheduler."` // draft to preview; defaults to the bank's
+}
+
+type GradingPromptPreviewResponse struct {
+	Prompt string `json:"prompt" example:"/no_think\nGrade the answer. ..."`
+}
+
+type SimulateGradeResponse struct {
+	Score          int      `json:"score" example:"80"`
+	Covered        []string `json:"covered" example:"lightweight thread,concurrent execution"`
+	Missed         []string `json:"missed" example:"managed by Go runtime"`
+	CoveredIndices []int    `json:"covered_indices" example:"0,1"`
+	MissedIndices  []int    `json:"missed_indices" example:"2"`
+}
+
 // ── Handlers ────────────────────────────────────────────────────────────────
 
 // simulateGrade grades a single answer without creating a session.
@@ -54,7 +124,7 @@ func (h *Handler) simulateGrade(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req SimulateGradeRequest
-	if !decodeAndValidate(w, r, &req) {
+	if !decodeAndValidate(w, r, &req, strictFields) {
 		return
 	}
 
@@ -71,18 +141,188 @@ func (h *Handler) simulateGrade(w http.ResponseWriter, r *http.Request) {
 		ExpectedAnswer: req.ExpectedAnswer,
 		UserAnswer:     req.UserAnswer,
 		GradingPrompt:  req.GradingPrompt,
+		Rubric:         req.Rubric,
 		BankType:       bankType,
+		AnswerLanguage: req.AnswerLanguage,
+	}
+
+	result, err := h.grading.GradeOnce(ctx, gradeReq)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "grading failed: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, newSimulateGradeResponse(result))
+}
+
+// maxDryRunGradeBodySize caps dry-run grading requests well below
+// maxRequestBodySize: a question, an answer and a prompt are small.
+const maxDryRunGradeBodySize = 64 << 10
+
+// dryRunGrade grades a sample answer with a draft grading prompt.
+// @Summary      Dry-run grading
+// @Description  Grade an answer with an optional draft grading prompt and return the parsed result without touching any session or stats. The body is limited to 64 KB and grading is abandoned with 504 when the model takes longer than the preview timeout (25 seconds by default).
+// @Tags         Simulate
+// @Accept       json
+// @Produce      json
+// @Param        body  body      DryRunGradeRequest  true  "Answer and grading prompt"
+// @Success      200   {object}  DryRunGradeResponse
+// @Failure      400   {object}  ErrorResponse
+// @Failure      413   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Failure      504   {object}  ErrorResponse
+// @Router       /grade/preview [post]
+func (h *Handler) dryRunGrade(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxDryRunGradeBodySize)
+	var req DryRunGradeRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.gradePreviewTimeout)
+	defer cancel()
+
+	result, err := h.grading.GradeOnce(ctx, service.GradeRequest{
+		Question:       req.Question,
+		ExpectedAnswer: req.ExpectedAnswer,
+		UserAnswer:     req.UserAnswer,
+		GradingPrompt:  req.GradingPrompt,
+		BankType:       req.BankType,
+	})
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		respondError(w, http.StatusGatewayTimeout, "grading timed out")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "grading failed: "+err.Error())
+		return
+	}
+
+	resp := DryRunGradeResponse{Score: result.Score, Covered: result.Covered, Missed: result.Missed}
+	if resp.Covered == nil {
+		resp.Covered = []string{}
 	}
+	if resp.Missed == nil {
+		resp.Missed = []string{}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// previewBankGrade grades a sample answer using a bank's grading settings.
+// @Summary      Preview grading for a bank
+// @Description  Grade a sample question/answer pair synchronously using the bank's type, grading prompt, rubric, and grading mode. Nothing is persisted — use this to tune a bank's grading prompt.
+// @Tags         Simulate
+// @Accept       json
+// @Produce      json
+// @Param        bankID  path      string               true  "Bank ID"
+// @Param        body    body      GradePreviewRequest  true  "Sample question and answer"
+// @Success      200     {object}  SimulateGradeResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      404     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /banks/{bankID}/grade-preview [post]
+func (h *Handler) previewBankGrade(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
 
-	score, covered, missed, err := h.grading.GradeOnce(ctx, gradeReq)
+	var req GradePreviewRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	bank, err := h.store.GetBank(ctx, bankID)
+	if h.handleStoreError(w, err, "bank") {
+		return
+	}
+
+	result, err := h.grading.GradeOnce(ctx, service.GradeRequest{
+		Question:       req.Question,
+		ExpectedAnswer: req.ExpectedAnswer,
+		UserAnswer:     req.Answer,
+		GradingPrompt:  bank.GradingPrompt,
+		Rubric:         bank.Rubric,
+		BankType:       string(bank.BankType),
+		GradingMode:    string(bank.GradingMode),
+		ExactMatch:     bank.ExactMatch,
+		ScoringCurve:   bank.ScoringCurve,
+	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "grading failed: "+err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, SimulateGradeResponse{
-		Score:   score,
-		Covered: covered,
-		Missed:  missed,
+	respondJSON(w, http.StatusOK, newSimulateGradeResponse(result))
+}
+
+// previewGradingPrompt renders the prompt a bank would send to the LLM.
+// @Summary      Preview a bank's grading prompt
+// @Description  Return the full prompt that grading a sample question/answer pair with this bank would send to the LLM, without calling it. The bank's grading prompt is added to the default rules as additional rules; pass grading_prompt to preview a draft instead. Banks that grade in exact mode send no prompt and return 409.
+// @Tags         Simulate
+// @Accept       json
+// @Produce      json
+// @Param        bankID  path      string                       true  "Bank ID"
+// @Param        body    body      GradingPromptPreviewRequest  true  "Sample question and answer"
+// @Success      200     {object}  GradingPromptPreviewResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      404     {object}  ErrorResponse
+// @Failure      409     {object}  ErrorResponse
+// @Failure      501     {object}  ErrorResponse
+// @Router       /banks/{bankID}/grading-prompt/preview [post]
+func (h *Handler) previewGradingPrompt(w http.ResponseWriter, r *http.Request) {
+	var req GradingPromptPreviewRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	bank, err := h.store.GetBank(r.Context(), r.PathValue("bankID"))
+	if h.handleStoreError(w, err, "bank") {
+		return
+	}
+
+	gradingPrompt := bank.GradingPrompt
+	if req.GradingPrompt != nil {
+		gradingPrompt = req.GradingPrompt
+	}
+
+	prompt, err := h.grading.RenderPrompt(service.GradeRequest{
+		Question:       req.Question,
+		ExpectedAnswer: req.ExpectedAnswer,
+		UserAnswer:     req.Answer,
+		GradingPrompt:  gradingPrompt,
+		Rubric:         bank.Rubric,
+		BankType:       string(bank.BankType),
+		GradingMode:    string(bank.GradingMode),
 	})
+	switch {
+	case errors.Is(err, service.ErrNoPrompt):
+		respondError(w, http.StatusConflict, "bank grades in exact mode and sends no prompt")
+		return
+	case errors.Is(err, service.ErrPromptUnavailable):
+		respondError(w, http.StatusNotImplemented, "the configured grader cannot preview its prompt")
+		return
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, "failed to render prompt")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, GradingPromptPreviewResponse{Prompt: prompt})
+}
+
+// newSimulateGradeResponse converts a grading result, filling in empty
+// index lists for graders that do not report them.
+func newSimulateGradeResponse(result grader.GradeResult) SimulateGradeResponse {
+	resp := SimulateGradeResponse{
+		Score:          result.Score,
+		Covered:        result.Covered,
+		Missed:         result.Missed,
+		CoveredIndices: result.CoveredIndices,
+		MissedIndices:  result.MissedIndices,
+	}
+	if resp.CoveredIndices == nil {
+		resp.CoveredIndices = []int{}
+	}
+	if resp.MissedIndices == nil {
+		resp.MissedIndices = []int{}
+	}
+	return resp
 }
@@ -1,6 +1,12 @@
 package api
 
-import "net/http"
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+
+	"github.com/remaimber-it/backend/internal/store"
+)
 
 type OverallStatsResponse struct {
 	Mastery int `json:"mastery"`
@@ -14,3 +20,188 @@ func (h *Handler) getOverallStats(w http.ResponseWriter, r *http.Request) {
 	}
 	respondJSON(w, http.StatusOK, OverallStatsResponse{Mastery: mastery})
 }
+
+// overviewWeakestLimit is how many questions GET /stats/overview lists as
+// the weakest.
+const overviewWeakestLimit = 10
+
+type StatsOverviewResponse struct {
+	Folders             int                    `json:"folders" example:"3"`
+	Categories          int                    `json:"categories" example:"8"`
+	Banks               int                    `json:"banks" example:"21"`
+	Questions           int                    `json:"questions" example:"240"`
+	UnansweredQuestions int                    `json:"unanswered_questions" example:"35"`
+	Mastery             int                    `json:"mastery" example:"58"`
+	WeakestQuestions    []WeakQuestionResponse `json:"weakest_questions"`
+}
+
+// getStatsOverview reports library-wide totals for the dashboard.
+// @Summary      Get a library overview
+// @Description  Returns how many folders, categories, banks and questions the library holds (trash excluded), how many questions were never answered, the average mastery over all questions (never-answered ones count as 0) and the 10 answered questions with the lowest mastery.
+// @Tags         Stats
+// @Produce      json
+// @Success      200  {object}  StatsOverviewResponse
+// @Failure      500  {object}  map[string]string
+// @Router       /stats/overview [get]
+func (h *Handler) getStatsOverview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	overview, err := h.store.GetLibraryOverview(ctx)
+	if err != nil {
+		h.logger.Error("failed to load library overview", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load overview")
+		return
+	}
+	weakest, err := h.store.GetWeakestAnsweredQuestions(ctx, overviewWeakestLimit)
+	if err != nil {
+		h.logger.Error("failed to load weakest questions", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load overview")
+		return
+	}
+
+	resp := StatsOverviewResponse{
+		Folders:             overview.Folders,
+		Categories:          overview.Categories,
+		Banks:               overview.Banks,
+		Questions:           overview.Questions,
+		UnansweredQuestions: overview.UnansweredQuestions,
+		Mastery:             overview.Mastery,
+		WeakestQuestions:    make([]WeakQuestionResponse, len(weakest)),
+	}
+	for i, q := range weakest {
+		resp.WeakestQuestions[i] = WeakQuestionResponse{
+			ID:             q.ID,
+			Subject:        q.Subject,
+			ExpectedAnswer: q.ExpectedAnswer,
+			BankID:         q.BankID,
+			BankSubject:    q.BankSubject,
+			Mastery:        q.Mastery,
+			TimesAnswered:  q.TimesAnswered,
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+type BankTypeStatsResponse struct {
+	Types []BankTypeMasteryResponse `json:"types"`
+}
+
+type BankTypeMasteryResponse struct {
+	BankType      string `json:"bank_type" example:"theory"`
+	QuestionCount int    `json:"question_count" example:"12"`
+	AnsweredCount int    `json:"answered_count" example:"8"`
+	Mastery       int    `json:"mastery" example:"42"`
+}
+
+// getStatsByType reports mastery grouped by bank type.
+// @Summary      Get mastery by bank type
+// @Description  Returns average mastery and question counts for each bank type (theory, code, cli) that has questions. Never-answered questions count as 0 mastery.
+// @Tags         Stats
+// @Produce      json
+// @Param        folder_id    query     string  false  "Only include questions in this folder"
+// @Param        category_id  query     string  false  "Only include questions in this category"
+// @Success      200          {object}  BankTypeStatsResponse
+// @Failure      404          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /stats/by-type [get]
+func (h *Handler) getStatsByType(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.statsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	byType, err := h.store.GetMasteryByBankType(r.Context(), filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to fetch mastery by type")
+		return
+	}
+
+	resp := BankTypeStatsResponse{Types: make([]BankTypeMasteryResponse, len(byType))}
+	for i, m := range byType {
+		resp.Types[i] = BankTypeMasteryResponse{
+			BankType:      m.BankType,
+			QuestionCount: m.QuestionCount,
+			AnsweredCount: m.AnsweredCount,
+			Mastery:       m.Mastery,
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// statsFilter reads the folder_id and category_id scope of a stats request,
+// responding 404 when either names a missing entity.
+func (h *Handler) statsFilter(w http.ResponseWriter, r *http.Request) (store.QuestionStatsFilter, bool) {
+	ctx := r.Context()
+	filter := store.QuestionStatsFilter{
+		FolderID:   r.URL.Query().Get("folder_id"),
+		CategoryID: r.URL.Query().Get("category_id"),
+	}
+
+	if filter.FolderID != "" {
+		_, err := h.store.GetFolder(ctx, filter.FolderID)
+		if h.handleStoreError(w, err, "folder") {
+			return filter, false
+		}
+	}
+	if filter.CategoryID != "" {
+		_, err := h.store.GetCategory(ctx, filter.CategoryID)
+		if h.handleStoreError(w, err, "category") {
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+// statsCSVHeader lists the columns of /stats/export.csv.
+var statsCSVHeader = []string{
+	"question_id", "question", "bank_id", "bank", "category_id", "category", "folder_id", "folder",
+	"times_answered", "times_correct", "latest_score", "mastery", "accuracy",
+}
+
+// exportStatsCSV streams per-question stats as CSV.
+// @Summary      Export question stats as CSV
+// @Description  Streams one row per question with its bank, category, folder, and stats. Accuracy is times_correct / times_answered and is empty for never-answered questions.
+// @Tags         Stats
+// @Produce      text/csv
+// @Param        folder_id    query     string  false  "Only include questions in this folder"
+// @Param        category_id  query     string  false  "Only include questions in this category"
+// @Success      200          {string}  string  "CSV file"
+// @Failure      404          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /stats/export.csv [get]
+func (h *Handler) exportStatsCSV(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	filter, ok := h.statsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=remaimber-stats.csv")
+
+	cw := csv.NewWriter(w)
+	cw.Write(statsCSVHeader)
+
+	// Headers are already sent once the first row is written, so failures
+	// past this point can only be logged.
+	err := h.store.StreamQuestionStats(ctx, filter, func(row store.QuestionStatsRow) error {
+		accuracy := ""
+		if row.TimesAnswered > 0 {
+			accuracy = strconv.FormatFloat(float64(row.TimesCorrect)/float64(row.TimesAnswered), 'f', 4, 64)
+		}
+		return cw.Write([]string{
+			row.QuestionID, row.Question, row.BankID, row.Bank,
+			row.CategoryID, row.Category, row.FolderID, row.Folder,
+			strconv.Itoa(row.TimesAnswered), strconv.Itoa(row.TimesCorrect),
+			strconv.Itoa(row.LatestScore), strconv.Itoa(row.Mastery),
+			accuracy,
+		})
+	})
+	if err != nil {
+		h.logger.Error("failed to export stats", "error", err)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		h.logger.Error("failed to write stats csv", "error", err)
+	}
+}
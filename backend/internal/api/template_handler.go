@@ -0,0 +1,164 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+//go:embed templates/*.json
+var templateFS embed.FS
+
+// Template is a built-in starter bank set. Its banks use the export format
+// so installing one goes through the same code path as /import.
+type Template struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Version     string       `json:"version"`
+	Banks       []ExportBank `json:"banks"`
+}
+
+// loadTemplates parses the embedded templates once. A template whose
+// version does not match ExportVersion is a build-time mistake, so it is
+// reported rather than silently skipped.
+var loadTemplates = sync.OnceValues(func() ([]Template, error) {
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]Template, 0, len(entries))
+	for _, e := range entries {
+		data, err := templateFS.ReadFile("templates/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		var t Template
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("template %s: %w", e.Name(), err)
+		}
+		if t.Version != ExportVersion {
+			return nil, fmt.Errorf("template %s: version %q does not match export version %q", e.Name(), t.Version, ExportVersion)
+		}
+		templates = append(templates, t)
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates, nil
+})
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type TemplateResponse struct {
+	ID            string `json:"id" example:"go-basics"`
+	Name          string `json:"name" example:"Go basics"`
+	Description   string `json:"description" example:"Core Go language concepts."`
+	Version       string `json:"version" example:"1.1"`
+	BankCount     int    `json:"bank_count" example:"1"`
+	QuestionCount int    `json:"question_count" example:"5"`
+}
+
+type InstallTemplateRequest struct {
+	CategoryID string `json:"category_id" example:"a1b2c3d4e5f6g7h8"`
+}
+
+func (r *InstallTemplateRequest) Validate() error {
+	if r.CategoryID == "" {
+		return errors.New("category_id is required")
+	}
+	return nil
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// listTemplates returns the built-in starter templates.
+// @Summary      List templates
+// @Description  Returns the built-in starter bank templates that can be installed into a category.
+// @Tags         Templates
+// @Produce      json
+// @Success      200  {array}   TemplateResponse
+// @Failure      500  {object}  map[string]string
+// @Router       /templates [get]
+func (h *Handler) listTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := loadTemplates()
+	if err != nil {
+		h.logger.Error("failed to load templates", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load templates")
+		return
+	}
+
+	response := make([]TemplateResponse, len(templates))
+	for i, t := range templates {
+		questionCount := 0
+		for _, b := range t.Banks {
+			questionCount += len(b.Questions)
+		}
+		response[i] = TemplateResponse{
+			ID:            t.ID,
+			Name:          t.Name,
+			Description:   t.Description,
+			Version:       t.Version,
+			BankCount:     len(t.Banks),
+			QuestionCount: questionCount,
+		}
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// installTemplate imports a template's banks into a category.
+// @Summary      Install a template
+// @Description  Create the template's banks and questions inside an existing category. New IDs are generated for all entities.
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        templateID  path      string                  true  "Template ID"
+// @Param        body        body      InstallTemplateRequest  true  "Target category"
+// @Success      201         {object}  ImportResult
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /templates/{templateID}/install [post]
+func (h *Handler) installTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	templateID := r.PathValue("templateID")
+
+	var req InstallTemplateRequest
+	if !decodeAndValidate(w, r, &req, strictFields) {
+		return
+	}
+
+	templates, err := loadTemplates()
+	if err != nil {
+		h.logger.Error("failed to load templates", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to load templates")
+		return
+	}
+
+	var tmpl *Template
+	for i := range templates {
+		if templates[i].ID == templateID {
+			tmpl = &templates[i]
+			break
+		}
+	}
+	if tmpl == nil {
+		respondError(w, http.StatusNotFound, "template not found")
+		return
+	}
+
+	_, err = h.store.GetCategory(ctx, req.CategoryID)
+	if h.handleStoreError(w, err, "category") {
+		return
+	}
+
+	result := ImportResult{}
+	h.importBanks(ctx, ExportCategory{Name: tmpl.Name, Banks: tmpl.Banks}, req.CategoryID, importOptions{}, &result)
+
+	respondJSON(w, http.StatusCreated, result)
+}
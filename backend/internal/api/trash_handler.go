@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type TrashedBankResponse struct {
+	ID            string    `json:"id" example:"x9y8z7w6v5u4t3s2"`
+	Subject       string    `json:"subject" example:"Go concurrency patterns"`
+	CategoryID    *string   `json:"category_id,omitempty" example:"a1b2c3d4e5f6g7h8"`
+	QuestionCount int       `json:"question_count" example:"12"` // questions deleted along with the bank
+	DeletedAt     time.Time `json:"deleted_at" example:"2025-01-15T10:30:00Z"`
+}
+
+type TrashedQuestionResponse struct {
+	ID          string    `json:"id" example:"q1w2e3r4t5y6u7i8"`
+	Subject     string    `json:"subject" example:"What is a goroutine?"`
+	BankID      string    `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	BankSubject string    `json:"bank_subject" example:"Go concurrency patterns"`
+	DeletedAt   time.Time `json:"deleted_at" example:"2025-01-15T10:30:00Z"`
+}
+
+type TrashResponse struct {
+	Banks     []TrashedBankResponse     `json:"banks"`
+	Questions []TrashedQuestionResponse `json:"questions"`
+}
+
+// parsePermanent reads the optional "permanent" query parameter of DELETE
+// endpoints. On an invalid value it writes a 400 response and returns false.
+func parsePermanent(w http.ResponseWriter, r *http.Request) (permanent, ok bool) {
+	v := r.URL.Query().Get("permanent")
+	if v == "" {
+		return false, true
+	}
+	permanent, err := strconv.ParseBool(v)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "permanent must be true or false")
+		return false, false
+	}
+	return permanent, true
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// listTrash lists deleted banks and questions.
+// @Summary      List the trash
+// @Description  Returns banks and questions deleted without permanent=true, most recently deleted first. Questions deleted along with their bank are only counted in the bank's question_count. Restore a bank with POST /banks/{bankID}/restore.
+// @Tags         Trash
+// @Produce      json
+// @Success      200  {object}  TrashResponse
+// @Failure      500  {object}  map[string]string
+// @Router       /trash [get]
+func (h *Handler) listTrash(w http.ResponseWriter, r *http.Request) {
+	trash, err := h.store.ListTrash(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list trash")
+		return
+	}
+
+	resp := TrashResponse{
+		Banks:     make([]TrashedBankResponse, len(trash.Banks)),
+		Questions: make([]TrashedQuestionResponse, len(trash.Questions)),
+	}
+	for i, b := range trash.Banks {
+		resp.Banks[i] = TrashedBankResponse{
+			ID:            b.ID,
+			Subject:       b.Subject,
+			CategoryID:    b.CategoryID,
+			QuestionCount: b.QuestionCount,
+			DeletedAt:     b.DeletedAt.UTC(),
+		}
+	}
+	for i, q := range trash.Questions {
+		resp.Questions[i] = TrashedQuestionResponse{
+			ID:          q.ID,
+			Subject:     q.Subject,
+			BankID:      q.BankID,
+			BankSubject: q.BankSubject,
+			DeletedAt:   q.DeletedAt.UTC(),
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// restoreBank takes a bank out of the trash.
+// @Summary      Restore a deleted bank
+// @Description  Restore a bank from the trash together with the questions deleted along with it, keeping their stats. Questions deleted on their own stay in the trash.
+// @Tags         Trash
+// @Param        bankID  path  string  true  "Bank ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /banks/{bankID}/restore [post]
+func (h *Handler) restoreBank(w http.ResponseWriter, r *http.Request) {
+	if h.handleStoreError(w, h.store.RestoreBank(r.Context(), r.PathValue("bankID")), "bank") {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
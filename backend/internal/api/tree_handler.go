@@ -0,0 +1,211 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+// ── Response types ──────────────────────────────────────────────────────────
+
+type TreeBank struct {
+	ID            string `json:"id" example:"b1a2n3k4i5d6"`
+	Subject       string `json:"subject" example:"Go concurrency patterns"`
+	BankType      string `json:"bank_type" example:"theory"`
+	QuestionCount int    `json:"question_count" example:"12"`
+	Mastery       int    `json:"mastery" example:"42"`
+}
+
+// TreeCategory is a category node. The virtual node holding banks without a
+// category has a null id.
+type TreeCategory struct {
+	ID            *string    `json:"id" example:"c1a2t3e4g5o6r7y8"`
+	Name          string     `json:"name" example:"Golang"`
+	BankCount     int        `json:"bank_count" example:"3"`
+	QuestionCount int        `json:"question_count" example:"36"`
+	Mastery       int        `json:"mastery" example:"42"`
+	Banks         []TreeBank `json:"banks"`
+}
+
+// TreeFolder is a folder node. The virtual node holding unfiled categories
+// has a null id.
+type TreeFolder struct {
+	ID            *string        `json:"id" example:"f1o2l3d4e5r6i7d8"`
+	Name          string         `json:"name" example:"Programming"`
+	IsSystem      bool           `json:"is_system" example:"false"`
+	CategoryCount int            `json:"category_count" example:"2"`
+	BankCount     int            `json:"bank_count" example:"5"`
+	QuestionCount int            `json:"question_count" example:"60"`
+	Mastery       int            `json:"mastery" example:"42"`
+	Categories    []TreeCategory `json:"categories"`
+}
+
+type TreeResponse struct {
+	Folders []TreeFolder `json:"folders"`
+}
+
+// unfiledFolderName names the virtual node for categories without a folder.
+const unfiledFolderName = "Unfiled"
+
+// uncategorizedName names the virtual node for banks without a category.
+const uncategorizedName = "Uncategorized"
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// getTree returns the full folder → category → bank hierarchy.
+// @Summary      Get the navigation tree
+// @Description  Returns every folder with its categories and banks, including counts and masteries. Categories without a folder are grouped under a virtual "Unfiled" node with a null id, listed last when present. Banks without a category are grouped under a virtual "Uncategorized" category with a null id, listed last in the Unfiled node.
+// @Tags         Tree
+// @Produce      json
+// @Success      200  {object}  TreeResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /tree [get]
+func (h *Handler) getTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	folders, err := h.store.ListFolders(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load folders")
+		return
+	}
+	categories, err := h.store.ListCategories(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load categories")
+		return
+	}
+	banks, err := h.store.ListBanksWithCounts(ctx)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load banks")
+		return
+	}
+
+	folderIDs := make([]string, len(folders))
+	for i, f := range folders {
+		folderIDs[i] = f.ID
+	}
+	categoryIDs := make([]string, len(categories))
+	for i, c := range categories {
+		categoryIDs[i] = c.ID
+	}
+	bankIDs := make([]string, len(banks))
+	for i, b := range banks {
+		bankIDs[i] = b.ID
+	}
+
+	folderMastery, _ := h.store.GetFolderMasteryBatch(ctx, folderIDs)
+	categoryMastery, _ := h.store.GetCategoryMasteryBatch(ctx, categoryIDs)
+	bankMastery, _ := h.store.GetBankMasteryBatch(ctx, bankIDs, h.masteryScope)
+
+	banksByCategory := make(map[string][]*store.BankWithCount)
+	var uncategorized []*store.BankWithCount
+	for _, b := range banks {
+		if b.CategoryID != nil {
+			banksByCategory[*b.CategoryID] = append(banksByCategory[*b.CategoryID], b)
+		} else {
+			uncategorized = append(uncategorized, b)
+		}
+	}
+
+	categoriesByFolder := make(map[string][]TreeCategory)
+	var unfiled []TreeCategory
+	for _, c := range categories {
+		node := newTreeCategory(&c.ID, c.Name, banksByCategory[c.ID], bankMastery)
+		node.Mastery = categoryMastery[c.ID]
+
+		if c.FolderID == nil {
+			unfiled = append(unfiled, node)
+		} else {
+			categoriesByFolder[*c.FolderID] = append(categoriesByFolder[*c.FolderID], node)
+		}
+	}
+
+	if len(uncategorized) > 0 {
+		node := newTreeCategory(nil, uncategorizedName, uncategorized, bankMastery)
+		node.Mastery = weightedBankMastery(node.Banks)
+		unfiled = append(unfiled, node)
+	}
+
+	response := TreeResponse{Folders: make([]TreeFolder, 0, len(folders)+1)}
+	for _, f := range folders {
+		node := newTreeFolder(&f.ID, f.Name, categoriesByFolder[f.ID])
+		node.IsSystem = f.IsSystem
+		node.Mastery = folderMastery[f.ID]
+		response.Folders = append(response.Folders, node)
+	}
+
+	if len(unfiled) > 0 {
+		node := newTreeFolder(nil, unfiledFolderName, unfiled)
+		node.Mastery = weightedMastery(unfiled)
+		response.Folders = append(response.Folders, node)
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// newTreeCategory builds a category node from its banks and totals their
+// counts. The caller sets its mastery.
+func newTreeCategory(id *string, name string, banks []*store.BankWithCount, bankMastery map[string]int) TreeCategory {
+	node := TreeCategory{
+		ID:    id,
+		Name:  name,
+		Banks: make([]TreeBank, 0, len(banks)),
+	}
+	for _, b := range banks {
+		node.Banks = append(node.Banks, TreeBank{
+			ID:            b.ID,
+			Subject:       b.Subject,
+			BankType:      b.BankType,
+			QuestionCount: b.QuestionCount,
+			Mastery:       bankMastery[b.ID],
+		})
+		node.BankCount++
+		node.QuestionCount += b.QuestionCount
+	}
+	return node
+}
+
+// newTreeFolder builds a folder node and totals its children's counts.
+func newTreeFolder(id *string, name string, categories []TreeCategory) TreeFolder {
+	node := TreeFolder{
+		ID:         id,
+		Name:       name,
+		Categories: categories,
+	}
+	if node.Categories == nil {
+		node.Categories = make([]TreeCategory, 0)
+	}
+	for _, c := range node.Categories {
+		node.CategoryCount++
+		node.BankCount += c.BankCount
+		node.QuestionCount += c.QuestionCount
+	}
+	return node
+}
+
+// weightedBankMastery averages bank masteries weighted by question count,
+// as weightedMastery does for categories.
+func weightedBankMastery(banks []TreeBank) int {
+	total, questions := 0, 0
+	for _, b := range banks {
+		total += b.Mastery * b.QuestionCount
+		questions += b.QuestionCount
+	}
+	if questions == 0 {
+		return 0
+	}
+	return total / questions
+}
+
+// weightedMastery averages category masteries weighted by question count,
+// matching how the store computes folder mastery.
+func weightedMastery(categories []TreeCategory) int {
+	total, questions := 0, 0
+	for _, c := range categories {
+		total += c.Mastery * c.QuestionCount
+		questions += c.QuestionCount
+	}
+	if questions == 0 {
+		return 0
+	}
+	return total / questions
+}
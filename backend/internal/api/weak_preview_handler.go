@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ── Request / Response types ────────────────────────────────────────────────
+
+type WeakPreviewQuestion struct {
+	ID             string `json:"id" example:"q1w2e3r4t5y6u7i8"`
+	Subject        string `json:"subject" example:"What is a goroutine?"`
+	ExpectedAnswer string `json:"expected_answer" example:"A goroutine is a lightweight thread managed by the Go runtime."`
+	Mastery        int    `json:"mastery" example:"20"`
+	TimesAnswered  int    `json:"times_answered" example:"3"`
+}
+
+type WeakPreviewResponse struct {
+	BankID    string                `json:"bank_id" example:"x9y8z7w6v5u4t3s2"`
+	Questions []WeakPreviewQuestion `json:"questions"`
+}
+
+// ── Handlers ────────────────────────────────────────────────────────────────
+
+// getWeakPreview lists the questions a focus-on-weak session would drill.
+// @Summary      Preview a focus-on-weak session
+// @Description  Returns the questions POST /sessions with focus_on_weak=true would pick for this bank, weakest first, with their current mastery. No session is created. Questions with equal mastery may come in a different order in the session.
+// @Tags         Banks
+// @Produce      json
+// @Param        bankID  path      string  true   "Bank ID"
+// @Param        limit   query     int     false  "Maximum number of questions, as max_questions on the session (default all)"
+// @Success      200     {object}  WeakPreviewResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /banks/{bankID}/weak-preview [get]
+func (h *Handler) getWeakPreview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bankID := r.PathValue("bankID")
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	if _, err := h.store.GetBank(ctx, bankID); h.handleStoreError(w, err, "bank") {
+		return
+	}
+
+	questions, err := h.store.GetQuestionsOrderedByMastery(ctx, bankID, true)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get question order")
+		return
+	}
+	if limit > 0 && limit < len(questions) {
+		questions = questions[:limit]
+	}
+
+	stats, err := h.store.GetQuestionStatsByBank(ctx, bankID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to get stats")
+		return
+	}
+	mastery := make(map[string]int, len(stats))
+	answered := make(map[string]int, len(stats))
+	for _, s := range stats {
+		mastery[s.QuestionID] = s.Mastery
+		answered[s.QuestionID] = s.TimesAnswered
+	}
+
+	resp := WeakPreviewResponse{BankID: bankID, Questions: make([]WeakPreviewQuestion, len(questions))}
+	for i, q := range questions {
+		resp.Questions[i] = WeakPreviewQuestion{
+			ID:             q.ID,
+			Subject:        q.Subject,
+			ExpectedAnswer: q.ExpectedAnswer,
+			Mastery:        mastery[q.ID],
+			TimesAnswered:  answered[q.ID],
+		}
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -7,6 +7,7 @@ type Category struct {
 	Name      string
 	FolderID  *string // Optional — nil means uncategorized (no folder)
 	SortOrder int
+	Version   int // Incremented on every update, for optimistic concurrency
 }
 
 func New(name string) *Category {
@@ -14,6 +15,7 @@ func New(name string) *Category {
 		ID:       id.GenerateID(),
 		Name:     name,
 		FolderID: nil,
+		Version:  1,
 	}
 }
 
@@ -23,6 +25,7 @@ func NewWithFolder(name string, folderID string) *Category {
 		ID:       id.GenerateID(),
 		Name:     name,
 		FolderID: &folderID,
+		Version:  1,
 	}
 }
 
@@ -12,6 +12,7 @@ type Folder struct {
 	ID       string
 	Name     string
 	IsSystem bool // System folders (e.g. "Deleted") cannot be renamed
+	Version  int  // Incremented on every update, for optimistic concurrency
 }
 
 // New creates a Folder with a generated ID.
@@ -20,6 +21,7 @@ func New(name string) *Folder {
 		ID:       id.GenerateID(),
 		Name:     name,
 		IsSystem: false,
+		Version:  1,
 	}
 }
 
@@ -29,6 +31,7 @@ func NewSystem(name string) *Folder {
 		ID:       id.GenerateID(),
 		Name:     name,
 		IsSystem: true,
+		Version:  1,
 	}
 }
 
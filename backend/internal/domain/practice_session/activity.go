@@ -0,0 +1,44 @@
+package practicesession
+
+import (
+	"slices"
+	"time"
+)
+
+// Day returns the UTC calendar day t falls on, at midnight.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Streaks returns the current and longest runs of consecutive active days.
+// days may be in any order and contain duplicates; each is taken as its
+// UTC calendar day. The current streak ends today, or yesterday while today
+// has no activity yet, and is 0 otherwise.
+func Streaks(days []time.Time, today time.Time) (current, longest int) {
+	unique := make([]time.Time, len(days))
+	for i, d := range days {
+		unique[i] = Day(d)
+	}
+	slices.SortFunc(unique, func(a, b time.Time) int { return a.Compare(b) })
+	unique = slices.Compact(unique)
+
+	run := 0
+	for i, d := range unique {
+		if i > 0 && unique[i-1].AddDate(0, 0, 1).Equal(d) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+
+	if len(unique) > 0 {
+		last := unique[len(unique)-1]
+		today = Day(today)
+		if last.Equal(today) || last.AddDate(0, 0, 1).Equal(today) {
+			current = run
+		}
+	}
+	return current, longest
+}
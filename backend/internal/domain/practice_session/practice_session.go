@@ -2,6 +2,7 @@ package practicesession
 
 import (
 	"math/rand"
+	"time"
 
 	"github.com/remaimber-it/backend/internal/domain/questionbank"
 	"github.com/remaimber-it/backend/internal/id"
@@ -24,6 +25,7 @@ type PracticeSession struct {
 	MaxDuration     *int          // Duration in minutes (optional)
 	FocusOnWeak     bool          // Whether this session focuses on weak questions
 	Status          SessionStatus // active or completed
+	StartedAt       time.Time     // When the session was saved; zero for sessions saved before it was recorded
 }
 
 // New creates a practice session with all questions from the bank (randomized).
@@ -34,7 +36,8 @@ func New(bank *questionbank.QuestionBank) *PracticeSession {
 
 // NewWithConfig creates a practice session with the given configuration.
 // If orderedQuestions is provided (for focus on weak mode), use that order.
-// Otherwise, questions are randomized.
+// Otherwise, questions keep the bank's order when config.PreserveOrder is
+// set and are randomized when it is not.
 func NewWithConfig(bank *questionbank.QuestionBank, config SessionConfig, orderedQuestions []questionbank.Question) *PracticeSession {
 	var questions []questionbank.Question
 
@@ -42,6 +45,9 @@ func NewWithConfig(bank *questionbank.QuestionBank, config SessionConfig, ordere
 		// Use pre-ordered questions (sorted by mastery)
 		questions = make([]questionbank.Question, len(orderedQuestions))
 		copy(questions, orderedQuestions)
+	} else if config.PreserveOrder {
+		questions = make([]questionbank.Question, len(bank.Questions))
+		copy(questions, bank.Questions)
 	} else {
 		// Randomize questions
 		questions = shuffleQuestions(bank.Questions)
@@ -127,6 +133,40 @@ func (ps *PracticeSession) IsActive() bool {
 	return ps.Status == SessionStatusActive
 }
 
+// ExpiresAt returns when the session stops accepting answers. ok is false
+// when the session has no time limit or no recorded start.
+func (ps *PracticeSession) ExpiresAt() (expiresAt time.Time, ok bool) {
+	if ps.MaxDuration == nil || *ps.MaxDuration <= 0 || ps.StartedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return ps.StartedAt.Add(time.Duration(*ps.MaxDuration) * time.Minute), true
+}
+
+// IsExpired reports whether the session's time limit has run out at now.
+func (ps *PracticeSession) IsExpired(now time.Time) bool {
+	expiresAt, ok := ps.ExpiresAt()
+	return ok && now.After(expiresAt)
+}
+
+// Reshuffle puts the session's questions in a new random order. With two or
+// more questions the new order always differs from the current one.
+func (ps *PracticeSession) Reshuffle() {
+	shuffled := shuffleQuestions(ps.Questions)
+	if len(shuffled) > 1 && sameOrder(shuffled, ps.Questions) {
+		shuffled = append(shuffled[1:], shuffled[0])
+	}
+	ps.Questions = shuffled
+}
+
+func sameOrder(a, b []questionbank.Question) bool {
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
 // shuffleQuestions returns a new slice with questions in random order.
 func shuffleQuestions(questions []questionbank.Question) []questionbank.Question {
 	shuffled := make([]questionbank.Question, len(questions))
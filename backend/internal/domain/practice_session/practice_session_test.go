@@ -173,6 +173,29 @@ func TestNewWithConfig_FocusOnWeak(t *testing.T) {
 	}
 }
 
+func TestNewWithConfig_PreserveOrder(t *testing.T) {
+	bank := createBankWithQuestions(20)
+
+	maxQ := 5
+	config := practicesession.SessionConfig{MaxQuestions: &maxQ, PreserveOrder: true}
+	session := practicesession.NewWithConfig(bank, config, nil)
+
+	if !sameOrder(bank.Questions[:maxQ], session.Questions) {
+		t.Error("expected the first questions in bank order when PreserveOrder is set")
+	}
+
+	// Weak-first ordering wins over the bank order.
+	reversed := make([]questionbank.Question, len(bank.Questions))
+	for i, q := range bank.Questions {
+		reversed[len(reversed)-1-i] = q
+	}
+	config.FocusOnWeak = true
+	session = practicesession.NewWithConfig(bank, config, reversed)
+	if !sameOrder(reversed[:maxQ], session.Questions) {
+		t.Error("expected focus-on-weak order to take precedence")
+	}
+}
+
 func TestNewWithConfig_FocusOnWeakWithLimit(t *testing.T) {
 	bank := createBankWithQuestions(20)
 
@@ -211,6 +234,25 @@ func TestNew_SessionStatusIsActive(t *testing.T) {
 	}
 }
 
+func TestReshuffle_ChangesOrder(t *testing.T) {
+	bank := createBankWithQuestions(2)
+	for i := 0; i < 20; i++ {
+		session := practicesession.New(bank)
+		before := append([]questionbank.Question(nil), session.Questions...)
+
+		session.Reshuffle()
+		if sameOrder(before, session.Questions) {
+			t.Fatalf("expected a new order, got %v again", session.Questions)
+		}
+	}
+
+	single := practicesession.New(createBankWithQuestions(1))
+	single.Reshuffle()
+	if len(single.Questions) != 1 {
+		t.Errorf("expected the single question to stay, got %d", len(single.Questions))
+	}
+}
+
 // Helper to check if two question slices have the same order
 func sameOrder(a, b []questionbank.Question) bool {
 	if len(a) != len(b) {
@@ -223,3 +265,51 @@ func sameOrder(a, b []questionbank.Question) bool {
 	}
 	return true
 }
+
+func TestIsExpired(t *testing.T) {
+	limit := 10 * time.Minute
+	session := practicesession.NewWithConfig(createBankWithQuestions(1), practicesession.SessionConfig{MaxDuration: &limit}, nil)
+	now := time.Now()
+
+	if session.IsExpired(now.Add(time.Hour)) {
+		t.Error("expected a session without a start time never to expire")
+	}
+
+	session.StartedAt = now
+	if session.IsExpired(now.Add(limit)) {
+		t.Error("expected the session to accept answers up to its limit")
+	}
+	if !session.IsExpired(now.Add(limit + time.Second)) {
+		t.Error("expected the session to expire after its limit")
+	}
+
+	session.MaxDuration = nil
+	if session.IsExpired(now.Add(time.Hour)) {
+		t.Error("expected a session without a limit never to expire")
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	today := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	day := func(offset int) time.Time { return today.AddDate(0, 0, offset) }
+
+	tests := []struct {
+		name             string
+		days             []time.Time
+		current, longest int
+	}{
+		{"no activity", nil, 0, 0},
+		{"today only", []time.Time{day(0)}, 1, 1},
+		{"run ending yesterday is still current", []time.Time{day(-1), day(-2), day(-2), day(-3)}, 3, 3},
+		{"broken run", []time.Time{day(-2), day(-3)}, 0, 2},
+		{"longest in the past", []time.Time{day(0), day(-10), day(-11), day(-12), day(-5)}, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := practicesession.Streaks(tt.days, today)
+			if current != tt.current || longest != tt.longest {
+				t.Errorf("expected current %d / longest %d, got %d / %d", tt.current, tt.longest, current, longest)
+			}
+		})
+	}
+}
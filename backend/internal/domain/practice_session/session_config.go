@@ -4,9 +4,10 @@ import "time"
 
 // SessionConfig holds optional constraints for a practice session.
 type SessionConfig struct {
-	MaxQuestions *int           // nil = all questions from the bank
-	MaxDuration  *time.Duration // nil = no time limit
-	FocusOnWeak  bool           // true = prioritize low mastery questions
+	MaxQuestions  *int           // nil = all questions from the bank
+	MaxDuration   *time.Duration // nil = no time limit
+	FocusOnWeak   bool           // true = prioritize low mastery questions
+	PreserveOrder bool           // true = keep the bank's question order instead of shuffling
 }
 
 // DefaultConfig returns a config with no constraints.
@@ -1,8 +1,76 @@
 package questionbank
 
+// Difficulty rates how hard a question is. It weights the question's mastery
+// in its bank's mastery.
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium" // default
+	DifficultyHard   Difficulty = "hard"
+)
+
+// IsValid reports whether d is a known difficulty.
+func (d Difficulty) IsValid() bool {
+	return d == DifficultyEasy || d == DifficultyMedium || d == DifficultyHard
+}
+
+// OrDefault returns d, or DifficultyMedium when d is empty or unknown.
+func (d Difficulty) OrDefault() Difficulty {
+	if d.IsValid() {
+		return d
+	}
+	return DifficultyMedium
+}
+
+// Weight is how much a question of difficulty d counts towards its bank's
+// mastery: 1 for easy, 2 for medium and 3 for hard.
+func (d Difficulty) Weight() int {
+	switch d.OrDefault() {
+	case DifficultyEasy:
+		return 1
+	case DifficultyHard:
+		return 3
+	default:
+		return 2
+	}
+}
+
 type Question struct {
-	ID             string
-	Subject        string
-	ExpectedAnswer string
-	GradingPrompt  *string // Optional per-question grading instructions
+	ID                 string
+	Subject            string
+	ExpectedAnswer     string
+	AlternativeAnswers []string     // Other equally correct answers; ExpectedAnswer stays the primary one
+	GradingPrompt      *string      // Optional per-question grading instructions
+	Rubric             *string      // Optional grading criteria; the expected answer stays the reference solution
+	GradingMode        *GradingMode // Optional per-question override of the bank's grading mode
+	Difficulty         Difficulty   // Weights the question in bank mastery; empty means medium
+	Tags               []string     // Normalized with NormalizeTag, sorted
+}
+
+// ExpectedAnswers returns every acceptable answer to q, the primary
+// expected answer first.
+func (q Question) ExpectedAnswers() []string {
+	return append([]string{q.ExpectedAnswer}, q.AlternativeAnswers...)
+}
+
+// HasTags reports whether q carries any of tags, or all of them when
+// matchAll is set. Tags must be normalized with NormalizeTag.
+func (q Question) HasTags(tags []string, matchAll bool) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range q.Tags {
+			if own == tag {
+				found = true
+				break
+			}
+		}
+		if found && !matchAll {
+			return true
+		}
+		if !found && matchAll {
+			return false
+		}
+	}
+	return matchAll
 }
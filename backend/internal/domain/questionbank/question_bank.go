@@ -2,8 +2,15 @@ package questionbank
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/remaimber-it/backend/internal/id"
+	"github.com/remaimber-it/backend/internal/textutil"
 )
 
 type BankType string
@@ -14,32 +21,126 @@ const (
 	BankTypeCLI    BankType = "cli"
 )
 
+// IsValid reports whether t is a known bank type.
+func (t BankType) IsValid() bool {
+	return t == BankTypeTheory || t == BankTypeCode || t == BankTypeCLI
+}
+
+// Languages lists the code languages the frontend offers for code banks.
+// Keep it in sync with frontend/src/utils/languages.ts.
+var Languages = []string{
+	"go", "javascript", "typescript", "python", "rust", "java", "c", "cpp",
+	"csharp", "php", "ruby", "swift", "kotlin", "sql", "yaml", "dockerfile",
+	"json", "xml", "html", "css", "shell", "markdown", "graphql", "scala",
+	"lua", "perl", "r", "powershell", "hcl",
+}
+
+// IsKnownLanguage reports whether language is in Languages.
+func IsKnownLanguage(language string) bool {
+	return slices.Contains(Languages, language)
+}
+
+// ValidateLanguage checks that language suits a bank of type t: only code
+// banks have a language, and it must be one of Languages. An empty
+// language is always valid.
+func (t BankType) ValidateLanguage(language string) error {
+	if language == "" {
+		return nil
+	}
+	if t != BankTypeCode {
+		return fmt.Errorf("language is only allowed for code banks, not %s banks", t)
+	}
+	if !IsKnownLanguage(language) {
+		return fmt.Errorf("unknown language %q: must be one of %s", language, strings.Join(Languages, ", "))
+	}
+	return nil
+}
+
+// GradingMode selects how answers are graded.
+type GradingMode string
+
+const (
+	GradingModeLLM   GradingMode = "llm"   // graded by the language model (default)
+	GradingModeExact GradingMode = "exact" // compared to the expected answer in Go, 100 or 0
+)
+
+// IsValid reports whether m is a known grading mode.
+func (m GradingMode) IsValid() bool {
+	return m == GradingModeLLM || m == GradingModeExact
+}
+
+// ScoringCurve reshapes a grade's raw coverage score so partial answers can
+// earn more credit. Every curve maps 0 to 0 and 100 to 100.
+type ScoringCurve string
+
+const (
+	ScoringCurveLinear ScoringCurve = "linear" // score unchanged (default)
+	ScoringCurveSqrt   ScoringCurve = "sqrt"   // 100·√(score/100): 25 → 50, 50 → 71, 81 → 90
+)
+
+// IsValid reports whether c is a known scoring curve.
+func (c ScoringCurve) IsValid() bool {
+	return c == ScoringCurveLinear || c == ScoringCurveSqrt
+}
+
+// Apply curves score, clamped to [0, 100] before and after. Unknown curves
+// leave it unchanged.
+func (c ScoringCurve) Apply(score int) int {
+	score = min(max(score, 0), 100)
+	if c == ScoringCurveSqrt {
+		score = int(math.Round(10 * math.Sqrt(float64(score))))
+	}
+	return min(max(score, 0), 100)
+}
+
 type QuestionBank struct {
 	ID            string
 	Subject       string
-	CategoryID    *string  // Optional - can be nil for uncategorized banks
-	BankType      BankType // theory, code, or cli
-	Language      *string  // Optional - programming language for code banks
-	GradingPrompt *string  // Optional default grading rules for all questions in the bank
+	CategoryID    *string     // Optional - can be nil for uncategorized banks
+	BankType      BankType    // theory, code, or cli
+	Language      *string     // Optional - programming language for code banks
+	GradingPrompt *string     // Optional default grading rules for all questions in the bank
+	Rubric        *string     // Optional default rubric for questions without their own
+	GradingMode   GradingMode // Default grading mode for all questions in the bank
+	ExactMatch    ExactMatchOptions
+	ScoringCurve  ScoringCurve // Empty uses the server-wide default
+	PreserveOrder bool         // Sessions serve questions in stored order instead of shuffling
+	Tags          []string     // Normalized with NormalizeTag, sorted
 	Questions     []Question
+	Version       int       // Incremented on every update, for optimistic concurrency
+	CreatedAt     time.Time // Zero for banks created before creation times were recorded
+}
+
+// ExactMatchOptions tunes the comparison used by exact grading.
+// The zero value is lenient: case is ignored and runs of whitespace count
+// as a single space.
+type ExactMatchOptions struct {
+	CaseSensitive       bool
+	WhitespaceSensitive bool
 }
 
 func New(subject string) *QuestionBank {
 	return &QuestionBank{
-		ID:        id.GenerateID(),
-		Subject:   subject,
-		BankType:  BankTypeTheory,
-		Questions: []Question{},
+		ID:          id.GenerateID(),
+		Subject:     subject,
+		BankType:    BankTypeTheory,
+		GradingMode: GradingModeLLM,
+		Questions:   []Question{},
+		Version:     1,
+		CreatedAt:   time.Now(),
 	}
 }
 
 func NewWithCategory(subject string, categoryID string) *QuestionBank {
 	return &QuestionBank{
-		ID:         id.GenerateID(),
-		Subject:    subject,
-		CategoryID: &categoryID,
-		BankType:   BankTypeTheory,
-		Questions:  []Question{},
+		ID:          id.GenerateID(),
+		Subject:     subject,
+		CategoryID:  &categoryID,
+		BankType:    BankTypeTheory,
+		GradingMode: GradingModeLLM,
+		Questions:   []Question{},
+		Version:     1,
+		CreatedAt:   time.Now(),
 	}
 }
 
@@ -49,19 +150,124 @@ func NewWithOptions(subject string, categoryID *string, bankType BankType, langu
 		bt = BankTypeTheory
 	}
 	return &QuestionBank{
-		ID:         id.GenerateID(),
-		Subject:    subject,
-		CategoryID: categoryID,
-		BankType:   bt,
-		Language:   language,
-		Questions:  []Question{},
+		ID:          id.GenerateID(),
+		Subject:     subject,
+		CategoryID:  categoryID,
+		BankType:    bt,
+		Language:    language,
+		GradingMode: GradingModeLLM,
+		Questions:   []Question{},
+		Version:     1,
+		CreatedAt:   time.Now(),
 	}
 }
 
+// MaxTagLength is the longest tag accepted, in characters.
+const MaxTagLength = 50
+
+// NormalizeTag trims and lowercases tag so "Go " and "go" are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// ValidateTag reports whether a normalized tag is acceptable.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return errors.New("tag cannot be empty")
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+	}
+	return nil
+}
+
 func (qb *QuestionBank) SetCategory(categoryID *string) {
 	qb.CategoryID = categoryID
 }
 
+// Clone returns a deep copy of the bank and its questions with fresh IDs,
+// placed in categoryID and created now. Stats are not part of the bank, so
+// the copy starts unanswered.
+func (qb *QuestionBank) Clone(categoryID *string) *QuestionBank {
+	clone := *qb
+	clone.ID = id.GenerateID()
+	clone.CategoryID = categoryID
+	clone.Version = 1
+	clone.CreatedAt = time.Now()
+	clone.Tags = append([]string(nil), qb.Tags...)
+	clone.Questions = make([]Question, len(qb.Questions))
+	for i, q := range qb.Questions {
+		q.ID = id.GenerateID()
+		q.Tags = append([]string(nil), q.Tags...)
+		clone.Questions[i] = q
+	}
+	return &clone
+}
+
+// GradingModeFor returns the grading mode that applies to q: the question's
+// own override if set, otherwise the bank default.
+func (qb *QuestionBank) GradingModeFor(q Question) GradingMode {
+	if q.GradingMode != nil && q.GradingMode.IsValid() {
+		return *q.GradingMode
+	}
+	if qb.GradingMode.IsValid() {
+		return qb.GradingMode
+	}
+	return GradingModeLLM
+}
+
+// RubricFor returns the rubric that applies to q: the question's own if
+// set, otherwise the bank default. Nil means grade against the expected
+// answer alone.
+func (qb *QuestionBank) RubricFor(q Question) *string {
+	if q.Rubric != nil && *q.Rubric != "" {
+		return q.Rubric
+	}
+	if qb.Rubric != nil && *qb.Rubric != "" {
+		return qb.Rubric
+	}
+	return nil
+}
+
+// DuplicateSubjectThreshold is the SubjectSimilarity above which a new
+// question is taken to repeat one already in its bank. One extra word on a
+// four-word subject ("What is a goroutine leak?" against "What is a
+// goroutine?") scores exactly 0.8 and is a different question.
+const DuplicateSubjectThreshold = 0.8
+
+// SubjectSimilarity scores how alike two question subjects are, from 0 to
+// 1: the share of their distinct words they have in common (the Jaccard
+// index), ignoring case, punctuation, word order and repeated words.
+// Subjects without any words score 0.
+func SubjectSimilarity(a, b string) float64 {
+	wordsA, wordsB := textutil.WordSet(a), textutil.WordSet(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// FindNearDuplicate returns the question of the bank whose subject is most
+// similar to subject, provided its SubjectSimilarity is above threshold,
+// or nil. Ties go to the earlier question.
+func (qb *QuestionBank) FindNearDuplicate(subject string, threshold float64) *Question {
+	var match *Question
+	var best float64
+	for i := range qb.Questions {
+		s := SubjectSimilarity(subject, qb.Questions[i].Subject)
+		if s > threshold && (match == nil || s > best) {
+			match, best = &qb.Questions[i], s
+		}
+	}
+	return match
+}
+
 // AddQuestion appends a single question to the bank.
 func (qb *QuestionBank) AddQuestion(subject string, expectedAnswer string) error {
 	return qb.AddQuestionWithGradingPrompt(subject, expectedAnswer, nil)
@@ -78,6 +284,7 @@ func (qb *QuestionBank) AddQuestionWithGradingPrompt(subject string, expectedAns
 		Subject:        subject,
 		ExpectedAnswer: expectedAnswer,
 		GradingPrompt:  gradingPrompt,
+		Difficulty:     DifficultyMedium,
 	})
 	return nil
 }
@@ -1,13 +1,33 @@
 package questionbank
 
+import (
+	"math"
+	"time"
+)
+
+// PassThreshold is the default minimum score for an answer to count as
+// correct in TimesCorrect and streaks. Stores can be configured with another
+// one, see MASTERY_PASS_THRESHOLD.
+const PassThreshold = 70
+
 // QuestionStats tracks performance statistics for a single question
 type QuestionStats struct {
 	QuestionID    string
 	TimesAnswered int
-	TimesCorrect  int // Score >= 70 considered correct
-	TotalScore    int // Sum of all scores
-	LatestScore   int // Most recent score
-	Mastery       int // Calculated mastery level (0-100)
+	TimesCorrect  int       // Scores >= PassThreshold are considered correct
+	TotalScore    int       // Sum of all scores
+	LatestScore   int       // Most recent score
+	Mastery       int       // Calculated mastery level (0-100)
+	Streak        int       // Consecutive correct answers, counting back from the latest
+	LastAnswered  time.Time // When LatestScore was graded; zero if never answered or unknown
+}
+
+// Accuracy returns the percentage of answers that were correct (0-100).
+func (qs *QuestionStats) Accuracy() int {
+	if qs.TimesAnswered == 0 {
+		return 0
+	}
+	return qs.TimesCorrect * 100 / qs.TimesAnswered
 }
 
 // CalculateMastery computes mastery based on Option 3 formula:
@@ -36,6 +56,16 @@ func (qs *QuestionStats) CalculateMastery() int {
 	return mastery
 }
 
+// DecayMastery decays mastery toward zero by half for every halfLife that
+// has passed since the question was last answered (age). A non-positive
+// halfLife or age leaves it unchanged.
+func DecayMastery(mastery int, age, halfLife time.Duration) int {
+	if halfLife <= 0 || age <= 0 {
+		return mastery
+	}
+	return int(math.Round(float64(mastery) * math.Exp2(-float64(age)/float64(halfLife))))
+}
+
 // BankStats aggregates statistics for a question bank
 type BankStats struct {
 	BankID         string
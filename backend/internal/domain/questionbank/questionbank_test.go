@@ -1,7 +1,10 @@
 package questionbank_test
 
 import (
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/remaimber-it/backend/internal/domain/questionbank"
 )
@@ -72,3 +75,226 @@ func TestAddMultipleQuestions(t *testing.T) {
 		t.Errorf("expected 3 questions, got %d", len(bank.Questions))
 	}
 }
+
+func TestClone(t *testing.T) {
+	bank := questionbank.NewWithCategory("Architecture", "cat-1")
+	bank.AddQuestion("What is DDD?", "Domain-Driven Design")
+	bank.AddQuestion("What is CQRS?", "Command Query Responsibility Segregation")
+	bank.Version = 4
+	bank.CreatedAt = time.Now().Add(-24 * time.Hour)
+
+	target := "cat-2"
+	before := time.Now()
+	clone := bank.Clone(&target)
+
+	if clone.ID == bank.ID {
+		t.Error("expected a new bank ID")
+	}
+	if clone.CategoryID == nil || *clone.CategoryID != target {
+		t.Errorf("expected category %q, got %v", target, clone.CategoryID)
+	}
+	if clone.Subject != bank.Subject || clone.Version != 1 {
+		t.Errorf("expected subject %q at version 1, got %q at %d", bank.Subject, clone.Subject, clone.Version)
+	}
+	if clone.CreatedAt.Before(before) {
+		t.Errorf("expected the clone created now, got %v", clone.CreatedAt)
+	}
+	if len(clone.Questions) != 2 {
+		t.Fatalf("expected 2 questions, got %d", len(clone.Questions))
+	}
+	for i, q := range clone.Questions {
+		if q.ID == bank.Questions[i].ID {
+			t.Errorf("question %d: expected a new ID", i)
+		}
+		if q.Subject != bank.Questions[i].Subject {
+			t.Errorf("question %d: expected subject %q, got %q", i, bank.Questions[i].Subject, q.Subject)
+		}
+	}
+
+	clone.Questions[0].Subject = "changed"
+	if bank.Questions[0].Subject == "changed" {
+		t.Error("clone shares its questions with the original")
+	}
+}
+
+func TestDifficultyWeight(t *testing.T) {
+	tests := []struct {
+		difficulty questionbank.Difficulty
+		want       int
+	}{
+		{questionbank.DifficultyEasy, 1},
+		{questionbank.DifficultyMedium, 2},
+		{questionbank.DifficultyHard, 3},
+		{"", 2},
+		{"extreme", 2},
+	}
+	for _, tt := range tests {
+		if got := tt.difficulty.Weight(); got != tt.want {
+			t.Errorf("%q: expected weight %d, got %d", tt.difficulty, tt.want, got)
+		}
+	}
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	if bank.Questions[0].Difficulty != questionbank.DifficultyMedium {
+		t.Errorf("expected new questions to be medium, got %q", bank.Questions[0].Difficulty)
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	if got := questionbank.NormalizeTag("  Go "); got != "go" {
+		t.Errorf("expected %q, got %q", "go", got)
+	}
+	if err := questionbank.ValidateTag(""); err == nil {
+		t.Error("expected an empty tag to be rejected")
+	}
+	if err := questionbank.ValidateTag(strings.Repeat("x", questionbank.MaxTagLength+1)); err == nil {
+		t.Error("expected an overly long tag to be rejected")
+	}
+	if err := questionbank.ValidateTag("concurrency"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestQuestionHasTags(t *testing.T) {
+	q := questionbank.Question{Tags: []string{"channels", "go"}}
+	tests := []struct {
+		tags          []string
+		any, matchAll bool
+	}{
+		{[]string{"go"}, true, true},
+		{[]string{"go", "generics"}, true, false},
+		{[]string{"channels", "go"}, true, true},
+		{[]string{"generics"}, false, false},
+	}
+	for _, tt := range tests {
+		if got := q.HasTags(tt.tags, false); got != tt.any {
+			t.Errorf("any %v: expected %v, got %v", tt.tags, tt.any, got)
+		}
+		if got := q.HasTags(tt.tags, true); got != tt.matchAll {
+			t.Errorf("all %v: expected %v, got %v", tt.tags, tt.matchAll, got)
+		}
+	}
+}
+
+func TestScoringCurve(t *testing.T) {
+	tests := []struct {
+		raw, linear, sqrt int
+	}{
+		{0, 0, 0},
+		{1, 1, 10},
+		{25, 25, 50},
+		{50, 50, 71},
+		{81, 81, 90},
+		{100, 100, 100},
+		{-5, 0, 0},
+		{120, 100, 100},
+	}
+	for _, tt := range tests {
+		if got := questionbank.ScoringCurveLinear.Apply(tt.raw); got != tt.linear {
+			t.Errorf("linear(%d): expected %d, got %d", tt.raw, tt.linear, got)
+		}
+		if got := questionbank.ScoringCurveSqrt.Apply(tt.raw); got != tt.sqrt {
+			t.Errorf("sqrt(%d): expected %d, got %d", tt.raw, tt.sqrt, got)
+		}
+		if tt.raw >= 0 && tt.raw <= 100 && questionbank.ScoringCurveSqrt.Apply(tt.raw) < tt.raw {
+			t.Errorf("sqrt(%d): expected a curved score at least the raw score", tt.raw)
+		}
+	}
+	if got := questionbank.ScoringCurve("cubic").Apply(40); got != 40 {
+		t.Errorf("expected an unknown curve to leave 40 unchanged, got %d", got)
+	}
+}
+
+func TestDecayMastery(t *testing.T) {
+	const halfLife = 30 * 24 * time.Hour
+	tests := []struct {
+		mastery int
+		age     time.Duration
+		want    int
+	}{
+		{96, 0, 96},
+		{96, time.Minute, 96},
+		{96, halfLife, 48},
+		{96, 2 * halfLife, 24},
+		{96, 3 * halfLife, 12},
+		{96, -time.Hour, 96},
+		{0, halfLife, 0},
+	}
+	for _, tt := range tests {
+		if got := questionbank.DecayMastery(tt.mastery, tt.age, halfLife); got != tt.want {
+			t.Errorf("DecayMastery(%d, %v): expected %d, got %d", tt.mastery, tt.age, tt.want, got)
+		}
+	}
+	if got := questionbank.DecayMastery(96, 10*halfLife, 0); got != 96 {
+		t.Errorf("expected a zero half-life to disable decay, got %d", got)
+	}
+}
+
+func TestBankTypeValidateLanguage(t *testing.T) {
+	tests := []struct {
+		bankType questionbank.BankType
+		language string
+		valid    bool
+	}{
+		{questionbank.BankTypeCode, "go", true},
+		{questionbank.BankTypeCode, "", true},
+		{questionbank.BankTypeCode, "klingon", false},
+		{questionbank.BankTypeTheory, "", true},
+		{questionbank.BankTypeTheory, "go", false},
+		{questionbank.BankTypeCLI, "", true},
+		{questionbank.BankTypeCLI, "shell", false},
+	}
+	for _, tt := range tests {
+		if err := tt.bankType.ValidateLanguage(tt.language); (err == nil) != tt.valid {
+			t.Errorf("%s/%q: expected valid=%v, got %v", tt.bankType, tt.language, tt.valid, err)
+		}
+	}
+}
+
+func TestSubjectSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"What is a goroutine?", "what is a goroutine", 1},
+		{"  What is a GOROUTINE?! ", "goroutine: what is a", 1},
+		{"What is a goroutine?", "What is a goroutine leak?", 0.8},
+		{"What is a goroutine?", "What is a channel?", 0.6},
+		{"What is a goroutine?", "Explain mutexes", 0},
+		{"", "What is a goroutine?", 0},
+		{"?!", "...", 0},
+	}
+	for _, tt := range tests {
+		if got := questionbank.SubjectSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("SubjectSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := questionbank.SubjectSimilarity(tt.b, tt.a); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("SubjectSimilarity(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestFindNearDuplicate(t *testing.T) {
+	bank := questionbank.New("Go")
+	bank.AddQuestion("What is a channel?", "A typed pipe")
+	bank.AddQuestion("What is a goroutine?", "A lightweight thread")
+	bank.AddQuestion("What is a goroutine leak?", "A goroutine that never exits")
+
+	if q := bank.FindNearDuplicate("what is a goroutine", questionbank.DuplicateSubjectThreshold); q == nil || q.ID != bank.Questions[1].ID {
+		t.Errorf("expected the closest question to match, got %+v", q)
+	}
+	if q := bank.FindNearDuplicate("How do goroutines leak?", questionbank.DuplicateSubjectThreshold); q != nil {
+		t.Errorf("expected no match below the threshold, got %q", q.Subject)
+	}
+	if q := bank.FindNearDuplicate("What is a goroutine?", 0.6); q == nil || q.ID != bank.Questions[1].ID {
+		t.Errorf("expected the best match rather than the first above the threshold, got %+v", q)
+	}
+
+	// One more word makes a different question, even on a short subject.
+	other := questionbank.New("Go")
+	other.AddQuestion("What is a goroutine?", "A lightweight thread")
+	if q := other.FindNearDuplicate("What is a goroutine leak?", questionbank.DuplicateSubjectThreshold); q != nil {
+		t.Errorf("expected a goroutine leak not to duplicate a goroutine, got %q", q.Subject)
+	}
+}
@@ -0,0 +1,43 @@
+package grader
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// GradeExact grades userAnswer deterministically, without an LLM: it scores
+// 100 if the answer equals expectedAnswer after normalization, 0 otherwise.
+// Alternative expected answers are tried by the caller, one GradeExact each.
+// The result has the same JSON shape as GradeAnswer, with the expected
+// answer as its single key point.
+func GradeExact(expectedAnswer, userAnswer string, opts questionbank.ExactMatchOptions) string {
+	expected := strings.TrimSpace(expectedAnswer)
+	result := GradeResult{Covered: []string{}, Missed: []string{}, CoveredIndices: []int{}, MissedIndices: []int{}}
+	if normalizeExact(expected, opts) == normalizeExact(userAnswer, opts) {
+		result.Score = 100
+		result.Covered = []string{expected}
+		result.CoveredIndices = []int{0}
+	} else {
+		result.Missed = []string{expected}
+		result.MissedIndices = []int{0}
+	}
+
+	out, _ := json.Marshal(result)
+	return string(out)
+}
+
+// normalizeExact trims s and, unless opts say otherwise, collapses each run
+// of whitespace into one space and lowercases it. Whitespace between words is
+// kept, so "a b" never matches "ab".
+func normalizeExact(s string, opts questionbank.ExactMatchOptions) string {
+	s = strings.TrimSpace(s)
+	if !opts.WhitespaceSensitive {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+	if !opts.CaseSensitive {
+		s = strings.ToLower(s)
+	}
+	return s
+}
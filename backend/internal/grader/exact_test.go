@@ -0,0 +1,51 @@
+package grader_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/grader"
+)
+
+func TestGradeExact(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		answer   string
+		opts     questionbank.ExactMatchOptions
+		want     int
+	}{
+		{"identical", "mitochondria", "mitochondria", questionbank.ExactMatchOptions{}, 100},
+		{"case ignored by default", "Mitochondria", "MITOCHONDRIA", questionbank.ExactMatchOptions{}, 100},
+		{"whitespace ignored by default", "ice cream", "  ice   cream ", questionbank.ExactMatchOptions{}, 100},
+		{"words stay apart by default", "ice cream", "icecream", questionbank.ExactMatchOptions{}, 0},
+		{"lines are not variants", "colour\ncolor", "color", questionbank.ExactMatchOptions{}, 0},
+		{"near miss", "mitochondria", "mitochondrion", questionbank.ExactMatchOptions{}, 0},
+		{"case sensitive rejects", "Paris", "paris", questionbank.ExactMatchOptions{CaseSensitive: true}, 0},
+		{"case sensitive accepts", "Paris", " Paris ", questionbank.ExactMatchOptions{CaseSensitive: true}, 100},
+		{"whitespace sensitive rejects", "ice cream", "ice  cream", questionbank.ExactMatchOptions{WhitespaceSensitive: true}, 0},
+		{"whitespace sensitive accepts", "ice cream", "ice cream", questionbank.ExactMatchOptions{WhitespaceSensitive: true}, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result grader.GradeResult
+			if err := json.Unmarshal([]byte(grader.GradeExact(tt.expected, tt.answer, tt.opts)), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if result.Score != tt.want {
+				t.Errorf("expected score %d, got %d", tt.want, result.Score)
+			}
+			if tt.want == 100 && len(result.Covered) != 1 {
+				t.Errorf("expected the expected answer in covered, got %v", result.Covered)
+			}
+			if tt.want == 100 && len(result.CoveredIndices) != 1 {
+				t.Errorf("expected the expected answer's index in covered_indices, got %v", result.CoveredIndices)
+			}
+			if tt.want == 0 && len(result.Missed) != 1 {
+				t.Errorf("expected the expected answer in missed, got %v", result.Missed)
+			}
+		})
+	}
+}
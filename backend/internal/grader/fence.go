@@ -0,0 +1,50 @@
+package grader
+
+import "strings"
+
+// isFenceLine reports whether line opens or closes a markdown code fence,
+// such as "```" or "```go".
+func isFenceLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// splitCodeFences separates s into the text outside markdown code fences
+// and the contents of each fenced block. An unclosed fence runs to the end
+// of s.
+func splitCodeFences(s string) (outside string, blocks []string) {
+	var out, block strings.Builder
+	inFence := false
+	for _, line := range strings.SplitAfter(s, "\n") {
+		switch {
+		case isFenceLine(line) && inFence:
+			blocks = append(blocks, block.String())
+			block.Reset()
+			inFence = false
+		case isFenceLine(line):
+			inFence = true
+		case inFence:
+			block.WriteString(line)
+		default:
+			out.WriteString(line)
+		}
+	}
+	if inFence {
+		blocks = append(blocks, block.String())
+	}
+	return out.String(), blocks
+}
+
+// stripCodeFences removes markdown fence lines from a pasted answer, keeping
+// the code between them, so the model sees plain code as USER CODE.
+func stripCodeFences(s string) string {
+	if !strings.Contains(s, "```") {
+		return s
+	}
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if !isFenceLine(line) {
+			b.WriteString(line)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
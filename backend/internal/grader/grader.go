@@ -1,12 +1,52 @@
 package grader
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Grader grades a user's answer against an expected answer.
-// Implementations may call an LLM, use heuristics, or return canned results (for tests).
+// Implementations may call an LLM (OllamaGrader) or use heuristics
+// (StubGrader, for tests and offline use).
 type Grader interface {
 	// GradeAnswer returns a JSON string with {score, covered, missed}.
 	// bankType is one of "theory", "code", "cli".
 	// customPrompt optionally overrides the default grading rules.
 	GradeAnswer(ctx context.Context, question, expectedAnswer, userAnswer string, customPrompt *string, bankType string) (string, error)
+
+	// Ping checks that the grader can be reached. It should be cheap and
+	// honour ctx's deadline; graders without a backend return nil.
+	Ping(ctx context.Context) error
+}
+
+// SelfCheckVerifier is implemented by graders that can verify a user's own
+// marking of key points instead of classifying them from scratch.
+// selfCovered holds the indices into KeyPoints(expectedAnswer) the user
+// believes they covered. The returned JSON has the same shape as
+// GradeAnswer's and reflects the grader's judgment, not the user's.
+type SelfCheckVerifier interface {
+	VerifyAnswer(ctx context.Context, question, expectedAnswer, userAnswer string, selfCovered []int, customPrompt *string, bankType string) (string, error)
+}
+
+// RubricGrader is implemented by graders that can grade against a rubric
+// kept separate from the expected answer. The rubric lists the criteria the
+// answer is judged on; the expected answer is only a reference solution.
+// Covered and missed labels name rubric criteria, so the returned JSON has
+// no key point indices.
+type RubricGrader interface {
+	GradeAnswerWithRubric(ctx context.Context, question, expectedAnswer, userAnswer, rubric string, customPrompt *string, bankType string) (string, error)
+}
+
+// PromptRenderer is implemented by graders that send the model a prompt
+// and can show it without sending it. RenderPrompt returns the prompt
+// GradeAnswer would send, or GradeAnswerWithRubric when rubric is non-empty.
+type PromptRenderer interface {
+	RenderPrompt(question, expectedAnswer, userAnswer string, rubric, customPrompt *string, bankType string) string
+}
+
+// TimeoutReporter is implemented by graders that bound each grading by its
+// bank type. GradingTimeout returns how long grading an answer of bankType
+// may take, so callers do not cut it short with a deadline of their own.
+type TimeoutReporter interface {
+	GradingTimeout(bankType string) time.Duration
 }
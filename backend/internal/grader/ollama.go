@@ -1,12 +1,18 @@
 package grader
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 )
@@ -16,17 +22,40 @@ import (
 // -----------------------------------------------------------------------------
 
 type OllamaGrader struct {
-	url    string
-	model  string
-	client *http.Client
+	url        string
+	model      string
+	client     *http.Client
+	similarity float64 // minimum Similarity for a label to map to a key point
+	stream     bool    // request server-sent events instead of one response
+	strict     bool    // reject grade JSON that needs coercing (see ParseGradeResult)
+
+	timeouts map[string]time.Duration // how long grading may take per bank type; see SetBankTypeTimeouts
+
+	retryAttempts int           // calls per request when the server fails transiently
+	retryDelay    time.Duration // wait before the first transient retry; doubles after each
+
+	temperature float64 // sampling temperature; 0 keeps grading consistent
+	maxTokens   int     // cap on generated tokens; 0 leaves it to the server
+	topP        float64 // nucleus sampling cutoff; 0 leaves it to the server
+
+	shuffleMu sync.Mutex
+	shuffle   *rand.Rand // non-nil when key points are shuffled in prompts
 }
 
-var _ Grader = (*OllamaGrader)(nil)
+var (
+	_ Grader            = (*OllamaGrader)(nil)
+	_ SelfCheckVerifier = (*OllamaGrader)(nil)
+)
 
+// GradeResult is the JSON shape returned by graders. CoveredIndices and
+// MissedIndices reference the expected answer's KeyPoints, so clients can
+// map labels back to the source reliably.
 type GradeResult struct {
-	Score   int      `json:"score"`
-	Covered []string `json:"covered"`
-	Missed  []string `json:"missed"`
+	Score          int      `json:"score"`
+	Covered        []string `json:"covered"`
+	Missed         []string `json:"missed"`
+	CoveredIndices []int    `json:"covered_indices"`
+	MissedIndices  []int    `json:"missed_indices"`
 }
 
 type GradeError struct {
@@ -47,22 +76,175 @@ func (e *GradeError) Unwrap() error { return e.Wrapped }
 // Constructor
 // -----------------------------------------------------------------------------
 
+// DefaultLLMTimeout bounds a grading, retries included, until
+// SetBankTypeTimeouts changes it, and every single HTTP request to the model.
+const DefaultLLMTimeout = 120 * time.Second
+
 func NewOllamaGrader(url, model string) *OllamaGrader {
 	return &OllamaGrader{
 		url:   url,
 		model: model,
 		client: &http.Client{
-			Timeout: 120 * time.Second,
+			Timeout: DefaultLLMTimeout,
 		},
+		similarity:    DefaultSimilarityThreshold,
+		retryAttempts: DefaultRetryAttempts,
+		retryDelay:    DefaultRetryDelay,
+	}
+}
+
+// Model returns the name of the model used for grading.
+func (g *OllamaGrader) Model() string { return g.model }
+
+// Endpoint returns the base URL of the OpenAI-compatible server.
+func (g *OllamaGrader) Endpoint() string { return g.url }
+
+// SetSimilarityThreshold changes the minimum Similarity (0-1) a covered or
+// missed label needs to be mapped to a key point. Values outside (0, 1]
+// are ignored.
+func (g *OllamaGrader) SetSimilarityThreshold(threshold float64) {
+	if threshold > 0 && threshold <= 1 {
+		g.similarity = threshold
+	}
+}
+
+// SetRetryPolicy controls how transient failures (network errors and 5xx
+// responses) are retried: up to attempts calls in total, waiting baseDelay
+// before the first retry and twice as long before each further one.
+// Attempts below 1 are treated as 1; tests pass a zero delay.
+func (g *OllamaGrader) SetRetryPolicy(attempts int, baseDelay time.Duration) {
+	g.retryAttempts = max(attempts, 1)
+	g.retryDelay = max(baseDelay, 0)
+}
+
+// SetBankTypeTimeouts sets how long grading an answer of a theory, code or
+// cli bank may take, retries included; other bank types use the theory
+// timeout. Non-positive values keep DefaultLLMTimeout. A single HTTP request
+// may take as long as the longest of them.
+func (g *OllamaGrader) SetBankTypeTimeouts(theory, code, cli time.Duration) {
+	g.timeouts = make(map[string]time.Duration, 3)
+	for bankType, d := range map[string]time.Duration{"theory": theory, "code": code, "cli": cli} {
+		if d <= 0 {
+			d = DefaultLLMTimeout
+		}
+		g.timeouts[bankType] = d
+		g.client.Timeout = max(g.client.Timeout, d)
 	}
 }
 
+// GradingTimeout returns how long grading an answer of bankType may take.
+func (g *OllamaGrader) GradingTimeout(bankType string) time.Duration {
+	if d, ok := g.timeouts[bankType]; ok {
+		return d
+	}
+	if d, ok := g.timeouts["theory"]; ok {
+		return d
+	}
+	return DefaultLLMTimeout
+}
+
+// SetStrictParsing controls whether model output must match the GradeResult
+// JSON shape exactly. When false (the default), recoverable formatting
+// quirks such as a score given as a string are coerced.
+func (g *OllamaGrader) SetStrictParsing(strict bool) {
+	g.strict = strict
+}
+
+// SetShuffleKeyPoints controls whether theory key points are presented to
+// the model in random order, which reduces bias towards the first point.
+// Returned indices always refer to the original order. A zero seed picks a
+// time-based one; tests pass a fixed seed for a deterministic order.
+func (g *OllamaGrader) SetShuffleKeyPoints(enabled bool, seed int64) {
+	g.shuffleMu.Lock()
+	defer g.shuffleMu.Unlock()
+	if !enabled {
+		g.shuffle = nil
+		return
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	g.shuffle = rand.New(rand.NewSource(seed))
+}
+
+// SetSampling tunes how the model samples its output. Temperature 0 (the
+// default) is recommended so the same answer gets the same grade, but
+// higher values can be tried. A maxTokens or topP of 0 is not sent, leaving
+// the server's default; negative values are treated as 0.
+func (g *OllamaGrader) SetSampling(temperature float64, maxTokens int, topP float64) {
+	g.temperature = max(temperature, 0)
+	g.maxTokens = max(maxTokens, 0)
+	g.topP = max(topP, 0)
+}
+
+// SetStreaming controls whether model output is requested as a stream of
+// server-sent events. Streaming lets callers registered with WithProgress
+// see output as it arrives; the final result is the same either way.
+func (g *OllamaGrader) SetStreaming(enabled bool) {
+	g.stream = enabled
+}
+
+// ProgressFunc receives each chunk of model output as it streams in.
+type ProgressFunc func(chunk string)
+
+type progressKey struct{}
+
+// WithProgress returns a context that makes a streaming grader report model
+// output to fn as it arrives. Non-streaming calls never invoke fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// AnswerLanguageAuto, passed to WithAnswerLanguage, tells the model the
+// answer may be in any language without naming it.
+const AnswerLanguageAuto = "auto"
+
+type answerLanguageKey struct{}
+
+// WithAnswerLanguage returns a context that makes the grader accept an
+// answer written in language, or in any language for AnswerLanguageAuto,
+// as long as it means the same as the expected answer. CLI prompts ignore
+// it: commands have no language.
+func WithAnswerLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, answerLanguageKey{}, language)
+}
+
+// answerLanguage returns the language set with WithAnswerLanguage, or "".
+func answerLanguage(ctx context.Context) string {
+	language, _ := ctx.Value(answerLanguageKey{}).(string)
+	return language
+}
+
+// promptKeyPoints returns the expected answer as it should appear in the
+// prompt: unchanged, or with its key points shuffled one per line.
+func (g *OllamaGrader) promptKeyPoints(expectedAnswer string) string {
+	g.shuffleMu.Lock()
+	defer g.shuffleMu.Unlock()
+	points := KeyPoints(expectedAnswer)
+	if g.shuffle == nil || len(points) < 2 {
+		return expectedAnswer
+	}
+	g.shuffle.Shuffle(len(points), func(i, j int) {
+		points[i], points[j] = points[j], points[i]
+	})
+	return strings.Join(points, "\n")
+}
+
 // -----------------------------------------------------------------------------
 // Public API
 // -----------------------------------------------------------------------------
 
+// maxRetries is how many times a request is sent when the model's output
+// is unusable. Such retries happen immediately.
 const maxRetries = 2
 
+// Transient failures are retried with exponential backoff: 250ms, 500ms,
+// then 1s, unless changed with SetRetryPolicy.
+const (
+	DefaultRetryAttempts = 4
+	DefaultRetryDelay    = 250 * time.Millisecond
+)
+
 func (g *OllamaGrader) GradeAnswer(ctx context.Context, question, expectedAnswer, userAnswer string, customPrompt *string, bankType string) (string, error) {
 	customRules := ""
 	hasCustomRules := customPrompt != nil && *customPrompt != ""
@@ -70,21 +252,95 @@ func (g *OllamaGrader) GradeAnswer(ctx context.Context, question, expectedAnswer
 		customRules = *customPrompt
 	}
 
-	var prompt string
+	prompt := g.answerPrompt(question, expectedAnswer, userAnswer, customRules, bankType, answerLanguage(ctx))
+	return g.grade(ctx, prompt, expectedAnswer, bankType, hasCustomRules)
+}
+
+// answerPrompt picks the prompt builder for bankType.
+func (g *OllamaGrader) answerPrompt(question, expectedAnswer, userAnswer, customRules, bankType, answerLanguage string) string {
 	switch bankType {
 	case "code":
-		prompt = buildSemanticCodePrompt(question, expectedAnswer, userAnswer, customRules)
+		return buildSemanticCodePrompt(question, expectedAnswer, userAnswer, customRules, answerLanguage)
 	case "cli":
-		prompt = buildCLIPrompt(question, expectedAnswer, userAnswer, customRules)
+		return buildCLIPrompt(question, expectedAnswer, userAnswer, customRules)
 	default:
-		prompt = buildTheoryPrompt(question, expectedAnswer, userAnswer, customRules)
+		return buildTheoryPrompt(question, g.promptKeyPoints(expectedAnswer), userAnswer, customRules, answerLanguage)
 	}
+}
 
+// RenderPrompt returns the prompt GradeAnswer, or GradeAnswerWithRubric when
+// rubric is non-empty, would send, without calling the model.
+func (g *OllamaGrader) RenderPrompt(question, expectedAnswer, userAnswer string, rubric, customPrompt *string, bankType string) string {
+	customRules := ""
+	if customPrompt != nil {
+		customRules = *customPrompt
+	}
+	if rubric != nil && *rubric != "" {
+		return buildRubricPrompt(question, expectedAnswer, userAnswer, *rubric, customRules, bankType, "")
+	}
+	return g.answerPrompt(question, expectedAnswer, userAnswer, customRules, bankType, "")
+}
+
+// VerifyAnswer grades a theory answer the user has already self-marked.
+// The model is shown each key point with the user's claim and only has to
+// confirm or overturn it. Other bank types, and answers whose expected
+// answer has no list of key points, fall back to GradeAnswer.
+func (g *OllamaGrader) VerifyAnswer(ctx context.Context, question, expectedAnswer, userAnswer string, selfCovered []int, customPrompt *string, bankType string) (string, error) {
+	points := KeyPoints(expectedAnswer)
+	if bankType == "code" || bankType == "cli" || len(points) < 2 {
+		return g.GradeAnswer(ctx, question, expectedAnswer, userAnswer, customPrompt, bankType)
+	}
+
+	customRules := ""
+	hasCustomRules := customPrompt != nil && *customPrompt != ""
+	if hasCustomRules {
+		customRules = *customPrompt
+	}
+
+	prompt := buildVerifyPrompt(question, points, selfCovered, userAnswer, customRules, answerLanguage(ctx))
+	return g.grade(ctx, prompt, expectedAnswer, bankType, hasCustomRules)
+}
+
+// GradeAnswerWithRubric grades an answer against rubric, showing the model
+// the expected answer as a reference solution only.
+func (g *OllamaGrader) GradeAnswerWithRubric(ctx context.Context, question, expectedAnswer, userAnswer, rubric string, customPrompt *string, bankType string) (string, error) {
+	customRules := ""
+	hasCustomRules := customPrompt != nil && *customPrompt != ""
+	if hasCustomRules {
+		customRules = *customPrompt
+	}
+
+	prompt := buildRubricPrompt(question, expectedAnswer, userAnswer, rubric, customRules, bankType, answerLanguage(ctx))
+	// Labels name rubric criteria, not key points of the expected answer,
+	// so there is nothing to align them with.
+	return g.grade(ctx, prompt, "", bankType, hasCustomRules)
+}
+
+// grade sends prompt to the model, retrying on unusable output, and turns
+// the reply into the GradeResult JSON. It gives up once bankType's timeout
+// has passed, reporting "grading timed out" rather than the bare context
+// error; a done parent context is still reported as such.
+func (g *OllamaGrader) grade(parent context.Context, prompt, expectedAnswer, bankType string, hasCustomRules bool) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, g.GradingTimeout(bankType))
+	defer cancel()
+
+	result, err := g.gradeWithRetries(ctx, prompt, expectedAnswer, bankType, hasCustomRules)
+	if err != nil && parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", &GradeError{Reason: "grading timed out", Wrapped: ctx.Err()}
+	}
+	return result, err
+}
+
+// gradeWithRetries is grade without its timeout.
+func (g *OllamaGrader) gradeWithRetries(ctx context.Context, prompt, expectedAnswer, bankType string, hasCustomRules bool) (string, error) {
 	var lastErr error
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		result, err := g.callLLM(ctx, prompt)
+		result, err := g.callLLMWithBackoff(ctx, prompt)
 		if err != nil {
+			if isFinal(ctx, err) {
+				return "", &GradeError{Reason: "LLM unavailable", Wrapped: err}
+			}
 			lastErr = err
 			continue
 		}
@@ -95,8 +351,8 @@ func (g *OllamaGrader) GradeAnswer(ctx context.Context, question, expectedAnswer
 			continue
 		}
 
-		var gradeResult GradeResult
-		if err := json.Unmarshal([]byte(jsonStr), &gradeResult); err != nil {
+		gradeResult, err := ParseGradeResult([]byte(jsonStr), g.strict)
+		if err != nil {
 			lastErr = &GradeError{Reason: "invalid JSON from LLM", Wrapped: err}
 			continue
 		}
@@ -108,11 +364,14 @@ func (g *OllamaGrader) GradeAnswer(ctx context.Context, question, expectedAnswer
 			gradeResult.Missed = []string{"unable to evaluate"}
 		}
 
-		// When custom rules are active, the LLM applies them to the score field directly.
-		// Without custom rules, calculate deterministically from covered/missed counts.
+		// Prefer the model's own score, which can give partial credit for a
+		// half-covered point. A zero or out-of-range score falls back to the
+		// covered/missed ratio, except under custom rules, where the model
+		// applies the rules to the score directly and may mean 0.
 		var score int
-		if hasCustomRules && gradeResult.Score >= 0 && gradeResult.Score <= 100 {
-			score = gradeResult.Score
+		modelScore := gradeResult.Score
+		if modelScore > 0 && modelScore <= 100 || hasCustomRules && modelScore == 0 {
+			score = modelScore
 		} else {
 			total := len(gradeResult.Covered) + len(gradeResult.Missed)
 			if total > 0 {
@@ -120,10 +379,21 @@ func (g *OllamaGrader) GradeAnswer(ctx context.Context, question, expectedAnswer
 			}
 		}
 
+		// Key points are only presented to the model for theory banks, so
+		// only there can labels be mapped back to them.
+		coveredIndices, missedIndices := []int{}, []int{}
+		if bankType != "code" && bankType != "cli" {
+			points := KeyPoints(expectedAnswer)
+			coveredIndices = alignKeyPoints(gradeResult.Covered, points, g.similarity)
+			missedIndices = alignKeyPoints(gradeResult.Missed, points, g.similarity)
+		}
+
 		finalResult := map[string]interface{}{
-			"score":   score,
-			"covered": gradeResult.Covered,
-			"missed":  gradeResult.Missed,
+			"score":           score,
+			"covered":         gradeResult.Covered,
+			"missed":          gradeResult.Missed,
+			"covered_indices": coveredIndices,
+			"missed_indices":  missedIndices,
 		}
 
 		resultJSON, _ := json.Marshal(finalResult)
@@ -144,6 +414,9 @@ type llmRequest struct {
 	Model       string       `json:"model"`
 	Messages    []llmMessage `json:"messages"`
 	Temperature float64      `json:"temperature"`
+	MaxTokens   int          `json:"max_tokens,omitempty"`
+	TopP        float64      `json:"top_p,omitempty"`
+	Stream      bool         `json:"stream,omitempty"`
 }
 
 type llmMessage struct {
@@ -159,6 +432,53 @@ type llmResponse struct {
 	} `json:"choices"`
 }
 
+type llmStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// transientError marks an LLM call that failed in a way worth retrying
+// after a pause: the server could not be reached or answered with a 5xx
+// status.
+type transientError struct{ err error }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// isFinal reports whether err, returned by callLLMWithBackoff, should end
+// the request instead of being retried as unusable output: ctx is done,
+// or transient retries are exhausted.
+func isFinal(ctx context.Context, err error) bool {
+	var transient *transientError
+	return ctx.Err() != nil || errors.As(err, &transient)
+}
+
+// callLLMWithBackoff calls the model, retrying transient failures with
+// exponential backoff per the retry policy. It returns promptly once ctx
+// is done.
+func (g *OllamaGrader) callLLMWithBackoff(ctx context.Context, prompt string) (string, error) {
+	delay := g.retryDelay
+	for attempt := 1; ; attempt++ {
+		result, err := g.callLLM(ctx, prompt)
+		var transient *transientError
+		if err == nil || !errors.As(err, &transient) || attempt >= g.retryAttempts {
+			return result, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
 func (g *OllamaGrader) callLLM(ctx context.Context, prompt string) (string, error) {
 	reqBody := llmRequest{
 		Model: g.model,
@@ -166,7 +486,10 @@ func (g *OllamaGrader) callLLM(ctx context.Context, prompt string) (string, erro
 			Role:    "user",
 			Content: prompt,
 		}},
-		Temperature: 0,
+		Temperature: g.temperature,
+		MaxTokens:   g.maxTokens,
+		TopP:        g.topP,
+		Stream:      g.stream,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -182,14 +505,25 @@ func (g *OllamaGrader) callLLM(ctx context.Context, prompt string) (string, erro
 
 	resp, err := g.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("LLM request failed: %w", err)
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("LLM request failed: %w", err)
+		}
+		return "", &transientError{fmt.Errorf("LLM request failed: %w", err)}
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return "", &transientError{fmt.Errorf("LLM returned status %d", resp.StatusCode)}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("LLM returned status %d", resp.StatusCode)
 	}
 
+	if g.stream {
+		progress, _ := ctx.Value(progressKey{}).(ProgressFunc)
+		return readStream(resp.Body, progress)
+	}
+
 	var llmResp llmResponse
 	if err := json.NewDecoder(resp.Body).Decode(&llmResp); err != nil {
 		return "", fmt.Errorf("failed to decode LLM response: %w", err)
@@ -207,11 +541,85 @@ func (g *OllamaGrader) callLLM(ctx context.Context, prompt string) (string, erro
 	return content, nil
 }
 
+// Ping lists the server's models, which is cheap and needs no inference.
+func (g *OllamaGrader) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.url+"/v1/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("LLM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("LLM returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// readStream accumulates the content of a server-sent event stream until
+// its [DONE] marker, passing each chunk to progress when it is non-nil. A
+// stream that ends without the marker was cut off and is reported as
+// interrupted.
+func readStream(body io.Reader, progress ProgressFunc) (string, error) {
+	var content strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // blank separators, comments, event names
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			if content.Len() == 0 {
+				return "", fmt.Errorf("LLM returned empty content")
+			}
+			return content.String(), nil
+		}
+
+		var chunk llmStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to decode LLM stream chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if progress != nil {
+			progress(chunk.Choices[0].Delta.Content)
+		}
+	}
+
+	return "", &GradeError{Reason: "stream interrupted", Wrapped: scanner.Err()}
+}
+
 // -----------------------------------------------------------------------------
-// JSON Extraction (unchanged, already correct)
+// JSON Extraction
 // -----------------------------------------------------------------------------
 
+// extractJSON finds the JSON object in a model reply. Models sometimes echo
+// the user's fenced code before answering, or wrap the JSON itself in a
+// ```json fence, so the text outside fences is searched first, then each
+// fenced block, and only then the raw reply.
 func extractJSON(s string) string {
+	outside, blocks := splitCodeFences(s)
+	for _, text := range append([]string{outside}, blocks...) {
+		if obj := firstJSONObject(text); obj != "" && json.Valid([]byte(obj)) {
+			return obj
+		}
+	}
+	return firstJSONObject(s)
+}
+
+// firstJSONObject returns the first balanced {...} in s, skipping braces
+// inside JSON strings, or "" if there is none.
+func firstJSONObject(s string) string {
 	start := -1
 	depth := 0
 	inString := false
@@ -259,13 +667,28 @@ func extractJSON(s string) string {
 // If this ever becomes a multi-user server, customRules must be sanitized before
 // being included in any LLM prompt.
 
-func buildSemanticCodePrompt(question, expected, user, customRules string) string {
+// crossLanguageRule returns a rule line telling the model that an answer in
+// answerLanguage is intentional and judged on meaning, or "" when no
+// language is set.
+func crossLanguageRule(answerLanguage string) string {
+	switch answerLanguage {
+	case "":
+		return ""
+	case AnswerLanguageAuto:
+		return "\n- The user may answer in a different language than the expected answer. Compare meaning across languages: a correct translation = COVERED. Never mark a point MISSED because of the language it is written in."
+	default:
+		return "\n- The user answered in " + answerLanguage + " on purpose; the expected answer may be in another language. Compare meaning across languages: a correct translation = COVERED. Never mark a point MISSED because of the language it is written in."
+	}
+}
+
+func buildSemanticCodePrompt(question, expected, user, customRules, answerLanguage string) string {
 	baseRules := `SEMANTIC GRADING RULES:
 - Compare structure and logic, not exact variable names.
 - The code must be syntactically valid and achieve the same result.
 - If a key element is partially correct (right idea, small typo), mark it COVERED.
 - If a key element is completely wrong or missing, mark it MISSED.
-- Do NOT check for imports unless they are critical to the logic.`
+- Do NOT check for imports unless they are critical to the logic.` + crossLanguageRule(answerLanguage)
+	user = stripCodeFences(user)
 
 	rules := baseRules
 	if customRules != "" {
@@ -292,10 +715,10 @@ Return ONLY valid JSON. Items in "covered" and "missed" must be SHORT labels (th
 		rules, question, expected, user)
 }
 
-func buildTheoryPrompt(question, expectedAnswer, userAnswer, customRules string) string {
+func buildTheoryPrompt(question, expectedAnswer, userAnswer, customRules, answerLanguage string) string {
 	baseRules := `RULES:
 - Same meaning with different wording = COVERED.
-- Missing or incorrect concept = MISSED.`
+- Missing or incorrect concept = MISSED.` + crossLanguageRule(answerLanguage)
 
 	rules := baseRules
 	if customRules != "" {
@@ -354,6 +777,51 @@ Return ONLY valid JSON. Items in "covered" and "missed" must be SHORT labels (th
 		rules, question, keyPoints, userAnswer)
 }
 
+// buildVerifyPrompt asks the model to check the user's self-marking of each
+// key point rather than classify the answer from scratch.
+func buildVerifyPrompt(question string, points []string, selfCovered []int, userAnswer, customRules, answerLanguage string) string {
+	baseRules := `RULES:
+- Same meaning with different wording = COVERED.
+- Missing or incorrect concept = MISSED.
+- The user's claims are NOT evidence. Judge every key point from the user answer alone.` + crossLanguageRule(answerLanguage)
+
+	rules := baseRules
+	if customRules != "" {
+		rules = baseRules + "\n\nADDITIONAL RULES (override base rules if conflicting):\n" + customRules
+	}
+
+	claimed := make(map[int]bool, len(selfCovered))
+	for _, i := range selfCovered {
+		claimed[i] = true
+	}
+	var lines strings.Builder
+	for i, p := range points {
+		claim := "user says MISSED"
+		if claimed[i] {
+			claim = "user says COVERED"
+		}
+		fmt.Fprintf(&lines, "- %s (%s)\n", p, claim)
+	}
+
+	return fmt.Sprintf(`/no_think
+The user has marked which key points they believe their answer covers. Verify each claim and correct any that are wrong.
+
+%s
+
+QUESTION:
+%s
+
+KEY POINTS WITH THE USER'S CLAIMS:
+%s
+USER ANSWER:
+%s
+
+The grading rules define comparison strictness, not independent quality. If a rule specifies a fixed score override, apply it.
+Return ONLY valid JSON. Items in "covered" and "missed" must be SHORT labels (the key point itself, ≤5 words). Every key point must appear in exactly one list. No sentences, no explanations.
+{"score": <0-100>, "covered": ["label", ...], "missed": ["label", ...]}`,
+		rules, question, lines.String(), userAnswer)
+}
+
 func buildCLIPrompt(question, expectedAnswer, userAnswer, customRules string) string {
 	baseRules := `BASE RULES:
 - Break the expected command into logical requirements (e.g. "correct tool", "correct subcommand", "container name arg", "required flag -f").
@@ -388,6 +856,55 @@ Return ONLY valid JSON. Each item in "covered"/"missed" is a brief requirement p
 		rules, question, expectedAnswer, userAnswer)
 }
 
+// buildRubricPrompt grades against an explicit rubric. The expected answer is
+// shown as one correct solution, to help interpret the criteria, but the
+// rubric alone decides what is covered.
+func buildRubricPrompt(question, expected, user, rubric, customRules, bankType, answerLanguage string) string {
+	baseRules := `RUBRIC RULES:
+- Judge the user's answer against each RUBRIC criterion, one at a time.
+- A criterion met with different wording or a different approach = COVERED.
+- A criterion not met, or met incorrectly = MISSED.
+- The reference solution illustrates one correct answer. Do NOT penalize the user for differing from it where the rubric does not require it.`
+	if bankType != "cli" {
+		baseRules += crossLanguageRule(answerLanguage)
+	}
+
+	rules := baseRules
+	if customRules != "" {
+		rules = baseRules + "\n\nADDITIONAL RULES (override base rules if conflicting):\n" + customRules
+	}
+
+	reference, answer := "REFERENCE ANSWER", "USER ANSWER"
+	switch bankType {
+	case "code":
+		reference, answer = "REFERENCE CODE", "USER CODE"
+		user = stripCodeFences(user)
+	case "cli":
+		reference, answer = "REFERENCE COMMAND", "USER COMMAND"
+	}
+
+	return fmt.Sprintf(`/no_think
+Grade the answer against the rubric.
+
+%s
+
+QUESTION:
+%s
+
+RUBRIC (the ONLY grading criteria):
+%s
+%s (context only — not a checklist):
+%s
+
+%s:
+%s
+
+The score field must reflect the rubric and the grading rules — if they specify a fixed score or override, honor it.
+Return ONLY valid JSON. Items in "covered" and "missed" must be SHORT labels (the rubric criterion itself, ≤5 words). Every criterion must appear in exactly one list. No sentences, no explanations.
+{"score": <0-100>, "covered": ["label", ...], "missed": ["label", ...]}`,
+		rules, question, splitKeyPoints(rubric), reference, expected, answer, user)
+}
+
 // -----------------------------------------------------------------------------
 // Helpers (unchanged)
 // -----------------------------------------------------------------------------
@@ -402,7 +919,10 @@ func filterEmpty(items []string) []string {
 	return out
 }
 
-func splitKeyPoints(text string) string {
+// KeyPoints splits an expected answer into its key points, one per
+// non-empty line, with bullet and numbering prefixes removed. Indices in
+// GradeResult refer to positions in this list.
+func KeyPoints(text string) []string {
 	lines := strings.Split(strings.TrimSpace(text), "\n")
 
 	var points []string
@@ -425,14 +945,41 @@ func splitKeyPoints(text string) string {
 			points = append(points, trimmed)
 		}
 	}
+	return points
+}
 
+func splitKeyPoints(text string) string {
 	var b strings.Builder
-	for i, p := range points {
+	for i, p := range KeyPoints(text) {
 		fmt.Fprintf(&b, "%d. %s\n", i+1, p)
 	}
 	return b.String()
 }
 
+// alignKeyPoints maps grader labels to the indices of the key points they
+// describe. Each label is matched to its most similar key point; labels
+// whose best similarity is below threshold are dropped. The result is
+// sorted and free of duplicates.
+func alignKeyPoints(labels, points []string, threshold float64) []int {
+	seen := make(map[int]bool)
+	indices := []int{}
+	for _, label := range labels {
+		best, bestScore := -1, 0.0
+		for i, p := range points {
+			if score := Similarity(label, p); score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		if best >= 0 && bestScore >= threshold && !seen[best] {
+			seen[best] = true
+			indices = append(indices, best)
+		}
+	}
+	sort.Ints(indices)
+	return indices
+}
+
 func stripNumberedPrefix(s string) string {
 	runes := []rune(s)
 	if len(runes) < 3 || !unicode.IsDigit(runes[0]) {
@@ -479,8 +1026,11 @@ func (g *OllamaGrader) GenerateQuestions(ctx context.Context, req GenerateReques
 
 	var lastErr error
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		result, err := g.callLLM(ctx, prompt)
+		result, err := g.callLLMWithBackoff(ctx, prompt)
 		if err != nil {
+			if isFinal(ctx, err) {
+				return nil, &GradeError{Reason: "LLM unavailable", Wrapped: err}
+			}
 			lastErr = err
 			continue
 		}
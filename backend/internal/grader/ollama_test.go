@@ -0,0 +1,669 @@
+package grader_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/grader"
+)
+
+// newFakeLLM serves an OpenAI-compatible chat completion whose content is reply.
+func newFakeLLM(t *testing.T, reply string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"content": reply}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestKeyPoints(t *testing.T) {
+	got := grader.KeyPoints("- goroutines are lightweight\n\n2. managed by the Go runtime\n• communicate via channels\n")
+	want := []string{"goroutines are lightweight", "managed by the Go runtime", "communicate via channels"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestGradeAnswer_KeyPointIndices(t *testing.T) {
+	expected := "- goroutines are lightweight\n- managed by the Go runtime\n- communicate via channels"
+	srv := newFakeLLM(t, `{"score": 66, "covered": ["Managed by Go runtime", "lightweight"], "missed": ["communicate via channels"]}`)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	out, err := g.GradeAnswer(context.Background(), "What is a goroutine?", expected, "A cheap thread run by the runtime", nil, "theory")
+	if err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+
+	var result grader.GradeResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	points := grader.KeyPoints(expected)
+	if !reflect.DeepEqual(result.CoveredIndices, []int{0, 1}) {
+		t.Errorf("expected covered indices [0 1], got %v", result.CoveredIndices)
+	}
+	if !reflect.DeepEqual(result.MissedIndices, []int{2}) {
+		t.Errorf("expected missed indices [2], got %v", result.MissedIndices)
+	}
+	if points[result.MissedIndices[0]] != "communicate via channels" {
+		t.Errorf("missed index points at %q", points[result.MissedIndices[0]])
+	}
+}
+
+func TestGradeAnswer_UnmatchedLabelHasNoIndex(t *testing.T) {
+	srv := newFakeLLM(t, `{"score": 0, "covered": [], "missed": ["unrelated remark"]}`)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	out, err := g.GradeAnswer(context.Background(), "Q", "- first point\n- second point", "nothing", nil, "theory")
+	if err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+
+	var result grader.GradeResult
+	json.Unmarshal([]byte(out), &result)
+	if result.CoveredIndices == nil || len(result.CoveredIndices) != 0 {
+		t.Errorf("expected empty covered indices, got %v", result.CoveredIndices)
+	}
+	if len(result.MissedIndices) != 0 {
+		t.Errorf("expected no missed indices, got %v", result.MissedIndices)
+	}
+}
+
+// newPromptRecordingLLM is like newFakeLLM but also returns every prompt it
+// receives.
+func newPromptRecordingLLM(t *testing.T, reply string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompts = append(prompts, req.Messages[0].Content)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"content": reply}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &prompts
+}
+
+// promptOrder returns the points sorted by where they appear in prompt.
+func promptOrder(prompt string, points []string) []string {
+	ordered := append([]string(nil), points...)
+	sort.Slice(ordered, func(i, j int) bool {
+		return strings.Index(prompt, ordered[i]) < strings.Index(prompt, ordered[j])
+	})
+	return ordered
+}
+
+func TestGradeAnswer_ShuffledKeyPoints(t *testing.T) {
+	expected := "- goroutines are lightweight\n- managed by the Go runtime\n- communicate via channels\n- started with the go keyword"
+	points := grader.KeyPoints(expected)
+	srv, prompts := newPromptRecordingLLM(t, `{"score": 50, "covered": ["started with go keyword", "lightweight"], "missed": ["communicate via channels", "managed by Go runtime"]}`)
+
+	grade := func(seed int64) grader.GradeResult {
+		g := grader.NewOllamaGrader(srv.URL, "test")
+		g.SetShuffleKeyPoints(true, seed)
+		out, err := g.GradeAnswer(context.Background(), "What is a goroutine?", expected, "A cheap thread started with go", nil, "theory")
+		if err != nil {
+			t.Fatalf("GradeAnswer: %v", err)
+		}
+		var result grader.GradeResult
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return result
+	}
+
+	result := grade(1)
+	grade(1)
+
+	order := promptOrder((*prompts)[0], points)
+	if reflect.DeepEqual(order, points) {
+		t.Errorf("expected key points to be reordered in the prompt, got %q", order)
+	}
+	if again := promptOrder((*prompts)[1], points); !reflect.DeepEqual(order, again) {
+		t.Errorf("expected the same seed to give the same order, got %q and %q", order, again)
+	}
+
+	if !reflect.DeepEqual(result.CoveredIndices, []int{0, 3}) {
+		t.Errorf("expected covered indices [0 3] in original order, got %v", result.CoveredIndices)
+	}
+	if !reflect.DeepEqual(result.MissedIndices, []int{1, 2}) {
+		t.Errorf("expected missed indices [1 2] in original order, got %v", result.MissedIndices)
+	}
+}
+
+func TestGradeAnswer_KeyPointsNotShuffledByDefault(t *testing.T) {
+	expected := "- goroutines are lightweight\n- managed by the Go runtime\n- communicate via channels\n- started with the go keyword"
+	points := grader.KeyPoints(expected)
+	srv, prompts := newPromptRecordingLLM(t, `{"score": 100, "covered": ["lightweight"], "missed": []}`)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	if _, err := g.GradeAnswer(context.Background(), "Q", expected, "answer", nil, "theory"); err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+	if order := promptOrder((*prompts)[0], points); !reflect.DeepEqual(order, points) {
+		t.Errorf("expected original order, got %q", order)
+	}
+}
+
+func TestVerifyAnswer_PromptCarriesClaims(t *testing.T) {
+	expected := "- goroutines are lightweight\n- managed by the Go runtime"
+	srv, prompts := newPromptRecordingLLM(t, `{"score": 50, "covered": ["lightweight"], "missed": ["managed by Go runtime"]}`)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	out, err := g.VerifyAnswer(context.Background(), "What is a goroutine?", expected, "A cheap thread", []int{0, 1}, nil, "theory")
+	if err != nil {
+		t.Fatalf("VerifyAnswer: %v", err)
+	}
+
+	prompt := (*prompts)[0]
+	for _, want := range []string{
+		"goroutines are lightweight (user says COVERED)",
+		"managed by the Go runtime (user says COVERED)",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q", want)
+		}
+	}
+
+	var result grader.GradeResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(result.MissedIndices, []int{1}) {
+		t.Errorf("expected the model to overturn key point 1, got missed indices %v", result.MissedIndices)
+	}
+}
+
+func TestVerifyAnswer_FallsBackForCLI(t *testing.T) {
+	srv, prompts := newPromptRecordingLLM(t, `{"score": 100, "covered": ["correct tool"], "missed": []}`)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	if _, err := g.VerifyAnswer(context.Background(), "List files", "ls -la", "ls -la", []int{0}, nil, "cli"); err != nil {
+		t.Fatalf("VerifyAnswer: %v", err)
+	}
+	if strings.Contains((*prompts)[0], "user says") {
+		t.Error("expected the regular CLI prompt for cli banks")
+	}
+}
+
+func TestGradeAnswerWithRubric_Prompt(t *testing.T) {
+	rubric := "- Names the data race\n- Proposes a mutex or channel"
+	reference := "The counter is shared without locking; wrap it in a sync.Mutex."
+
+	tests := []struct {
+		bankType  string
+		reference string
+		answer    string
+	}{
+		{"theory", "REFERENCE ANSWER", "USER ANSWER"},
+		{"code", "REFERENCE CODE", "USER CODE"},
+		{"cli", "REFERENCE COMMAND", "USER COMMAND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bankType, func(t *testing.T) {
+			srv, prompts := newPromptRecordingLLM(t, `{"score": 50, "covered": ["names the data race"], "missed": ["proposes a fix"]}`)
+			g := grader.NewOllamaGrader(srv.URL, "test")
+
+			custom := "Ignore style comments."
+			out, err := g.GradeAnswerWithRubric(context.Background(), "Review this code", reference, "There is a race on count", rubric, &custom, tt.bankType)
+			if err != nil {
+				t.Fatalf("GradeAnswerWithRubric: %v", err)
+			}
+
+			prompt := (*prompts)[0]
+			for _, want := range []string{
+				"RUBRIC (the ONLY grading criteria):\n1. Names the data race\n2. Proposes a mutex or channel\n",
+				tt.reference + " (context only — not a checklist):\n" + reference,
+				tt.answer + ":\nThere is a race on count",
+				"ADDITIONAL RULES (override base rules if conflicting):\nIgnore style comments.",
+			} {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("expected prompt to contain %q, got:\n%s", want, prompt)
+				}
+			}
+			if strings.Contains(prompt, "KEY POINTS") || strings.Contains(prompt, "EXPECTED") {
+				t.Error("expected the reference answer not to be presented as key points")
+			}
+
+			var result grader.GradeResult
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if result.Score != 50 || len(result.CoveredIndices) != 0 || len(result.MissedIndices) != 0 {
+				t.Errorf("expected score 50 and no key point indices, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestRenderPrompt(t *testing.T) {
+	srv, prompts := newPromptRecordingLLM(t, `{"score": 100, "covered": ["lightweight"], "missed": []}`)
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	custom := "Accept answers that omit the runtime."
+
+	rendered := g.RenderPrompt("What is a goroutine?", "A lightweight thread", "A light thread", nil, &custom, "theory")
+	if len(*prompts) != 0 {
+		t.Fatal("expected RenderPrompt not to call the model")
+	}
+	for _, want := range []string{
+		"RULES:\n- Same meaning with different wording = COVERED.",
+		"ADDITIONAL RULES (override base rules if conflicting):\n" + custom,
+		"USER ANSWER:\nA light thread",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, rendered)
+		}
+	}
+
+	// The rendered prompt is exactly what grading sends.
+	if _, err := g.GradeAnswer(context.Background(), "What is a goroutine?", "A lightweight thread", "A light thread", &custom, "theory"); err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+	if (*prompts)[0] != rendered {
+		t.Errorf("expected the graded prompt to match the rendered one, got:\n%s", (*prompts)[0])
+	}
+
+	for _, bankType := range []string{"theory", "code", "cli"} {
+		if p := g.RenderPrompt("Q", "A", "B", nil, nil, bankType); strings.Contains(p, "ADDITIONAL RULES") {
+			t.Errorf("%s: expected no additional rules without a grading prompt, got:\n%s", bankType, p)
+		}
+	}
+
+	rubric := "- Names the runtime"
+	if p := g.RenderPrompt("Q", "A", "B", &rubric, &custom, "code"); !strings.Contains(p, "RUBRIC (the ONLY grading criteria):\n1. Names the runtime") || !strings.Contains(p, custom) {
+		t.Errorf("expected the rubric prompt with the custom rules, got:\n%s", p)
+	}
+}
+
+// newStreamingLLM serves chunks as server-sent events, followed by the
+// [DONE] marker unless done is false.
+func newStreamingLLM(t *testing.T, chunks []string, done bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("expected a streaming request")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range chunks {
+			data, _ := json.Marshal(map[string]any{
+				"choices": []any{map[string]any{"delta": map[string]string{"content": c}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+		if done {
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGradeAnswer_Streaming(t *testing.T) {
+	chunks := []string{`Sure: {"score": 50, "cov`, `ered": ["lightweight"], `, `"missed": ["runtime"]}`}
+	srv := newStreamingLLM(t, chunks, true)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	g.SetStreaming(true)
+
+	var got []string
+	ctx := grader.WithProgress(context.Background(), func(chunk string) { got = append(got, chunk) })
+	out, err := g.GradeAnswer(ctx, "Q", "- lightweight\n- runtime", "cheap", nil, "theory")
+	if err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+	if !reflect.DeepEqual(got, chunks) {
+		t.Errorf("expected progress %q, got %q", chunks, got)
+	}
+
+	var result grader.GradeResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(result.Covered, []string{"lightweight"}) || !reflect.DeepEqual(result.Missed, []string{"runtime"}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestGradeAnswer_StreamInterrupted(t *testing.T) {
+	srv := newStreamingLLM(t, []string{`{"score": 50, "covered": [`}, false)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	g.SetStreaming(true)
+
+	_, err := g.GradeAnswer(context.Background(), "Q", "- a\n- b", "a", nil, "theory")
+	var ge *grader.GradeError
+	if !errors.As(err, &ge) || !errors.As(ge.Unwrap(), &ge) {
+		t.Fatalf("expected a wrapped GradeError, got %v", err)
+	}
+	if ge.Reason != "stream interrupted" {
+		t.Errorf("expected reason %q, got %q", "stream interrupted", ge.Reason)
+	}
+}
+
+func TestGradeAnswer_PrefersModelScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  int
+	}{
+		{"partial credit", `{"score": 85, "covered": ["lightweight"], "missed": ["managed by the runtime"]}`, 85},
+		{"zero falls back to ratio", `{"score": 0, "covered": ["lightweight"], "missed": ["managed by the runtime"]}`, 50},
+		{"out of range falls back to ratio", `{"score": 150, "covered": ["lightweight"], "missed": ["managed by the runtime"]}`, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeLLM(t, tt.reply)
+			g := grader.NewOllamaGrader(srv.URL, "test")
+			out, err := g.GradeAnswer(context.Background(), "Q", "- lightweight\n- managed by the runtime", "cheap", nil, "theory")
+			if err != nil {
+				t.Fatalf("GradeAnswer: %v", err)
+			}
+			var result grader.GradeResult
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if result.Score != tt.want {
+				t.Errorf("expected score %d, got %d", tt.want, result.Score)
+			}
+		})
+	}
+}
+
+func TestGradeAnswer_CoercesModelFormatting(t *testing.T) {
+	srv := newFakeLLM(t, `{"score": "85", "covered": "lightweight", "missed": ["managed by the runtime"]}`)
+	g := grader.NewOllamaGrader(srv.URL, "test")
+
+	out, err := g.GradeAnswer(context.Background(), "Q", "- lightweight\n- managed by the runtime", "cheap", nil, "theory")
+	if err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+	var result grader.GradeResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if result.Score != 85 || len(result.Covered) != 1 || len(result.CoveredIndices) != 1 || result.CoveredIndices[0] != 0 {
+		t.Errorf("expected coerced score and covered label, got %+v", result)
+	}
+
+	g.SetStrictParsing(true)
+	if _, err := g.GradeAnswer(context.Background(), "Q", "- lightweight\n- managed by the runtime", "cheap", nil, "theory"); err == nil {
+		t.Error("expected strict parsing to reject the string score")
+	}
+}
+
+func TestGradeAnswer_FencedCodeAnswer(t *testing.T) {
+	srv, prompts := newPromptRecordingLLM(t, `{"score": 100, "covered": ["loop"], "missed": []}`)
+	g := grader.NewOllamaGrader(srv.URL, "test")
+
+	answer := "Here:\n```go\nfor i := range 3 {\n\tfmt.Println(i)\n}\n```"
+	if _, err := g.GradeAnswer(context.Background(), "Print 0 to 2", "for i := 0; i < 3; i++ { fmt.Println(i) }", answer, nil, "code"); err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+
+	prompt := (*prompts)[0]
+	if !strings.Contains(prompt, "USER CODE:\nHere:\nfor i := range 3 {\n\tfmt.Println(i)\n}\n") {
+		t.Errorf("expected the fences stripped and the code kept, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "```") {
+		t.Error("expected no markdown fences in the prompt")
+	}
+}
+
+func TestGradeAnswer_FencedModelReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+	}{
+		{"json fence", "```json\n{\"score\": 85, \"covered\": [\"lightweight\"], \"missed\": []}\n```"},
+		{"echoed code first", "The user wrote:\n```go\nfunc f() { go work() }\n```\n{\"score\": 85, \"covered\": [\"lightweight\"], \"missed\": []}"},
+		{"echoed code with quotes", "```go\nif s == \"{\" { return }\n```\n```json\n{\"score\": 85, \"covered\": [\"lightweight\"], \"missed\": []}\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeLLM(t, tt.reply)
+			g := grader.NewOllamaGrader(srv.URL, "test")
+			out, err := g.GradeAnswer(context.Background(), "Q", "- lightweight", "go work()", nil, "code")
+			if err != nil {
+				t.Fatalf("GradeAnswer: %v", err)
+			}
+			var result grader.GradeResult
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if result.Score != 85 || len(result.Covered) != 1 {
+				t.Errorf("expected the fenced grade to be found, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestGradeAnswer_SamplingParameters(t *testing.T) {
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"content": `{"score": 100, "covered": [], "missed": []}`}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	if _, err := g.GradeAnswer(context.Background(), "Q", "A", "A", nil, "theory"); err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+	g.SetSampling(0.7, 512, 0.9)
+	if _, err := g.GradeAnswer(context.Background(), "Q", "A", "A", nil, "theory"); err != nil {
+		t.Fatalf("GradeAnswer: %v", err)
+	}
+
+	// By default temperature 0 is sent and the server keeps its defaults.
+	if bodies[0]["temperature"] != 0.0 {
+		t.Errorf("expected temperature 0 by default, got %v", bodies[0]["temperature"])
+	}
+	for _, field := range []string{"max_tokens", "top_p"} {
+		if _, ok := bodies[0][field]; ok {
+			t.Errorf("expected no %s by default, got %v", field, bodies[0][field])
+		}
+	}
+	if bodies[1]["temperature"] != 0.7 || bodies[1]["max_tokens"] != 512.0 || bodies[1]["top_p"] != 0.9 {
+		t.Errorf("expected the configured sampling, got %v", bodies[1])
+	}
+}
+
+func TestGradeAnswer_AnswerLanguage(t *testing.T) {
+	srv, prompts := newPromptRecordingLLM(t, `{"score": 100, "covered": [], "missed": []}`)
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	french := grader.WithAnswerLanguage(context.Background(), "French")
+	auto := grader.WithAnswerLanguage(context.Background(), grader.AnswerLanguageAuto)
+
+	g.GradeAnswer(context.Background(), "Q", "A lightweight thread", "Un thread léger", nil, "theory")
+	g.GradeAnswer(french, "Q", "A lightweight thread", "Un thread léger", nil, "theory")
+	g.GradeAnswer(auto, "Q", "A lightweight thread", "Un thread léger", nil, "code")
+	g.GradeAnswerWithRubric(french, "Q", "A", "B", "- Names the runtime", nil, "theory")
+	g.VerifyAnswer(french, "Q", "- first\n- second", "premier", []int{0}, nil, "theory")
+	g.GradeAnswer(french, "Q", "docker ps", "docker ps", nil, "cli")
+
+	tests := []struct {
+		name, want string
+	}{
+		{"no language", ""},
+		{"theory", "The user answered in French on purpose"},
+		{"auto", "The user may answer in a different language"},
+		{"rubric", "The user answered in French on purpose"},
+		{"verify", "The user answered in French on purpose"},
+		{"cli", ""},
+	}
+	for i, tt := range tests {
+		prompt := (*prompts)[i]
+		if tt.want == "" {
+			if strings.Contains(prompt, "across languages") {
+				t.Errorf("%s: expected no cross-language rule, got:\n%s", tt.name, prompt)
+			}
+			continue
+		}
+		if !strings.Contains(prompt, tt.want) || !strings.Contains(prompt, "Compare meaning across languages") {
+			t.Errorf("%s: expected the cross-language rule, got:\n%s", tt.name, prompt)
+		}
+	}
+}
+
+func TestPing(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.Method + " " + r.URL.Path
+		json.NewEncoder(w).Encode(map[string]any{"data": []any{}})
+	}))
+	t.Cleanup(srv.Close)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	if err := g.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if path != "GET /v1/models" {
+		t.Errorf("expected GET /v1/models, got %q", path)
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(down.Close)
+	if err := grader.NewOllamaGrader(down.URL, "test").Ping(context.Background()); err == nil {
+		t.Error("expected an error for a non-200 status")
+	}
+}
+
+func TestGradeAnswer_RetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"content": `{"score": 80, "covered": ["a"], "missed": []}`}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	g.SetRetryPolicy(3, 0)
+	if _, err := g.GradeAnswer(context.Background(), "Q", "a", "a", nil, "theory"); err != nil {
+		t.Fatalf("expected success on the third call, got %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected 3 calls, got %d", n)
+	}
+
+	// Once transient retries are exhausted the grade fails without also
+	// spending the bad-output retries.
+	var downCalls atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	g = grader.NewOllamaGrader(down.URL, "test")
+	g.SetRetryPolicy(3, 0)
+	if _, err := g.GradeAnswer(context.Background(), "Q", "a", "a", nil, "theory"); err == nil {
+		t.Fatal("expected an error while the server is down")
+	}
+	if n := downCalls.Load(); n != 3 {
+		t.Errorf("expected 3 calls, got %d", n)
+	}
+}
+
+func TestGradeAnswer_RetriesBadOutputImmediately(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"content": "no JSON here"}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	g.SetRetryPolicy(4, time.Hour)
+	start := time.Now()
+	if _, err := g.GradeAnswer(context.Background(), "Q", "a", "a", nil, "theory"); err == nil {
+		t.Fatal("expected an error for unusable output")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected 2 calls, got %d", n)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("expected bad output to be retried without backoff")
+	}
+}
+
+func TestGradeAnswer_CancelledDuringBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	g.SetRetryPolicy(4, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := g.GradeAnswer(ctx, "Q", "a", "a", nil, "theory")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline error, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("expected cancellation to interrupt the backoff")
+	}
+}
+
+func TestGradeAnswer_BankTypeTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"content": `{"score":90,"covered":["a"],"missed":[]}`}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	g := grader.NewOllamaGrader(srv.URL, "test")
+	g.SetBankTypeTimeouts(5*time.Second, 50*time.Millisecond, 0)
+
+	if _, err := g.GradeAnswer(context.Background(), "Q", "a", "a", nil, "theory"); err != nil {
+		t.Fatalf("expected the theory answer to be graded within its timeout, got %v", err)
+	}
+
+	_, err := g.GradeAnswer(context.Background(), "Q", "a", "a", nil, "code")
+	var gradeErr *grader.GradeError
+	if !errors.As(err, &gradeErr) || gradeErr.Reason != "grading timed out" {
+		t.Errorf("expected a grading timed out error, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package grader
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseGradeResult decodes grading JSON. In strict mode it must match the
+// GradeResult shape exactly. Otherwise it also accepts what some models
+// emit instead: a score given as a string ("85", "85%") or a fractional
+// number (rounded), a single string where a list of labels is expected,
+// and a single index or numeric strings where a list of indices is expected.
+func ParseGradeResult(data []byte, strict bool) (GradeResult, error) {
+	var result GradeResult
+	if strict {
+		err := json.Unmarshal(data, &result)
+		return result, err
+	}
+
+	var raw struct {
+		Score          json.RawMessage `json:"score"`
+		Covered        json.RawMessage `json:"covered"`
+		Missed         json.RawMessage `json:"missed"`
+		CoveredIndices json.RawMessage `json:"covered_indices"`
+		MissedIndices  json.RawMessage `json:"missed_indices"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return result, err
+	}
+
+	var err error
+	if result.Score, err = lenientInt(raw.Score); err != nil {
+		return result, fmt.Errorf("score: %w", err)
+	}
+	if result.Covered, err = lenientStrings(raw.Covered); err != nil {
+		return result, fmt.Errorf("covered: %w", err)
+	}
+	if result.Missed, err = lenientStrings(raw.Missed); err != nil {
+		return result, fmt.Errorf("missed: %w", err)
+	}
+	if result.CoveredIndices, err = lenientInts(raw.CoveredIndices); err != nil {
+		return result, fmt.Errorf("covered_indices: %w", err)
+	}
+	if result.MissedIndices, err = lenientInts(raw.MissedIndices); err != nil {
+		return result, fmt.Errorf("missed_indices: %w", err)
+	}
+	return result, nil
+}
+
+// lenientInt accepts a JSON number or a numeric string, optionally with a
+// trailing "%", rounding fractions. Missing and null values are 0.
+func lenientInt(raw json.RawMessage) (int, error) {
+	if isNull(raw) {
+		return 0, nil
+	}
+	var f float64
+	if err := json.Unmarshal(raw, &f); err == nil {
+		return int(math.Round(f)), nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, fmt.Errorf("expected a number, got %s", raw)
+	}
+	f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, got %q", s)
+	}
+	return int(math.Round(f)), nil
+}
+
+// lenientStrings accepts a list of strings or a single string, which
+// becomes a one-element list (none when empty).
+func lenientStrings(raw json.RawMessage) ([]string, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("expected a list of strings, got %s", raw)
+	}
+	if strings.TrimSpace(s) == "" {
+		return []string{}, nil
+	}
+	return []string{s}, nil
+}
+
+// lenientInts accepts a list whose elements lenientInt understands, or a
+// single such element.
+func lenientInts(raw json.RawMessage) ([]int, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		list = []json.RawMessage{raw}
+	}
+	ints := make([]int, len(list))
+	for i, item := range list {
+		n, err := lenientInt(item)
+		if err != nil {
+			return nil, err
+		}
+		ints[i] = n
+	}
+	return ints, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
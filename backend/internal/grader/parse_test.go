@@ -0,0 +1,89 @@
+package grader_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/remaimber-it/backend/internal/grader"
+)
+
+func TestParseGradeResult_Lenient(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    grader.GradeResult
+	}{
+		{
+			"well formed",
+			`{"score": 80, "covered": ["a"], "missed": ["b"], "covered_indices": [0], "missed_indices": [1]}`,
+			grader.GradeResult{Score: 80, Covered: []string{"a"}, Missed: []string{"b"}, CoveredIndices: []int{0}, MissedIndices: []int{1}},
+		},
+		{
+			"score as string",
+			`{"score": "85", "covered": ["a"], "missed": []}`,
+			grader.GradeResult{Score: 85, Covered: []string{"a"}, Missed: []string{}},
+		},
+		{
+			"score as percentage with spaces",
+			`{"score": " 70% ", "covered": [], "missed": ["b"]}`,
+			grader.GradeResult{Score: 70, Covered: []string{}, Missed: []string{"b"}},
+		},
+		{
+			"fractional score",
+			`{"score": 66.6, "covered": ["a"], "missed": ["b"]}`,
+			grader.GradeResult{Score: 67, Covered: []string{"a"}, Missed: []string{"b"}},
+		},
+		{
+			"single strings instead of lists",
+			`{"score": 50, "covered": "a", "missed": ""}`,
+			grader.GradeResult{Score: 50, Covered: []string{"a"}, Missed: []string{}},
+		},
+		{
+			"single index and numeric string indices",
+			`{"score": 50, "covered": ["a"], "missed": ["b"], "covered_indices": 0, "missed_indices": ["1"]}`,
+			grader.GradeResult{Score: 50, Covered: []string{"a"}, Missed: []string{"b"}, CoveredIndices: []int{0}, MissedIndices: []int{1}},
+		},
+		{
+			"null and missing fields",
+			`{"score": null, "covered": ["a"]}`,
+			grader.GradeResult{Covered: []string{"a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := grader.ParseGradeResult([]byte(tt.payload), false)
+			if err != nil {
+				t.Fatalf("ParseGradeResult: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseGradeResult_Unrecoverable(t *testing.T) {
+	for _, payload := range []string{
+		`{"score": "high", "covered": [], "missed": []}`,
+		`{"score": 50, "covered": {"a": 1}, "missed": []}`,
+		`{"score": 50, "covered": [], "missed": [], "covered_indices": ["first"]}`,
+		`not json`,
+	} {
+		if _, err := grader.ParseGradeResult([]byte(payload), false); err == nil {
+			t.Errorf("expected an error for %s", payload)
+		}
+	}
+}
+
+func TestParseGradeResult_Strict(t *testing.T) {
+	if _, err := grader.ParseGradeResult([]byte(`{"score": "85", "covered": [], "missed": []}`), true); err == nil {
+		t.Error("expected strict parsing to reject a string score")
+	}
+	if _, err := grader.ParseGradeResult([]byte(`{"score": 50, "covered": "a", "missed": []}`), true); err == nil {
+		t.Error("expected strict parsing to reject a single string label")
+	}
+	got, err := grader.ParseGradeResult([]byte(`{"score": 85, "covered": ["a"], "missed": []}`), true)
+	if err != nil || got.Score != 85 {
+		t.Errorf("expected well-formed JSON to parse strictly, got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,30 @@
+package grader
+
+import "github.com/remaimber-it/backend/internal/textutil"
+
+// DefaultSimilarityThreshold is the minimum Similarity for two texts to be
+// considered a match when no threshold is configured.
+const DefaultSimilarityThreshold = 0.5
+
+// Similarity scores how alike two short texts are, from 0 (no words in
+// common) to 1. It is the token overlap coefficient: the number of shared
+// words divided by the word count of the shorter text, ignoring case and
+// punctuation. Measuring against the shorter text lets a terse label such
+// as "lightweight" fully match "goroutines are lightweight".
+func Similarity(a, b string) float64 {
+	wordsA, wordsB := textutil.WordSet(a), textutil.WordSet(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	if len(wordsA) > len(wordsB) {
+		wordsA, wordsB = wordsB, wordsA
+	}
+
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA))
+}
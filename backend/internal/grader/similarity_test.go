@@ -0,0 +1,90 @@
+package grader_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/remaimber-it/backend/internal/grader"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"identical", "managed by the Go runtime", "managed by the Go runtime", 1},
+		{"case and punctuation ignored", "Managed by Go-runtime!", "managed by go runtime", 1},
+		{"label contained in point", "lightweight", "goroutines are lightweight", 1},
+		{"half the words shared", "cheap green threads", "cheap threads run by runtime", 2.0 / 3.0},
+		{"exactly half", "send values", "send channels", 0.5},
+		{"one of three words", "blocking channel send", "buffered queue send", 1.0 / 3.0},
+		{"nothing shared", "channels", "mutexes", 0},
+		{"empty", "", "anything", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grader.Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got, rev := grader.Similarity(tt.a, tt.b), grader.Similarity(tt.b, tt.a); got != rev {
+				t.Errorf("Similarity is not symmetric: %v vs %v", got, rev)
+			}
+		})
+	}
+}
+
+func TestGradeAnswer_SimilarityThreshold(t *testing.T) {
+	// "send values" shares exactly half its words with the first key point
+	// and a third with the second, so it sits right at the default threshold.
+	expected := "- send channels\n- blocking queue receive"
+	srv := newFakeLLM(t, `{"score": 50, "covered": ["send values"], "missed": ["blocking channel receive"]}`)
+
+	tests := []struct {
+		name        string
+		threshold   float64
+		wantCovered []int
+		wantMissed  []int
+	}{
+		{"default threshold matches at the boundary", 0, []int{0}, []int{1}},
+		{"just above the boundary rejects", 0.51, []int{}, []int{1}},
+		{"strict threshold rejects near matches", 0.9, []int{}, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := grader.NewOllamaGrader(srv.URL, "test")
+			g.SetSimilarityThreshold(tt.threshold)
+
+			out, err := g.GradeAnswer(context.Background(), "Q", expected, "answer", nil, "theory")
+			if err != nil {
+				t.Fatalf("GradeAnswer: %v", err)
+			}
+			var result grader.GradeResult
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if !equalInts(result.CoveredIndices, tt.wantCovered) {
+				t.Errorf("expected covered indices %v, got %v", tt.wantCovered, result.CoveredIndices)
+			}
+			if !equalInts(result.MissedIndices, tt.wantMissed) {
+				t.Errorf("expected missed indices %v, got %v", tt.wantMissed, result.MissedIndices)
+			}
+		})
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
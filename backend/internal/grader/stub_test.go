@@ -0,0 +1,48 @@
+package grader_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/remaimber-it/backend/internal/grader"
+)
+
+func TestStubGrader(t *testing.T) {
+	expected := "- goroutines are lightweight\n- managed by the Go runtime\n- communicate via channels"
+	tests := []struct {
+		name    string
+		answer  string
+		score   int
+		covered []int
+	}{
+		{"all points", "Goroutines are lightweight, managed by the runtime and communicate over channels", 100, []int{0, 1, 2}},
+		{"some points", "They are lightweight and use channels to communicate", 67, []int{0, 2}},
+		{"nothing", "I don't know", 0, []int{}},
+		{"empty", "", 0, []int{}},
+	}
+
+	g := grader.NewStubGrader()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := g.GradeAnswer(context.Background(), "What is a goroutine?", expected, tt.answer, nil, "theory")
+			if err != nil {
+				t.Fatalf("GradeAnswer: %v", err)
+			}
+			var result grader.GradeResult
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON %q: %v", out, err)
+			}
+			if result.Score != tt.score {
+				t.Errorf("expected score %d, got %d", tt.score, result.Score)
+			}
+			if !reflect.DeepEqual(result.CoveredIndices, tt.covered) {
+				t.Errorf("expected covered indices %v, got %v", tt.covered, result.CoveredIndices)
+			}
+			if len(result.Covered)+len(result.Missed) != 3 {
+				t.Errorf("expected every key point covered or missed, got %+v", result)
+			}
+		})
+	}
+}
@@ -3,31 +3,176 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+
+	"github.com/remaimber-it/backend/internal/service"
 )
 
 type Config struct {
 	ServerAddress   string
 	ShutdownTimeout time.Duration
 
+	// StoreDriver selects the persistence backend: "sqlite" or "postgres".
+	StoreDriver string
+	// DatabaseURL is the Postgres connection string, required when
+	// StoreDriver is "postgres".
+	DatabaseURL string
+
 	// LLM grading
 	LLMURL   string // OpenAI-compatible endpoint, e.g. "http://localhost:1234"
 	LLMModel string // model name, e.g. "qwen3-8b"
+
+	// LLMTemperature, LLMMaxTokens and LLMTopP tune the model's sampling.
+	// Temperature 0 is recommended for consistent grades; a max tokens or
+	// top-p of 0 keeps the server's default.
+	LLMTemperature float64
+	LLMMaxTokens   int
+	LLMTopP        float64
+
+	// GradingTimeout bounds a single asynchronous grading call.
+	GradingTimeout time.Duration
+
+	// GradingStuckThreshold is how long a grading may run before
+	// GET /health/grading reports its session as stuck. 0 uses twice the
+	// grading timeout.
+	GradingStuckThreshold time.Duration
+
+	// LLMMaxConcurrency is how many answers are graded at once; further
+	// answers wait their turn. 0 removes the limit.
+	LLMMaxConcurrency int
+
+	// LLMTimeoutTheory, LLMTimeoutCode and LLMTimeoutCLI bound how long the
+	// model may take to grade an answer of each bank type, retries included.
+	// A grading of that bank type may run for the longer of this and
+	// GradingTimeout.
+	LLMTimeoutTheory time.Duration
+	LLMTimeoutCode   time.Duration
+	LLMTimeoutCLI    time.Duration
+
+	// GradePreviewTimeout bounds the synchronous POST /grade/preview call.
+	// Keep it below the server's 30s write timeout.
+	GradePreviewTimeout time.Duration
+
+	// SimilarityThreshold is the minimum fuzzy match score (0-1) used when
+	// mapping grader labels back to key points.
+	SimilarityThreshold float64
+
+	// ShuffleKeyPoints randomises key point order in theory grading prompts.
+	ShuffleKeyPoints bool
+
+	// StrictGradeParsing rejects grade JSON that needs coercing, such as a
+	// score given as a string, instead of recovering it.
+	StrictGradeParsing bool
+
+	// StreamGrading requests model output as a stream so grading progress
+	// can be reported while it runs.
+	StreamGrading bool
+
+	// ScoringCurve reshapes grade scores for banks that do not choose their
+	// own curve: "linear" (unchanged) or "sqrt".
+	ScoringCurve string
+
+	// MasteryScope is the default for which questions count towards every
+	// reported bank mastery: "all" counts never-answered questions as 0,
+	// "answered" leaves them out.
+	MasteryScope string
+
+	// MasteryHalfLife is how long an unanswered question takes to lose half
+	// its stored mastery, so stale questions resurface in FocusOnWeak
+	// sessions. 0 disables decay.
+	MasteryHalfLife time.Duration
+
+	// MasteryPassThreshold is the minimum score (1-100) an answer needs to
+	// count in times_correct and streaks. Changing it applies to new answers
+	// only; times_correct already recorded is not recomputed.
+	MasteryPassThreshold int
+
+	// MaxSessionDurationMin caps a session's max_duration_min; 0 disables it.
+	MaxSessionDurationMin int
+
+	// StaleSessionAge is how long a session may stay active before
+	// GET /sessions/incomplete reports it.
+	StaleSessionAge time.Duration
+
+	// CORSAllowedOrigins lists the origins browsers may call the API from.
+	// "*" allows any origin. The default covers the frontend's dev server
+	// and the Tauri app.
+	CORSAllowedOrigins []string
+
+	// AdminToken protects /admin routes; empty disables them.
+	AdminToken string
+
+	// APIKey, when set, must be sent as a bearer token on every request
+	// except GET /health and the Swagger UI. The admin token is accepted
+	// too. Empty leaves the API open.
+	APIKey string
+
+	// AuditLogMaxEntries is how many audit log entries are kept, newest
+	// first; 0 disables the audit log.
+	AuditLogMaxEntries int
+
+	// AnswerRateLimit is how many answers a client, identified by API key
+	// or IP, may submit per minute; 0 removes the limit.
+	AnswerRateLimit int
+
+	// ImportConcurrency is how many banks POST /import fills with questions
+	// at once; 1 imports sequentially.
+	ImportConcurrency int
+
+	// LogGradeAnswers adds user answers to grade outcome logs.
+	LogGradeAnswers bool
 }
 
 func Load() *Config {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 	return &Config{
-		ServerAddress:   mustGetenv("SERVER_ADDRESS"),
-		ShutdownTimeout: mustGetDuration("SHUTDOWN_TIMEOUT"),
-		LLMURL:          getenvDefault("LLM_URL", "http://localhost:1234"),
-		LLMModel:        getenvDefault("LLM_MODEL", "qwen3-8b"),
+		ServerAddress:         mustGetenv("SERVER_ADDRESS"),
+		ShutdownTimeout:       mustGetDuration("SHUTDOWN_TIMEOUT"),
+		StoreDriver:           getenvDefault("STORE_DRIVER", "sqlite"),
+		DatabaseURL:           os.Getenv("DATABASE_URL"),
+		LLMURL:                getenvAllowEmpty("LLM_URL", "http://localhost:1234"),
+		LLMModel:              getenvDefault("LLM_MODEL", "qwen3-8b"),
+		LLMTemperature:        getFloatDefault("LLM_TEMPERATURE", 0),
+		LLMMaxTokens:          getIntDefault("LLM_MAX_TOKENS", 0),
+		LLMTopP:               getFloatDefault("LLM_TOP_P", 0),
+		GradingTimeout:        getDurationDefault("GRADING_TIMEOUT", service.DefaultGradingTimeout),
+		GradingStuckThreshold: getDurationDefault("GRADING_STUCK_THRESHOLD", 0),
+		LLMMaxConcurrency:     getIntDefault("LLM_MAX_CONCURRENCY", service.DefaultMaxConcurrency),
+		LLMTimeoutTheory:      getDurationDefault("LLM_TIMEOUT_THEORY", 2*time.Minute),
+		LLMTimeoutCode:        getDurationDefault("LLM_TIMEOUT_CODE", 2*time.Minute),
+		LLMTimeoutCLI:         getDurationDefault("LLM_TIMEOUT_CLI", 2*time.Minute),
+		GradePreviewTimeout:   getDurationDefault("GRADE_PREVIEW_TIMEOUT", 25*time.Second),
+		SimilarityThreshold:   getFloatDefault("SIMILARITY_THRESHOLD", 0.5),
+		ShuffleKeyPoints:      getBoolDefault("SHUFFLE_KEY_POINTS", false),
+		StreamGrading:         getBoolDefault("STREAM_GRADING", false),
+		StrictGradeParsing:    getBoolDefault("STRICT_GRADE_PARSING", false),
+		ScoringCurve:          getenvDefault("SCORING_CURVE", "linear"),
+		MasteryScope:          getenvDefault("MASTERY_SCOPE", "all"),
+		MasteryHalfLife:       getDurationDefault("MASTERY_HALF_LIFE", 0),
+		MasteryPassThreshold:  getIntDefault("MASTERY_PASS_THRESHOLD", 70),
+		MaxSessionDurationMin: getIntDefault("MAX_SESSION_DURATION_MIN", 240),
+		StaleSessionAge:       getDurationDefault("STALE_SESSION_AGE", 24*time.Hour),
+		LogGradeAnswers:       getBoolDefault("LOG_GRADE_ANSWERS", false),
+		CORSAllowedOrigins:    getListDefault("CORS_ALLOWED_ORIGINS", "http://localhost:1420,tauri://localhost,http://tauri.localhost"),
+		AdminToken:            os.Getenv("ADMIN_TOKEN"),
+		APIKey:                os.Getenv("API_KEY"),
+		AuditLogMaxEntries:    getIntDefault("AUDIT_LOG_MAX_ENTRIES", 10000),
+		ImportConcurrency:     getIntDefault("IMPORT_CONCURRENCY", 1),
+		AnswerRateLimit:       getIntDefault("ANSWER_RATE_LIMIT_PER_MIN", 60),
 	}
 }
 
+// UseStubGrader reports whether grading should run offline with the stub
+// grader: LLM_URL is set but empty, or LLM_MODEL is "stub".
+func (c *Config) UseStubGrader() bool {
+	return c.LLMURL == "" || c.LLMModel == "stub"
+}
+
 func mustGetenv(k string) string {
 	v := os.Getenv(k)
 	if v == "" {
@@ -54,3 +199,72 @@ func getenvDefault(k, fallback string) string {
 	}
 	return fallback
 }
+
+// getenvAllowEmpty is like getenvDefault, but a variable that is set to
+// the empty string stays empty.
+func getenvAllowEmpty(k, fallback string) string {
+	if v, ok := os.LookupEnv(k); ok {
+		return v
+	}
+	return fallback
+}
+
+// getListDefault splits a comma-separated variable, trimming spaces and
+// dropping empty items.
+func getListDefault(k, fallback string) []string {
+	var items []string
+	for _, item := range strings.Split(getenvDefault(k, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getDurationDefault(k string, fallback time.Duration) time.Duration {
+	v := os.Getenv(k)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("config: %s=%q is not a valid duration: %v", k, v, err)
+	}
+	return d
+}
+
+func getFloatDefault(k string, fallback float64) float64 {
+	v := os.Getenv(k)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Fatalf("config: %s=%q is not a valid number: %v", k, v, err)
+	}
+	return f
+}
+
+func getIntDefault(k string, fallback int) int {
+	v := os.Getenv(k)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("config: %s=%q is not a valid integer: %v", k, v, err)
+	}
+	return n
+}
+
+func getBoolDefault(k string, fallback bool) bool {
+	v := os.Getenv(k)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("config: %s=%q is not a valid boolean: %v", k, v, err)
+	}
+	return b
+}
@@ -3,12 +3,17 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
 	"github.com/remaimber-it/backend/internal/grader"
 	"github.com/remaimber-it/backend/internal/store"
+	"github.com/remaimber-it/backend/internal/worker"
 )
 
 // Generator is implemented by types that can generate questions from content.
@@ -18,13 +23,77 @@ type Generator interface {
 
 // GradeRequest contains everything needed to grade a single answer.
 type GradeRequest struct {
-	SessionID      string
-	QuestionID     string
-	Question       string // the question text
-	ExpectedAnswer string
-	UserAnswer     string
-	GradingPrompt  *string // optional custom prompt
-	BankType       string  // "theory", "code", "cli"
+	SessionID          string
+	QuestionID         string
+	Question           string // the question text
+	ExpectedAnswer     string
+	AlternativeAnswers []string // other acceptable answers; the answer is graded against whichever matches it best
+	UserAnswer         string
+	GradingPrompt      *string // optional custom prompt
+	Rubric             *string // optional grading criteria; ExpectedAnswer is then only a reference
+	BankType           string  // "theory", "code", "cli"
+	GradingMode        string  // "llm" (default) or "exact"
+	ExactMatch         questionbank.ExactMatchOptions
+	SelfCovered        []int                     // key point indices the user self-marked as covered; nil when not self-checked
+	ScoringCurve       questionbank.ScoringCurve // the bank's curve; empty uses the service default
+	AnswerLanguage     string                    // language the user answered in, or grader.AnswerLanguageAuto; empty assumes the expected answer's
+	RequestID          string                    // ID of the HTTP request that submitted the answer, for logs; may be empty
+}
+
+// DefaultGradingTimeout bounds how long a single answer may be graded
+// before the model call is abandoned.
+const DefaultGradingTimeout = 2 * time.Minute
+
+// DefaultMaxConcurrency is the recommended number of answers to grade at
+// once, low enough that a burst of answers cannot flood a local model with
+// simultaneous calls.
+const DefaultMaxConcurrency = 3
+
+// sessionGrading tracks the grading goroutines of one session. Their
+// contexts derive from ctx, which is cancelled when the session is
+// forgotten or its completion stops waiting for them.
+type sessionGrading struct {
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lastActive time.Time // when the session was tracked or last submitted an answer
+}
+
+// runningGrade records when an in-flight grading started and how much
+// model output has streamed in since.
+type runningGrade struct {
+	sessionID  string
+	questionID string
+	started    time.Time // zero while waiting for a grading slot
+	received   int       // bytes of streamed model output
+}
+
+// StuckSession is a session whose oldest in-flight grading has been running
+// longer than the stuck threshold.
+type StuckSession struct {
+	SessionID  string
+	RunningFor time.Duration
+}
+
+// GradingHealth is a snapshot of the grading pipeline.
+type GradingHealth struct {
+	InFlight        int
+	TrackedSessions int // sessions whose gradings WaitForSession can wait for
+	StuckThreshold  time.Duration
+	StuckSessions   []StuckSession
+}
+
+// GraderPingTimeout bounds PingGrader so a hung model server cannot stall
+// a health probe.
+const GraderPingTimeout = 2 * time.Second
+
+// GraderHealth is the result of probing the grader's backend. Model and
+// Endpoint are empty when the grader does not report them.
+type GraderHealth struct {
+	Model    string
+	Endpoint string
+	Latency  time.Duration
+	Err      error // nil when the grader is reachable
 }
 
 // GradingService manages asynchronous grading of user answers.
@@ -36,21 +105,73 @@ type GradingService struct {
 	grader    grader.Grader
 	generator Generator
 	logger    *slog.Logger
+	timeout   time.Duration
+	stuck     time.Duration             // in-flight grading older than this is reported as stuck
+	verbose   bool                      // include user answers in grade outcome logs
+	strict    bool                      // reject grader JSON that needs coercing
+	curve     questionbank.ScoringCurve // applied to scores of requests without their own curve
+	pool      *worker.Pool              // bounds concurrent gradings
 
 	mu       sync.RWMutex
-	pending  map[string]*sync.WaitGroup // sessionID → WaitGroup
+	pending  map[string]*sessionGrading // sessionID → grading state
 	inflight sync.WaitGroup             // tracks all grading goroutines for shutdown
+	running  map[uint64]runningGrade    // in-flight gradings by sequence number
+	nextSeq  uint64
 }
 
-// NewGradingService creates a GradingService.
-// The generator parameter can be nil if question generation is not needed.
-func NewGradingService(s store.Store, g grader.Grader, gen Generator, logger *slog.Logger) *GradingService {
+// NewGradingService creates a GradingService grading at most maxConcurrency
+// answers at once; further submissions wait for a free slot, and values
+// below 1 remove the limit. The generator parameter can be nil if question
+// generation is not needed.
+func NewGradingService(s store.Store, g grader.Grader, gen Generator, logger *slog.Logger, maxConcurrency int) *GradingService {
 	return &GradingService{
 		store:     s,
 		grader:    g,
 		generator: gen,
 		logger:    logger,
-		pending:   make(map[string]*sync.WaitGroup),
+		timeout:   DefaultGradingTimeout,
+		curve:     questionbank.ScoringCurveLinear,
+		pool:      worker.NewPool(maxConcurrency),
+		pending:   make(map[string]*sessionGrading),
+		running:   make(map[uint64]runningGrade),
+	}
+}
+
+// SetGradingTimeout changes how long a single asynchronous grading may run
+// before it is cancelled and recorded as a failure. A grader.TimeoutReporter
+// allowing longer for the answer's bank type extends it. Non-positive values
+// are ignored.
+func (gs *GradingService) SetGradingTimeout(d time.Duration) {
+	if d > 0 {
+		gs.timeout = d
+	}
+}
+
+// SetStuckThreshold changes how long an in-flight grading may run before
+// Health reports its session as stuck. Non-positive values restore the
+// default of twice the grading timeout.
+func (gs *GradingService) SetStuckThreshold(d time.Duration) {
+	gs.stuck = d
+}
+
+// SetVerboseGradeLogging controls whether grade outcome logs include the
+// user's answer. It is off by default since answers may be long or personal.
+func (gs *GradingService) SetVerboseGradeLogging(verbose bool) {
+	gs.verbose = verbose
+}
+
+// SetStrictGradeParsing controls whether grader output must match the
+// grader.GradeResult JSON shape exactly. It is lenient by default; see
+// grader.ParseGradeResult for what is coerced.
+func (gs *GradingService) SetStrictGradeParsing(strict bool) {
+	gs.strict = strict
+}
+
+// SetScoringCurve changes the curve applied to grades whose bank does not
+// choose one. Unknown curves are ignored.
+func (gs *GradingService) SetScoringCurve(curve questionbank.ScoringCurve) {
+	if curve.IsValid() {
+		gs.curve = curve
 	}
 }
 
@@ -59,51 +180,268 @@ func NewGradingService(s store.Store, g grader.Grader, gen Generator, logger *sl
 func (gs *GradingService) TrackSession(sessionID string) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
-	gs.pending[sessionID] = &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+	gs.pending[sessionID] = &sessionGrading{ctx: ctx, cancel: cancel, lastActive: time.Now()}
 }
 
 // SubmitGrading sends an answer for async grading.
 // The goroutine calls the LLM, parses the result, and persists the grade.
 //
-// wg.Add(1) is called while holding the read-lock so that a concurrent
+// wg.Add(1) is called while holding the lock so that a concurrent
 // WaitForSession cannot observe a "zero" WaitGroup between the unlock
-// and the Add — eliminating the TOCTOU race.
+// and the Add — eliminating the TOCTOU race. The same lock registers the
+// grading for PendingQuestions and Health.
+//
+// At most the service's maxConcurrency gradings run at once; the others wait
+// in their goroutine for a slot, and their start time is recorded once they
+// get one.
 func (gs *GradingService) SubmitGrading(req GradeRequest) {
-	gs.mu.RLock()
-	wg, ok := gs.pending[req.SessionID]
+	gs.mu.Lock()
+	sg, ok := gs.pending[req.SessionID]
+	parent := context.Background()
 	if ok {
-		wg.Add(1)
+		sg.wg.Add(1)
+		sg.lastActive = time.Now()
+		parent = sg.ctx
 	}
-	gs.mu.RUnlock()
+	gs.nextSeq++
+	seq := gs.nextSeq
+	gs.running[seq] = runningGrade{sessionID: req.SessionID, questionID: req.QuestionID}
+	gs.mu.Unlock()
 
 	gs.inflight.Add(1)
 
 	go func() {
 		defer gs.inflight.Done()
 		if ok {
-			defer wg.Done()
+			defer sg.wg.Done()
 		}
-		gs.grade(req)
+		defer func() {
+			gs.mu.Lock()
+			delete(gs.running, seq)
+			gs.mu.Unlock()
+		}()
+
+		// Queued gradings still count towards the session's WaitGroup and
+		// PendingQuestions; their timeout starts once they get a slot.
+		gs.pool.Do(func() {
+			gs.mu.Lock()
+			if rg, ok := gs.running[seq]; ok {
+				rg.started = time.Now()
+				gs.running[seq] = rg
+			}
+			gs.mu.Unlock()
+
+			gs.grade(grader.WithProgress(parent, func(chunk string) {
+				gs.mu.Lock()
+				if rg, ok := gs.running[seq]; ok {
+					rg.received += len(chunk)
+					gs.running[seq] = rg
+				}
+				gs.mu.Unlock()
+			}), req)
+		})
 	}()
 }
 
+// SubmitAnswer persists req's answer as pending, so it can be graded again
+// if the server stops before grading finishes, and then submits it with
+// SubmitGrading. Nothing is submitted when the answer cannot be persisted.
+func (gs *GradingService) SubmitAnswer(ctx context.Context, req GradeRequest) error {
+	err := gs.store.SavePendingAnswer(ctx, store.PendingAnswer{
+		SessionID:      req.SessionID,
+		QuestionID:     req.QuestionID,
+		UserAnswer:     req.UserAnswer,
+		SelfCovered:    req.SelfCovered,
+		AnswerLanguage: req.AnswerLanguage,
+		SubmittedAt:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	gs.SubmitGrading(req)
+	return nil
+}
+
 // WaitForSession blocks until all grading goroutines for a session have
 // finished, then removes the session from the pending map to prevent
-// memory leaks.
-func (gs *GradingService) WaitForSession(sessionID string) {
+// memory leaks. If ctx is done first, as when the client completing the
+// session disconnects, the session's in-flight gradings are cancelled and
+// recorded as failures, and WaitForSession returns once they have settled.
+func (gs *GradingService) WaitForSession(ctx context.Context, sessionID string) {
 	gs.mu.RLock()
-	wg, ok := gs.pending[sessionID]
+	sg, ok := gs.pending[sessionID]
 	gs.mu.RUnlock()
 
 	if ok {
-		wg.Wait()
+		done := make(chan struct{})
+		go func() {
+			sg.wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			sg.cancel()
+			<-done
+		}
+		sg.cancel()
 
 		gs.mu.Lock()
-		delete(gs.pending, sessionID)
+		if gs.pending[sessionID] == sg {
+			delete(gs.pending, sessionID)
+		}
 		gs.mu.Unlock()
 	}
 }
 
+// ForgetSession stops tracking a session that will not be completed,
+// cancelling its in-flight gradings, which are recorded as failures.
+// Answers submitted afterwards are still graded, but WaitForSession no
+// longer waits for them.
+func (gs *GradingService) ForgetSession(sessionID string) {
+	gs.mu.Lock()
+	sg, ok := gs.pending[sessionID]
+	delete(gs.pending, sessionID)
+	gs.mu.Unlock()
+
+	if ok {
+		sg.cancel()
+	}
+}
+
+// ForgetIdleSessions forgets every tracked session with no grading in
+// flight that has not submitted an answer for longer than idle, and
+// returns how many it forgot. It releases the state of sessions that are
+// abandoned rather than completed.
+func (gs *GradingService) ForgetIdleSessions(idle time.Duration) int {
+	cutoff := time.Now().Add(-idle)
+
+	gs.mu.Lock()
+	busy := make(map[string]bool)
+	for _, rg := range gs.running {
+		busy[rg.sessionID] = true
+	}
+	var idleSessions []*sessionGrading
+	for sessionID, sg := range gs.pending {
+		if !busy[sessionID] && sg.lastActive.Before(cutoff) {
+			idleSessions = append(idleSessions, sg)
+			delete(gs.pending, sessionID)
+		}
+	}
+	gs.mu.Unlock()
+
+	for _, sg := range idleSessions {
+		sg.cancel()
+	}
+	return len(idleSessions)
+}
+
+// SweepIdleSessions calls ForgetIdleSessions(idle) every interval until
+// ctx is done.
+func (gs *GradingService) SweepIdleSessions(ctx context.Context, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := gs.ForgetIdleSessions(idle); n > 0 {
+				gs.logger.Info("forgot idle sessions", "count", n)
+			}
+		}
+	}
+}
+
+// SweepMasteryDecay decays stored mastery with the given half-life (see
+// store.Store.DecayMastery) now and then every interval until ctx is done,
+// so questions not answered for a while sink in FocusOnWeak ordering.
+func (gs *GradingService) SweepMasteryDecay(ctx context.Context, interval, halfLife time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := gs.store.DecayMastery(ctx, halfLife, time.Now())
+		if err != nil {
+			gs.logger.Error("failed to decay mastery", "error", err)
+		} else if n > 0 {
+			gs.logger.Info("decayed mastery", "questions", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PendingQuestions returns the IDs of a session's questions whose grading
+// is still in flight, mapped to how many bytes of model output have
+// streamed in so far (always 0 when the grader does not stream).
+func (gs *GradingService) PendingQuestions(sessionID string) map[string]int {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	pending := make(map[string]int)
+	for _, rg := range gs.running {
+		if rg.sessionID == sessionID {
+			pending[rg.questionID] += rg.received
+		}
+	}
+	return pending
+}
+
+// Health reports how many gradings are in flight and which sessions have a
+// grading running longer than the stuck threshold, oldest first.
+func (gs *GradingService) Health() GradingHealth {
+	threshold := gs.stuck
+	if threshold <= 0 {
+		threshold = 2 * gs.timeout
+	}
+
+	gs.mu.RLock()
+	oldest := make(map[string]time.Time)
+	for _, rg := range gs.running {
+		if rg.started.IsZero() {
+			continue // queued, not running yet
+		}
+		if t, ok := oldest[rg.sessionID]; !ok || rg.started.Before(t) {
+			oldest[rg.sessionID] = rg.started
+		}
+	}
+	health := GradingHealth{InFlight: len(gs.running), TrackedSessions: len(gs.pending), StuckThreshold: threshold}
+	gs.mu.RUnlock()
+
+	now := time.Now()
+	for sessionID, started := range oldest {
+		if d := now.Sub(started); d > threshold {
+			health.StuckSessions = append(health.StuckSessions, StuckSession{SessionID: sessionID, RunningFor: d})
+		}
+	}
+	sort.Slice(health.StuckSessions, func(i, j int) bool {
+		return health.StuckSessions[i].RunningFor > health.StuckSessions[j].RunningFor
+	})
+	return health
+}
+
+// PingGrader checks that the grader is reachable, giving up after
+// GraderPingTimeout.
+func (gs *GradingService) PingGrader(ctx context.Context) GraderHealth {
+	var health GraderHealth
+	if m, ok := gs.grader.(modelNamer); ok {
+		health.Model = m.Model()
+	}
+	if e, ok := gs.grader.(endpointNamer); ok {
+		health.Endpoint = e.Endpoint()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, GraderPingTimeout)
+	defer cancel()
+	start := time.Now()
+	health.Err = gs.grader.Ping(ctx)
+	health.Latency = time.Since(start)
+	return health
+}
+
 // Shutdown waits for every in-flight grading goroutine to finish.
 // Call this during server shutdown so LLM calls in progress are not
 // abandoned and their results are persisted.
@@ -111,6 +449,13 @@ func (gs *GradingService) Shutdown() {
 	gs.logger.Info("waiting for in-flight grading to complete")
 	gs.inflight.Wait()
 	gs.logger.Info("all grading goroutines finished")
+
+	gs.mu.Lock()
+	for sessionID, sg := range gs.pending {
+		sg.cancel()
+		delete(gs.pending, sessionID)
+	}
+	gs.mu.Unlock()
 }
 
 // GenerateQuestions generates flashcard questions from study content.
@@ -122,40 +467,123 @@ func (gs *GradingService) GenerateQuestions(ctx context.Context, req grader.Gene
 	return gs.generator.GenerateQuestions(ctx, req)
 }
 
+// Errors returned by RenderPrompt.
+var (
+	ErrNoPrompt          = errors.New("exact-mode grading does not use a prompt")
+	ErrPromptUnavailable = errors.New("the grader cannot show its prompt")
+)
+
+// RenderPrompt returns the prompt that grading req would send to the model,
+// without sending it. Self-marking is ignored: the prompt is the one used
+// for answers that were not self-marked.
+func (gs *GradingService) RenderPrompt(req GradeRequest) (string, error) {
+	if req.GradingMode == "exact" {
+		return "", ErrNoPrompt
+	}
+	r, ok := gs.grader.(grader.PromptRenderer)
+	if !ok {
+		return "", ErrPromptUnavailable
+	}
+	return r.RenderPrompt(req.Question, req.ExpectedAnswer, req.UserAnswer, req.Rubric, req.GradingPrompt, req.BankType), nil
+}
+
 // GradeOnce performs a synchronous, one-shot grading without persisting results.
 // This is used for simulation/testing grading prompts before adding questions.
-func (gs *GradingService) GradeOnce(ctx context.Context, req GradeRequest) (score int, covered []string, missed []string, err error) {
-	response, err := gs.grader.GradeAnswer(
-		ctx,
-		req.Question,
-		req.ExpectedAnswer,
-		req.UserAnswer,
-		req.GradingPrompt,
-		req.BankType,
-	)
+func (gs *GradingService) GradeOnce(ctx context.Context, req GradeRequest) (grader.GradeResult, error) {
+	req, _ = matchExpectedAnswer(req)
+	response, err := gs.gradeAnswer(ctx, req)
 	if err != nil {
-		return 0, nil, nil, fmt.Errorf("grading error: %w", err)
+		return grader.GradeResult{}, fmt.Errorf("grading error: %w", err)
 	}
 
-	var result struct {
-		Score   int      `json:"score"`
-		Covered []string `json:"covered"`
-		Missed  []string `json:"missed"`
-	}
-	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		return 0, nil, nil, fmt.Errorf("failed to parse grading response: %w", err)
+	result, err := grader.ParseGradeResult([]byte(response), gs.strict)
+	if err != nil {
+		return grader.GradeResult{}, fmt.Errorf("failed to parse grading response: %w", err)
 	}
 
-	return result.Score, result.Covered, result.Missed, nil
+	result.Score = gs.scoringCurve(req).Apply(result.Score)
+	return result, nil
 }
 
-// grade does the actual LLM call and persists the result.
-// It uses context.Background because grading runs asynchronously
-// and must not be cancelled when the originating HTTP request ends.
-func (gs *GradingService) grade(req GradeRequest) {
-	ctx := context.Background()
+// scoringCurve returns the curve for req: its bank's, or the default.
+func (gs *GradingService) scoringCurve(req GradeRequest) questionbank.ScoringCurve {
+	if req.ScoringCurve.IsValid() {
+		return req.ScoringCurve
+	}
+	return gs.curve
+}
+
+// matchExpectedAnswer picks which of req's expected answers, the primary one
+// or an alternative, the user's answer is graded against, and returns req
+// with that one as its ExpectedAnswer together with its index (0 for the
+// primary). Exact mode takes the first one the answer matches exactly;
+// otherwise the one sharing the most words with the answer is taken, so only
+// one model call is made. Self-marked key points refer to the primary
+// answer, so self-checked answers are always graded against it.
+func matchExpectedAnswer(req GradeRequest) (GradeRequest, int) {
+	if len(req.AlternativeAnswers) == 0 || req.SelfCovered != nil {
+		return req, 0
+	}
+	answers := append([]string{req.ExpectedAnswer}, req.AlternativeAnswers...)
+
+	best := 0
+	if req.GradingMode == "exact" {
+		for i, answer := range answers {
+			var result grader.GradeResult
+			if json.Unmarshal([]byte(grader.GradeExact(answer, req.UserAnswer, req.ExactMatch)), &result) == nil && result.Score == 100 {
+				best = i
+				break
+			}
+		}
+	} else {
+		bestSimilarity := grader.Similarity(answers[0], req.UserAnswer)
+		for i, answer := range answers[1:] {
+			if similarity := grader.Similarity(answer, req.UserAnswer); similarity > bestSimilarity {
+				best, bestSimilarity = i+1, similarity
+			}
+		}
+	}
+
+	req.ExpectedAnswer = answers[best]
+	return req, best
+}
 
-	response, err := gs.grader.GradeAnswer(
+// gradeAnswer returns the raw grading JSON (see grader.GradeResult) for req.
+// Exact-mode requests are graded in Go; everything else goes to the grader,
+// which grades against the rubric when there is one, and otherwise only
+// verifies the user's self-marking when there is one, provided it supports
+// doing so. Self-marking refers to key points of the expected answer, so it
+// does not apply under a rubric.
+func (gs *GradingService) gradeAnswer(ctx context.Context, req GradeRequest) (string, error) {
+	if req.GradingMode == "exact" {
+		return grader.GradeExact(req.ExpectedAnswer, req.UserAnswer, req.ExactMatch), nil
+	}
+	if req.AnswerLanguage != "" {
+		ctx = grader.WithAnswerLanguage(ctx, req.AnswerLanguage)
+	}
+	if r, ok := gs.grader.(grader.RubricGrader); ok && req.Rubric != nil && *req.Rubric != "" {
+		return r.GradeAnswerWithRubric(
+			ctx,
+			req.Question,
+			req.ExpectedAnswer,
+			req.UserAnswer,
+			*req.Rubric,
+			req.GradingPrompt,
+			req.BankType,
+		)
+	}
+	if v, ok := gs.grader.(grader.SelfCheckVerifier); ok && req.SelfCovered != nil {
+		return v.VerifyAnswer(
+			ctx,
+			req.Question,
+			req.ExpectedAnswer,
+			req.UserAnswer,
+			req.SelfCovered,
+			req.GradingPrompt,
+			req.BankType,
+		)
+	}
+	return gs.grader.GradeAnswer(
 		ctx,
 		req.Question,
 		req.ExpectedAnswer,
@@ -163,45 +591,159 @@ func (gs *GradingService) grade(req GradeRequest) {
 		req.GradingPrompt,
 		req.BankType,
 	)
+}
+
+// grade does the actual LLM call and persists the result.
+// The model call is bounded by the session context and the grading
+// timeout rather than the originating HTTP request, so it survives the
+// request ending but cannot run forever. Results are persisted with
+// context.Background so a timed-out grading is still recorded.
+func (gs *GradingService) grade(parent context.Context, req GradeRequest) {
+	logger := gs.requestLogger(req)
+	req, matched := matchExpectedAnswer(req)
+	start := time.Now()
+	gradeCtx, cancel := context.WithTimeout(parent, gs.gradingTimeout(req))
+	response, err := gs.gradeAnswer(gradeCtx, req)
+	cancel()
+	elapsed := time.Since(start)
+
+	ctx := context.Background()
 	if err != nil {
-		gs.logger.Error("grading error",
+		logger.Error("grading error",
 			"question_id", req.QuestionID,
 			"error", err,
 		)
-		if saveErr := gs.store.SaveGradeFailure(ctx, req.SessionID, req.QuestionID, req.UserAnswer, err.Error()); saveErr != nil {
-			gs.logger.Error("failed to save grade failure", "error", saveErr)
+		if saveErr := gs.store.SaveGradeFailure(ctx, req.SessionID, req.QuestionID, req.UserAnswer, err.Error(), ""); saveErr != nil {
+			logger.Error("failed to save grade failure", "error", saveErr)
 		}
 		return
 	}
 
-	var result struct {
-		Score   int      `json:"score"`
-		Covered []string `json:"covered"`
-		Missed  []string `json:"missed"`
-	}
-	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		gs.logger.Error("parse error",
+	result, err := grader.ParseGradeResult([]byte(response), gs.strict)
+	if err != nil {
+		logger.Error("parse error",
 			"question_id", req.QuestionID,
 			"error", err,
 			"response", response,
 		)
 		if saveErr := gs.store.SaveGradeFailure(
 			ctx, req.SessionID, req.QuestionID, req.UserAnswer,
-			fmt.Sprintf("failed to parse grading response: %v", err),
+			fmt.Sprintf("failed to parse grading response: %v", err), response,
 		); saveErr != nil {
-			gs.logger.Error("failed to save grade failure", "error", saveErr)
+			logger.Error("failed to save grade failure", "error", saveErr)
 		}
 		return
 	}
 
+	rawScore := result.Score
+	result.Score = gs.scoringCurve(req).Apply(result.Score)
+	gs.logGradeOutcome(logger, req, result, rawScore, elapsed)
+
+	var matchedAnswer *int
+	if len(req.AlternativeAnswers) > 0 {
+		matchedAnswer = &matched
+	}
 	if err := gs.store.SaveGrade(
 		ctx, req.SessionID, req.QuestionID,
 		result.Score, result.Covered, result.Missed,
-		req.UserAnswer,
+		result.CoveredIndices, result.MissedIndices,
+		req.UserAnswer, gs.gradedBy(req), elapsed, matchedAnswer,
 	); err != nil {
-		gs.logger.Error("failed to save grade",
+		logger.Error("failed to save grade",
 			"question_id", req.QuestionID,
 			"error", err,
 		)
 	}
 }
+
+// gradingTimeout returns how long grading req may run: the grading timeout,
+// or the grader's own limit for req's bank type when that is longer.
+func (gs *GradingService) gradingTimeout(req GradeRequest) time.Duration {
+	if t, ok := gs.grader.(grader.TimeoutReporter); ok {
+		return max(gs.timeout, t.GradingTimeout(req.BankType))
+	}
+	return gs.timeout
+}
+
+// requestLogger returns the service logger, tagged with req's request ID
+// when it has one so grading logs can be traced to the submitting request.
+func (gs *GradingService) requestLogger(req GradeRequest) *slog.Logger {
+	if req.RequestID == "" {
+		return gs.logger
+	}
+	return gs.logger.With("request_id", req.RequestID)
+}
+
+// modelNamer is implemented by graders that can report their model name.
+type modelNamer interface {
+	Model() string
+}
+
+// gradedBy names what grades req: "exact" for exact grading, otherwise the
+// grader's model, or "" when the grader does not report one.
+func (gs *GradingService) gradedBy(req GradeRequest) string {
+	if req.GradingMode == "exact" {
+		return "exact"
+	}
+	if m, ok := gs.grader.(modelNamer); ok {
+		return m.Model()
+	}
+	return ""
+}
+
+// endpointNamer is implemented by graders that call a remote server.
+type endpointNamer interface {
+	Endpoint() string
+}
+
+// logGradeOutcome emits one structured record per completed grade so
+// grading behaviour can be analysed from the logs.
+func (gs *GradingService) logGradeOutcome(logger *slog.Logger, req GradeRequest, result grader.GradeResult, rawScore int, elapsed time.Duration) {
+	graderType, model := "llm", ""
+	if req.GradingMode == "exact" {
+		graderType = "exact"
+	} else if m, ok := gs.grader.(modelNamer); ok {
+		model = m.Model()
+	}
+
+	attrs := []any{
+		"session_id", req.SessionID,
+		"question_id", req.QuestionID,
+		"score", result.Score,
+		"raw_score", rawScore,
+		"covered_count", len(result.Covered),
+		"missed_count", len(result.Missed),
+		"duration_ms", elapsed.Milliseconds(),
+		"model", model,
+		"grader", graderType,
+	}
+	if req.SelfCovered != nil && graderType == "llm" {
+		attrs = append(attrs, "self_check_overturned", selfCheckOverturned(req.SelfCovered, result))
+	}
+	if gs.verbose {
+		attrs = append(attrs, "user_answer", req.UserAnswer)
+	}
+	logger.Info("grade completed", attrs...)
+}
+
+// selfCheckOverturned counts the key points where the grader disagreed with
+// the user's self-marking. The grader's result is what gets stored; this
+// only measures how reliable self-marking is.
+func selfCheckOverturned(selfCovered []int, result grader.GradeResult) int {
+	claimed := make(map[int]bool, len(selfCovered))
+	for _, i := range selfCovered {
+		claimed[i] = true
+	}
+	overturned := 0
+	for _, i := range result.CoveredIndices {
+		if !claimed[i] {
+			overturned++
+		}
+	}
+	for _, i := range result.MissedIndices {
+		if claimed[i] {
+			overturned++
+		}
+	}
+	return overturned
+}
@@ -0,0 +1,711 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/grader"
+	"github.com/remaimber-it/backend/internal/service"
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+// hangingGrader blocks until its context is cancelled.
+type hangingGrader struct{}
+
+func (hangingGrader) GradeAnswer(ctx context.Context, _, _, _ string, _ *string, _ string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (hangingGrader) Ping(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+var _ grader.Grader = hangingGrader{}
+
+// newTestStore opens a file-backed SQLite store. An in-memory database is
+// private to one connection, so the grading goroutines' writes would land in
+// an empty schema whenever the pool opened a second one.
+func newTestStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	s, err := store.NewSQLite(t.TempDir() + "/grading.db")
+	if err != nil {
+		t.Fatalf("store.NewSQLite: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestGradingAbandonedAfterTimeout(t *testing.T) {
+	s := newTestStore(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	gs := service.NewGradingService(s, hangingGrader{}, nil, logger, service.DefaultMaxConcurrency)
+	gs.SetGradingTimeout(50 * time.Millisecond)
+
+	gs.TrackSession("session-1")
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:      "session-1",
+		QuestionID:     "question-1",
+		Question:       "What is a goroutine?",
+		ExpectedAnswer: "A lightweight thread",
+		UserAnswer:     "A thread",
+	})
+
+	done := make(chan struct{})
+	go func() {
+		gs.WaitForSession(context.Background(), "session-1")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("grading was not abandoned after the timeout")
+	}
+
+	grades, err := s.GetGrades(context.Background(), "session-1")
+	if err != nil {
+		t.Fatalf("GetGrades: %v", err)
+	}
+	if len(grades) != 1 {
+		t.Fatalf("expected 1 grade, got %d", len(grades))
+	}
+	if grades[0].Status != store.GradeStatusFailed {
+		t.Errorf("expected failed grade, got %q", grades[0].Status)
+	}
+}
+
+// patientGrader answers after a delay, within the per-bank-type timeouts
+// it reports.
+type patientGrader struct {
+	delay    time.Duration
+	timeouts map[string]time.Duration
+}
+
+func (g patientGrader) GradeAnswer(ctx context.Context, _, _, _ string, _ *string, _ string) (string, error) {
+	select {
+	case <-time.After(g.delay):
+		return `{"score":90,"covered":[],"missed":[]}`, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (patientGrader) Ping(context.Context) error { return nil }
+
+func (g patientGrader) GradingTimeout(bankType string) time.Duration { return g.timeouts[bankType] }
+
+var _ grader.TimeoutReporter = patientGrader{}
+
+func TestWaitForSession_CancelledWaitAbandonsGrading(t *testing.T) {
+	s := newTestStore(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	gs := service.NewGradingService(s, hangingGrader{}, nil, logger, service.DefaultMaxConcurrency)
+
+	gs.TrackSession("session-1")
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:      "session-1",
+		QuestionID:     "question-1",
+		Question:       "What is a goroutine?",
+		ExpectedAnswer: "A lightweight thread",
+		UserAnswer:     "A thread",
+	})
+
+	// The grading timeout is minutes away; giving up the wait cancels it.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		gs.WaitForSession(ctx, "session-1")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a cancelled wait did not abandon the session's grading")
+	}
+
+	grades, _ := s.GetGrades(context.Background(), "session-1")
+	if len(grades) != 1 || grades[0].Status != store.GradeStatusFailed {
+		t.Errorf("expected one failed grade, got %+v", grades)
+	}
+	if n := gs.Health().TrackedSessions; n != 0 {
+		t.Errorf("expected the session to be released, got %d tracked", n)
+	}
+}
+
+func TestGradingTimeout_ExtendedByBankType(t *testing.T) {
+	s := newTestStore(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	g := patientGrader{delay: 200 * time.Millisecond, timeouts: map[string]time.Duration{"code": 5 * time.Second}}
+	gs := service.NewGradingService(s, g, nil, logger, service.DefaultMaxConcurrency)
+	gs.SetGradingTimeout(50 * time.Millisecond)
+
+	gs.TrackSession("session-1")
+	for _, bankType := range []string{"theory", "code"} {
+		gs.SubmitGrading(service.GradeRequest{
+			SessionID:      "session-1",
+			QuestionID:     bankType,
+			Question:       "What is a goroutine?",
+			ExpectedAnswer: "A lightweight thread",
+			UserAnswer:     "A thread",
+			BankType:       bankType,
+		})
+	}
+	gs.WaitForSession(context.Background(), "session-1")
+
+	grades, err := s.GetGrades(context.Background(), "session-1")
+	if err != nil {
+		t.Fatalf("GetGrades: %v", err)
+	}
+	status := map[string]store.GradeStatus{}
+	for _, gr := range grades {
+		status[gr.QuestionID] = gr.Status
+	}
+	if status["code"] != store.GradeStatusSuccess {
+		t.Errorf("expected the code answer graded within its longer bank type timeout, got %q", status["code"])
+	}
+	if status["theory"] != store.GradeStatusFailed {
+		t.Errorf("expected the theory answer cut off by the grading timeout, got %q", status["theory"])
+	}
+}
+
+// slowGrader takes a while to grade and records the most gradings it saw
+// running at once.
+type slowGrader struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (g *slowGrader) GradeAnswer(_ context.Context, _, _, _ string, _ *string, _ string) (string, error) {
+	g.mu.Lock()
+	g.running++
+	g.peak = max(g.peak, g.running)
+	g.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	g.mu.Lock()
+	g.running--
+	g.mu.Unlock()
+	return `{"score":75,"covered":["a"],"missed":[]}`, nil
+}
+
+func (*slowGrader) Ping(context.Context) error { return nil }
+
+func TestSubmitGrading_BoundedConcurrency(t *testing.T) {
+	s := newTestStore(t)
+
+	g := &slowGrader{}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	gs := service.NewGradingService(s, g, nil, logger, 2)
+
+	const answers = 12
+	gs.TrackSession("session-1")
+	for i := range answers {
+		gs.SubmitGrading(service.GradeRequest{
+			SessionID:      "session-1",
+			QuestionID:     fmt.Sprintf("question-%d", i),
+			Question:       "What is a goroutine?",
+			ExpectedAnswer: "A lightweight thread",
+			UserAnswer:     "A thread",
+		})
+	}
+	if pending := gs.PendingQuestions("session-1"); len(pending) != answers {
+		t.Errorf("expected queued gradings to be pending, got %d of %d", len(pending), answers)
+	}
+	gs.WaitForSession(context.Background(), "session-1")
+
+	if g.peak != 2 {
+		t.Errorf("expected at most 2 gradings at once, saw %d", g.peak)
+	}
+	grades, err := s.GetGrades(context.Background(), "session-1")
+	if err != nil {
+		t.Fatalf("GetGrades: %v", err)
+	}
+	if len(grades) != answers {
+		t.Errorf("expected WaitForSession to wait for all %d grades, got %d", answers, len(grades))
+	}
+}
+
+func TestSessionTrackingReleased(t *testing.T) {
+	s := newTestStore(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	gs := service.NewGradingService(s, fixedGrader{}, nil, logger, service.DefaultMaxConcurrency)
+
+	for _, id := range []string{"completed", "abandoned", "forgotten"} {
+		gs.TrackSession(id)
+	}
+	if n := gs.Health().TrackedSessions; n != 3 {
+		t.Fatalf("expected 3 tracked sessions, got %d", n)
+	}
+
+	gs.SubmitGrading(service.GradeRequest{SessionID: "completed", QuestionID: "q1", Question: "Q", ExpectedAnswer: "A", UserAnswer: "A"})
+	gs.WaitForSession(context.Background(), "completed")
+	if n := gs.Health().TrackedSessions; n != 2 {
+		t.Errorf("expected a completed session to be released, got %d tracked", n)
+	}
+
+	gs.ForgetSession("forgotten")
+	if n := gs.Health().TrackedSessions; n != 1 {
+		t.Errorf("expected a forgotten session to be released, got %d tracked", n)
+	}
+
+	if n := gs.ForgetIdleSessions(time.Hour); n != 0 {
+		t.Errorf("expected a recently tracked session to be kept, forgot %d", n)
+	}
+	if n := gs.ForgetIdleSessions(0); n != 1 {
+		t.Errorf("expected the abandoned session to be forgotten, forgot %d", n)
+	}
+	if n := gs.Health().TrackedSessions; n != 0 {
+		t.Errorf("expected no tracked sessions, got %d", n)
+	}
+}
+
+func TestForgetIdleSessions_KeepsBusySessions(t *testing.T) {
+	s := newTestStore(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	gs := service.NewGradingService(s, hangingGrader{}, nil, logger, service.DefaultMaxConcurrency)
+
+	gs.TrackSession("session-1")
+	gs.SubmitGrading(service.GradeRequest{SessionID: "session-1", QuestionID: "q1", Question: "Q", ExpectedAnswer: "A", UserAnswer: "A"})
+
+	if n := gs.ForgetIdleSessions(0); n != 0 {
+		t.Errorf("expected a session with grading in flight to be kept, forgot %d", n)
+	}
+
+	// Forgetting it cancels the hanging grading, which is saved as failed.
+	gs.ForgetSession("session-1")
+	done := make(chan struct{})
+	go func() {
+		gs.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("forgetting the session did not cancel its grading")
+	}
+	grades, _ := s.GetGrades(context.Background(), "session-1")
+	if len(grades) != 1 || grades[0].Status != store.GradeStatusFailed {
+		t.Errorf("expected one failed grade, got %+v", grades)
+	}
+}
+
+// fixedGrader returns a canned result and reports a model name.
+type fixedGrader struct{}
+
+func (fixedGrader) GradeAnswer(_ context.Context, _, _, _ string, _ *string, _ string) (string, error) {
+	return `{"score":75,"covered":["a","b"],"missed":["c"]}`, nil
+}
+
+func (fixedGrader) Model() string { return "test-model" }
+
+func (fixedGrader) Ping(context.Context) error { return nil }
+
+// recordingHandler is a slog.Handler that keeps every record it receives.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// gradeOutcome grades one answer and returns the attributes of the
+// "grade completed" record.
+func gradeOutcome(t *testing.T, verbose bool) map[string]slog.Value {
+	t.Helper()
+	s := newTestStore(t)
+
+	h := &recordingHandler{}
+	gs := service.NewGradingService(s, fixedGrader{}, nil, slog.New(h), service.DefaultMaxConcurrency)
+	gs.SetVerboseGradeLogging(verbose)
+
+	gs.TrackSession("session-1")
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:      "session-1",
+		QuestionID:     "question-1",
+		Question:       "What is a goroutine?",
+		ExpectedAnswer: "A lightweight thread",
+		UserAnswer:     "A thread",
+	})
+	gs.WaitForSession(context.Background(), "session-1")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Message != "grade completed" {
+			continue
+		}
+		attrs := make(map[string]slog.Value)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value
+			return true
+		})
+		return attrs
+	}
+	t.Fatal("no grade completed record logged")
+	return nil
+}
+
+func TestGradeOutcomeLogged(t *testing.T) {
+	attrs := gradeOutcome(t, false)
+
+	want := map[string]any{
+		"session_id":    "session-1",
+		"question_id":   "question-1",
+		"score":         int64(75),
+		"covered_count": int64(2),
+		"missed_count":  int64(1),
+		"model":         "test-model",
+		"grader":        "llm",
+	}
+	for k, v := range want {
+		got, ok := attrs[k]
+		if !ok {
+			t.Errorf("missing attribute %q", k)
+			continue
+		}
+		if got.Any() != v {
+			t.Errorf("%s: expected %v, got %v", k, v, got.Any())
+		}
+	}
+	if _, ok := attrs["duration_ms"]; !ok {
+		t.Error("missing attribute \"duration_ms\"")
+	}
+	if _, ok := attrs["user_answer"]; ok {
+		t.Error("user_answer should not be logged unless verbose")
+	}
+}
+
+func TestGradeOutcomeLogged_Verbose(t *testing.T) {
+	attrs := gradeOutcome(t, true)
+	if got := attrs["user_answer"].String(); got != "A thread" {
+		t.Errorf("expected user_answer %q, got %q", "A thread", got)
+	}
+}
+
+func TestGradingLogsRequestID(t *testing.T) {
+	s := newTestStore(t)
+
+	var logs bytes.Buffer
+	gs := service.NewGradingService(s, fixedGrader{}, nil, slog.New(slog.NewJSONHandler(&logs, nil)), service.DefaultMaxConcurrency)
+	gs.TrackSession("session-1")
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:      "session-1",
+		QuestionID:     "question-1",
+		Question:       "What is a goroutine?",
+		ExpectedAnswer: "A lightweight thread",
+		UserAnswer:     "A thread",
+		RequestID:      "request-1",
+	})
+	gs.WaitForSession(context.Background(), "session-1")
+
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if strings.Contains(line, `"msg":"grade completed"`) {
+			if !strings.Contains(line, `"request_id":"request-1"`) {
+				t.Errorf("expected the grade outcome to carry the request ID, got %s", line)
+			}
+			return
+		}
+	}
+	t.Fatalf("no grade completed record logged: %s", logs.String())
+}
+
+// selfCheckGrader records which grading pass was used. Its verification
+// confirms key point 0 and overturns the user's claim on key point 1.
+type selfCheckGrader struct {
+	mu          sync.Mutex
+	verified    [][]int
+	fromScratch int
+}
+
+func (g *selfCheckGrader) GradeAnswer(_ context.Context, _, _, _ string, _ *string, _ string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.fromScratch++
+	return `{"score":100,"covered":["a","b"],"missed":[],"covered_indices":[0,1],"missed_indices":[]}`, nil
+}
+
+func (g *selfCheckGrader) Ping(context.Context) error { return nil }
+
+func (g *selfCheckGrader) VerifyAnswer(_ context.Context, _, _, _ string, selfCovered []int, _ *string, _ string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.verified = append(g.verified, selfCovered)
+	return `{"score":50,"covered":["a"],"missed":["b"],"covered_indices":[0],"missed_indices":[1]}`, nil
+}
+
+func TestSelfCheckedAnswerIsVerified(t *testing.T) {
+	s := newTestStore(t)
+
+	g := &selfCheckGrader{}
+	h := &recordingHandler{}
+	gs := service.NewGradingService(s, g, nil, slog.New(h), service.DefaultMaxConcurrency)
+
+	gs.TrackSession("session-1")
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:      "session-1",
+		QuestionID:     "question-1",
+		Question:       "What is a goroutine?",
+		ExpectedAnswer: "- lightweight\n- runtime managed",
+		UserAnswer:     "A lightweight thread",
+		SelfCovered:    []int{0, 1},
+	})
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:      "session-1",
+		QuestionID:     "question-2",
+		Question:       "What is a channel?",
+		ExpectedAnswer: "- typed\n- synchronises goroutines",
+		UserAnswer:     "A typed pipe",
+	})
+	gs.WaitForSession(context.Background(), "session-1")
+
+	if len(g.verified) != 1 || g.fromScratch != 1 {
+		t.Fatalf("expected one verification and one full grading, got %d and %d", len(g.verified), g.fromScratch)
+	}
+
+	grades, err := s.GetGrades(context.Background(), "session-1")
+	if err != nil {
+		t.Fatalf("GetGrades: %v", err)
+	}
+	for _, gr := range grades {
+		if gr.QuestionID == "question-1" && gr.Score != 50 {
+			t.Errorf("expected the model's score 50 for the self-checked answer, got %d", gr.Score)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	overturned := map[string]int64{}
+	for _, r := range h.records {
+		if r.Message != "grade completed" {
+			continue
+		}
+		var questionID string
+		r.Attrs(func(a slog.Attr) bool {
+			switch a.Key {
+			case "question_id":
+				questionID = a.Value.String()
+			case "self_check_overturned":
+				overturned[questionID] = a.Value.Int64()
+			}
+			return true
+		})
+	}
+	if got, ok := overturned["question-1"]; !ok || got != 1 {
+		t.Errorf("expected one overturned self-mark for question-1, got %v", overturned)
+	}
+	if _, ok := overturned["question-2"]; ok {
+		t.Error("self_check_overturned should only be logged for self-checked answers")
+	}
+}
+
+// cannedGrader returns its own string as the grading JSON.
+type cannedGrader string
+
+func (g cannedGrader) GradeAnswer(_ context.Context, _, _, _ string, _ *string, _ string) (string, error) {
+	return string(g), nil
+}
+
+func (cannedGrader) Ping(context.Context) error { return nil }
+
+func TestGradeOnce_CoercesGraderOutput(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	gs := service.NewGradingService(nil, cannedGrader(`{"score":"85","covered":"a","missed":[],"covered_indices":"0"}`), nil, logger, service.DefaultMaxConcurrency)
+
+	result, err := gs.GradeOnce(context.Background(), service.GradeRequest{Question: "Q", ExpectedAnswer: "a", UserAnswer: "a"})
+	if err != nil {
+		t.Fatalf("GradeOnce: %v", err)
+	}
+	if result.Score != 85 || len(result.Covered) != 1 || len(result.CoveredIndices) != 1 {
+		t.Errorf("expected coerced result, got %+v", result)
+	}
+
+	gs.SetStrictGradeParsing(true)
+	if _, err := gs.GradeOnce(context.Background(), service.GradeRequest{Question: "Q", ExpectedAnswer: "a", UserAnswer: "a"}); err == nil {
+		t.Error("expected strict parsing to fail")
+	}
+}
+
+func TestGradeOnce_ScoringCurve(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	gs := service.NewGradingService(nil, cannedGrader(`{"score":25,"covered":["a"],"missed":["b","c"]}`), nil, logger, service.DefaultMaxConcurrency)
+	req := service.GradeRequest{Question: "Q", ExpectedAnswer: "a", UserAnswer: "a"}
+
+	grade := func(req service.GradeRequest) int {
+		t.Helper()
+		result, err := gs.GradeOnce(context.Background(), req)
+		if err != nil {
+			t.Fatalf("GradeOnce: %v", err)
+		}
+		return result.Score
+	}
+
+	if got := grade(req); got != 25 {
+		t.Errorf("expected the raw score 25 by default, got %d", got)
+	}
+
+	req.ScoringCurve = questionbank.ScoringCurveSqrt
+	if got := grade(req); got != 50 {
+		t.Errorf("expected the bank's sqrt curve to give 50, got %d", got)
+	}
+
+	gs.SetScoringCurve(questionbank.ScoringCurveSqrt)
+	req.ScoringCurve = ""
+	if got := grade(req); got != 50 {
+		t.Errorf("expected the default sqrt curve to give 50, got %d", got)
+	}
+	req.ScoringCurve = questionbank.ScoringCurveLinear
+	if got := grade(req); got != 25 {
+		t.Errorf("expected the bank's linear curve to override the default, got %d", got)
+	}
+}
+
+// rubricGrader records the rubric each answer was graded against; answers
+// graded without one record "".
+type rubricGrader struct {
+	selfCheckGrader
+	rubrics []string
+}
+
+func (g *rubricGrader) GradeAnswer(ctx context.Context, q, e, u string, customPrompt *string, bankType string) (string, error) {
+	g.rubrics = append(g.rubrics, "")
+	return g.selfCheckGrader.GradeAnswer(ctx, q, e, u, customPrompt, bankType)
+}
+
+func (g *rubricGrader) GradeAnswerWithRubric(_ context.Context, _, _, _, rubric string, _ *string, _ string) (string, error) {
+	g.rubrics = append(g.rubrics, rubric)
+	return `{"score":40,"covered":["names the race"],"missed":["proposes a fix"]}`, nil
+}
+
+func TestGradeOnce_Rubric(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	g := &rubricGrader{}
+	gs := service.NewGradingService(nil, g, nil, logger, service.DefaultMaxConcurrency)
+
+	rubric, empty := "- names the race\n- proposes a fix", ""
+	for _, req := range []service.GradeRequest{
+		{Question: "Q", ExpectedAnswer: "- a\n- b", UserAnswer: "a", Rubric: &rubric},
+		// The rubric takes precedence over verifying self-marked key points.
+		{Question: "Q", ExpectedAnswer: "- a\n- b", UserAnswer: "a", Rubric: &rubric, SelfCovered: []int{0}},
+		{Question: "Q", ExpectedAnswer: "- a\n- b", UserAnswer: "a", Rubric: &empty},
+		{Question: "Q", ExpectedAnswer: "- a\n- b", UserAnswer: "a"},
+	} {
+		if _, err := gs.GradeOnce(context.Background(), req); err != nil {
+			t.Fatalf("GradeOnce: %v", err)
+		}
+	}
+
+	if want := []string{rubric, rubric, "", ""}; !reflect.DeepEqual(g.rubrics, want) {
+		t.Errorf("expected rubrics %q, got %q", want, g.rubrics)
+	}
+	if len(g.verified) != 0 {
+		t.Errorf("expected no self-check verification under a rubric, got %v", g.verified)
+	}
+}
+
+// expectedAnswerGrader records the expected answer each answer was graded
+// against.
+type expectedAnswerGrader struct {
+	mu       sync.Mutex
+	expected []string
+}
+
+func (g *expectedAnswerGrader) GradeAnswer(_ context.Context, _, expected, _ string, _ *string, _ string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.expected = append(g.expected, expected)
+	return `{"score":90,"covered":["a"],"missed":[]}`, nil
+}
+
+func (*expectedAnswerGrader) Ping(context.Context) error { return nil }
+
+func TestAlternativeExpectedAnswers(t *testing.T) {
+	s := newTestStore(t)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+	g := &expectedAnswerGrader{}
+	gs := service.NewGradingService(s, g, nil, logger, service.DefaultMaxConcurrency)
+
+	gs.TrackSession("session-1")
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:          "session-1",
+		QuestionID:         "llm",
+		Question:           "Which signal asks a process to stop?",
+		ExpectedAnswer:     "SIGTERM, the polite termination request",
+		AlternativeAnswers: []string{"SIGINT, as sent by Ctrl+C"},
+		UserAnswer:         "Ctrl+C sends SIGINT",
+	})
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:          "session-1",
+		QuestionID:         "exact",
+		Question:           "Name a signal that stops a process.",
+		ExpectedAnswer:     "SIGTERM",
+		AlternativeAnswers: []string{"SIGINT", "SIGKILL"},
+		UserAnswer:         "sigkill",
+		GradingMode:        "exact",
+	})
+	gs.SubmitGrading(service.GradeRequest{
+		SessionID:      "session-1",
+		QuestionID:     "single",
+		Question:       "What is a goroutine?",
+		ExpectedAnswer: "A lightweight thread",
+		UserAnswer:     "A lightweight thread",
+	})
+	gs.WaitForSession(context.Background(), "session-1")
+
+	// Answers are graded concurrently, so in no particular order.
+	sort.Strings(g.expected)
+	if want := []string{"A lightweight thread", "SIGINT, as sent by Ctrl+C"}; !reflect.DeepEqual(g.expected, want) {
+		t.Errorf("expected the model to grade against %q, got %q", want, g.expected)
+	}
+
+	grades, err := s.GetGrades(context.Background(), "session-1")
+	if err != nil {
+		t.Fatalf("GetGrades: %v", err)
+	}
+	matched := map[string]*int{}
+	for _, gr := range grades {
+		matched[gr.QuestionID] = gr.MatchedAnswer
+		if gr.QuestionID == "exact" && gr.Score != 100 {
+			t.Errorf("expected the exact answer to match an alternative, got score %d", gr.Score)
+		}
+	}
+	if m := matched["llm"]; m == nil || *m != 1 {
+		t.Errorf("expected the LLM grade to match answer 1, got %v", m)
+	}
+	if m := matched["exact"]; m == nil || *m != 2 {
+		t.Errorf("expected the exact grade to match answer 2, got %v", m)
+	}
+	if m := matched["single"]; m != nil {
+		t.Errorf("expected no matched answer without alternatives, got %d", *m)
+	}
+}
@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/domain/category"
+	"github.com/remaimber-it/backend/internal/domain/folder"
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// Audit log entity types.
+const (
+	AuditEntityFolder   = "folder"
+	AuditEntityCategory = "category"
+	AuditEntityBank     = "bank"
+	AuditEntityQuestion = "question"
+)
+
+// Audit log actions.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"  // deleted, or moved to the trash for banks and questions
+	AuditActionRestore = "restore" // taken back out of the trash
+	AuditActionPurge   = "purge"   // permanently removed from the trash
+)
+
+// AuditEntry records one mutation made through an AuditingStore.
+type AuditEntry struct {
+	ID         int64
+	EntityType string // one of the AuditEntity constants
+	EntityID   string
+	Action     string // one of the AuditAction constants
+	Actor      string // who made the change, see WithAuditActor; empty when unknown
+	At         time.Time
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context whose mutations are attributed to actor
+// in the audit log.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditActor returns the actor set with WithAuditActor, or "".
+func auditActor(ctx context.Context) string {
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+	return actor
+}
+
+// AuditingStore wraps a Store and appends an audit entry for every
+// successful create, update or delete of a folder, category, bank or
+// question. Practice activity (sessions, grades, stats) is not audited.
+// A failure to write the entry is logged and does not fail the mutation,
+// which has already happened.
+type AuditingStore struct {
+	Store
+	keep   int
+	logger *slog.Logger
+}
+
+// NewAuditingStore wraps s. The log is trimmed to the newest keep entries
+// on every write; keep <= 0 leaves it unbounded.
+func NewAuditingStore(s Store, keep int, logger *slog.Logger) *AuditingStore {
+	return &AuditingStore{Store: s, keep: keep, logger: logger}
+}
+
+// record appends an entry for a mutation that returned err, unless it
+// failed.
+func (a *AuditingStore) record(ctx context.Context, err error, entityType, action string, ids ...string) error {
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	actor := auditActor(ctx)
+	for _, id := range ids {
+		entry := AuditEntry{EntityType: entityType, EntityID: id, Action: action, Actor: actor, At: now}
+		if err := a.Store.AppendAuditEntry(ctx, entry, a.keep); err != nil {
+			a.logger.Error("failed to write audit entry", "entity_type", entityType, "entity_id", id, "action", action, "error", err)
+		}
+	}
+	return nil
+}
+
+// ── Folders ─────────────────────────────────────────────────────────────────
+
+func (a *AuditingStore) SaveFolder(ctx context.Context, f *folder.Folder) error {
+	return a.record(ctx, a.Store.SaveFolder(ctx, f), AuditEntityFolder, AuditActionCreate, f.ID)
+}
+
+func (a *AuditingStore) UpdateFolder(ctx context.Context, f *folder.Folder) error {
+	return a.record(ctx, a.Store.UpdateFolder(ctx, f), AuditEntityFolder, AuditActionUpdate, f.ID)
+}
+
+func (a *AuditingStore) DeleteFolder(ctx context.Context, id string) error {
+	return a.record(ctx, a.Store.DeleteFolder(ctx, id), AuditEntityFolder, AuditActionDelete, id)
+}
+
+// EmptyDeletedFolder is recorded as a purge of the system "Deleted" folder,
+// if there is one.
+func (a *AuditingStore) EmptyDeletedFolder(ctx context.Context) error {
+	folders, err := a.Store.ListFolders(ctx)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, f := range folders {
+		if f.IsSystem {
+			ids = append(ids, f.ID)
+		}
+	}
+	return a.record(ctx, a.Store.EmptyDeletedFolder(ctx), AuditEntityFolder, AuditActionPurge, ids...)
+}
+
+// ── Categories ──────────────────────────────────────────────────────────────
+
+func (a *AuditingStore) SaveCategory(ctx context.Context, cat *category.Category) error {
+	return a.record(ctx, a.Store.SaveCategory(ctx, cat), AuditEntityCategory, AuditActionCreate, cat.ID)
+}
+
+func (a *AuditingStore) UpdateCategory(ctx context.Context, cat *category.Category) error {
+	return a.record(ctx, a.Store.UpdateCategory(ctx, cat), AuditEntityCategory, AuditActionUpdate, cat.ID)
+}
+
+func (a *AuditingStore) UpdateCategoryFolder(ctx context.Context, categoryID string, folderID *string, expectedVersion int) error {
+	err := a.Store.UpdateCategoryFolder(ctx, categoryID, folderID, expectedVersion)
+	return a.record(ctx, err, AuditEntityCategory, AuditActionUpdate, categoryID)
+}
+
+func (a *AuditingStore) SaveCategoryWithBanks(ctx context.Context, cat *category.Category, banks []*questionbank.QuestionBank) error {
+	if err := a.record(ctx, a.Store.SaveCategoryWithBanks(ctx, cat, banks), AuditEntityCategory, AuditActionCreate, cat.ID); err != nil {
+		return err
+	}
+	for _, bank := range banks {
+		a.record(ctx, nil, AuditEntityBank, AuditActionCreate, bank.ID)
+		a.record(ctx, nil, AuditEntityQuestion, AuditActionCreate, questionIDs(bank.Questions)...)
+	}
+	return nil
+}
+
+func (a *AuditingStore) ReorderCategories(ctx context.Context, ids []string) error {
+	return a.record(ctx, a.Store.ReorderCategories(ctx, ids), AuditEntityCategory, AuditActionUpdate, ids...)
+}
+
+func (a *AuditingStore) DeleteCategory(ctx context.Context, id string) error {
+	return a.record(ctx, a.Store.DeleteCategory(ctx, id), AuditEntityCategory, AuditActionDelete, id)
+}
+
+func (a *AuditingStore) DeleteCategoryReassigning(ctx context.Context, id, targetID string) error {
+	return a.record(ctx, a.Store.DeleteCategoryReassigning(ctx, id, targetID), AuditEntityCategory, AuditActionDelete, id)
+}
+
+// ── Banks ───────────────────────────────────────────────────────────────────
+
+func (a *AuditingStore) SaveBank(ctx context.Context, bank *questionbank.QuestionBank) error {
+	return a.record(ctx, a.Store.SaveBank(ctx, bank), AuditEntityBank, AuditActionCreate, bank.ID)
+}
+
+func (a *AuditingStore) SaveBankWithQuestions(ctx context.Context, bank *questionbank.QuestionBank) error {
+	if err := a.record(ctx, a.Store.SaveBankWithQuestions(ctx, bank), AuditEntityBank, AuditActionCreate, bank.ID); err != nil {
+		return err
+	}
+	a.record(ctx, nil, AuditEntityQuestion, AuditActionCreate, questionIDs(bank.Questions)...)
+	return nil
+}
+
+func (a *AuditingStore) UpdateBankCategory(ctx context.Context, bankID string, categoryID *string, expectedVersion int) error {
+	err := a.Store.UpdateBankCategory(ctx, bankID, categoryID, expectedVersion)
+	return a.record(ctx, err, AuditEntityBank, AuditActionUpdate, bankID)
+}
+
+func (a *AuditingStore) UpdateBankTags(ctx context.Context, bankIDs []string, add, remove []string) (*BankTagUpdate, error) {
+	update, err := a.Store.UpdateBankTags(ctx, bankIDs, add, remove)
+	return update, a.record(ctx, err, AuditEntityBank, AuditActionUpdate, bankIDs...)
+}
+
+func (a *AuditingStore) DeleteBank(ctx context.Context, id string) error {
+	return a.record(ctx, a.Store.DeleteBank(ctx, id), AuditEntityBank, AuditActionDelete, id)
+}
+
+func (a *AuditingStore) RestoreBank(ctx context.Context, id string) error {
+	return a.record(ctx, a.Store.RestoreBank(ctx, id), AuditEntityBank, AuditActionRestore, id)
+}
+
+func (a *AuditingStore) PurgeBank(ctx context.Context, id string) error {
+	return a.record(ctx, a.Store.PurgeBank(ctx, id), AuditEntityBank, AuditActionPurge, id)
+}
+
+// ── Questions ───────────────────────────────────────────────────────────────
+
+func (a *AuditingStore) AddQuestion(ctx context.Context, bankID string, question questionbank.Question) error {
+	return a.record(ctx, a.Store.AddQuestion(ctx, bankID, question), AuditEntityQuestion, AuditActionCreate, question.ID)
+}
+
+func (a *AuditingStore) AddQuestions(ctx context.Context, bankID string, questions []questionbank.Question) error {
+	err := a.Store.AddQuestions(ctx, bankID, questions)
+	return a.record(ctx, err, AuditEntityQuestion, AuditActionCreate, questionIDs(questions)...)
+}
+
+func (a *AuditingStore) MoveQuestion(ctx context.Context, questionID, targetBankID string) error {
+	err := a.Store.MoveQuestion(ctx, questionID, targetBankID)
+	return a.record(ctx, err, AuditEntityQuestion, AuditActionUpdate, questionID)
+}
+
+func (a *AuditingStore) CopyQuestion(ctx context.Context, questionID, targetBankID, newID string) error {
+	err := a.Store.CopyQuestion(ctx, questionID, targetBankID, newID)
+	return a.record(ctx, err, AuditEntityQuestion, AuditActionCreate, newID)
+}
+
+func (a *AuditingStore) UpdateQuestion(ctx context.Context, question questionbank.Question) error {
+	return a.record(ctx, a.Store.UpdateQuestion(ctx, question), AuditEntityQuestion, AuditActionUpdate, question.ID)
+}
+
+func (a *AuditingStore) UpdateQuestionDifficulty(ctx context.Context, questionID string, difficulty questionbank.Difficulty) error {
+	err := a.Store.UpdateQuestionDifficulty(ctx, questionID, difficulty)
+	return a.record(ctx, err, AuditEntityQuestion, AuditActionUpdate, questionID)
+}
+
+func (a *AuditingStore) SetQuestionTags(ctx context.Context, bankID, questionID string, tags []string) error {
+	err := a.Store.SetQuestionTags(ctx, bankID, questionID, tags)
+	return a.record(ctx, err, AuditEntityQuestion, AuditActionUpdate, questionID)
+}
+
+func (a *AuditingStore) DeleteQuestion(ctx context.Context, id string) error {
+	return a.record(ctx, a.Store.DeleteQuestion(ctx, id), AuditEntityQuestion, AuditActionDelete, id)
+}
+
+func (a *AuditingStore) PurgeQuestion(ctx context.Context, id string) error {
+	return a.record(ctx, a.Store.PurgeQuestion(ctx, id), AuditEntityQuestion, AuditActionPurge, id)
+}
+
+func questionIDs(questions []questionbank.Question) []string {
+	ids := make([]string, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	return ids
+}
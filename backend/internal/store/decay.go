@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// decayMastery sets the mastery of every answered question to the mastery
+// its scores give (see QuestionStats.CalculateMastery), decayed by the time
+// since last_answered_at (see questionbank.DecayMastery). Decay starts from
+// that mastery, not the stored one, so sweeping twice does not decay twice.
+// update is the driver's "set mastery of question_id" statement, taking
+// mastery then question ID. It returns how many questions changed.
+func decayMastery(ctx context.Context, db *sql.DB, update string, halfLife time.Duration, now time.Time) (int, error) {
+	type row struct {
+		questionID string
+		mastery    int
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT question_id, times_answered, total_score, latest_score, last_answered_at, mastery
+		FROM question_stats
+		WHERE times_answered > 0 AND last_answered_at > 0
+	`)
+	if err != nil {
+		return 0, err
+	}
+	var changed []row
+	for rows.Next() {
+		var (
+			r            row
+			stats        questionbank.QuestionStats
+			lastAnswered int64
+		)
+		if err := rows.Scan(&r.questionID, &stats.TimesAnswered, &stats.TotalScore, &stats.LatestScore, &lastAnswered, &r.mastery); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		age := now.Sub(time.Unix(0, lastAnswered))
+		decayed := questionbank.DecayMastery(stats.CalculateMastery(), age, halfLife)
+		if decayed != r.mastery {
+			r.mastery = decayed
+			changed = append(changed, r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, r := range changed {
+		if _, err := tx.ExecContext(ctx, update, r.mastery, r.questionID); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(changed), nil
+}
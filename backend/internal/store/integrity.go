@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// OrphanCount is the number of rows of Table whose parent row in Parent no
+// longer exists.
+type OrphanCount struct {
+	Table  string
+	Parent string
+	Count  int
+}
+
+// orphanCheck selects the rows of table whose parent is gone. where is
+// plain SQL that both SQLite and Postgres accept.
+type orphanCheck struct {
+	table, parent, where string
+}
+
+// orphanChecks lists every parent/child link. Children come after their
+// parents, so deleting in order also removes rows that only become orphans
+// once their orphaned parents are deleted.
+var orphanChecks = []orphanCheck{
+	{"questions", "banks", "NOT EXISTS (SELECT 1 FROM banks b WHERE b.id = questions.bank_id)"},
+	{"question_stats", "questions", "NOT EXISTS (SELECT 1 FROM questions q WHERE q.id = question_stats.question_id)"},
+	{"question_tags", "questions", "NOT EXISTS (SELECT 1 FROM questions q WHERE q.id = question_tags.question_id)"},
+	{"bank_tags", "banks", "NOT EXISTS (SELECT 1 FROM banks b WHERE b.id = bank_tags.bank_id)"},
+	{"review_log", "banks", "NOT EXISTS (SELECT 1 FROM banks b WHERE b.id = review_log.bank_id)"},
+	{"session_questions", "sessions", "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.id = session_questions.session_id)"},
+	{"grades", "sessions", "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.id = grades.session_id)"},
+	{"session_answers", "sessions", "NOT EXISTS (SELECT 1 FROM sessions s WHERE s.id = session_answers.session_id)"},
+}
+
+// countOrphans counts the orphaned rows of every check, in check order.
+func countOrphans(ctx context.Context, db *sql.DB) ([]OrphanCount, error) {
+	counts := make([]OrphanCount, len(orphanChecks))
+	for i, c := range orphanChecks {
+		counts[i] = OrphanCount{Table: c.table, Parent: c.parent}
+		err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", c.table, c.where)).Scan(&counts[i].Count)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// deleteOrphans deletes the orphaned rows of every check in one
+// transaction and returns how many rows each check removed.
+func deleteOrphans(ctx context.Context, db *sql.DB) ([]OrphanCount, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	counts := make([]OrphanCount, len(orphanChecks))
+	for i, c := range orphanChecks {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", c.table, c.where))
+		if err != nil {
+			return nil, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		counts[i] = OrphanCount{Table: c.table, Parent: c.parent, Count: int(n)}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,2599 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/remaimber-it/backend/internal/domain/category"
+	practicesession "github.com/remaimber-it/backend/internal/domain/practice_session"
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// pgSchema mirrors the SQLite schema, including every column its migrations
+// add. folders, banks and questions carry a seq column so listings keep
+// insertion order, which SQLite provides through rowid.
+// sessions.bank_id has no foreign key: multi-bank sessions store "multi".
+const pgSchema = `
+CREATE TABLE IF NOT EXISTS folders (
+    id TEXT PRIMARY KEY,
+    seq BIGSERIAL,
+    name TEXT NOT NULL,
+    is_system BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    folder_id TEXT REFERENCES folders(id) ON DELETE SET NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS banks (
+    id TEXT PRIMARY KEY,
+    seq BIGSERIAL,
+    subject TEXT NOT NULL,
+    category_id TEXT REFERENCES categories(id) ON DELETE SET NULL,
+    grading_prompt TEXT,
+    rubric TEXT,
+    bank_type TEXT NOT NULL DEFAULT 'theory',
+    language TEXT,
+    grading_mode TEXT NOT NULL DEFAULT 'llm',
+    scoring_curve TEXT NOT NULL DEFAULT '',
+    preserve_order BOOLEAN NOT NULL DEFAULT FALSE,
+    exact_case_sensitive BOOLEAN NOT NULL DEFAULT FALSE,
+    exact_whitespace_sensitive BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at BIGINT,
+    created_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS questions (
+    id TEXT PRIMARY KEY,
+    seq BIGSERIAL,
+    bank_id TEXT NOT NULL REFERENCES banks(id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    expected_answer TEXT NOT NULL,
+    grading_prompt TEXT,
+    rubric TEXT,
+    grading_mode TEXT,
+    difficulty TEXT NOT NULL DEFAULT 'medium',
+    position INTEGER NOT NULL DEFAULT 0,
+    deleted_at BIGINT,
+    alternative_answers TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    bank_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    focus_on_weak BOOLEAN NOT NULL DEFAULT FALSE,
+    max_duration_min INTEGER,
+    started_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS session_questions (
+    session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    question_id TEXT NOT NULL,
+    question_subject TEXT NOT NULL,
+    expected_answer TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    bank_id TEXT,
+    PRIMARY KEY (session_id, question_id)
+);
+
+CREATE TABLE IF NOT EXISTS grades (
+    id BIGSERIAL PRIMARY KEY,
+    session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    question_id TEXT NOT NULL,
+    score INTEGER NOT NULL,
+    covered TEXT NOT NULL,
+    missed TEXT NOT NULL,
+    covered_indices TEXT NOT NULL DEFAULT '[]',
+    missed_indices TEXT NOT NULL DEFAULT '[]',
+    user_answer TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'success',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    raw_response TEXT NOT NULL DEFAULT '',
+    failed_at BIGINT NOT NULL DEFAULT 0,
+    graded_at BIGINT NOT NULL DEFAULT 0,
+    model TEXT NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    matched_answer INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS session_answers (
+    session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    question_id TEXT NOT NULL,
+    user_answer TEXT NOT NULL,
+    self_covered TEXT,
+    answer_language TEXT NOT NULL DEFAULT '',
+    submitted_at BIGINT NOT NULL,
+    grade_status TEXT NOT NULL DEFAULT 'pending',
+    PRIMARY KEY (session_id, question_id)
+);
+
+CREATE TABLE IF NOT EXISTS question_stats (
+    question_id TEXT PRIMARY KEY REFERENCES questions(id) ON DELETE CASCADE,
+    times_answered INTEGER NOT NULL DEFAULT 0,
+    times_correct INTEGER NOT NULL DEFAULT 0,
+    total_score INTEGER NOT NULL DEFAULT 0,
+    latest_score INTEGER NOT NULL DEFAULT 0,
+    mastery INTEGER NOT NULL DEFAULT 0,
+    last_answered_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS bank_tags (
+    bank_id TEXT NOT NULL REFERENCES banks(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (bank_id, tag)
+);
+
+CREATE TABLE IF NOT EXISTS question_tags (
+    question_id TEXT NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (question_id, tag)
+);
+
+CREATE TABLE IF NOT EXISTS review_log (
+    id BIGSERIAL PRIMARY KEY,
+    bank_id TEXT NOT NULL REFERENCES banks(id) ON DELETE CASCADE,
+    reviewed_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_grades_session_question ON grades (session_id, question_id);
+`
+
+type PostgresStore struct {
+	db            *sql.DB
+	passThreshold int // minimum score counted in times_correct and streaks
+}
+
+// Compile-time check: *PostgresStore must satisfy the Store interface.
+var _ Store = (*PostgresStore)(nil)
+
+// NewPostgres connects to the database at dsn (a postgres:// URL or a
+// key=value connection string) and brings its schema up to date.
+func NewPostgres(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if _, err := db.Exec(pgSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Databases created by an older build may predate these columns; the
+	// list mirrors the SQLite migrations in NewSQLite.
+	migrations := []struct{ table, column, definition string }{
+		{"grades", "status", "TEXT NOT NULL DEFAULT 'success'"},
+		{"sessions", "status", "TEXT NOT NULL DEFAULT 'active'"},
+		{"categories", "folder_id", "TEXT REFERENCES folders(id) ON DELETE SET NULL"},
+		{"folders", "is_system", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"folders", "version", "INTEGER NOT NULL DEFAULT 1"},
+		{"session_questions", "bank_id", "TEXT"},
+		{"questions", "grading_prompt", "TEXT"},
+		{"banks", "rubric", "TEXT"},
+		{"questions", "rubric", "TEXT"},
+		{"banks", "deleted_at", "BIGINT"},     // unix nanoseconds
+		{"questions", "deleted_at", "BIGINT"}, // unix nanoseconds
+		{"categories", "sort_order", "INTEGER NOT NULL DEFAULT 0"},
+		{"banks", "grading_mode", "TEXT NOT NULL DEFAULT 'llm'"},
+		{"banks", "scoring_curve", "TEXT NOT NULL DEFAULT ''"},
+		{"banks", "exact_case_sensitive", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"banks", "exact_whitespace_sensitive", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"questions", "grading_mode", "TEXT"},
+		{"questions", "difficulty", "TEXT NOT NULL DEFAULT 'medium'"},
+		{"grades", "covered_indices", "TEXT NOT NULL DEFAULT '[]'"},
+		{"grades", "missed_indices", "TEXT NOT NULL DEFAULT '[]'"},
+		{"sessions", "focus_on_weak", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"sessions", "max_duration_min", "INTEGER"},
+		{"sessions", "started_at", "BIGINT NOT NULL DEFAULT 0"}, // unix nanoseconds
+		{"categories", "version", "INTEGER NOT NULL DEFAULT 1"},
+		{"banks", "version", "INTEGER NOT NULL DEFAULT 1"},
+		{"banks", "created_at", "BIGINT NOT NULL DEFAULT 0"}, // unix nanoseconds
+		{"grades", "failure_reason", "TEXT NOT NULL DEFAULT ''"},
+		{"grades", "raw_response", "TEXT NOT NULL DEFAULT ''"},
+		{"grades", "failed_at", "BIGINT NOT NULL DEFAULT 0"}, // unix nanoseconds
+		{"grades", "graded_at", "BIGINT NOT NULL DEFAULT 0"}, // unix nanoseconds
+		{"grades", "model", "TEXT NOT NULL DEFAULT ''"},
+		{"grades", "duration_ms", "BIGINT NOT NULL DEFAULT 0"},
+		{"question_stats", "last_answered_at", "BIGINT NOT NULL DEFAULT 0"}, // unix nanoseconds
+		{"questions", "position", "INTEGER NOT NULL DEFAULT 0"},
+		{"banks", "preserve_order", "BOOLEAN NOT NULL DEFAULT FALSE"},
+		{"sessions", "created_at", "BIGINT NOT NULL DEFAULT 0"}, // unix nanoseconds
+		{"questions", "alternative_answers", "TEXT NOT NULL DEFAULT '[]'"},
+		{"grades", "matched_answer", "INTEGER"},
+	}
+	for _, m := range migrations {
+		if err := addPgColumnIfNotExists(db, m.table, m.column, m.definition); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	if err := migratePgForSearch(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &PostgresStore{
+		db:            db,
+		passThreshold: questionbank.PassThreshold,
+	}, nil
+}
+
+// addPgColumnIfNotExists is the Postgres counterpart of addColumnIfNotExists.
+// Postgres can skip existing columns itself, so unlike SQLite any error is real.
+func addPgColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	if !validIdentifier(table) || !validIdentifier(column) {
+		panic("addPgColumnIfNotExists: invalid identifier: table=" + table + " column=" + column)
+	}
+	_, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS " + column + " " + definition)
+	return err
+}
+
+// versionedUpdateResult interprets the result of an UPDATE guarded by
+// "($n = 0 OR version = $m)": no affected rows means the row is missing or
+// its version moved on.
+func (s *PostgresStore) versionedUpdateResult(ctx context.Context, result sql.Result, table, id string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// ============================================================================
+// Categories
+// ============================================================================
+
+func (s *PostgresStore) SaveCategory(ctx context.Context, cat *category.Category) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO categories (id, name, folder_id, sort_order) VALUES ($1, $2, $3, (SELECT COALESCE(MAX(sort_order)+1, 0) FROM categories))",
+		cat.ID, cat.Name, cat.FolderID,
+	)
+	return err
+}
+
+func (s *PostgresStore) GetCategory(ctx context.Context, id string) (*category.Category, error) {
+	var cat category.Category
+	var folderID sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, name, folder_id, sort_order, version FROM categories WHERE id = $1", id,
+	).Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder, &cat.Version)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if folderID.Valid {
+		cat.FolderID = &folderID.String
+	}
+	return &cat, nil
+}
+
+func (s *PostgresStore) ListCategories(ctx context.Context) ([]*category.Category, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, folder_id, sort_order, version FROM categories ORDER BY sort_order ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*category.Category
+	for rows.Next() {
+		var cat category.Category
+		var folderID sql.NullString
+		if err := rows.Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder, &cat.Version); err != nil {
+			return nil, err
+		}
+		if folderID.Valid {
+			cat.FolderID = &folderID.String
+		}
+		categories = append(categories, &cat)
+	}
+	return categories, nil
+}
+
+func (s *PostgresStore) SaveCategoryWithBanks(ctx context.Context, cat *category.Category, banks []*questionbank.QuestionBank) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO categories (id, name, folder_id, sort_order) VALUES ($1, $2, $3, (SELECT COALESCE(MAX(sort_order)+1, 0) FROM categories))",
+		cat.ID, cat.Name, cat.FolderID,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, bank := range banks {
+		if err := s.insertBank(ctx, tx, bank); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) ReorderCategories(ctx context.Context, ids []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, "UPDATE categories SET sort_order = $1 WHERE id = $2", i, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) UpdateCategory(ctx context.Context, cat *category.Category) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE categories SET name = $1, folder_id = $2, version = version + 1 WHERE id = $3 AND ($4 = 0 OR version = $5)",
+		cat.Name, cat.FolderID, cat.ID, cat.Version, cat.Version,
+	)
+	if err != nil {
+		return err
+	}
+	return s.versionedUpdateResult(ctx, result, "categories", cat.ID)
+}
+
+func (s *PostgresStore) DeleteCategory(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// First, delete question stats for questions in banks of this category
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM question_stats 
+		WHERE question_id IN (
+			SELECT q.id FROM questions q
+			JOIN banks b ON q.bank_id = b.id
+			WHERE b.category_id = $1
+		)
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM question_tags
+		WHERE question_id IN (
+			SELECT q.id FROM questions q
+			JOIN banks b ON q.bank_id = b.id
+			WHERE b.category_id = $1
+		)
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	// Delete all questions belonging to banks in this category
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM questions 
+		WHERE bank_id IN (SELECT id FROM banks WHERE category_id = $1)
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM bank_tags WHERE bank_id IN (SELECT id FROM banks WHERE category_id = $1)", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM review_log WHERE bank_id IN (SELECT id FROM banks WHERE category_id = $1)", id)
+	if err != nil {
+		return err
+	}
+
+	// Then, delete all banks in this category
+	_, err = tx.ExecContext(ctx, "DELETE FROM banks WHERE category_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	// Finally, delete the category itself
+	result, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// DeleteCategoryReassigning moves every bank in the category to targetID
+// and then deletes the now-empty category, keeping questions and stats.
+// The caller is responsible for checking that targetID exists.
+func (s *PostgresStore) DeleteCategoryReassigning(ctx context.Context, id, targetID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE banks SET category_id = $1, version = version + 1 WHERE category_id = $2", targetID, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// ============================================================================
+// Banks
+// ============================================================================
+
+func (s *PostgresStore) SaveBank(ctx context.Context, bank *questionbank.QuestionBank) error {
+	gradingMode := bank.GradingMode
+	if gradingMode == "" {
+		gradingMode = questionbank.GradingModeLLM
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO banks (id, subject, category_id, bank_type, language, grading_prompt, rubric, grading_mode, exact_case_sensitive, exact_whitespace_sensitive, scoring_curve, created_at, preserve_order) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		bank.ID, bank.Subject, bank.CategoryID, bank.BankType, bank.Language, bank.GradingPrompt, bank.Rubric,
+		gradingMode, bank.ExactMatch.CaseSensitive, bank.ExactMatch.WhitespaceSensitive, bank.ScoringCurve, createdAtNanos(bank.CreatedAt), bank.PreserveOrder,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := s.insertBankTags(ctx, tx, bank.ID, bank.Tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) SaveBankWithQuestions(ctx context.Context, bank *questionbank.QuestionBank) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.insertBank(ctx, tx, bank); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertBank inserts a bank with its tags and questions inside tx.
+func (s *PostgresStore) insertBank(ctx context.Context, tx *sql.Tx, bank *questionbank.QuestionBank) error {
+	gradingMode := bank.GradingMode
+	if gradingMode == "" {
+		gradingMode = questionbank.GradingModeLLM
+	}
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO banks (id, subject, category_id, bank_type, language, grading_prompt, rubric, grading_mode, exact_case_sensitive, exact_whitespace_sensitive, scoring_curve, created_at, preserve_order) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		bank.ID, bank.Subject, bank.CategoryID, bank.BankType, bank.Language, bank.GradingPrompt, bank.Rubric,
+		gradingMode, bank.ExactMatch.CaseSensitive, bank.ExactMatch.WhitespaceSensitive, bank.ScoringCurve, createdAtNanos(bank.CreatedAt), bank.PreserveOrder,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := s.insertBankTags(ctx, tx, bank.ID, bank.Tags); err != nil {
+		return err
+	}
+	for _, q := range bank.Questions {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = $2))",
+			q.ID, bank.ID, q.Subject, q.ExpectedAnswer, q.GradingPrompt, q.Rubric, q.GradingMode, q.Difficulty.OrDefault(), alternativeAnswersJSON(q.AlternativeAnswers),
+		)
+		if err != nil {
+			return err
+		}
+		if err := s.insertQuestionTags(ctx, tx, q.ID, q.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetBank(ctx context.Context, id string) (*questionbank.QuestionBank, error) {
+	var bank questionbank.QuestionBank
+	var categoryID sql.NullString
+	var bankType sql.NullString
+	var language sql.NullString
+	var gradingPrompt sql.NullString
+	var rubric sql.NullString
+	var gradingMode string
+	var createdAt int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, subject, category_id, bank_type, language, grading_prompt, rubric, grading_mode, exact_case_sensitive, exact_whitespace_sensitive, scoring_curve, preserve_order, version, created_at FROM banks WHERE id = $1 AND deleted_at IS NULL", id,
+	).Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &gradingPrompt, &rubric,
+		&gradingMode, &bank.ExactMatch.CaseSensitive, &bank.ExactMatch.WhitespaceSensitive, &bank.ScoringCurve, &bank.PreserveOrder, &bank.Version, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if categoryID.Valid {
+		bank.CategoryID = &categoryID.String
+	}
+	if bankType.Valid {
+		bank.BankType = questionbank.BankType(bankType.String)
+	} else {
+		bank.BankType = questionbank.BankTypeTheory
+	}
+	if language.Valid {
+		bank.Language = &language.String
+	}
+	if gradingPrompt.Valid {
+		bank.GradingPrompt = &gradingPrompt.String
+	}
+	if rubric.Valid {
+		bank.Rubric = &rubric.String
+	}
+	bank.GradingMode = questionbank.GradingMode(gradingMode)
+	if createdAt > 0 {
+		bank.CreatedAt = time.Unix(0, createdAt).UTC()
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers FROM questions WHERE bank_id = $1 AND deleted_at IS NULL ORDER BY position, seq", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q questionbank.Question
+		var gradingPrompt sql.NullString
+		var rubric sql.NullString
+		var gradingMode sql.NullString
+		var alternatives string
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &gradingPrompt, &rubric, &gradingMode, &q.Difficulty, &alternatives); err != nil {
+			return nil, err
+		}
+		q.AlternativeAnswers = parseAlternativeAnswers(alternatives)
+		if gradingPrompt.Valid {
+			q.GradingPrompt = &gradingPrompt.String
+		}
+		if rubric.Valid {
+			q.Rubric = &rubric.String
+		}
+		if gradingMode.Valid {
+			mode := questionbank.GradingMode(gradingMode.String)
+			q.GradingMode = &mode
+		}
+		bank.Questions = append(bank.Questions, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	bank.Tags, err = s.bankTags(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	questionTags, err := s.bankQuestionTags(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bank.Questions {
+		bank.Questions[i].Tags = questionTags[bank.Questions[i].ID]
+		if bank.Questions[i].Tags == nil {
+			bank.Questions[i].Tags = []string{}
+		}
+	}
+
+	return &bank, nil
+}
+
+func (s *PostgresStore) ListBanks(ctx context.Context) ([]*questionbank.QuestionBank, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, category_id, bank_type, language, version FROM banks WHERE deleted_at IS NULL ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []*questionbank.QuestionBank
+	for rows.Next() {
+		var bank questionbank.QuestionBank
+		var categoryID sql.NullString
+		var bankType sql.NullString
+		var language sql.NullString
+		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &bank.Version); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			bank.CategoryID = &categoryID.String
+		}
+		if bankType.Valid {
+			bank.BankType = questionbank.BankType(bankType.String)
+		} else {
+			bank.BankType = questionbank.BankTypeTheory
+		}
+		if language.Valid {
+			bank.Language = &language.String
+		}
+		banks = append(banks, &bank)
+	}
+	return banks, nil
+}
+
+func (s *PostgresStore) ListBanksWithCounts(ctx context.Context) ([]*BankWithCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.id, b.subject, b.category_id, b.bank_type, b.language, b.version,
+		       (SELECT COUNT(*) FROM questions q WHERE q.bank_id = b.id AND q.deleted_at IS NULL) as question_count
+		FROM banks b
+		WHERE b.deleted_at IS NULL
+		ORDER BY b.seq
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []*BankWithCount
+	for rows.Next() {
+		var bank BankWithCount
+		var categoryID sql.NullString
+		var bankType sql.NullString
+		var language sql.NullString
+		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &bank.Version, &bank.QuestionCount); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			bank.CategoryID = &categoryID.String
+		}
+		if bankType.Valid {
+			bank.BankType = bankType.String
+		} else {
+			bank.BankType = "theory"
+		}
+		if language.Valid {
+			bank.Language = &language.String
+		}
+		banks = append(banks, &bank)
+	}
+	return banks, nil
+}
+
+// ListBanksWithMastery returns every bank with its question count and
+// mastery, computed in a single aggregate query.
+func (s *PostgresStore) ListBanksWithMastery(ctx context.Context, scope MasteryScope) ([]*BankWithMastery, error) {
+	return s.listBanksWithMastery(ctx, scope, "")
+}
+
+// ListBanksByCategoryWithMastery is ListBanksWithMastery restricted to one
+// category.
+func (s *PostgresStore) ListBanksByCategoryWithMastery(ctx context.Context, categoryID string, scope MasteryScope) ([]*BankWithMastery, error) {
+	return s.listBanksWithMastery(ctx, scope, "AND b.category_id = $1", categoryID)
+}
+
+// listBanksWithMastery computes mastery with the same expression as
+// GetBankMasteryScoped; banks without counted questions get 0.
+func (s *PostgresStore) listBanksWithMastery(ctx context.Context, scope MasteryScope, where string, args ...any) ([]*BankWithMastery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.id, b.subject, b.category_id, b.bank_type, b.language, b.version,
+		       COUNT(q.id),
+		       COALESCE(CAST(TRUNC(`+bankMasterySQL(scope)+`) AS INTEGER), 0)
+		FROM banks b
+		LEFT JOIN questions q ON q.bank_id = b.id AND q.deleted_at IS NULL
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		WHERE b.deleted_at IS NULL `+where+`
+		GROUP BY b.id
+		ORDER BY b.seq
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []*BankWithMastery
+	for rows.Next() {
+		var bank BankWithMastery
+		var categoryID sql.NullString
+		var bankType sql.NullString
+		var language sql.NullString
+		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &bank.Version, &bank.QuestionCount, &bank.Mastery); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			bank.CategoryID = &categoryID.String
+		}
+		if bankType.Valid {
+			bank.BankType = bankType.String
+		} else {
+			bank.BankType = "theory"
+		}
+		if language.Valid {
+			bank.Language = &language.String
+		}
+		banks = append(banks, &bank)
+	}
+	return banks, rows.Err()
+}
+
+func (s *PostgresStore) ListBanksByCategory(ctx context.Context, categoryID string) ([]*questionbank.QuestionBank, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, category_id, bank_type, language, version FROM banks WHERE category_id = $1 AND deleted_at IS NULL ORDER BY seq", categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []*questionbank.QuestionBank
+	for rows.Next() {
+		var bank questionbank.QuestionBank
+		var catID sql.NullString
+		var bankType sql.NullString
+		var language sql.NullString
+		if err := rows.Scan(&bank.ID, &bank.Subject, &catID, &bankType, &language, &bank.Version); err != nil {
+			return nil, err
+		}
+		if catID.Valid {
+			bank.CategoryID = &catID.String
+		}
+		if bankType.Valid {
+			bank.BankType = questionbank.BankType(bankType.String)
+		} else {
+			bank.BankType = questionbank.BankTypeTheory
+		}
+		if language.Valid {
+			bank.Language = &language.String
+		}
+		banks = append(banks, &bank)
+	}
+	return banks, nil
+}
+
+// UpdateBankCategory moves a bank to another category. A non-zero
+// expectedVersion must match the bank's current version.
+func (s *PostgresStore) UpdateBankCategory(ctx context.Context, bankID string, categoryID *string, expectedVersion int) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE banks SET category_id = $1, version = version + 1 WHERE id = $2 AND ($3 = 0 OR version = $4)",
+		categoryID, bankID, expectedVersion, expectedVersion,
+	)
+	if err != nil {
+		return err
+	}
+	return s.versionedUpdateResult(ctx, result, "banks", bankID)
+}
+
+// PurgeBank permanently deletes a bank, in the trash or not, with its
+// questions, tags and question stats.
+func (s *PostgresStore) PurgeBank(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Delete question stats for questions in this bank
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM question_stats 
+		WHERE question_id IN (SELECT id FROM questions WHERE bank_id = $1)
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM question_tags WHERE question_id IN (SELECT id FROM questions WHERE bank_id = $1)", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM questions WHERE bank_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM bank_tags WHERE bank_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM review_log WHERE bank_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM banks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// LogBankReview records that a bank was read through at the given time.
+func (s *PostgresStore) LogBankReview(ctx context.Context, bankID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO review_log (bank_id, reviewed_at) VALUES ($1, $2)", bankID, at.UnixNano())
+	return err
+}
+
+// ListBankReviews returns up to limit review times of a bank, newest first.
+func (s *PostgresStore) ListBankReviews(ctx context.Context, bankID string, limit int) ([]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT reviewed_at FROM review_log WHERE bank_id = $1 ORDER BY reviewed_at DESC, id DESC LIMIT $2",
+		bankID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []time.Time{}
+	for rows.Next() {
+		var at int64
+		if err := rows.Scan(&at); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, time.Unix(0, at))
+	}
+	return reviews, rows.Err()
+}
+
+func (s *PostgresStore) UpdateBankTags(ctx context.Context, bankIDs []string, add, remove []string) (*BankTagUpdate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	update := &BankTagUpdate{Tags: make(map[string][]string, len(bankIDs))}
+	for _, bankID := range bankIDs {
+		if _, seen := update.Tags[bankID]; seen {
+			continue
+		}
+
+		var exists int
+		err := tx.QueryRowContext(ctx, "SELECT 1 FROM banks WHERE id = $1 AND deleted_at IS NULL", bankID).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		removed := 0
+		for _, tag := range remove {
+			result, err := tx.ExecContext(ctx, "DELETE FROM bank_tags WHERE bank_id = $1 AND tag = $2", bankID, tag)
+			if err != nil {
+				return nil, err
+			}
+			n, err := result.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			removed += int(n)
+		}
+
+		added, err := s.insertBankTags(ctx, tx, bankID, add)
+		if err != nil {
+			return nil, err
+		}
+
+		// Tags are part of the bank, so a change bumps its version.
+		if added+removed > 0 {
+			if _, err := tx.ExecContext(ctx, "UPDATE banks SET version = version + 1 WHERE id = $1", bankID); err != nil {
+				return nil, err
+			}
+		}
+		update.Added += added
+		update.Removed += removed
+
+		update.Tags[bankID], err = s.bankTags(ctx, tx, bankID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// insertBankTags adds tags to a bank, ignoring those it already has, and
+// returns how many were new.
+func (s *PostgresStore) insertBankTags(ctx context.Context, tx *sql.Tx, bankID string, tags []string) (int, error) {
+	added := 0
+	for _, tag := range tags {
+		result, err := tx.ExecContext(ctx,
+			"INSERT INTO bank_tags (bank_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING", bankID, tag)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += int(n)
+	}
+	return added, nil
+}
+
+// bankTags returns a bank's tags in alphabetical order.
+func (s *PostgresStore) bankTags(ctx context.Context, q queryer, bankID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT tag FROM bank_tags WHERE bank_id = $1 ORDER BY tag", bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetQuestion returns a single question, scoped to its bank.
+// Returns ErrNotFound if the question does not exist in that bank.
+func (s *PostgresStore) GetQuestion(ctx context.Context, bankID, questionID string) (*questionbank.Question, error) {
+	var q questionbank.Question
+	var gradingPrompt sql.NullString
+	var rubric sql.NullString
+	var gradingMode sql.NullString
+	var alternatives string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers FROM questions WHERE id = $1 AND bank_id = $2 AND deleted_at IS NULL",
+		questionID, bankID,
+	).Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &gradingPrompt, &rubric, &gradingMode, &q.Difficulty, &alternatives)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.AlternativeAnswers = parseAlternativeAnswers(alternatives)
+	if gradingPrompt.Valid {
+		q.GradingPrompt = &gradingPrompt.String
+	}
+	if rubric.Valid {
+		q.Rubric = &rubric.String
+	}
+	if gradingMode.Valid {
+		mode := questionbank.GradingMode(gradingMode.String)
+		q.GradingMode = &mode
+	}
+	q.Tags, err = s.questionTags(ctx, s.db, questionID)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// SetQuestionTags replaces the tags of a question in a bank. Returns
+// ErrNotFound if the question does not exist in that bank.
+func (s *PostgresStore) SetQuestionTags(ctx context.Context, bankID, questionID string, tags []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM questions WHERE id = $1 AND bank_id = $2 AND deleted_at IS NULL", questionID, bankID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM question_tags WHERE question_id = $1", questionID); err != nil {
+		return err
+	}
+	if err := s.insertQuestionTags(ctx, tx, questionID, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertQuestionTags adds tags to a question, ignoring those it already has.
+func (s *PostgresStore) insertQuestionTags(ctx context.Context, tx *sql.Tx, questionID string, tags []string) error {
+	for _, tag := range tags {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO question_tags (question_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING", questionID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// questionTags returns a question's tags in alphabetical order.
+func (s *PostgresStore) questionTags(ctx context.Context, q queryer, questionID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT tag FROM question_tags WHERE question_id = $1 ORDER BY tag", questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// bankQuestionTags returns the tags of a bank's questions by question ID,
+// each in alphabetical order.
+func (s *PostgresStore) bankQuestionTags(ctx context.Context, bankID string) (map[string][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT qt.question_id, qt.tag
+		FROM question_tags qt
+		JOIN questions q ON q.id = qt.question_id
+		WHERE q.bank_id = $1
+		ORDER BY qt.tag
+	`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var questionID, tag string
+		if err := rows.Scan(&questionID, &tag); err != nil {
+			return nil, err
+		}
+		tags[questionID] = append(tags[questionID], tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *PostgresStore) AddQuestion(ctx context.Context, bankID string, question questionbank.Question) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = $2))",
+		question.ID, bankID, question.Subject, question.ExpectedAnswer, question.GradingPrompt, question.Rubric, question.GradingMode, question.Difficulty.OrDefault(), alternativeAnswersJSON(question.AlternativeAnswers),
+	)
+	return err
+}
+
+// AddQuestions inserts questions into a bank in one transaction: either all
+// are saved or none are.
+func (s *PostgresStore) AddQuestions(ctx context.Context, bankID string, questions []questionbank.Question) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, q := range questions {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = $2))",
+			q.ID, bankID, q.Subject, q.ExpectedAnswer, q.GradingPrompt, q.Rubric, q.GradingMode, q.Difficulty.OrDefault(), alternativeAnswersJSON(q.AlternativeAnswers),
+		)
+		if err != nil {
+			return err
+		}
+		if err := s.insertQuestionTags(ctx, tx, q.ID, q.Tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MoveQuestion moves a question to the end of another bank in one
+// transaction. It keeps its ID, so its stats, tags and grades come along.
+// It fails with ErrNotFound if the question or the target bank is missing.
+func (s *PostgresStore) MoveQuestion(ctx context.Context, questionID, targetBankID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM banks WHERE id = $1 AND deleted_at IS NULL", targetBankID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx,
+		"UPDATE questions SET bank_id = $1, position = (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = $2) WHERE id = $3 AND deleted_at IS NULL",
+		targetBankID, targetBankID, questionID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// CopyQuestion copies a question and its tags to the end of another bank
+// under newID, in one transaction. The copy starts with no stats. It fails
+// with ErrNotFound if the question or the target bank is missing.
+func (s *PostgresStore) CopyQuestion(ctx context.Context, questionID, targetBankID, newID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM banks WHERE id = $1 AND deleted_at IS NULL", targetBankID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position)
+		SELECT $1, $2, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers,
+		       (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = $3)
+		FROM questions WHERE id = $4 AND deleted_at IS NULL
+	`, newID, targetBankID, targetBankID, questionID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO question_tags (question_id, tag) SELECT $1, tag FROM question_tags WHERE question_id = $2",
+		newID, questionID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStore) UpdateQuestion(ctx context.Context, question questionbank.Question) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE questions SET subject = $1, expected_answer = $2, alternative_answers = $3, grading_prompt = $4, rubric = $5, grading_mode = $6 WHERE id = $7",
+		question.Subject, question.ExpectedAnswer, alternativeAnswersJSON(question.AlternativeAnswers), question.GradingPrompt, question.Rubric, question.GradingMode, question.ID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateQuestionDifficulty sets a question's difficulty, leaving its
+// content untouched.
+func (s *PostgresStore) UpdateQuestionDifficulty(ctx context.Context, questionID string, difficulty questionbank.Difficulty) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE questions SET difficulty = $1 WHERE id = $2", difficulty.OrDefault(), questionID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeQuestion permanently deletes a question, in the trash or not, with
+// its stats.
+func (s *PostgresStore) PurgeQuestion(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Delete question stats first
+	_, err = tx.ExecContext(ctx, "DELETE FROM question_stats WHERE question_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM question_tags WHERE question_id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM questions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// ============================================================================
+// Sessions
+// ============================================================================
+
+// SaveSession stores a new session. A zero StartedAt is set to now, which
+// starts the session's time limit.
+func (s *PostgresStore) SaveSession(ctx context.Context, session *practicesession.PracticeSession) error {
+	if session.StartedAt.IsZero() {
+		session.StartedAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO sessions (id, bank_id, status, focus_on_weak, max_duration_min, started_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		session.ID, session.QuestionBankId, string(session.Status), session.FocusOnWeak, session.MaxDuration, session.StartedAt.UnixNano(), session.StartedAt.UnixNano(),
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, q := range session.Questions {
+		// Get bank_id from QuestionBankMap if available, otherwise use session's QuestionBankId
+		bankID := session.QuestionBankId
+		if session.QuestionBankMap != nil {
+			if bid, ok := session.QuestionBankMap[q.ID]; ok {
+				bankID = bid
+			}
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO session_questions (session_id, question_id, question_subject, expected_answer, position, bank_id) VALUES ($1, $2, $3, $4, $5, $6)",
+			session.ID, q.ID, q.Subject, q.ExpectedAnswer, i, bankID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) GetSession(ctx context.Context, id string) (*practicesession.PracticeSession, error) {
+	var session practicesession.PracticeSession
+	var bankID string
+	var status string
+	var maxDuration sql.NullInt64
+	var startedAt int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, bank_id, COALESCE(status, 'active'), focus_on_weak, max_duration_min, started_at FROM sessions WHERE id = $1", id,
+	).Scan(&session.ID, &bankID, &status, &session.FocusOnWeak, &maxDuration, &startedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	session.QuestionBankId = bankID
+	session.Status = practicesession.SessionStatus(status)
+	if maxDuration.Valid {
+		mins := int(maxDuration.Int64)
+		session.MaxDuration = &mins
+	}
+	if startedAt > 0 {
+		session.StartedAt = time.Unix(0, startedAt)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT question_id, question_subject, expected_answer FROM session_questions WHERE session_id = $1 ORDER BY position",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q questionbank.Question
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer); err != nil {
+			return nil, err
+		}
+		session.Questions = append(session.Questions, q)
+	}
+
+	return &session, nil
+}
+
+// CompleteSession transitions a session from active to completed.
+// Returns ErrSessionCompleted if already completed, ErrNotFound if missing.
+func (s *PostgresStore) CompleteSession(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE sessions SET status = $1 WHERE id = $2 AND status = $3",
+		string(practicesession.SessionStatusCompleted), id, string(practicesession.SessionStatusActive),
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		// Distinguish between "not found" and "already completed"
+		var exists bool
+		err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM sessions WHERE id = $1)", id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrSessionCompleted
+		}
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListIncompleteSessions returns active sessions started before
+// startedBefore, oldest first. Sessions without a recorded start count as
+// older than any cutoff.
+func (s *PostgresStore) ListIncompleteSessions(ctx context.Context, startedBefore time.Time) ([]IncompleteSession, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.bank_id, s.started_at, s.max_duration_min,
+		       (SELECT COUNT(*) FROM session_questions sq WHERE sq.session_id = s.id),
+		       (SELECT COUNT(*) FROM grades g WHERE g.session_id = s.id)
+		FROM sessions s
+		WHERE COALESCE(s.status, 'active') = $1 AND s.started_at < $2
+		ORDER BY s.started_at, s.id
+	`, string(practicesession.SessionStatusActive), startedBefore.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []IncompleteSession{}
+	for rows.Next() {
+		var session IncompleteSession
+		var startedAt int64
+		var maxDuration sql.NullInt64
+		if err := rows.Scan(&session.ID, &session.BankID, &startedAt, &maxDuration, &session.QuestionCount, &session.AnsweredCount); err != nil {
+			return nil, err
+		}
+		if startedAt > 0 {
+			session.StartedAt = time.Unix(0, startedAt)
+		}
+		if maxDuration.Valid {
+			mins := int(maxDuration.Int64)
+			session.MaxDuration = &mins
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *PostgresStore) ListSessionsByBank(ctx context.Context, bankID string) ([]SessionSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, COALESCE(s.status, 'active'),
+		       CASE WHEN s.created_at > 0 THEN s.created_at ELSE s.started_at END AS created,
+		       (SELECT COUNT(*) FROM session_questions sq WHERE sq.session_id = s.id),
+		       (SELECT COUNT(*) FROM grades g WHERE g.session_id = s.id),
+		       (SELECT COALESCE(SUM(g.score), 0) FROM grades g WHERE g.session_id = s.id)
+		FROM sessions s
+		WHERE s.bank_id = $1
+		ORDER BY created DESC, s.id
+	`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []SessionSummary{}
+	for rows.Next() {
+		var session SessionSummary
+		var status string
+		var createdAt int64
+		if err := rows.Scan(&session.ID, &status, &createdAt, &session.QuestionCount, &session.AnsweredCount, &session.TotalScore); err != nil {
+			return nil, err
+		}
+		session.Status = practicesession.SessionStatus(status)
+		if createdAt > 0 {
+			session.CreatedAt = time.Unix(0, createdAt)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// ListSessionActivity returns the start time, status and successfully
+// graded answers of every session, oldest first. Sessions saved before
+// start times were recorded are left out.
+func (s *PostgresStore) ListSessionActivity(ctx context.Context) ([]SessionActivity, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT CASE WHEN s.created_at > 0 THEN s.created_at ELSE s.started_at END AS created,
+		       COALESCE(s.status, 'active'),
+		       (SELECT COUNT(*) FROM grades g WHERE g.session_id = s.id AND g.status = $1)
+		FROM sessions s
+		WHERE s.created_at > 0 OR s.started_at > 0
+		ORDER BY created, s.id
+	`, GradeStatusSuccess)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []SessionActivity
+	for rows.Next() {
+		var a SessionActivity
+		var startedAt int64
+		var status string
+		if err := rows.Scan(&startedAt, &status, &a.Answered); err != nil {
+			return nil, err
+		}
+		a.StartedAt = time.Unix(0, startedAt)
+		a.Status = practicesession.SessionStatus(status)
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// AverageTimePerQuestion estimates how long answering one of a bank's
+// questions takes from its past sessions: the time from each session's
+// start to its last grade, divided by the answers given. Sessions without
+// recorded times are skipped. It also returns how many answers the
+// average is based on; with none the average is 0.
+func (s *PostgresStore) AverageTimePerQuestion(ctx context.Context, bankID string) (time.Duration, int, error) {
+	var total sql.NullInt64
+	var answers int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT SUM(last_graded_at - started_at), COALESCE(SUM(answers), 0)
+		FROM (
+			SELECT s.started_at, MAX(g.graded_at) AS last_graded_at, COUNT(*) AS answers
+			FROM sessions s
+			JOIN grades g ON g.session_id = s.id
+			WHERE s.bank_id = $1 AND s.started_at > 0 AND g.graded_at > 0
+			GROUP BY s.id, s.started_at
+			HAVING MAX(g.graded_at) > s.started_at
+		) timed
+	`, bankID).Scan(&total, &answers)
+	if err != nil {
+		return 0, 0, err
+	}
+	if answers == 0 {
+		return 0, 0, nil
+	}
+	return time.Duration(total.Int64 / int64(answers)), answers, nil
+}
+
+// RestartSession deletes an active session's grades and persisted answers,
+// stores the current order of session.Questions and restarts its time limit
+// from now. Question stats already updated by those grades are kept.
+// Completed sessions return ErrSessionCompleted.
+func (s *PostgresStore) RestartSession(ctx context.Context, session *practicesession.PracticeSession) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(status, 'active') FROM sessions WHERE id = $1", session.ID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != string(practicesession.SessionStatusActive) {
+		return ErrSessionCompleted
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM grades WHERE session_id = $1", session.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM session_answers WHERE session_id = $1", session.ID); err != nil {
+		return err
+	}
+
+	startedAt := time.Now()
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET started_at = $1 WHERE id = $2", startedAt.UnixNano(), session.ID); err != nil {
+		return err
+	}
+
+	for i, q := range session.Questions {
+		_, err := tx.ExecContext(ctx,
+			"UPDATE session_questions SET position = $1 WHERE session_id = $2 AND question_id = $3",
+			i, session.ID, q.ID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	session.StartedAt = startedAt
+	return nil
+}
+
+// ============================================================================
+// Grades
+// ============================================================================
+
+// SaveGrade stores a successful grading result.
+// If a grade already exists for this (session, question) pair it is
+// overwritten, and a previous successful grade's contribution to the
+// question stats is replaced rather than counted again. A result for an
+// answer that a newer submission replaced while it was being graded is
+// dropped, so the latest submission wins whichever grading finishes last.
+func (s *PostgresStore) SaveGrade(ctx context.Context, sessionID string, questionID string, score int, covered, missed []string, coveredIndices, missedIndices []int, userAnswer string, model string, duration time.Duration, matchedAnswer *int) error {
+	coveredJSON, _ := json.Marshal(covered)
+	missedJSON, _ := json.Marshal(missed)
+	coveredIdxJSON, _ := json.Marshal(nonNilInts(coveredIndices))
+	missedIdxJSON, _ := json.Marshal(nonNilInts(missedIndices))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.settleAnswer(ctx, tx, sessionID, questionID, userAnswer, GradeStatusSuccess)
+	if err != nil || !current {
+		return err
+	}
+
+	previous, regraded, err := s.previousGradeScore(ctx, tx, sessionID, questionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO grades (session_id, question_id, score, covered, missed, covered_indices, missed_indices, user_answer, status, graded_at, model, duration_ms, matched_answer)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT(session_id, question_id) DO UPDATE SET
+			score = excluded.score,
+			covered = excluded.covered,
+			missed = excluded.missed,
+			covered_indices = excluded.covered_indices,
+			missed_indices = excluded.missed_indices,
+			user_answer = excluded.user_answer,
+			status = excluded.status,
+			graded_at = excluded.graded_at,
+			model = excluded.model,
+			duration_ms = excluded.duration_ms,
+			matched_answer = excluded.matched_answer`,
+		sessionID, questionID, score, string(coveredJSON), string(missedJSON), string(coveredIdxJSON), string(missedIdxJSON), userAnswer, GradeStatusSuccess,
+		time.Now().UnixNano(), model, duration.Milliseconds(), matchedAnswer,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Update question statistics
+	if regraded {
+		err = s.replaceQuestionStatsScore(ctx, tx, questionID, previous, score)
+	} else {
+		err = s.updateQuestionStats(ctx, tx, questionID, score)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveGradeFailure stores a record when grading fails, so the user sees
+// "grading failed" instead of "not answered." A successful grade it
+// overwrites is retracted from the question stats. Like SaveGrade, it drops
+// the failure of an answer a newer submission replaced.
+func (s *PostgresStore) SaveGradeFailure(ctx context.Context, sessionID string, questionID string, userAnswer string, reason string, rawResponse string) error {
+	missed := []string{"Grading failed: " + reason}
+	missedJSON, _ := json.Marshal(missed)
+	coveredJSON, _ := json.Marshal([]string{})
+	now := time.Now().UnixNano()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.settleAnswer(ctx, tx, sessionID, questionID, userAnswer, GradeStatusFailed)
+	if err != nil || !current {
+		return err
+	}
+
+	previous, regraded, err := s.previousGradeScore(ctx, tx, sessionID, questionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO grades (session_id, question_id, score, covered, missed, user_answer, status, failure_reason, raw_response, failed_at, graded_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT(session_id, question_id) DO UPDATE SET
+			score = excluded.score,
+			covered = excluded.covered,
+			missed = excluded.missed,
+			covered_indices = '[]',
+			missed_indices = '[]',
+			user_answer = excluded.user_answer,
+			status = excluded.status,
+			failure_reason = excluded.failure_reason,
+			raw_response = excluded.raw_response,
+			failed_at = excluded.failed_at,
+			graded_at = excluded.graded_at,
+			model = '',
+			duration_ms = 0,
+			matched_answer = NULL`,
+		sessionID, questionID, 0, string(coveredJSON), string(missedJSON), userAnswer, GradeStatusFailed,
+		reason, rawResponse, now, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	if regraded {
+		if err := s.retractQuestionStatsScore(ctx, tx, questionID, previous); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SavePendingAnswer persists a submitted answer before it is graded,
+// replacing an earlier answer to the same question of the session.
+func (s *PostgresStore) SavePendingAnswer(ctx context.Context, answer PendingAnswer) error {
+	var selfCovered sql.NullString
+	if answer.SelfCovered != nil {
+		data, _ := json.Marshal(answer.SelfCovered)
+		selfCovered = sql.NullString{String: string(data), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO session_answers (session_id, question_id, user_answer, self_covered, answer_language, submitted_at, grade_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT(session_id, question_id) DO UPDATE SET
+			user_answer = excluded.user_answer,
+			self_covered = excluded.self_covered,
+			answer_language = excluded.answer_language,
+			submitted_at = excluded.submitted_at,
+			grade_status = excluded.grade_status`,
+		answer.SessionID, answer.QuestionID, answer.UserAnswer, selfCovered, answer.AnswerLanguage, answer.SubmittedAt.UnixNano(), GradeStatusPending,
+	)
+	return err
+}
+
+// DeletePendingAnswer drops the persisted answer to a question of a
+// session, e.g. one that can no longer be graded because its session is
+// gone.
+func (s *PostgresStore) DeletePendingAnswer(ctx context.Context, sessionID string, questionID string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM session_answers WHERE session_id = $1 AND question_id = $2", sessionID, questionID)
+	return err
+}
+
+// ListPendingAnswers returns the answers SaveGrade and SaveGradeFailure
+// have not settled yet, oldest first.
+func (s *PostgresStore) ListPendingAnswers(ctx context.Context) ([]PendingAnswer, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, question_id, user_answer, self_covered, answer_language, submitted_at
+		FROM session_answers
+		WHERE grade_status = $1
+		ORDER BY submitted_at, session_id, question_id`,
+		GradeStatusPending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []PendingAnswer
+	for rows.Next() {
+		var a PendingAnswer
+		var selfCovered sql.NullString
+		var submittedAt int64
+		if err := rows.Scan(&a.SessionID, &a.QuestionID, &a.UserAnswer, &selfCovered, &a.AnswerLanguage, &submittedAt); err != nil {
+			return nil, err
+		}
+		if selfCovered.Valid {
+			json.Unmarshal([]byte(selfCovered.String), &a.SelfCovered)
+		}
+		a.SubmittedAt = time.Unix(0, submittedAt)
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+// ListGradeFailures returns up to limit failed grades, newest first.
+func (s *PostgresStore) ListGradeFailures(ctx context.Context, limit int) ([]GradeFailure, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, question_id, user_answer, failure_reason, raw_response, failed_at
+		FROM grades
+		WHERE status = $1
+		ORDER BY failed_at DESC, id DESC
+		LIMIT $2`,
+		GradeStatusFailed, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := []GradeFailure{}
+	for rows.Next() {
+		var f GradeFailure
+		var failedAt int64
+		if err := rows.Scan(&f.SessionID, &f.QuestionID, &f.UserAnswer, &f.Reason, &f.RawResponse, &failedAt); err != nil {
+			return nil, err
+		}
+		if failedAt > 0 {
+			f.FailedAt = time.Unix(0, failedAt).UTC()
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+func (s *PostgresStore) GetGrades(ctx context.Context, sessionID string) ([]StoredGrade, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT question_id, score, covered, missed, covered_indices, missed_indices, user_answer, COALESCE(status, 'success'), graded_at, model, duration_ms, matched_answer FROM grades WHERE session_id = $1",
+		sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grades []StoredGrade
+	for rows.Next() {
+		var g StoredGrade
+		var coveredJSON, missedJSON, coveredIdxJSON, missedIdxJSON string
+		var status string
+		var gradedAt, durationMs int64
+		var matched sql.NullInt64
+		if err := rows.Scan(&g.QuestionID, &g.Score, &coveredJSON, &missedJSON, &coveredIdxJSON, &missedIdxJSON, &g.UserAnswer, &status, &gradedAt, &g.Model, &durationMs, &matched); err != nil {
+			return nil, err
+		}
+		if matched.Valid {
+			index := int(matched.Int64)
+			g.MatchedAnswer = &index
+		}
+		if gradedAt > 0 {
+			g.GradedAt = time.Unix(0, gradedAt).UTC()
+		}
+		g.Duration = time.Duration(durationMs) * time.Millisecond
+		json.Unmarshal([]byte(coveredJSON), &g.Covered)
+		json.Unmarshal([]byte(missedJSON), &g.Missed)
+		json.Unmarshal([]byte(coveredIdxJSON), &g.CoveredIndices)
+		json.Unmarshal([]byte(missedIdxJSON), &g.MissedIndices)
+		g.Status = GradeStatus(status)
+		grades = append(grades, g)
+	}
+	return grades, nil
+}
+
+// GetGradesByQuestion returns up to limit grades of a question across
+// sessions, newest first.
+func (s *PostgresStore) GetGradesByQuestion(ctx context.Context, questionID string, limit int) ([]QuestionGrade, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, score, covered, missed, covered_indices, missed_indices, user_answer, status, graded_at, model, duration_ms
+		FROM grades
+		WHERE question_id = $1
+		ORDER BY graded_at DESC, id DESC
+		LIMIT $2`,
+		questionID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grades := []QuestionGrade{}
+	for rows.Next() {
+		g := QuestionGrade{StoredGrade: StoredGrade{QuestionID: questionID}}
+		var coveredJSON, missedJSON, coveredIdxJSON, missedIdxJSON, status string
+		var gradedAt, durationMs int64
+		if err := rows.Scan(&g.SessionID, &g.Score, &coveredJSON, &missedJSON, &coveredIdxJSON, &missedIdxJSON, &g.UserAnswer, &status, &gradedAt, &g.Model, &durationMs); err != nil {
+			return nil, err
+		}
+		g.Duration = time.Duration(durationMs) * time.Millisecond
+		json.Unmarshal([]byte(coveredJSON), &g.Covered)
+		json.Unmarshal([]byte(missedJSON), &g.Missed)
+		json.Unmarshal([]byte(coveredIdxJSON), &g.CoveredIndices)
+		json.Unmarshal([]byte(missedIdxJSON), &g.MissedIndices)
+		g.Status = GradeStatus(status)
+		if gradedAt > 0 {
+			g.GradedAt = time.Unix(0, gradedAt).UTC()
+		}
+		grades = append(grades, g)
+	}
+	return grades, rows.Err()
+}
+
+// ============================================================================
+// Question Statistics
+// ============================================================================
+
+// settleAnswer marks the persisted answer to a question of a session with
+// the status its grading ended in. It reports false, settling nothing, when
+// a newer submission replaced userAnswer while it was being graded; an
+// answer that was never persisted counts as current. The row lock it takes
+// holds off a newer submission until the grade is saved.
+func (s *PostgresStore) settleAnswer(ctx context.Context, tx *sql.Tx, sessionID, questionID, userAnswer string, status GradeStatus) (bool, error) {
+	res, err := tx.ExecContext(ctx,
+		"UPDATE session_answers SET grade_status = $1 WHERE session_id = $2 AND question_id = $3 AND user_answer = $4",
+		status, sessionID, questionID, userAnswer,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err == nil, err
+	}
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM session_answers WHERE session_id = $1 AND question_id = $2", sessionID, questionID).Scan(&found)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	return false, err
+}
+
+// previousGradeScore returns the score of the successful grade already stored
+// for the (session, question) pair, if any. Failed gradings never counted
+// towards the question stats, so they are ignored.
+func (s *PostgresStore) previousGradeScore(ctx context.Context, tx *sql.Tx, sessionID, questionID string) (int, bool, error) {
+	var score int
+	err := tx.QueryRowContext(ctx,
+		"SELECT score FROM grades WHERE session_id = $1 AND question_id = $2 AND status = $3",
+		sessionID, questionID, GradeStatusSuccess,
+	).Scan(&score)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return score, true, nil
+}
+
+// pgStatsSQL holds the question_stats statements of changeQuestionStats.
+var pgStatsSQL = statsSQL{
+	get: "SELECT times_answered, times_correct, total_score, latest_score, last_answered_at FROM question_stats WHERE question_id = $1",
+	put: `
+		INSERT INTO question_stats (question_id, times_answered, times_correct, total_score, latest_score, last_answered_at, mastery)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT(question_id) DO UPDATE SET
+		    times_answered   = excluded.times_answered,
+		    times_correct    = excluded.times_correct,
+		    total_score      = excluded.total_score,
+		    latest_score     = excluded.latest_score,
+		    last_answered_at = excluded.last_answered_at,
+		    mastery          = excluded.mastery
+	`,
+	delete: "DELETE FROM question_stats WHERE question_id = $1",
+	latest: "SELECT score, graded_at FROM grades WHERE question_id = $1 AND status = 'success' ORDER BY graded_at DESC, id DESC LIMIT 1",
+}
+
+func (s *PostgresStore) updateQuestionStats(ctx context.Context, tx *sql.Tx, questionID string, score int) error {
+	return changeQuestionStats(ctx, tx, nil, pgStatsSQL, questionID, countAnswer(score, s.passThreshold, time.Now()))
+}
+
+// replaceQuestionStatsScore swaps a re-graded answer's previous score for
+// its new one without counting another attempt. Stats that no longer exist
+// are recreated as a first attempt.
+func (s *PostgresStore) replaceQuestionStatsScore(ctx context.Context, tx *sql.Tx, questionID string, previous, score int) error {
+	return changeQuestionStats(ctx, tx, nil, pgStatsSQL, questionID, replaceAnswer(previous, score, s.passThreshold, time.Now()))
+}
+
+// retractQuestionStatsScore removes a previously counted attempt whose
+// grade was replaced by a grading failure, taking the latest score from the
+// newest remaining grade; stats left with no attempts are deleted.
+func (s *PostgresStore) retractQuestionStatsScore(ctx context.Context, tx *sql.Tx, questionID string, previous int) error {
+	return retractQuestionStats(ctx, tx, nil, pgStatsSQL, questionID, previous, s.passThreshold)
+}
+
+// GetQuestionStats returns the aggregate stats for a question, including its
+// current streak. A question that was never answered yields zeroed stats.
+func (s *PostgresStore) GetQuestionStats(ctx context.Context, questionID string) (*questionbank.QuestionStats, error) {
+	var stats questionbank.QuestionStats
+	var lastAnswered int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT question_id, times_answered, times_correct, total_score, latest_score, mastery, last_answered_at
+		FROM question_stats WHERE question_id = $1
+	`, questionID).Scan(&stats.QuestionID, &stats.TimesAnswered, &stats.TimesCorrect, &stats.TotalScore, &stats.LatestScore, &stats.Mastery, &lastAnswered)
+
+	if err == sql.ErrNoRows {
+		return &questionbank.QuestionStats{QuestionID: questionID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	streak, err := s.questionStreak(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Streak = streak
+	if lastAnswered > 0 {
+		stats.LastAnswered = time.Unix(0, lastAnswered)
+	}
+	return &stats, nil
+}
+
+// RestoreQuestionStats overwrites a question's aggregate stats, e.g. when
+// importing a backup. Streak is derived from grades and is not restored.
+func (s *PostgresStore) RestoreQuestionStats(ctx context.Context, stats questionbank.QuestionStats) error {
+	var lastAnswered int64
+	if !stats.LastAnswered.IsZero() {
+		lastAnswered = stats.LastAnswered.UnixNano()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO question_stats (question_id, times_answered, times_correct, total_score, latest_score, last_answered_at, mastery)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (question_id) DO UPDATE SET
+		    times_answered   = EXCLUDED.times_answered,
+		    times_correct    = EXCLUDED.times_correct,
+		    total_score      = EXCLUDED.total_score,
+		    latest_score     = EXCLUDED.latest_score,
+		    last_answered_at = EXCLUDED.last_answered_at,
+		    mastery          = EXCLUDED.mastery
+	`, stats.QuestionID, stats.TimesAnswered, stats.TimesCorrect, stats.TotalScore, stats.LatestScore, lastAnswered, stats.Mastery)
+	return err
+}
+
+// ResetQuestionStats zeroes a question's aggregate stats so its mastery
+// starts over. Grades are kept, so its answer history is not lost.
+func (s *PostgresStore) ResetQuestionStats(ctx context.Context, questionID string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM question_stats WHERE question_id = $1", questionID)
+	return err
+}
+
+// ResetBankStats zeroes the aggregate stats of every question of a bank.
+func (s *PostgresStore) ResetBankStats(ctx context.Context, bankID string) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM question_stats WHERE question_id IN (SELECT id FROM questions WHERE bank_id = $1)",
+		bankID,
+	)
+	return err
+}
+
+// DecayMastery decays the stored mastery of every answered question by
+// the time since it was last answered, halving it every halfLife. The next
+// answer recomputes mastery as usual.
+func (s *PostgresStore) DecayMastery(ctx context.Context, halfLife time.Duration, now time.Time) (int, error) {
+	return decayMastery(ctx, s.db, "UPDATE question_stats SET mastery = $1 WHERE question_id = $2", halfLife, now)
+}
+
+// SetPassThreshold sets the minimum score counted as correct in
+// times_correct and streaks. Values outside 1-100 are ignored. Stats
+// recorded under a previous threshold are not recomputed.
+func (s *PostgresStore) SetPassThreshold(score int) {
+	if score >= 1 && score <= 100 {
+		s.passThreshold = score
+	}
+}
+
+// PassThreshold returns the minimum score counted as correct.
+func (s *PostgresStore) PassThreshold() int {
+	return s.passThreshold
+}
+
+// questionStreak counts consecutive correct grades (see
+// SetPassThreshold) for a question, walking back from the most recent
+// one. Failed gradings are skipped.
+func (s *PostgresStore) questionStreak(ctx context.Context, questionID string) (int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT score FROM grades WHERE question_id = $1 AND status = $2 ORDER BY id DESC",
+		questionID, GradeStatusSuccess,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	streak := 0
+	for rows.Next() {
+		var score int
+		if err := rows.Scan(&score); err != nil {
+			return 0, err
+		}
+		if score < s.passThreshold {
+			break
+		}
+		streak++
+	}
+	return streak, rows.Err()
+}
+
+func (s *PostgresStore) GetQuestionStatsByBank(ctx context.Context, bankID string) ([]questionbank.QuestionStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, COALESCE(qs.times_answered, 0), COALESCE(qs.times_correct, 0), 
+		       COALESCE(qs.total_score, 0), COALESCE(qs.latest_score, 0), COALESCE(qs.mastery, 0),
+		       COALESCE(qs.last_answered_at, 0)
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id = $1 AND q.deleted_at IS NULL
+	`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []questionbank.QuestionStats
+	for rows.Next() {
+		var s questionbank.QuestionStats
+		var lastAnswered int64
+		if err := rows.Scan(&s.QuestionID, &s.TimesAnswered, &s.TimesCorrect, &s.TotalScore, &s.LatestScore, &s.Mastery, &lastAnswered); err != nil {
+			return nil, err
+		}
+		if lastAnswered > 0 {
+			s.LastAnswered = time.Unix(0, lastAnswered)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func (s *PostgresStore) GetBankMastery(ctx context.Context, bankID string) (int, error) {
+	return s.GetBankMasteryScoped(ctx, bankID, MasteryScopeAll)
+}
+
+func (s *PostgresStore) GetBankMasteryScoped(ctx context.Context, bankID string, scope MasteryScope) (int, error) {
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+bankMasterySQL(scope)+`
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id = $1 AND q.deleted_at IS NULL`, bankID).Scan(&mastery)
+
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+func (s *PostgresStore) GetBankMasteryBatch(ctx context.Context, bankIDs []string, scope MasteryScope) (map[string]int, error) {
+	result := make(map[string]int, len(bankIDs))
+	if len(bankIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.bank_id, COALESCE(CAST(TRUNC(`+bankMasterySQL(scope)+`) AS INTEGER), 0)
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id = ANY($1) AND q.deleted_at IS NULL
+		GROUP BY q.bank_id
+	`, bankIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var mastery int
+		if err := rows.Scan(&id, &mastery); err != nil {
+			return nil, err
+		}
+		result[id] = mastery
+	}
+	return result, nil
+}
+
+// GetUnansweredCountBatch returns, per bank, how many questions have never
+// been answered (no question_stats row). Banks with none are omitted.
+func (s *PostgresStore) GetUnansweredCountBatch(ctx context.Context, bankIDs []string) (map[string]int, error) {
+	result := make(map[string]int, len(bankIDs))
+	if len(bankIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.bank_id, COUNT(q.id)
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id = ANY($1) AND q.deleted_at IS NULL
+		  AND qs.question_id IS NULL
+		GROUP BY q.bank_id
+	`, bankIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		result[id] = count
+	}
+	return result, rows.Err()
+}
+
+func (s *PostgresStore) GetOverallMastery(ctx context.Context) (int, error) {
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT CAST(AVG(qs.mastery) * COUNT(qs.mastery) / COUNT(q.id) AS DOUBLE PRECISION)
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.deleted_at IS NULL
+	`).Scan(&mastery)
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+// GetLibraryOverview counts the library's folders, categories, banks and
+// questions and averages mastery over all questions in one query.
+func (s *PostgresStore) GetLibraryOverview(ctx context.Context) (*LibraryOverview, error) {
+	var o LibraryOverview
+	var totalMastery int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM folders WHERE NOT is_system),
+		       (SELECT COUNT(*) FROM categories),
+		       (SELECT COUNT(*) FROM banks WHERE deleted_at IS NULL),
+		       COUNT(q.id),
+		       COUNT(q.id) - COUNT(CASE WHEN qs.times_answered > 0 THEN 1 END),
+		       COALESCE(SUM(qs.mastery), 0)
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.deleted_at IS NULL
+	`).Scan(&o.Folders, &o.Categories, &o.Banks, &o.Questions, &o.UnansweredQuestions, &totalMastery)
+	if err != nil {
+		return nil, err
+	}
+	if o.Questions > 0 {
+		o.Mastery = totalMastery / o.Questions
+	}
+	return &o, nil
+}
+
+func (s *PostgresStore) StreamQuestionStats(ctx context.Context, filter QuestionStatsFilter, fn func(QuestionStatsRow) error) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer, b.id, b.subject,
+		       COALESCE(c.id, ''), COALESCE(c.name, ''),
+		       COALESCE(f.id, ''), COALESCE(f.name, ''),
+		       COALESCE(qs.times_answered, 0), COALESCE(qs.times_correct, 0),
+		       COALESCE(qs.latest_score, 0), COALESCE(qs.mastery, 0)
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		LEFT JOIN categories c ON b.category_id = c.id
+		LEFT JOIN folders f ON c.folder_id = f.id
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		WHERE q.deleted_at IS NULL AND ($1 = '' OR f.id = $2) AND ($3 = '' OR c.id = $4)
+		ORDER BY f.name NULLS FIRST, c.name NULLS FIRST, b.subject, q.id`,
+		filter.FolderID, filter.FolderID, filter.CategoryID, filter.CategoryID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r QuestionStatsRow
+		if err := rows.Scan(
+			&r.QuestionID, &r.Question, &r.ExpectedAnswer, &r.BankID, &r.Bank,
+			&r.CategoryID, &r.Category, &r.FolderID, &r.Folder,
+			&r.TimesAnswered, &r.TimesCorrect, &r.LatestScore, &r.Mastery,
+		); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetMasteryByBankType returns one entry per bank type that has questions,
+// ordered by type.
+func (s *PostgresStore) GetMasteryByBankType(ctx context.Context, filter QuestionStatsFilter) ([]BankTypeMastery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.bank_type, COUNT(q.id), COUNT(qs.question_id),
+		       CAST(TRUNC(AVG(COALESCE(qs.mastery, 0))) AS INTEGER)
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		LEFT JOIN categories c ON b.category_id = c.id
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		WHERE q.deleted_at IS NULL AND ($1 = '' OR c.folder_id = $2) AND ($3 = '' OR c.id = $4)
+		GROUP BY b.bank_type
+		ORDER BY b.bank_type`,
+		filter.FolderID, filter.FolderID, filter.CategoryID, filter.CategoryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []BankTypeMastery{}
+	for rows.Next() {
+		var m BankTypeMastery
+		if err := rows.Scan(&m.BankType, &m.QuestionCount, &m.AnsweredCount, &m.Mastery); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+// ListCategoriesWithMastery returns every category with its mastery,
+// computed in a single aggregate query with the same averaging as
+// GetCategoryMastery. Categories without questions get 0.
+func (s *PostgresStore) ListCategoriesWithMastery(ctx context.Context) ([]*CategoryWithMastery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.name, c.folder_id, c.sort_order, c.version,
+		       COALESCE(CAST(TRUNC(`+bankMasterySQL(MasteryScopeAll)+`) AS INTEGER), 0)
+		FROM categories c
+		LEFT JOIN banks b ON b.category_id = c.id
+		LEFT JOIN questions q ON q.bank_id = b.id AND q.deleted_at IS NULL
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		GROUP BY c.id
+		ORDER BY c.sort_order ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*CategoryWithMastery
+	for rows.Next() {
+		var cat CategoryWithMastery
+		var folderID sql.NullString
+		if err := rows.Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder, &cat.Version, &cat.Mastery); err != nil {
+			return nil, err
+		}
+		if folderID.Valid {
+			cat.FolderID = &folderID.String
+		}
+		categories = append(categories, &cat)
+	}
+	return categories, rows.Err()
+}
+
+func (s *PostgresStore) GetCategoryMastery(ctx context.Context, categoryID string) (int, error) {
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+bankMasterySQL(MasteryScopeAll)+`
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE b.category_id = $1 AND q.deleted_at IS NULL
+	`, categoryID).Scan(&mastery)
+
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+// GetCategoryMasteryWeighted returns the category mastery aggregated with
+// the given weighting.
+func (s *PostgresStore) GetCategoryMasteryWeighted(ctx context.Context, categoryID string, weighting MasteryWeighting) (int, error) {
+	if weighting != MasteryWeightingAttempts {
+		return s.GetCategoryMastery(ctx, categoryID)
+	}
+
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+attemptsMasterySQL+`
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		JOIN question_stats qs ON q.id = qs.question_id
+		WHERE b.category_id = $1 AND q.deleted_at IS NULL AND qs.times_answered > 0
+	`, categoryID).Scan(&mastery)
+
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+func (s *PostgresStore) GetCategoryMasteryBatch(ctx context.Context, categoryIDs []string) (map[string]int, error) {
+	result := make(map[string]int, len(categoryIDs))
+	if len(categoryIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.category_id, CAST(TRUNC(`+bankMasterySQL(MasteryScopeAll)+`) AS INTEGER)
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE b.category_id = ANY($1) AND q.deleted_at IS NULL
+		GROUP BY b.category_id
+	`, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var mastery int
+		if err := rows.Scan(&id, &mastery); err != nil {
+			return nil, err
+		}
+		result[id] = mastery
+	}
+	return result, nil
+}
+
+// GetWeakQuestionsAcrossBanks returns weak questions from multiple banks, sorted by mastery ascending
+func (s *PostgresStore) GetWeakQuestionsAcrossBanks(ctx context.Context, bankIDs []string, maxPerBank int) ([]QuestionWithBank, error) {
+	if len(bankIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		WITH ranked AS (
+			SELECT q.id, q.subject, q.expected_answer, q.bank_id, COALESCE(qs.mastery, 0) as mastery,
+			       ROW_NUMBER() OVER (PARTITION BY q.bank_id ORDER BY COALESCE(qs.mastery, 0) ASC) as rn
+			FROM questions q
+			LEFT JOIN question_stats qs ON q.id = qs.question_id
+			WHERE q.bank_id = ANY($1) AND q.deleted_at IS NULL
+		)
+		SELECT id, subject, expected_answer, bank_id, mastery
+		FROM ranked
+		WHERE rn <= $2
+		ORDER BY mastery ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, bankIDs, maxPerBank)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []QuestionWithBank
+	for rows.Next() {
+		var q QuestionWithBank
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &q.BankID, &q.Mastery); err != nil {
+			return nil, err
+		}
+		results = append(results, q)
+	}
+	return results, nil
+}
+
+// GetWeakestQuestionsInCategory returns up to limit questions from all banks
+// in a category: never-answered questions first, then by ascending mastery.
+func (s *PostgresStore) GetWeakestQuestionsInCategory(ctx context.Context, categoryID string, limit int) ([]QuestionWithBank, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer, q.bank_id, b.subject,
+		       COALESCE(qs.mastery, 0), COALESCE(qs.times_answered, 0)
+		FROM questions q
+		JOIN banks b ON b.id = q.bank_id
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE b.category_id = $1 AND q.deleted_at IS NULL
+		ORDER BY COALESCE(qs.times_answered, 0) > 0, COALESCE(qs.mastery, 0), b.subject, q.seq
+		LIMIT $2
+	`, categoryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []QuestionWithBank
+	for rows.Next() {
+		var q QuestionWithBank
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &q.BankID, &q.BankSubject, &q.Mastery, &q.TimesAnswered); err != nil {
+			return nil, err
+		}
+		results = append(results, q)
+	}
+	return results, rows.Err()
+}
+
+// GetWeakestAnsweredQuestions returns up to limit answered questions from
+// every bank, lowest mastery first, each with its bank's subject.
+// Never-answered questions are left out; GetLibraryOverview counts them.
+func (s *PostgresStore) GetWeakestAnsweredQuestions(ctx context.Context, limit int) ([]QuestionWithBank, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer, q.bank_id, b.subject, qs.mastery, qs.times_answered
+		FROM questions q
+		JOIN banks b ON b.id = q.bank_id
+		JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.deleted_at IS NULL AND qs.times_answered > 0
+		ORDER BY qs.mastery, b.subject, q.seq
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []QuestionWithBank
+	for rows.Next() {
+		var q QuestionWithBank
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &q.BankID, &q.BankSubject, &q.Mastery, &q.TimesAnswered); err != nil {
+			return nil, err
+		}
+		results = append(results, q)
+	}
+	return results, rows.Err()
+}
+
+// GetSessionQuestionBankID returns the bank_id for a specific question in a session
+func (s *PostgresStore) GetSessionQuestionBankID(ctx context.Context, sessionID, questionID string) (string, error) {
+	var bankID sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COALESCE(sq.bank_id, s.bank_id) FROM session_questions sq JOIN sessions s ON sq.session_id = s.id WHERE sq.session_id = $1 AND sq.question_id = $2",
+		sessionID, questionID,
+	).Scan(&bankID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if bankID.Valid {
+		return bankID.String, nil
+	}
+	return "", nil
+}
+
+// GetQuestionsOrderedByMastery returns questions sorted by mastery (lowest first for weak focus)
+func (s *PostgresStore) GetQuestionsOrderedByMastery(ctx context.Context, bankID string, ascending bool) ([]questionbank.Question, error) {
+	order := "ASC"
+	if !ascending {
+		order = "DESC"
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer, COALESCE(qs.mastery, 0) as mastery
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id = $1 AND q.deleted_at IS NULL
+		ORDER BY mastery `+order, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []questionbank.Question
+	for rows.Next() {
+		var q questionbank.Question
+		var mastery int
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &mastery); err != nil {
+			return nil, err
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+// GetQuestionsUnansweredFirst returns questions that have never been answered
+// (no question_stats row) first, followed by answered ones sorted by mastery
+// ascending. Used to surface new questions added to a mature bank.
+func (s *PostgresStore) GetQuestionsUnansweredFirst(ctx context.Context, bankID string) ([]questionbank.Question, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id = $1 AND q.deleted_at IS NULL
+		ORDER BY qs.question_id IS NOT NULL, COALESCE(qs.mastery, 0) ASC`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []questionbank.Question
+	for rows.Next() {
+		var q questionbank.Question
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer); err != nil {
+			return nil, err
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
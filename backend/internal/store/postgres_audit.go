@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// ============================================================================
+// Audit log
+// ============================================================================
+
+// AppendAuditEntry appends entry to the audit log, then trims the log to
+// its newest keep entries when keep > 0.
+func (s *PostgresStore) AppendAuditEntry(ctx context.Context, entry AuditEntry, keep int) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO audit_log (entity_type, entity_id, action, actor, created_at) VALUES ($1, $2, $3, $4, $5)",
+		entry.EntityType, entry.EntityID, entry.Action, entry.Actor, entry.At.UnixNano(),
+	)
+	if err != nil || keep <= 0 {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		"DELETE FROM audit_log WHERE id <= (SELECT id FROM audit_log ORDER BY id DESC LIMIT 1 OFFSET $1)",
+		keep,
+	)
+	return err
+}
+
+// ListAuditEntries returns up to limit audit entries, newest first.
+func (s *PostgresStore) ListAuditEntries(ctx context.Context, limit int) ([]AuditEntry, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, entity_type, entity_id, action, actor, created_at FROM audit_log ORDER BY id DESC LIMIT $1",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var at int64
+		if err := rows.Scan(&e.ID, &e.EntityType, &e.EntityID, &e.Action, &e.Actor, &at); err != nil {
+			return nil, err
+		}
+		e.At = time.Unix(0, at).UTC()
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/remaimber-it/backend/internal/domain/category"
+	"github.com/remaimber-it/backend/internal/domain/folder"
+)
+
+// ============================================================================
+// Folders
+// ============================================================================
+
+func (s *PostgresStore) SaveFolder(ctx context.Context, f *folder.Folder) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO folders (id, name, is_system) VALUES ($1, $2, $3)",
+		f.ID, f.Name, f.IsSystem,
+	)
+	return err
+}
+
+func (s *PostgresStore) GetFolder(ctx context.Context, id string) (*folder.Folder, error) {
+	var f folder.Folder
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, name, COALESCE(is_system, FALSE), version FROM folders WHERE id = $1", id,
+	).Scan(&f.ID, &f.Name, &f.IsSystem, &f.Version)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ListFolders returns all folders. System folders (like "Deleted") are only
+// included if they have at least one category.
+func (s *PostgresStore) ListFolders(ctx context.Context) ([]*folder.Folder, error) {
+	// Query folders and count categories for system folders
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT f.id, f.name, COALESCE(f.is_system, FALSE), f.version,
+		       (SELECT COUNT(*) FROM categories c WHERE c.folder_id = f.id) as cat_count
+		FROM folders f
+		ORDER BY f.seq
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var folders []*folder.Folder
+	for rows.Next() {
+		var f folder.Folder
+		var catCount int
+		if err := rows.Scan(&f.ID, &f.Name, &f.IsSystem, &f.Version, &catCount); err != nil {
+			return nil, err
+		}
+		// Only include system folders if they have categories
+		if f.IsSystem && catCount == 0 {
+			continue
+		}
+		folders = append(folders, &f)
+	}
+	return folders, nil
+}
+
+func (s *PostgresStore) UpdateFolder(ctx context.Context, f *folder.Folder) error {
+	// Prevent renaming system folders
+	existing, err := s.GetFolder(context.Background(), f.ID)
+	if err != nil {
+		return err
+	}
+	if existing.IsSystem {
+		return ErrSystemFolder
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE folders SET name = $1, version = version + 1 WHERE id = $2 AND COALESCE(is_system, FALSE) = FALSE AND ($3 = 0 OR version = $4)",
+		f.Name, f.ID, f.Version, f.Version,
+	)
+	if err != nil {
+		return err
+	}
+	return s.versionedUpdateResult(ctx, result, "folders", f.ID)
+}
+
+// DeleteFolder handles folder deletion:
+//   - System "Deleted" folder: cascade-delete all its content (categories, banks, questions, stats)
+//   - Regular folder: move its categories to the "Deleted" folder, then remove the folder
+func (s *PostgresStore) DeleteFolder(ctx context.Context, id string) error {
+	f, err := s.GetFolder(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if f.IsDeletedFolder() {
+		return s.EmptyDeletedFolder(ctx)
+	}
+
+	// Regular folder: move categories to Deleted folder, then delete the folder
+	deletedFolder, err := s.GetOrCreateDeletedFolder(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Move all categories from this folder to the Deleted folder
+	_, err = tx.ExecContext(ctx,
+		"UPDATE categories SET folder_id = $1, version = version + 1 WHERE folder_id = $2",
+		deletedFolder.ID, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Delete the folder itself
+	result, err := tx.ExecContext(ctx, "DELETE FROM folders WHERE id = $1 AND COALESCE(is_system, FALSE) = FALSE", id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// ============================================================================
+// System "Deleted" folder
+// ============================================================================
+
+// GetOrCreateDeletedFolder returns the system "Deleted" folder, creating it if needed.
+func (s *PostgresStore) GetOrCreateDeletedFolder(ctx context.Context) (*folder.Folder, error) {
+	var f folder.Folder
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, name, is_system, version FROM folders WHERE is_system = TRUE AND name = $1",
+		folder.SystemDeletedFolderName,
+	).Scan(&f.ID, &f.Name, &f.IsSystem, &f.Version)
+
+	if err == nil {
+		return &f, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Create it
+	f = *folder.NewSystem(folder.SystemDeletedFolderName)
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO folders (id, name, is_system) VALUES ($1, $2, $3)",
+		f.ID, f.Name, f.IsSystem,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// EmptyDeletedFolder moves all categories from the "Deleted" folder back to "All"
+// (folder_id = NULL), then removes the "Deleted" folder itself.
+func (s *PostgresStore) EmptyDeletedFolder(ctx context.Context) error {
+	deletedFolder, err := s.GetOrCreateDeletedFolder(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	folderID := deletedFolder.ID
+
+	// 1. Move all categories back to "All" (folder_id = NULL)
+	_, err = tx.ExecContext(ctx, "UPDATE categories SET folder_id = NULL, version = version + 1 WHERE folder_id = $1", folderID)
+	if err != nil {
+		return err
+	}
+
+	// 2. Delete the "Deleted" folder itself — it will be recreated on next folder delete
+	_, err = tx.ExecContext(ctx, "DELETE FROM folders WHERE id = $1", folderID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetFolderMastery returns the average mastery across all questions in all
+// banks in all categories belonging to the folder.
+func (s *PostgresStore) GetFolderMastery(ctx context.Context, folderID string) (int, error) {
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT CAST(AVG(COALESCE(qs.mastery, 0)) AS DOUBLE PRECISION)
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		JOIN categories c ON b.category_id = c.id
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE c.folder_id = $1 AND q.deleted_at IS NULL
+	`, folderID).Scan(&mastery)
+
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+// GetFolderMasteryWeighted returns the folder mastery aggregated with the
+// given weighting.
+func (s *PostgresStore) GetFolderMasteryWeighted(ctx context.Context, folderID string, weighting MasteryWeighting) (int, error) {
+	if weighting != MasteryWeightingAttempts {
+		return s.GetFolderMastery(ctx, folderID)
+	}
+
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+attemptsMasterySQL+`
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		JOIN categories c ON b.category_id = c.id
+		JOIN question_stats qs ON q.id = qs.question_id
+		WHERE c.folder_id = $1 AND q.deleted_at IS NULL AND qs.times_answered > 0
+	`, folderID).Scan(&mastery)
+
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+func (s *PostgresStore) GetFolderMasteryBatch(ctx context.Context, folderIDs []string) (map[string]int, error) {
+	result := make(map[string]int, len(folderIDs))
+	if len(folderIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.folder_id, CAST(TRUNC(AVG(COALESCE(qs.mastery, 0))) AS INTEGER)
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		JOIN categories c ON b.category_id = c.id
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE c.folder_id = ANY($1) AND q.deleted_at IS NULL
+		GROUP BY c.folder_id
+	`, folderIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var mastery int
+		if err := rows.Scan(&id, &mastery); err != nil {
+			return nil, err
+		}
+		result[id] = mastery
+	}
+	return result, nil
+}
+
+// ============================================================================
+// Category ↔ Folder relationship
+// ============================================================================
+
+// ListCategoriesByFolder returns all categories belonging to a folder.
+func (s *PostgresStore) ListCategoriesByFolder(ctx context.Context, folderID string) ([]*category.Category, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, folder_id, sort_order, version FROM categories WHERE folder_id = $1 ORDER BY sort_order ASC", folderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*category.Category
+	for rows.Next() {
+		var cat category.Category
+		var fID sql.NullString
+		if err := rows.Scan(&cat.ID, &cat.Name, &fID, &cat.SortOrder, &cat.Version); err != nil {
+			return nil, err
+		}
+		if fID.Valid {
+			cat.FolderID = &fID.String
+		}
+		categories = append(categories, &cat)
+	}
+	return categories, nil
+}
+
+// UpdateCategoryFolder moves a category to a different folder (or removes it with nil).
+// A non-zero expectedVersion must match the category's current version.
+func (s *PostgresStore) UpdateCategoryFolder(ctx context.Context, categoryID string, folderID *string, expectedVersion int) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE categories SET folder_id = $1, version = version + 1 WHERE id = $2 AND ($3 = 0 OR version = $4)",
+		folderID, categoryID, expectedVersion, expectedVersion,
+	)
+	if err != nil {
+		return err
+	}
+	return s.versionedUpdateResult(ctx, result, "categories", categoryID)
+}
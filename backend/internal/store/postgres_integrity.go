@@ -0,0 +1,19 @@
+package store
+
+import "context"
+
+// ============================================================================
+// Integrity
+// ============================================================================
+
+// FindOrphans counts rows whose parent row no longer exists, per table.
+func (s *PostgresStore) FindOrphans(ctx context.Context) ([]OrphanCount, error) {
+	return countOrphans(ctx, s.db)
+}
+
+// DeleteOrphans removes every row FindOrphans would count, and any row
+// orphaned by that, in one transaction. It returns the rows removed per
+// table.
+func (s *PostgresStore) DeleteOrphans(ctx context.Context) ([]OrphanCount, error) {
+	return deleteOrphans(ctx, s.db)
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// pgSearchSchema indexes the text that Search matches. The 'simple'
+// configuration matches words as typed, like the SQLite FTS5 tokenizer.
+const pgSearchSchema = `
+CREATE INDEX IF NOT EXISTS idx_questions_search ON questions
+    USING GIN (to_tsvector('simple', subject || ' ' || expected_answer));
+CREATE INDEX IF NOT EXISTS idx_banks_search ON banks
+    USING GIN (to_tsvector('simple', subject));
+`
+
+func migratePgForSearch(db *sql.DB) error {
+	_, err := db.Exec(pgSearchSchema)
+	return err
+}
+
+func (s *PostgresStore) Search(ctx context.Context, query SearchQuery) ([]SearchHit, error) {
+	terms := searchTerms(query.Text)
+	if len(terms) == 0 {
+		return []SearchHit{}, nil
+	}
+	var limit any
+	if query.Limit > 0 {
+		limit = query.Limit
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT bank_id, bank_subject, bank_type, question_id, subject_snippet, answer_snippet, exact
+		FROM (
+			SELECT q.bank_id, b.subject AS bank_subject, b.bank_type, q.id AS question_id,
+			       ts_headline('simple', q.subject, plainto_tsquery('simple', $1), $4) AS subject_snippet,
+			       ts_headline('simple', q.expected_answer, plainto_tsquery('simple', $1), $4) AS answer_snippet,
+			       to_tsvector('simple', q.subject || ' ' || q.expected_answer) @@ phraseto_tsquery('simple', $1) AS exact,
+			       ts_rank(to_tsvector('simple', q.subject || ' ' || q.expected_answer), plainto_tsquery('simple', $1)) AS rank
+			FROM questions q
+			JOIN banks b ON b.id = q.bank_id
+			WHERE to_tsvector('simple', q.subject || ' ' || q.expected_answer) @@ plainto_tsquery('simple', $1)
+			  AND q.deleted_at IS NULL AND ($2 = '' OR b.bank_type = $2)
+			UNION ALL
+			SELECT b.id, b.subject, b.bank_type, '',
+			       ts_headline('simple', b.subject, plainto_tsquery('simple', $1), $4), '',
+			       to_tsvector('simple', b.subject) @@ phraseto_tsquery('simple', $1),
+			       ts_rank(to_tsvector('simple', b.subject), plainto_tsquery('simple', $1))
+			FROM banks b
+			WHERE to_tsvector('simple', b.subject) @@ plainto_tsquery('simple', $1)
+			  AND b.deleted_at IS NULL AND ($2 = '' OR b.bank_type = $2)
+		) hits
+		ORDER BY exact DESC, rank DESC
+		LIMIT $3`,
+		strings.Join(terms, " "), query.BankType, limit, "StartSel="+searchMarkStart+", StopSel="+searchMarkEnd,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []SearchHit{}
+	for rows.Next() {
+		var h SearchHit
+		var subjectSnippet, answerSnippet string
+		if err := rows.Scan(&h.BankID, &h.BankSubject, &h.BankType, &h.QuestionID, &subjectSnippet, &answerSnippet, &h.Exact); err != nil {
+			return nil, err
+		}
+		if h.QuestionID == "" {
+			h.Field, h.Snippet = "bank_subject", highlightSnippet(subjectSnippet)
+		} else {
+			h.Field, h.Snippet = searchField([]string{"subject", "expected_answer"}, []string{subjectSnippet, answerSnippet})
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
@@ -0,0 +1,165 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/domain/category"
+	"github.com/remaimber-it/backend/internal/domain/folder"
+	practicesession "github.com/remaimber-it/backend/internal/domain/practice_session"
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+	"github.com/remaimber-it/backend/internal/store"
+)
+
+// newPostgresTestStore opens a PostgresStore on a fresh schema of the
+// database at POSTGRES_TEST_DSN (a postgres:// URL). Tests are skipped when
+// the variable is unset.
+func newPostgresTestStore(t *testing.T) *store.PostgresStore {
+	t.Helper()
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open admin connection: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("POSTGRES_TEST_DSN must be a URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	s, err := store.NewPostgres(u.String())
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestPostgres_CategoryVersionAndFolderDelete(t *testing.T) {
+	s := newPostgresTestStore(t)
+	ctx := context.Background()
+
+	f := folder.New("Work")
+	if err := s.SaveFolder(ctx, f); err != nil {
+		t.Fatalf("SaveFolder: %v", err)
+	}
+	cat := category.NewWithFolder("Go", f.ID)
+	if err := s.SaveCategory(ctx, cat); err != nil {
+		t.Fatalf("SaveCategory: %v", err)
+	}
+
+	cat.Name = "Golang"
+	if err := s.UpdateCategory(ctx, cat); err != nil {
+		t.Fatalf("UpdateCategory: %v", err)
+	}
+	cat.Name = "Stale"
+	if err := s.UpdateCategory(ctx, cat); err != store.ErrVersionConflict {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+
+	if err := s.DeleteFolder(ctx, f.ID); err != nil {
+		t.Fatalf("DeleteFolder: %v", err)
+	}
+	got, err := s.GetCategory(ctx, cat.ID)
+	if err != nil {
+		t.Fatalf("GetCategory: %v", err)
+	}
+	if got.FolderID == nil || *got.FolderID == f.ID {
+		t.Errorf("expected category moved to Deleted folder, got %v", got.FolderID)
+	}
+}
+
+func TestPostgres_GradesAndMastery(t *testing.T) {
+	s := newPostgresTestStore(t)
+	ctx := context.Background()
+
+	cat := category.New("Go")
+	s.SaveCategory(ctx, cat)
+	strong := seedBankWithScore(t, s, ctx, cat.ID, 90)
+	weak := seedBankWithScore(t, s, ctx, cat.ID, 30)
+
+	masteries, err := s.GetBankMasteryBatch(ctx, []string{strong, weak}, store.MasteryScopeAll)
+	if err != nil {
+		t.Fatalf("GetBankMasteryBatch: %v", err)
+	}
+	if masteries[strong] != 90 || masteries[weak] != 30 {
+		t.Errorf("expected masteries 90 and 30, got %v", masteries)
+	}
+
+	overall, err := s.GetCategoryMastery(ctx, cat.ID)
+	if err != nil {
+		t.Fatalf("GetCategoryMastery: %v", err)
+	}
+	if overall != 60 {
+		t.Errorf("expected category mastery 60, got %d", overall)
+	}
+
+	weakQs, err := s.GetWeakQuestionsAcrossBanks(ctx, []string{strong, weak}, 1)
+	if err != nil {
+		t.Fatalf("GetWeakQuestionsAcrossBanks: %v", err)
+	}
+	if len(weakQs) != 2 || weakQs[0].BankID != weak {
+		t.Errorf("expected the weak bank's question first, got %+v", weakQs)
+	}
+}
+
+func TestPostgres_MasteryMatchesDomain(t *testing.T) {
+	testMasteryMatchesDomain(t, newPostgresTestStore(t))
+}
+
+func TestPostgres_SessionLifecycle(t *testing.T) {
+	s := newPostgresTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	if err := s.SaveSession(ctx, session); err != nil {
+		t.Fatalf("SaveSession: %v", err)
+	}
+
+	q := session.Questions[0]
+	if err := s.SaveGradeFailure(ctx, session.ID, q.ID, "answer", "timeout", ""); err != nil {
+		t.Fatalf("SaveGradeFailure: %v", err)
+	}
+	if err := s.SaveGrade(ctx, session.ID, q.ID, 80, []string{"A"}, nil, []int{0}, nil, "answer", "", 0, nil); err != nil {
+		t.Fatalf("SaveGrade: %v", err)
+	}
+	grades, err := s.GetGrades(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetGrades: %v", err)
+	}
+	if len(grades) != 1 || grades[0].Status != store.GradeStatusSuccess || grades[0].Score != 80 {
+		t.Errorf("expected one successful grade of 80, got %+v", grades)
+	}
+
+	if err := s.CompleteSession(ctx, session.ID); err != nil {
+		t.Fatalf("CompleteSession: %v", err)
+	}
+	if err := s.CompleteSession(ctx, session.ID); err != store.ErrSessionCompleted {
+		t.Errorf("expected ErrSessionCompleted, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/remaimber-it/backend/internal/domain/questionbank"
+)
+
+// statsSQL holds a driver's statements for changeQuestionStats.
+type statsSQL struct {
+	get    string // times_answered, times_correct, total_score, latest_score, last_answered_at of a question_id
+	put    string // upserts every column, taking question_id first and mastery last
+	delete string // removes the stats of a question_id
+	latest string // score and graded_at of a question_id's newest successful grade
+}
+
+// changeQuestionStats reads a question's stats in tx (zeroed if it has
+// none), applies change, recomputes mastery with
+// QuestionStats.CalculateMastery and writes them back. Stats left with no
+// attempts are deleted. Mastery is only ever computed in Go, so the stored
+// value always matches the domain formula. Statements of q found in stmts
+// run prepared.
+func changeQuestionStats(ctx context.Context, tx *sql.Tx, stmts stmtCache, q statsSQL, questionID string, change func(*questionbank.QuestionStats)) error {
+	stats := questionbank.QuestionStats{QuestionID: questionID}
+	var lastAnswered int64
+	err := stmts.txQueryRow(ctx, tx, q.get, questionID).Scan(&stats.TimesAnswered, &stats.TimesCorrect, &stats.TotalScore, &stats.LatestScore, &lastAnswered)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if lastAnswered > 0 {
+		stats.LastAnswered = time.Unix(0, lastAnswered)
+	}
+
+	change(&stats)
+	if stats.TimesAnswered <= 0 {
+		_, err = stmts.txExec(ctx, tx, q.delete, questionID)
+		return err
+	}
+
+	stats.Mastery = stats.CalculateMastery()
+	lastAnswered = 0
+	if !stats.LastAnswered.IsZero() {
+		lastAnswered = stats.LastAnswered.UnixNano()
+	}
+	_, err = stmts.txExec(ctx, tx, q.put, questionID, stats.TimesAnswered, stats.TimesCorrect, stats.TotalScore, stats.LatestScore, lastAnswered, stats.Mastery)
+	return err
+}
+
+// countAnswer records a new answer scoring score.
+func countAnswer(score, threshold int, now time.Time) func(*questionbank.QuestionStats) {
+	return func(stats *questionbank.QuestionStats) {
+		stats.TimesAnswered++
+		stats.TimesCorrect += correctCount(score, threshold)
+		stats.TotalScore += score
+		stats.LatestScore = score
+		stats.LastAnswered = now
+	}
+}
+
+// replaceAnswer swaps a re-graded answer's previous score for its new one
+// without counting another attempt. Missing stats are recreated as a first
+// attempt.
+func replaceAnswer(previous, score, threshold int, now time.Time) func(*questionbank.QuestionStats) {
+	return func(stats *questionbank.QuestionStats) {
+		if stats.TimesAnswered == 0 {
+			countAnswer(score, threshold, now)(stats)
+			return
+		}
+		stats.TimesCorrect = max(stats.TimesCorrect+correctCount(score, threshold)-correctCount(previous, threshold), 0)
+		stats.TotalScore += score - previous
+		stats.LatestScore = score
+		stats.LastAnswered = now
+	}
+}
+
+// retractQuestionStats removes a previously counted answer scoring
+// previous, whose grade tx has already replaced with a grading failure. The
+// latest score and answer time are reloaded from the question's newest
+// remaining successful grade, so mastery is not computed from a score no
+// longer counted in the total.
+func retractQuestionStats(ctx context.Context, tx *sql.Tx, stmts stmtCache, q statsSQL, questionID string, previous, threshold int) error {
+	latest := &questionbank.QuestionStats{}
+	var gradedAt int64
+	err := stmts.txQueryRow(ctx, tx, q.latest, questionID).Scan(&latest.LatestScore, &gradedAt)
+	if err == sql.ErrNoRows {
+		latest = nil
+	} else if err != nil {
+		return err
+	}
+	if latest != nil && gradedAt > 0 {
+		latest.LastAnswered = time.Unix(0, gradedAt)
+	}
+	return changeQuestionStats(ctx, tx, stmts, q, questionID, retractAnswer(previous, threshold, latest))
+}
+
+// retractAnswer removes a previously counted answer scoring previous and
+// takes the latest score and answer time from latest, the newest remaining
+// grade. Without one (stats restored from a backup have no grades) they are
+// kept. Missing stats are left missing.
+func retractAnswer(previous, threshold int, latest *questionbank.QuestionStats) func(*questionbank.QuestionStats) {
+	return func(stats *questionbank.QuestionStats) {
+		if stats.TimesAnswered == 0 {
+			return
+		}
+		stats.TimesAnswered--
+		stats.TimesCorrect = max(stats.TimesCorrect-correctCount(previous, threshold), 0)
+		stats.TotalScore -= previous
+		if latest != nil {
+			stats.LatestScore = latest.LatestScore
+			if !latest.LastAnswered.IsZero() {
+				stats.LastAnswered = latest.LastAnswered
+			}
+		}
+	}
+}
+
+// correctCount is 1 for a score of at least threshold, else 0.
+func correctCount(score, threshold int) int {
+	if score >= threshold {
+		return 1
+	}
+	return 0
+}
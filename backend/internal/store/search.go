@@ -0,0 +1,42 @@
+package store
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// searchMarkStart and searchMarkEnd delimit the matched terms in the
+// snippets the databases return. They become <mark> tags only once the
+// snippet is HTML-escaped, so question text never reaches a client as
+// markup.
+const (
+	searchMarkStart = "\x02"
+	searchMarkEnd   = "\x03"
+)
+
+var searchMarkReplacer = strings.NewReplacer(searchMarkStart, "<mark>", searchMarkEnd, "</mark>")
+
+// highlightSnippet HTML-escapes a database snippet and wraps its matched
+// terms in <mark></mark>.
+func highlightSnippet(snippet string) string {
+	return searchMarkReplacer.Replace(html.EscapeString(snippet))
+}
+
+// searchTerms splits text into the letter/digit tokens that are matched.
+// Splitting also strips any FTS query syntax from user input.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchField picks the first snippet containing a match and highlights it.
+func searchField(fields []string, snippets []string) (string, string) {
+	for i, snippet := range snippets {
+		if strings.Contains(snippet, searchMarkStart) {
+			return fields[i], highlightSnippet(snippet)
+		}
+	}
+	return fields[0], highlightSnippet(snippets[0])
+}
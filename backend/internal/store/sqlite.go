@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 
@@ -74,6 +76,18 @@ CREATE TABLE IF NOT EXISTS grades (
     FOREIGN KEY (session_id) REFERENCES sessions(id)
 );
 
+CREATE TABLE IF NOT EXISTS session_answers (
+    session_id TEXT NOT NULL,
+    question_id TEXT NOT NULL,
+    user_answer TEXT NOT NULL,
+    self_covered TEXT,
+    answer_language TEXT NOT NULL DEFAULT '',
+    submitted_at INTEGER NOT NULL,
+    grade_status TEXT NOT NULL DEFAULT 'pending',
+    PRIMARY KEY (session_id, question_id),
+    FOREIGN KEY (session_id) REFERENCES sessions(id)
+);
+
 CREATE TABLE IF NOT EXISTS question_stats (
     question_id TEXT PRIMARY KEY,
     times_answered INTEGER NOT NULL DEFAULT 0,
@@ -83,22 +97,70 @@ CREATE TABLE IF NOT EXISTS question_stats (
     mastery INTEGER NOT NULL DEFAULT 0,
     FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
 );
+
+CREATE TABLE IF NOT EXISTS bank_tags (
+    bank_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (bank_id, tag),
+    FOREIGN KEY (bank_id) REFERENCES banks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS question_tags (
+    question_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (question_id, tag),
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS review_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bank_id TEXT NOT NULL,
+    reviewed_at INTEGER NOT NULL,
+    FOREIGN KEY (bank_id) REFERENCES banks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
 `
 
 type SQLiteStore struct {
-	db *sql.DB
+	db            *sql.DB
+	stmts         stmtCache // the hot statements listed in sqliteHotSQL, prepared once
+	passThreshold int       // minimum score counted in times_correct and streaks
 }
 
 // Compile-time check: *SQLiteStore must satisfy the Store interface.
 var _ Store = (*SQLiteStore)(nil)
 
+// sqliteBusyTimeout makes a connection wait up to 5s for another
+// connection's write lock instead of failing with SQLITE_BUSY, so concurrent
+// writers (such as a parallel import) queue up. A transaction that reads
+// before it writes cannot wait to upgrade its read lock and fails with
+// SQLITE_BUSY instead, so the ones that run concurrently start with a write.
+const sqliteBusyTimeout = "_pragma=busy_timeout(5000)"
+
 func NewSQLite(dbPath string) (*SQLiteStore, error) {
+	if dbPath != ":memory:" {
+		sep := "?"
+		if strings.Contains(dbPath, "?") {
+			sep = "&"
+		}
+		dbPath += sep + sqliteBusyTimeout
+	}
+
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, err
 	}
 
 	if _, err := db.Exec(schema); err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -108,6 +170,7 @@ func NewSQLite(dbPath string) (*SQLiteStore, error) {
 
 	// Folder support migration for existing databases
 	if err := migrateForFolders(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -117,17 +180,120 @@ func NewSQLite(dbPath string) (*SQLiteStore, error) {
 	// Add grading_prompt to questions for per-question grading override
 	_ = addColumnIfNotExists(db, "questions", "grading_prompt", "TEXT")
 
+	// Grading rubric, kept apart from the expected answer: banks set a
+	// default, questions may override it
+	_ = addColumnIfNotExists(db, "banks", "rubric", "TEXT")
+	_ = addColumnIfNotExists(db, "questions", "rubric", "TEXT")
+
+	// Soft delete: banks and questions in the trash carry the time they
+	// were deleted (unix nanoseconds) and are hidden from every read
+	_ = addColumnIfNotExists(db, "banks", "deleted_at", "INTEGER")
+	_ = addColumnIfNotExists(db, "questions", "deleted_at", "INTEGER")
+
 	// Add sort_order to categories for user-defined ordering
 	_ = addColumnIfNotExists(db, "categories", "sort_order", "INTEGER NOT NULL DEFAULT 0")
 
-	// Ensure only one grade per question per session.
+	// Grading mode: banks set a default, questions may override it
+	_ = addColumnIfNotExists(db, "banks", "grading_mode", "TEXT NOT NULL DEFAULT 'llm'")
+	// Per-bank scoring curve; empty uses the server-wide default
+	_ = addColumnIfNotExists(db, "banks", "scoring_curve", "TEXT NOT NULL DEFAULT ''")
+	_ = addColumnIfNotExists(db, "banks", "exact_case_sensitive", "BOOLEAN NOT NULL DEFAULT FALSE")
+	_ = addColumnIfNotExists(db, "banks", "exact_whitespace_sensitive", "BOOLEAN NOT NULL DEFAULT FALSE")
+	_ = addColumnIfNotExists(db, "questions", "grading_mode", "TEXT")
+
+	// Difficulty weights questions in bank mastery; existing ones are medium
+	_ = addColumnIfNotExists(db, "questions", "difficulty", "TEXT NOT NULL DEFAULT 'medium'")
+
+	// Key point indices for covered/missed labels
+	_ = addColumnIfNotExists(db, "grades", "covered_indices", "TEXT NOT NULL DEFAULT '[]'")
+	_ = addColumnIfNotExists(db, "grades", "missed_indices", "TEXT NOT NULL DEFAULT '[]'")
+
+	// Remember weak-first ordering so reloaded sessions report it
+	_ = addColumnIfNotExists(db, "sessions", "focus_on_weak", "BOOLEAN NOT NULL DEFAULT FALSE")
+
+	// Session time limit, enforced from when the session started
+	_ = addColumnIfNotExists(db, "sessions", "max_duration_min", "INTEGER")
+	_ = addColumnIfNotExists(db, "sessions", "started_at", "INTEGER NOT NULL DEFAULT 0") // unix nanoseconds
+
+	// Optimistic concurrency: bumped on every update of a mutable entity
+	// (folders.version is added by migrateForFolders)
+	_ = addColumnIfNotExists(db, "categories", "version", "INTEGER NOT NULL DEFAULT 1")
+	_ = addColumnIfNotExists(db, "banks", "version", "INTEGER NOT NULL DEFAULT 1")
+
+	// When each bank was created; 0 for banks that predate it
+	_ = addColumnIfNotExists(db, "banks", "created_at", "INTEGER NOT NULL DEFAULT 0") // unix nanoseconds
+
+	// Failure details for the admin grade-failures view
+	_ = addColumnIfNotExists(db, "grades", "failure_reason", "TEXT NOT NULL DEFAULT ''")
+	_ = addColumnIfNotExists(db, "grades", "raw_response", "TEXT NOT NULL DEFAULT ''")
+	_ = addColumnIfNotExists(db, "grades", "failed_at", "INTEGER NOT NULL DEFAULT 0") // unix nanoseconds
+
+	// When each grade was saved, for a question's answer history; 0 for
+	// grades that predate it
+	_ = addColumnIfNotExists(db, "grades", "graded_at", "INTEGER NOT NULL DEFAULT 0") // unix nanoseconds
+
+	// Which model graded each answer and how long it took; empty and 0 for
+	// grades that predate them
+	_ = addColumnIfNotExists(db, "grades", "model", "TEXT NOT NULL DEFAULT ''")
+	_ = addColumnIfNotExists(db, "grades", "duration_ms", "INTEGER NOT NULL DEFAULT 0")
+
+	// When each question was last graded; 0 for stats that predate it
+	_ = addColumnIfNotExists(db, "question_stats", "last_answered_at", "INTEGER NOT NULL DEFAULT 0") // unix nanoseconds
+
+	// Question order within a bank, served by banks with preserve_order;
+	// existing questions keep their insertion order
+	_ = addColumnIfNotExists(db, "questions", "position", "INTEGER NOT NULL DEFAULT 0")
+	_, _ = db.Exec("UPDATE questions SET position = rowid WHERE position = 0")
+	_ = addColumnIfNotExists(db, "banks", "preserve_order", "BOOLEAN NOT NULL DEFAULT FALSE")
+
+	// When each session was created; unlike started_at it survives restarts.
+	// 0 for sessions that predate it, which fall back to started_at
+	_ = addColumnIfNotExists(db, "sessions", "created_at", "INTEGER NOT NULL DEFAULT 0") // unix nanoseconds
+
+	// Other acceptable answers of a question, as a JSON array, and which of
+	// a question's answers a grade matched; NULL when it had only one
+	_ = addColumnIfNotExists(db, "questions", "alternative_answers", "TEXT NOT NULL DEFAULT '[]'")
+	_ = addColumnIfNotExists(db, "grades", "matched_answer", "INTEGER")
+
+	// Full-text search indexes, kept in sync by triggers
+	if err := migrateForSearch(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Ensure only one grade per question per session, keeping the latest of
+	// any duplicates saved before the index existed.
+	_, _ = db.Exec("DELETE FROM grades WHERE id NOT IN (SELECT MAX(id) FROM grades GROUP BY session_id, question_id)")
 	_, _ = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_grades_session_question ON grades (session_id, question_id)")
 
+	stmts, err := prepareStmts(context.Background(), db, sqliteHotSQL...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return &SQLiteStore{
-		db: db,
+		db:            db,
+		stmts:         stmts,
+		passThreshold: questionbank.PassThreshold,
 	}, nil
 }
 
+// sqliteHotSQL lists the statements run for every grade, prepared once in
+// NewSQLite rather than parsed on each call. Everything else runs
+// unprepared. Over eight runs of BenchmarkSaveGrade, preparing them cut the
+// median SaveGrade from about 155µs to 120µs in memory and from about 890µs
+// to 760µs on disk, where syncing each commit dominates and runs vary by
+// ±20%.
+var sqliteHotSQL = []string{
+	sqliteStatsSQL.get,
+	sqliteStatsSQL.put,
+	sqliteStatsSQL.delete,
+	sqliteSettleAnswerSQL,
+	sqlitePreviousGradeSQL,
+	sqliteStatsByBankSQL,
+}
+
 // validIdentifier reports whether s is a safe SQL identifier (letters, digits, underscores only).
 func validIdentifier(s string) bool {
 	if s == "" {
@@ -163,8 +329,29 @@ func isColumnExistsError(err error) bool {
 	return strings.Contains(msg, "duplicate column") || strings.Contains(msg, "already exists")
 }
 
+// versionedUpdateResult interprets the result of an UPDATE guarded by
+// "(? = 0 OR version = ?)": no affected rows means the row is missing or
+// its version moved on.
+func (s *SQLiteStore) versionedUpdateResult(ctx context.Context, result sql.Result, table, id string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ?)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
 func (s *SQLiteStore) Close() error {
-	return s.db.Close()
+	return errors.Join(s.stmts.close(), s.db.Close())
 }
 
 // ============================================================================
@@ -183,8 +370,8 @@ func (s *SQLiteStore) GetCategory(ctx context.Context, id string) (*category.Cat
 	var cat category.Category
 	var folderID sql.NullString
 	err := s.db.QueryRowContext(ctx,
-		"SELECT id, name, folder_id, sort_order FROM categories WHERE id = ?", id,
-	).Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder)
+		"SELECT id, name, folder_id, sort_order, version FROM categories WHERE id = ?", id,
+	).Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder, &cat.Version)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -198,7 +385,7 @@ func (s *SQLiteStore) GetCategory(ctx context.Context, id string) (*category.Cat
 }
 
 func (s *SQLiteStore) ListCategories(ctx context.Context) ([]*category.Category, error) {
-	rows, err := s.db.QueryContext(ctx, "SELECT id, name, folder_id, sort_order FROM categories ORDER BY sort_order ASC")
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, folder_id, sort_order, version FROM categories ORDER BY sort_order ASC")
 	if err != nil {
 		return nil, err
 	}
@@ -208,7 +395,7 @@ func (s *SQLiteStore) ListCategories(ctx context.Context) ([]*category.Category,
 	for rows.Next() {
 		var cat category.Category
 		var folderID sql.NullString
-		if err := rows.Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder); err != nil {
+		if err := rows.Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder, &cat.Version); err != nil {
 			return nil, err
 		}
 		if folderID.Valid {
@@ -219,6 +406,30 @@ func (s *SQLiteStore) ListCategories(ctx context.Context) ([]*category.Category,
 	return categories, nil
 }
 
+func (s *SQLiteStore) SaveCategoryWithBanks(ctx context.Context, cat *category.Category, banks []*questionbank.QuestionBank) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO categories (id, name, folder_id, sort_order) VALUES (?, ?, ?, (SELECT COALESCE(MAX(sort_order)+1, 0) FROM categories))",
+		cat.ID, cat.Name, cat.FolderID,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, bank := range banks {
+		if err := s.insertBank(ctx, tx, bank); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (s *SQLiteStore) ReorderCategories(ctx context.Context, ids []string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -235,18 +446,14 @@ func (s *SQLiteStore) ReorderCategories(ctx context.Context, ids []string) error
 }
 
 func (s *SQLiteStore) UpdateCategory(ctx context.Context, cat *category.Category) error {
-	result, err := s.db.ExecContext(ctx, "UPDATE categories SET name = ? WHERE id = ?", cat.Name, cat.ID)
-	if err != nil {
-		return err
-	}
-	rowsAffected, err := result.RowsAffected()
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE categories SET name = ?, folder_id = ?, version = version + 1 WHERE id = ? AND (? = 0 OR version = ?)",
+		cat.Name, cat.FolderID, cat.ID, cat.Version, cat.Version,
+	)
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return s.versionedUpdateResult(ctx, result, "categories", cat.ID)
 }
 
 func (s *SQLiteStore) DeleteCategory(ctx context.Context, id string) error {
@@ -269,6 +476,18 @@ func (s *SQLiteStore) DeleteCategory(ctx context.Context, id string) error {
 		return err
 	}
 
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM question_tags
+		WHERE question_id IN (
+			SELECT q.id FROM questions q
+			JOIN banks b ON q.bank_id = b.id
+			WHERE b.category_id = ?
+		)
+	`, id)
+	if err != nil {
+		return err
+	}
+
 	// Delete all questions belonging to banks in this category
 	_, err = tx.ExecContext(ctx, `
 		DELETE FROM questions 
@@ -278,6 +497,16 @@ func (s *SQLiteStore) DeleteCategory(ctx context.Context, id string) error {
 		return err
 	}
 
+	_, err = tx.ExecContext(ctx, "DELETE FROM bank_tags WHERE bank_id IN (SELECT id FROM banks WHERE category_id = ?)", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM review_log WHERE bank_id IN (SELECT id FROM banks WHERE category_id = ?)", id)
+	if err != nil {
+		return err
+	}
+
 	// Then, delete all banks in this category
 	_, err = tx.ExecContext(ctx, "DELETE FROM banks WHERE category_id = ?", id)
 	if err != nil {
@@ -301,13 +530,112 @@ func (s *SQLiteStore) DeleteCategory(ctx context.Context, id string) error {
 	return tx.Commit()
 }
 
+// DeleteCategoryReassigning moves every bank in the category to targetID
+// and then deletes the now-empty category, keeping questions and stats.
+// The caller is responsible for checking that targetID exists.
+func (s *SQLiteStore) DeleteCategoryReassigning(ctx context.Context, id, targetID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE banks SET category_id = ?, version = version + 1 WHERE category_id = ?", targetID, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
 // ============================================================================
 // Banks
 // ============================================================================
 
 func (s *SQLiteStore) SaveBank(ctx context.Context, bank *questionbank.QuestionBank) error {
-	_, err := s.db.ExecContext(ctx, "INSERT INTO banks (id, subject, category_id, bank_type, language, grading_prompt) VALUES (?, ?, ?, ?, ?, ?)", bank.ID, bank.Subject, bank.CategoryID, bank.BankType, bank.Language, bank.GradingPrompt)
-	return err
+	gradingMode := bank.GradingMode
+	if gradingMode == "" {
+		gradingMode = questionbank.GradingModeLLM
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO banks (id, subject, category_id, bank_type, language, grading_prompt, rubric, grading_mode, exact_case_sensitive, exact_whitespace_sensitive, scoring_curve, created_at, preserve_order) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		bank.ID, bank.Subject, bank.CategoryID, bank.BankType, bank.Language, bank.GradingPrompt, bank.Rubric,
+		gradingMode, bank.ExactMatch.CaseSensitive, bank.ExactMatch.WhitespaceSensitive, bank.ScoringCurve, createdAtNanos(bank.CreatedAt), bank.PreserveOrder,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := s.insertBankTags(ctx, tx, bank.ID, bank.Tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) SaveBankWithQuestions(ctx context.Context, bank *questionbank.QuestionBank) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.insertBank(ctx, tx, bank); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertBank inserts a bank with its tags and questions inside tx.
+func (s *SQLiteStore) insertBank(ctx context.Context, tx *sql.Tx, bank *questionbank.QuestionBank) error {
+	gradingMode := bank.GradingMode
+	if gradingMode == "" {
+		gradingMode = questionbank.GradingModeLLM
+	}
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO banks (id, subject, category_id, bank_type, language, grading_prompt, rubric, grading_mode, exact_case_sensitive, exact_whitespace_sensitive, scoring_curve, created_at, preserve_order) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		bank.ID, bank.Subject, bank.CategoryID, bank.BankType, bank.Language, bank.GradingPrompt, bank.Rubric,
+		gradingMode, bank.ExactMatch.CaseSensitive, bank.ExactMatch.WhitespaceSensitive, bank.ScoringCurve, createdAtNanos(bank.CreatedAt), bank.PreserveOrder,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := s.insertBankTags(ctx, tx, bank.ID, bank.Tags); err != nil {
+		return err
+	}
+	for _, q := range bank.Questions {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = ?))",
+			q.ID, bank.ID, q.Subject, q.ExpectedAnswer, q.GradingPrompt, q.Rubric, q.GradingMode, q.Difficulty.OrDefault(), alternativeAnswersJSON(q.AlternativeAnswers), bank.ID,
+		)
+		if err != nil {
+			return err
+		}
+		if err := s.insertQuestionTags(ctx, tx, q.ID, q.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *SQLiteStore) GetBank(ctx context.Context, id string) (*questionbank.QuestionBank, error) {
@@ -316,8 +644,14 @@ func (s *SQLiteStore) GetBank(ctx context.Context, id string) (*questionbank.Que
 	var bankType sql.NullString
 	var language sql.NullString
 	var gradingPrompt sql.NullString
+	var rubric sql.NullString
+	var gradingMode string
+	var createdAt int64
 
-	err := s.db.QueryRowContext(ctx, "SELECT id, subject, category_id, bank_type, language, grading_prompt FROM banks WHERE id = ?", id).Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &gradingPrompt)
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, subject, category_id, bank_type, language, grading_prompt, rubric, grading_mode, exact_case_sensitive, exact_whitespace_sensitive, scoring_curve, preserve_order, version, created_at FROM banks WHERE id = ? AND deleted_at IS NULL", id,
+	).Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &gradingPrompt, &rubric,
+		&gradingMode, &bank.ExactMatch.CaseSensitive, &bank.ExactMatch.WhitespaceSensitive, &bank.ScoringCurve, &bank.PreserveOrder, &bank.Version, &createdAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -339,8 +673,15 @@ func (s *SQLiteStore) GetBank(ctx context.Context, id string) (*questionbank.Que
 	if gradingPrompt.Valid {
 		bank.GradingPrompt = &gradingPrompt.String
 	}
+	if rubric.Valid {
+		bank.Rubric = &rubric.String
+	}
+	bank.GradingMode = questionbank.GradingMode(gradingMode)
+	if createdAt > 0 {
+		bank.CreatedAt = time.Unix(0, createdAt).UTC()
+	}
 
-	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, expected_answer, grading_prompt FROM questions WHERE bank_id = ?", id)
+	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers FROM questions WHERE bank_id = ? AND deleted_at IS NULL ORDER BY position, rowid", id)
 	if err != nil {
 		return nil, err
 	}
@@ -349,20 +690,51 @@ func (s *SQLiteStore) GetBank(ctx context.Context, id string) (*questionbank.Que
 	for rows.Next() {
 		var q questionbank.Question
 		var gradingPrompt sql.NullString
-		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &gradingPrompt); err != nil {
+		var rubric sql.NullString
+		var gradingMode sql.NullString
+		var alternatives string
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &gradingPrompt, &rubric, &gradingMode, &q.Difficulty, &alternatives); err != nil {
 			return nil, err
 		}
+		q.AlternativeAnswers = parseAlternativeAnswers(alternatives)
 		if gradingPrompt.Valid {
 			q.GradingPrompt = &gradingPrompt.String
 		}
+		if rubric.Valid {
+			q.Rubric = &rubric.String
+		}
+		if gradingMode.Valid {
+			mode := questionbank.GradingMode(gradingMode.String)
+			q.GradingMode = &mode
+		}
 		bank.Questions = append(bank.Questions, q)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	bank.Tags, err = s.bankTags(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	questionTags, err := s.bankQuestionTags(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bank.Questions {
+		bank.Questions[i].Tags = questionTags[bank.Questions[i].ID]
+		if bank.Questions[i].Tags == nil {
+			bank.Questions[i].Tags = []string{}
+		}
+	}
+
 	return &bank, nil
 }
 
 func (s *SQLiteStore) ListBanks(ctx context.Context) ([]*questionbank.QuestionBank, error) {
-	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, category_id, bank_type, language FROM banks")
+	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, category_id, bank_type, language, version FROM banks WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -374,7 +746,7 @@ func (s *SQLiteStore) ListBanks(ctx context.Context) ([]*questionbank.QuestionBa
 		var categoryID sql.NullString
 		var bankType sql.NullString
 		var language sql.NullString
-		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language); err != nil {
+		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &bank.Version); err != nil {
 			return nil, err
 		}
 		if categoryID.Valid {
@@ -395,9 +767,10 @@ func (s *SQLiteStore) ListBanks(ctx context.Context) ([]*questionbank.QuestionBa
 
 func (s *SQLiteStore) ListBanksWithCounts(ctx context.Context) ([]*BankWithCount, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT b.id, b.subject, b.category_id, b.bank_type, b.language,
-		       (SELECT COUNT(*) FROM questions q WHERE q.bank_id = b.id) as question_count
+		SELECT b.id, b.subject, b.category_id, b.bank_type, b.language, b.version,
+		       (SELECT COUNT(*) FROM questions q WHERE q.bank_id = b.id AND q.deleted_at IS NULL) as question_count
 		FROM banks b
+		WHERE b.deleted_at IS NULL
 	`)
 	if err != nil {
 		return nil, err
@@ -410,7 +783,7 @@ func (s *SQLiteStore) ListBanksWithCounts(ctx context.Context) ([]*BankWithCount
 		var categoryID sql.NullString
 		var bankType sql.NullString
 		var language sql.NullString
-		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &bank.QuestionCount); err != nil {
+		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &bank.Version, &bank.QuestionCount); err != nil {
 			return nil, err
 		}
 		if categoryID.Valid {
@@ -429,8 +802,64 @@ func (s *SQLiteStore) ListBanksWithCounts(ctx context.Context) ([]*BankWithCount
 	return banks, nil
 }
 
+// ListBanksWithMastery returns every bank with its question count and
+// mastery, computed in a single aggregate query.
+func (s *SQLiteStore) ListBanksWithMastery(ctx context.Context, scope MasteryScope) ([]*BankWithMastery, error) {
+	return s.listBanksWithMastery(ctx, scope, "")
+}
+
+// ListBanksByCategoryWithMastery is ListBanksWithMastery restricted to one
+// category.
+func (s *SQLiteStore) ListBanksByCategoryWithMastery(ctx context.Context, categoryID string, scope MasteryScope) ([]*BankWithMastery, error) {
+	return s.listBanksWithMastery(ctx, scope, "AND b.category_id = ?", categoryID)
+}
+
+// listBanksWithMastery computes mastery with the same expression as
+// GetBankMasteryScoped; banks without counted questions get 0.
+func (s *SQLiteStore) listBanksWithMastery(ctx context.Context, scope MasteryScope, where string, args ...any) ([]*BankWithMastery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.id, b.subject, b.category_id, b.bank_type, b.language, b.version,
+		       COUNT(q.id),
+		       COALESCE(CAST(`+bankMasterySQL(scope)+` AS INTEGER), 0)
+		FROM banks b
+		LEFT JOIN questions q ON q.bank_id = b.id AND q.deleted_at IS NULL
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		WHERE b.deleted_at IS NULL `+where+`
+		GROUP BY b.id
+		ORDER BY b.rowid
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var banks []*BankWithMastery
+	for rows.Next() {
+		var bank BankWithMastery
+		var categoryID sql.NullString
+		var bankType sql.NullString
+		var language sql.NullString
+		if err := rows.Scan(&bank.ID, &bank.Subject, &categoryID, &bankType, &language, &bank.Version, &bank.QuestionCount, &bank.Mastery); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			bank.CategoryID = &categoryID.String
+		}
+		if bankType.Valid {
+			bank.BankType = bankType.String
+		} else {
+			bank.BankType = "theory"
+		}
+		if language.Valid {
+			bank.Language = &language.String
+		}
+		banks = append(banks, &bank)
+	}
+	return banks, rows.Err()
+}
+
 func (s *SQLiteStore) ListBanksByCategory(ctx context.Context, categoryID string) ([]*questionbank.QuestionBank, error) {
-	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, category_id, bank_type, language FROM banks WHERE category_id = ?", categoryID)
+	rows, err := s.db.QueryContext(ctx, "SELECT id, subject, category_id, bank_type, language, version FROM banks WHERE category_id = ? AND deleted_at IS NULL", categoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -442,7 +871,7 @@ func (s *SQLiteStore) ListBanksByCategory(ctx context.Context, categoryID string
 		var catID sql.NullString
 		var bankType sql.NullString
 		var language sql.NullString
-		if err := rows.Scan(&bank.ID, &bank.Subject, &catID, &bankType, &language); err != nil {
+		if err := rows.Scan(&bank.ID, &bank.Subject, &catID, &bankType, &language, &bank.Version); err != nil {
 			return nil, err
 		}
 		if catID.Valid {
@@ -461,22 +890,22 @@ func (s *SQLiteStore) ListBanksByCategory(ctx context.Context, categoryID string
 	return banks, nil
 }
 
-func (s *SQLiteStore) UpdateBankCategory(ctx context.Context, bankID string, categoryID *string) error {
-	result, err := s.db.ExecContext(ctx, "UPDATE banks SET category_id = ? WHERE id = ?", categoryID, bankID)
-	if err != nil {
-		return err
-	}
-	rowsAffected, err := result.RowsAffected()
+// UpdateBankCategory moves a bank to another category. A non-zero
+// expectedVersion must match the bank's current version.
+func (s *SQLiteStore) UpdateBankCategory(ctx context.Context, bankID string, categoryID *string, expectedVersion int) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE banks SET category_id = ?, version = version + 1 WHERE id = ? AND (? = 0 OR version = ?)",
+		categoryID, bankID, expectedVersion, expectedVersion,
+	)
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return s.versionedUpdateResult(ctx, result, "banks", bankID)
 }
 
-func (s *SQLiteStore) DeleteBank(ctx context.Context, id string) error {
+// PurgeBank permanently deletes a bank, in the trash or not, with its
+// questions, tags and question stats.
+func (s *SQLiteStore) PurgeBank(ctx context.Context, id string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -492,11 +921,26 @@ func (s *SQLiteStore) DeleteBank(ctx context.Context, id string) error {
 		return err
 	}
 
+	_, err = tx.ExecContext(ctx, "DELETE FROM question_tags WHERE question_id IN (SELECT id FROM questions WHERE bank_id = ?)", id)
+	if err != nil {
+		return err
+	}
+
 	_, err = tx.ExecContext(ctx, "DELETE FROM questions WHERE bank_id = ?", id)
 	if err != nil {
 		return err
 	}
 
+	_, err = tx.ExecContext(ctx, "DELETE FROM bank_tags WHERE bank_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM review_log WHERE bank_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	result, err := tx.ExecContext(ctx, "DELETE FROM banks WHERE id = ?", id)
 	if err != nil {
 		return err
@@ -513,24 +957,387 @@ func (s *SQLiteStore) DeleteBank(ctx context.Context, id string) error {
 	return tx.Commit()
 }
 
-func (s *SQLiteStore) AddQuestion(ctx context.Context, bankID string, question questionbank.Question) error {
-	_, err := s.db.ExecContext(ctx,
-		"INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt) VALUES (?, ?, ?, ?, ?)",
-		question.ID, bankID, question.Subject, question.ExpectedAnswer, question.GradingPrompt,
-	)
+// LogBankReview records that a bank was read through at the given time.
+func (s *SQLiteStore) LogBankReview(ctx context.Context, bankID string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO review_log (bank_id, reviewed_at) VALUES (?, ?)", bankID, at.UnixNano())
 	return err
 }
 
-func (s *SQLiteStore) UpdateQuestion(ctx context.Context, question questionbank.Question) error {
-	result, err := s.db.ExecContext(ctx,
-		"UPDATE questions SET subject = ?, expected_answer = ?, grading_prompt = ? WHERE id = ?",
-		question.Subject, question.ExpectedAnswer, question.GradingPrompt, question.ID,
+// ListBankReviews returns up to limit review times of a bank, newest first.
+func (s *SQLiteStore) ListBankReviews(ctx context.Context, bankID string, limit int) ([]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT reviewed_at FROM review_log WHERE bank_id = ? ORDER BY reviewed_at DESC, id DESC LIMIT ?",
+		bankID, limit,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	defer rows.Close()
+
+	reviews := []time.Time{}
+	for rows.Next() {
+		var at int64
+		if err := rows.Scan(&at); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, time.Unix(0, at))
+	}
+	return reviews, rows.Err()
+}
+
+func (s *SQLiteStore) UpdateBankTags(ctx context.Context, bankIDs []string, add, remove []string) (*BankTagUpdate, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	update := &BankTagUpdate{Tags: make(map[string][]string, len(bankIDs))}
+	for _, bankID := range bankIDs {
+		if _, seen := update.Tags[bankID]; seen {
+			continue
+		}
+
+		var exists int
+		err := tx.QueryRowContext(ctx, "SELECT 1 FROM banks WHERE id = ? AND deleted_at IS NULL", bankID).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		removed := 0
+		for _, tag := range remove {
+			result, err := tx.ExecContext(ctx, "DELETE FROM bank_tags WHERE bank_id = ? AND tag = ?", bankID, tag)
+			if err != nil {
+				return nil, err
+			}
+			n, err := result.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			removed += int(n)
+		}
+
+		added, err := s.insertBankTags(ctx, tx, bankID, add)
+		if err != nil {
+			return nil, err
+		}
+
+		// Tags are part of the bank, so a change bumps its version.
+		if added+removed > 0 {
+			if _, err := tx.ExecContext(ctx, "UPDATE banks SET version = version + 1 WHERE id = ?", bankID); err != nil {
+				return nil, err
+			}
+		}
+		update.Added += added
+		update.Removed += removed
+
+		update.Tags[bankID], err = s.bankTags(ctx, tx, bankID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// insertBankTags adds tags to a bank, ignoring those it already has, and
+// returns how many were new.
+func (s *SQLiteStore) insertBankTags(ctx context.Context, tx *sql.Tx, bankID string, tags []string) (int, error) {
+	added := 0
+	for _, tag := range tags {
+		result, err := tx.ExecContext(ctx,
+			"INSERT INTO bank_tags (bank_id, tag) VALUES (?, ?) ON CONFLICT DO NOTHING", bankID, tag)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += int(n)
+	}
+	return added, nil
+}
+
+// bankTags returns a bank's tags in alphabetical order.
+func (s *SQLiteStore) bankTags(ctx context.Context, q queryer, bankID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT tag FROM bank_tags WHERE bank_id = ? ORDER BY tag", bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetQuestion returns a single question, scoped to its bank.
+// Returns ErrNotFound if the question does not exist in that bank.
+func (s *SQLiteStore) GetQuestion(ctx context.Context, bankID, questionID string) (*questionbank.Question, error) {
+	var q questionbank.Question
+	var gradingPrompt sql.NullString
+	var rubric sql.NullString
+	var gradingMode sql.NullString
+	var alternatives string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers FROM questions WHERE id = ? AND bank_id = ? AND deleted_at IS NULL",
+		questionID, bankID,
+	).Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &gradingPrompt, &rubric, &gradingMode, &q.Difficulty, &alternatives)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.AlternativeAnswers = parseAlternativeAnswers(alternatives)
+	if gradingPrompt.Valid {
+		q.GradingPrompt = &gradingPrompt.String
+	}
+	if rubric.Valid {
+		q.Rubric = &rubric.String
+	}
+	if gradingMode.Valid {
+		mode := questionbank.GradingMode(gradingMode.String)
+		q.GradingMode = &mode
+	}
+	q.Tags, err = s.questionTags(ctx, s.db, questionID)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// SetQuestionTags replaces the tags of a question in a bank. Returns
+// ErrNotFound if the question does not exist in that bank.
+func (s *SQLiteStore) SetQuestionTags(ctx context.Context, bankID, questionID string, tags []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM questions WHERE id = ? AND bank_id = ? AND deleted_at IS NULL", questionID, bankID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM question_tags WHERE question_id = ?", questionID); err != nil {
+		return err
+	}
+	if err := s.insertQuestionTags(ctx, tx, questionID, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertQuestionTags adds tags to a question, ignoring those it already has.
+func (s *SQLiteStore) insertQuestionTags(ctx context.Context, tx *sql.Tx, questionID string, tags []string) error {
+	for _, tag := range tags {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO question_tags (question_id, tag) VALUES (?, ?) ON CONFLICT DO NOTHING", questionID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// questionTags returns a question's tags in alphabetical order.
+func (s *SQLiteStore) questionTags(ctx context.Context, q queryer, questionID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT tag FROM question_tags WHERE question_id = ? ORDER BY tag", questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// bankQuestionTags returns the tags of a bank's questions by question ID,
+// each in alphabetical order.
+func (s *SQLiteStore) bankQuestionTags(ctx context.Context, bankID string) (map[string][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT qt.question_id, qt.tag
+		FROM question_tags qt
+		JOIN questions q ON q.id = qt.question_id
+		WHERE q.bank_id = ?
+		ORDER BY qt.tag
+	`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var questionID, tag string
+		if err := rows.Scan(&questionID, &tag); err != nil {
+			return nil, err
+		}
+		tags[questionID] = append(tags[questionID], tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLiteStore) AddQuestion(ctx context.Context, bankID string, question questionbank.Question) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = ?))",
+		question.ID, bankID, question.Subject, question.ExpectedAnswer, question.GradingPrompt, question.Rubric, question.GradingMode, question.Difficulty.OrDefault(), alternativeAnswersJSON(question.AlternativeAnswers), bankID,
+	)
+	return err
+}
+
+// AddQuestions inserts questions into a bank in one transaction: either all
+// are saved or none are.
+func (s *SQLiteStore) AddQuestions(ctx context.Context, bankID string, questions []questionbank.Question) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, q := range questions {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = ?))",
+			q.ID, bankID, q.Subject, q.ExpectedAnswer, q.GradingPrompt, q.Rubric, q.GradingMode, q.Difficulty.OrDefault(), alternativeAnswersJSON(q.AlternativeAnswers), bankID,
+		)
+		if err != nil {
+			return err
+		}
+		if err := s.insertQuestionTags(ctx, tx, q.ID, q.Tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MoveQuestion moves a question to the end of another bank in one
+// transaction. It keeps its ID, so its stats, tags and grades come along.
+// It fails with ErrNotFound if the question or the target bank is missing.
+func (s *SQLiteStore) MoveQuestion(ctx context.Context, questionID, targetBankID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM banks WHERE id = ? AND deleted_at IS NULL", targetBankID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx,
+		"UPDATE questions SET bank_id = ?, position = (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = ?) WHERE id = ? AND deleted_at IS NULL",
+		targetBankID, targetBankID, questionID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// CopyQuestion copies a question and its tags to the end of another bank
+// under newID, in one transaction. The copy starts with no stats. It fails
+// with ErrNotFound if the question or the target bank is missing.
+func (s *SQLiteStore) CopyQuestion(ctx context.Context, questionID, targetBankID, newID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM banks WHERE id = ? AND deleted_at IS NULL", targetBankID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO questions (id, bank_id, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers, position)
+		SELECT ?, ?, subject, expected_answer, grading_prompt, rubric, grading_mode, difficulty, alternative_answers,
+		       (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE bank_id = ?)
+		FROM questions WHERE id = ? AND deleted_at IS NULL
+	`, newID, targetBankID, targetBankID, questionID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO question_tags (question_id, tag) SELECT ?, tag FROM question_tags WHERE question_id = ?",
+		newID, questionID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) UpdateQuestion(ctx context.Context, question questionbank.Question) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE questions SET subject = ?, expected_answer = ?, alternative_answers = ?, grading_prompt = ?, rubric = ?, grading_mode = ? WHERE id = ?",
+		question.Subject, question.ExpectedAnswer, alternativeAnswersJSON(question.AlternativeAnswers), question.GradingPrompt, question.Rubric, question.GradingMode, question.ID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateQuestionDifficulty sets a question's difficulty, leaving its
+// content untouched.
+func (s *SQLiteStore) UpdateQuestionDifficulty(ctx context.Context, questionID string, difficulty questionbank.Difficulty) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE questions SET difficulty = ? WHERE id = ?", difficulty.OrDefault(), questionID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
 		return err
 	}
 	if rowsAffected == 0 {
@@ -539,7 +1346,9 @@ func (s *SQLiteStore) UpdateQuestion(ctx context.Context, question questionbank.
 	return nil
 }
 
-func (s *SQLiteStore) DeleteQuestion(ctx context.Context, id string) error {
+// PurgeQuestion permanently deletes a question, in the trash or not, with
+// its stats.
+func (s *SQLiteStore) PurgeQuestion(ctx context.Context, id string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -552,6 +1361,11 @@ func (s *SQLiteStore) DeleteQuestion(ctx context.Context, id string) error {
 		return err
 	}
 
+	_, err = tx.ExecContext(ctx, "DELETE FROM question_tags WHERE question_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	result, err := tx.ExecContext(ctx, "DELETE FROM questions WHERE id = ?", id)
 	if err != nil {
 		return err
@@ -571,7 +1385,13 @@ func (s *SQLiteStore) DeleteQuestion(ctx context.Context, id string) error {
 // Sessions
 // ============================================================================
 
+// SaveSession stores a new session. A zero StartedAt is set to now, which
+// starts the session's time limit.
 func (s *SQLiteStore) SaveSession(ctx context.Context, session *practicesession.PracticeSession) error {
+	if session.StartedAt.IsZero() {
+		session.StartedAt = time.Now()
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -579,8 +1399,8 @@ func (s *SQLiteStore) SaveSession(ctx context.Context, session *practicesession.
 	defer tx.Rollback()
 
 	_, err = tx.ExecContext(ctx,
-		"INSERT INTO sessions (id, bank_id, status) VALUES (?, ?, ?)",
-		session.ID, session.QuestionBankId, string(session.Status),
+		"INSERT INTO sessions (id, bank_id, status, focus_on_weak, max_duration_min, started_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		session.ID, session.QuestionBankId, string(session.Status), session.FocusOnWeak, session.MaxDuration, session.StartedAt.UnixNano(), session.StartedAt.UnixNano(),
 	)
 	if err != nil {
 		return err
@@ -610,10 +1430,12 @@ func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*practicesessi
 	var session practicesession.PracticeSession
 	var bankID string
 	var status string
+	var maxDuration sql.NullInt64
+	var startedAt int64
 
 	err := s.db.QueryRowContext(ctx,
-		"SELECT id, bank_id, COALESCE(status, 'active') FROM sessions WHERE id = ?", id,
-	).Scan(&session.ID, &bankID, &status)
+		"SELECT id, bank_id, COALESCE(status, 'active'), focus_on_weak, max_duration_min, started_at FROM sessions WHERE id = ?", id,
+	).Scan(&session.ID, &bankID, &status, &session.FocusOnWeak, &maxDuration, &startedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -622,6 +1444,13 @@ func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*practicesessi
 	}
 	session.QuestionBankId = bankID
 	session.Status = practicesession.SessionStatus(status)
+	if maxDuration.Valid {
+		mins := int(maxDuration.Int64)
+		session.MaxDuration = &mins
+	}
+	if startedAt > 0 {
+		session.StartedAt = time.Unix(0, startedAt)
+	}
 
 	rows, err := s.db.QueryContext(ctx,
 		"SELECT question_id, question_subject, expected_answer FROM session_questions WHERE session_id = ? ORDER BY position",
@@ -675,131 +1504,610 @@ func (s *SQLiteStore) CompleteSession(ctx context.Context, id string) error {
 	return nil
 }
 
+// ListIncompleteSessions returns active sessions started before
+// startedBefore, oldest first. Sessions without a recorded start count as
+// older than any cutoff.
+func (s *SQLiteStore) ListIncompleteSessions(ctx context.Context, startedBefore time.Time) ([]IncompleteSession, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, s.bank_id, s.started_at, s.max_duration_min,
+		       (SELECT COUNT(*) FROM session_questions sq WHERE sq.session_id = s.id),
+		       (SELECT COUNT(*) FROM grades g WHERE g.session_id = s.id)
+		FROM sessions s
+		WHERE COALESCE(s.status, 'active') = ? AND s.started_at < ?
+		ORDER BY s.started_at, s.id
+	`, string(practicesession.SessionStatusActive), startedBefore.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []IncompleteSession{}
+	for rows.Next() {
+		var session IncompleteSession
+		var startedAt int64
+		var maxDuration sql.NullInt64
+		if err := rows.Scan(&session.ID, &session.BankID, &startedAt, &maxDuration, &session.QuestionCount, &session.AnsweredCount); err != nil {
+			return nil, err
+		}
+		if startedAt > 0 {
+			session.StartedAt = time.Unix(0, startedAt)
+		}
+		if maxDuration.Valid {
+			mins := int(maxDuration.Int64)
+			session.MaxDuration = &mins
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *SQLiteStore) ListSessionsByBank(ctx context.Context, bankID string) ([]SessionSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, COALESCE(s.status, 'active'),
+		       CASE WHEN s.created_at > 0 THEN s.created_at ELSE s.started_at END AS created,
+		       (SELECT COUNT(*) FROM session_questions sq WHERE sq.session_id = s.id),
+		       (SELECT COUNT(*) FROM grades g WHERE g.session_id = s.id),
+		       (SELECT COALESCE(SUM(g.score), 0) FROM grades g WHERE g.session_id = s.id)
+		FROM sessions s
+		WHERE s.bank_id = ?
+		ORDER BY created DESC, s.id
+	`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []SessionSummary{}
+	for rows.Next() {
+		var session SessionSummary
+		var status string
+		var createdAt int64
+		if err := rows.Scan(&session.ID, &status, &createdAt, &session.QuestionCount, &session.AnsweredCount, &session.TotalScore); err != nil {
+			return nil, err
+		}
+		session.Status = practicesession.SessionStatus(status)
+		if createdAt > 0 {
+			session.CreatedAt = time.Unix(0, createdAt)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// ListSessionActivity returns the start time, status and successfully
+// graded answers of every session, oldest first. Sessions saved before
+// start times were recorded are left out.
+func (s *SQLiteStore) ListSessionActivity(ctx context.Context) ([]SessionActivity, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT CASE WHEN s.created_at > 0 THEN s.created_at ELSE s.started_at END AS created,
+		       COALESCE(s.status, 'active'),
+		       (SELECT COUNT(*) FROM grades g WHERE g.session_id = s.id AND g.status = ?)
+		FROM sessions s
+		WHERE s.created_at > 0 OR s.started_at > 0
+		ORDER BY created, s.id
+	`, GradeStatusSuccess)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []SessionActivity
+	for rows.Next() {
+		var a SessionActivity
+		var startedAt int64
+		var status string
+		if err := rows.Scan(&startedAt, &status, &a.Answered); err != nil {
+			return nil, err
+		}
+		a.StartedAt = time.Unix(0, startedAt)
+		a.Status = practicesession.SessionStatus(status)
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// AverageTimePerQuestion estimates how long answering one of a bank's
+// questions takes from its past sessions: the time from each session's
+// start to its last grade, divided by the answers given. Sessions without
+// recorded times are skipped. It also returns how many answers the
+// average is based on; with none the average is 0.
+func (s *SQLiteStore) AverageTimePerQuestion(ctx context.Context, bankID string) (time.Duration, int, error) {
+	var total sql.NullInt64
+	var answers int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT SUM(last_graded_at - started_at), COALESCE(SUM(answers), 0)
+		FROM (
+			SELECT s.started_at, MAX(g.graded_at) AS last_graded_at, COUNT(*) AS answers
+			FROM sessions s
+			JOIN grades g ON g.session_id = s.id
+			WHERE s.bank_id = ? AND s.started_at > 0 AND g.graded_at > 0
+			GROUP BY s.id, s.started_at
+			HAVING MAX(g.graded_at) > s.started_at
+		) timed
+	`, bankID).Scan(&total, &answers)
+	if err != nil {
+		return 0, 0, err
+	}
+	if answers == 0 {
+		return 0, 0, nil
+	}
+	return time.Duration(total.Int64 / int64(answers)), answers, nil
+}
+
+// RestartSession deletes an active session's grades and persisted answers,
+// stores the current order of session.Questions and restarts its time limit
+// from now. Question stats already updated by those grades are kept.
+// Completed sessions return ErrSessionCompleted.
+func (s *SQLiteStore) RestartSession(ctx context.Context, session *practicesession.PracticeSession) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(status, 'active') FROM sessions WHERE id = ?", session.ID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != string(practicesession.SessionStatusActive) {
+		return ErrSessionCompleted
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM grades WHERE session_id = ?", session.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM session_answers WHERE session_id = ?", session.ID); err != nil {
+		return err
+	}
+
+	startedAt := time.Now()
+	if _, err := tx.ExecContext(ctx, "UPDATE sessions SET started_at = ? WHERE id = ?", startedAt.UnixNano(), session.ID); err != nil {
+		return err
+	}
+
+	for i, q := range session.Questions {
+		_, err := tx.ExecContext(ctx,
+			"UPDATE session_questions SET position = ? WHERE session_id = ? AND question_id = ?",
+			i, session.ID, q.ID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	session.StartedAt = startedAt
+	return nil
+}
+
 // ============================================================================
 // Grades
 // ============================================================================
 
 // SaveGrade stores a successful grading result.
-// If a grade already exists for this (session, question) pair it is overwritten.
-func (s *SQLiteStore) SaveGrade(ctx context.Context, sessionID string, questionID string, score int, covered, missed []string, userAnswer string) error {
+// If a grade already exists for this (session, question) pair it is
+// overwritten, and a previous successful grade's contribution to the
+// question stats is replaced rather than counted again. A result for an
+// answer that a newer submission replaced while it was being graded is
+// dropped, so the latest submission wins whichever grading finishes last.
+func (s *SQLiteStore) SaveGrade(ctx context.Context, sessionID string, questionID string, score int, covered, missed []string, coveredIndices, missedIndices []int, userAnswer string, model string, duration time.Duration, matchedAnswer *int) error {
 	coveredJSON, _ := json.Marshal(covered)
 	missedJSON, _ := json.Marshal(missed)
+	coveredIdxJSON, _ := json.Marshal(nonNilInts(coveredIndices))
+	missedIdxJSON, _ := json.Marshal(nonNilInts(missedIndices))
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO grades (session_id, question_id, score, covered, missed, user_answer, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.settleAnswer(ctx, tx, sessionID, questionID, userAnswer, GradeStatusSuccess)
+	if err != nil || !current {
+		return err
+	}
+
+	previous, regraded, err := s.previousGradeScore(ctx, tx, sessionID, questionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO grades (session_id, question_id, score, covered, missed, covered_indices, missed_indices, user_answer, status, graded_at, model, duration_ms, matched_answer)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(session_id, question_id) DO UPDATE SET
 			score = excluded.score,
 			covered = excluded.covered,
 			missed = excluded.missed,
+			covered_indices = excluded.covered_indices,
+			missed_indices = excluded.missed_indices,
 			user_answer = excluded.user_answer,
-			status = excluded.status`,
-		sessionID, questionID, score, string(coveredJSON), string(missedJSON), userAnswer, GradeStatusSuccess,
+			status = excluded.status,
+			graded_at = excluded.graded_at,
+			model = excluded.model,
+			duration_ms = excluded.duration_ms,
+			matched_answer = excluded.matched_answer`,
+		sessionID, questionID, score, string(coveredJSON), string(missedJSON), string(coveredIdxJSON), string(missedIdxJSON), userAnswer, GradeStatusSuccess,
+		time.Now().UnixNano(), model, duration.Milliseconds(), matchedAnswer,
 	)
 	if err != nil {
 		return err
 	}
 
 	// Update question statistics
-	return s.updateQuestionStats(ctx, questionID, score)
+	if regraded {
+		err = s.replaceQuestionStatsScore(ctx, tx, questionID, previous, score)
+	} else {
+		err = s.updateQuestionStats(ctx, tx, questionID, score)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // SaveGradeFailure stores a record when grading fails, so the user sees
-// "grading failed" instead of "not answered."
-func (s *SQLiteStore) SaveGradeFailure(ctx context.Context, sessionID string, questionID string, userAnswer string, reason string) error {
+// "grading failed" instead of "not answered." A successful grade it
+// overwrites is retracted from the question stats. Like SaveGrade, it drops
+// the failure of an answer a newer submission replaced.
+func (s *SQLiteStore) SaveGradeFailure(ctx context.Context, sessionID string, questionID string, userAnswer string, reason string, rawResponse string) error {
 	missed := []string{"Grading failed: " + reason}
 	missedJSON, _ := json.Marshal(missed)
 	coveredJSON, _ := json.Marshal([]string{})
+	now := time.Now().UnixNano()
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO grades (session_id, question_id, score, covered, missed, user_answer, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := s.settleAnswer(ctx, tx, sessionID, questionID, userAnswer, GradeStatusFailed)
+	if err != nil || !current {
+		return err
+	}
+
+	previous, regraded, err := s.previousGradeScore(ctx, tx, sessionID, questionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO grades (session_id, question_id, score, covered, missed, user_answer, status, failure_reason, raw_response, failed_at, graded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(session_id, question_id) DO UPDATE SET
 			score = excluded.score,
 			covered = excluded.covered,
 			missed = excluded.missed,
+			covered_indices = '[]',
+			missed_indices = '[]',
 			user_answer = excluded.user_answer,
-			status = excluded.status`,
+			status = excluded.status,
+			failure_reason = excluded.failure_reason,
+			raw_response = excluded.raw_response,
+			failed_at = excluded.failed_at,
+			graded_at = excluded.graded_at,
+			model = '',
+			duration_ms = 0,
+			matched_answer = NULL`,
 		sessionID, questionID, 0, string(coveredJSON), string(missedJSON), userAnswer, GradeStatusFailed,
+		reason, rawResponse, now, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	if regraded {
+		if err := s.retractQuestionStatsScore(ctx, tx, questionID, previous); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SavePendingAnswer persists a submitted answer before it is graded,
+// replacing an earlier answer to the same question of the session.
+func (s *SQLiteStore) SavePendingAnswer(ctx context.Context, answer PendingAnswer) error {
+	var selfCovered sql.NullString
+	if answer.SelfCovered != nil {
+		data, _ := json.Marshal(answer.SelfCovered)
+		selfCovered = sql.NullString{String: string(data), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO session_answers (session_id, question_id, user_answer, self_covered, answer_language, submitted_at, grade_status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(session_id, question_id) DO UPDATE SET
+			user_answer = excluded.user_answer,
+			self_covered = excluded.self_covered,
+			answer_language = excluded.answer_language,
+			submitted_at = excluded.submitted_at,
+			grade_status = excluded.grade_status`,
+		answer.SessionID, answer.QuestionID, answer.UserAnswer, selfCovered, answer.AnswerLanguage, answer.SubmittedAt.UnixNano(), GradeStatusPending,
 	)
 	return err
 }
 
-func (s *SQLiteStore) GetGrades(ctx context.Context, sessionID string) ([]StoredGrade, error) {
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT question_id, score, covered, missed, user_answer, COALESCE(status, 'success') FROM grades WHERE session_id = ?",
-		sessionID,
+// DeletePendingAnswer drops the persisted answer to a question of a
+// session, e.g. one that can no longer be graded because its session is
+// gone.
+func (s *SQLiteStore) DeletePendingAnswer(ctx context.Context, sessionID string, questionID string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM session_answers WHERE session_id = ? AND question_id = ?", sessionID, questionID)
+	return err
+}
+
+// ListPendingAnswers returns the answers SaveGrade and SaveGradeFailure
+// have not settled yet, oldest first.
+func (s *SQLiteStore) ListPendingAnswers(ctx context.Context) ([]PendingAnswer, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, question_id, user_answer, self_covered, answer_language, submitted_at
+		FROM session_answers
+		WHERE grade_status = ?
+		ORDER BY submitted_at, session_id, question_id`,
+		GradeStatusPending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []PendingAnswer
+	for rows.Next() {
+		var a PendingAnswer
+		var selfCovered sql.NullString
+		var submittedAt int64
+		if err := rows.Scan(&a.SessionID, &a.QuestionID, &a.UserAnswer, &selfCovered, &a.AnswerLanguage, &submittedAt); err != nil {
+			return nil, err
+		}
+		if selfCovered.Valid {
+			json.Unmarshal([]byte(selfCovered.String), &a.SelfCovered)
+		}
+		a.SubmittedAt = time.Unix(0, submittedAt)
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+// ListGradeFailures returns up to limit failed grades, newest first.
+func (s *SQLiteStore) ListGradeFailures(ctx context.Context, limit int) ([]GradeFailure, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, question_id, user_answer, failure_reason, raw_response, failed_at
+		FROM grades
+		WHERE status = ?
+		ORDER BY failed_at DESC, id DESC
+		LIMIT ?`,
+		GradeStatusFailed, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := []GradeFailure{}
+	for rows.Next() {
+		var f GradeFailure
+		var failedAt int64
+		if err := rows.Scan(&f.SessionID, &f.QuestionID, &f.UserAnswer, &f.Reason, &f.RawResponse, &failedAt); err != nil {
+			return nil, err
+		}
+		if failedAt > 0 {
+			f.FailedAt = time.Unix(0, failedAt).UTC()
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+func (s *SQLiteStore) GetGrades(ctx context.Context, sessionID string) ([]StoredGrade, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT question_id, score, covered, missed, covered_indices, missed_indices, user_answer, COALESCE(status, 'success'), graded_at, model, duration_ms, matched_answer FROM grades WHERE session_id = ?",
+		sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grades []StoredGrade
+	for rows.Next() {
+		var g StoredGrade
+		var coveredJSON, missedJSON, coveredIdxJSON, missedIdxJSON string
+		var status string
+		var gradedAt, durationMs int64
+		var matched sql.NullInt64
+		if err := rows.Scan(&g.QuestionID, &g.Score, &coveredJSON, &missedJSON, &coveredIdxJSON, &missedIdxJSON, &g.UserAnswer, &status, &gradedAt, &g.Model, &durationMs, &matched); err != nil {
+			return nil, err
+		}
+		if matched.Valid {
+			index := int(matched.Int64)
+			g.MatchedAnswer = &index
+		}
+		if gradedAt > 0 {
+			g.GradedAt = time.Unix(0, gradedAt).UTC()
+		}
+		g.Duration = time.Duration(durationMs) * time.Millisecond
+		json.Unmarshal([]byte(coveredJSON), &g.Covered)
+		json.Unmarshal([]byte(missedJSON), &g.Missed)
+		json.Unmarshal([]byte(coveredIdxJSON), &g.CoveredIndices)
+		json.Unmarshal([]byte(missedIdxJSON), &g.MissedIndices)
+		g.Status = GradeStatus(status)
+		grades = append(grades, g)
+	}
+	return grades, nil
+}
+
+// GetGradesByQuestion returns up to limit grades of a question across
+// sessions, newest first.
+func (s *SQLiteStore) GetGradesByQuestion(ctx context.Context, questionID string, limit int) ([]QuestionGrade, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, score, covered, missed, covered_indices, missed_indices, user_answer, status, graded_at, model, duration_ms
+		FROM grades
+		WHERE question_id = ?
+		ORDER BY graded_at DESC, id DESC
+		LIMIT ?`,
+		questionID, limit,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var grades []StoredGrade
+	grades := []QuestionGrade{}
 	for rows.Next() {
-		var g StoredGrade
-		var coveredJSON, missedJSON string
-		var status string
-		if err := rows.Scan(&g.QuestionID, &g.Score, &coveredJSON, &missedJSON, &g.UserAnswer, &status); err != nil {
+		g := QuestionGrade{StoredGrade: StoredGrade{QuestionID: questionID}}
+		var coveredJSON, missedJSON, coveredIdxJSON, missedIdxJSON, status string
+		var gradedAt, durationMs int64
+		if err := rows.Scan(&g.SessionID, &g.Score, &coveredJSON, &missedJSON, &coveredIdxJSON, &missedIdxJSON, &g.UserAnswer, &status, &gradedAt, &g.Model, &durationMs); err != nil {
 			return nil, err
 		}
+		g.Duration = time.Duration(durationMs) * time.Millisecond
 		json.Unmarshal([]byte(coveredJSON), &g.Covered)
 		json.Unmarshal([]byte(missedJSON), &g.Missed)
+		json.Unmarshal([]byte(coveredIdxJSON), &g.CoveredIndices)
+		json.Unmarshal([]byte(missedIdxJSON), &g.MissedIndices)
 		g.Status = GradeStatus(status)
+		if gradedAt > 0 {
+			g.GradedAt = time.Unix(0, gradedAt).UTC()
+		}
 		grades = append(grades, g)
 	}
-	return grades, nil
+	return grades, rows.Err()
+}
+
+// createdAtNanos stores t as unix nanoseconds, using the current time for
+// entities built without a creation time.
+func createdAtNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return time.Now().UnixNano()
+	}
+	return t.UnixNano()
+}
+
+// nonNilInts returns s, or an empty slice when s is nil, so it is stored
+// as a JSON array rather than null.
+func nonNilInts(s []int) []int {
+	if s == nil {
+		return []int{}
+	}
+	return s
+}
+
+// alternativeAnswersJSON encodes a question's alternative answers for the
+// alternative_answers column.
+func alternativeAnswersJSON(answers []string) string {
+	if len(answers) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(answers)
+	return string(data)
+}
+
+// parseAlternativeAnswers decodes the alternative_answers column, returning
+// nil when there are none.
+func parseAlternativeAnswers(data string) []string {
+	var answers []string
+	json.Unmarshal([]byte(data), &answers)
+	if len(answers) == 0 {
+		return nil
+	}
+	return answers
 }
 
 // ============================================================================
 // Question Statistics
 // ============================================================================
 
-func (s *SQLiteStore) updateQuestionStats(ctx context.Context, questionID string, score int) error {
-	// Check if stats exist
-	var exists bool
-	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM question_stats WHERE question_id = ?)", questionID).Scan(&exists)
+// settleAnswer marks the persisted answer to a question of a session with
+// the status its grading ended in. It reports false, settling nothing, when
+// a newer submission replaced userAnswer while it was being graded; an
+// answer that was never persisted counts as current. SaveGrade and
+// SaveGradeFailure run it first so that, as a write, it takes the database
+// lock before the transaction reads anything.
+func (s *SQLiteStore) settleAnswer(ctx context.Context, tx *sql.Tx, sessionID, questionID, userAnswer string, status GradeStatus) (bool, error) {
+	res, err := s.stmts.txExec(ctx, tx, sqliteSettleAnswerSQL, status, sessionID, questionID, userAnswer)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	isCorrect := 0
-	if score >= 70 {
-		isCorrect = 1
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err == nil, err
 	}
+	var found int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM session_answers WHERE session_id = ? AND question_id = ?", sessionID, questionID).Scan(&found)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	return false, err
+}
 
-	if exists {
-		// Compute mastery using the new total_score and times_answered AFTER incrementing.
-		// new_avg = (total_score + score) / (times_answered + 1)
-		// mastery  = latest_score * 0.6 + new_avg * 0.4
-		_, err = s.db.ExecContext(ctx, `
-			UPDATE question_stats
-			SET times_answered = times_answered + 1,
-			    times_correct  = times_correct + ?,
-			    total_score    = total_score + ?,
-			    latest_score   = ?,
-			    mastery        = CAST(
-			        ? * 0.6 +
-			        (CAST(total_score + ? AS REAL) / (times_answered + 1)) * 0.4
-			    AS INTEGER)
-			WHERE question_id = ?
-		`, isCorrect, score, score, score, score, questionID)
-	} else {
-		_, err = s.db.ExecContext(ctx, `
-			INSERT INTO question_stats (question_id, times_answered, times_correct, total_score, latest_score, mastery)
-			VALUES (?, 1, ?, ?, ?, ?)
-		`, questionID, isCorrect, score, score, score)
+const sqliteSettleAnswerSQL = "UPDATE session_answers SET grade_status = ? WHERE session_id = ? AND question_id = ? AND user_answer = ?"
+
+// previousGradeScore returns the score of the successful grade already stored
+// for the (session, question) pair, if any. Failed gradings never counted
+// towards the question stats, so they are ignored.
+func (s *SQLiteStore) previousGradeScore(ctx context.Context, tx *sql.Tx, sessionID, questionID string) (int, bool, error) {
+	var score int
+	err := s.stmts.txQueryRow(ctx, tx, sqlitePreviousGradeSQL, sessionID, questionID, GradeStatusSuccess).Scan(&score)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
 	}
+	return score, true, nil
+}
 
-	return err
+const sqlitePreviousGradeSQL = "SELECT score FROM grades WHERE session_id = ? AND question_id = ? AND status = ?"
+
+// sqliteStatsSQL holds the question_stats statements of changeQuestionStats.
+var sqliteStatsSQL = statsSQL{
+	get: "SELECT times_answered, times_correct, total_score, latest_score, last_answered_at FROM question_stats WHERE question_id = ?",
+	put: `
+		INSERT INTO question_stats (question_id, times_answered, times_correct, total_score, latest_score, last_answered_at, mastery)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(question_id) DO UPDATE SET
+		    times_answered   = excluded.times_answered,
+		    times_correct    = excluded.times_correct,
+		    total_score      = excluded.total_score,
+		    latest_score     = excluded.latest_score,
+		    last_answered_at = excluded.last_answered_at,
+		    mastery          = excluded.mastery
+	`,
+	delete: "DELETE FROM question_stats WHERE question_id = ?",
+	latest: "SELECT score, graded_at FROM grades WHERE question_id = ? AND status = 'success' ORDER BY graded_at DESC, id DESC LIMIT 1",
+}
+
+func (s *SQLiteStore) updateQuestionStats(ctx context.Context, tx *sql.Tx, questionID string, score int) error {
+	return changeQuestionStats(ctx, tx, s.stmts, sqliteStatsSQL, questionID, countAnswer(score, s.passThreshold, time.Now()))
+}
+
+// replaceQuestionStatsScore swaps a re-graded answer's previous score for
+// its new one without counting another attempt. Stats that no longer exist
+// are recreated as a first attempt.
+func (s *SQLiteStore) replaceQuestionStatsScore(ctx context.Context, tx *sql.Tx, questionID string, previous, score int) error {
+	return changeQuestionStats(ctx, tx, s.stmts, sqliteStatsSQL, questionID, replaceAnswer(previous, score, s.passThreshold, time.Now()))
+}
+
+// retractQuestionStatsScore removes a previously counted attempt whose
+// grade was replaced by a grading failure, taking the latest score from the
+// newest remaining grade; stats left with no attempts are deleted.
+func (s *SQLiteStore) retractQuestionStatsScore(ctx context.Context, tx *sql.Tx, questionID string, previous int) error {
+	return retractQuestionStats(ctx, tx, s.stmts, sqliteStatsSQL, questionID, previous, s.passThreshold)
 }
 
+// GetQuestionStats returns the aggregate stats for a question, including its
+// current streak. A question that was never answered yields zeroed stats.
 func (s *SQLiteStore) GetQuestionStats(ctx context.Context, questionID string) (*questionbank.QuestionStats, error) {
 	var stats questionbank.QuestionStats
+	var lastAnswered int64
 	err := s.db.QueryRowContext(ctx, `
-		SELECT question_id, times_answered, times_correct, total_score, latest_score, mastery
+		SELECT question_id, times_answered, times_correct, total_score, latest_score, mastery, last_answered_at
 		FROM question_stats WHERE question_id = ?
-	`, questionID).Scan(&stats.QuestionID, &stats.TimesAnswered, &stats.TimesCorrect, &stats.TotalScore, &stats.LatestScore, &stats.Mastery)
+	`, questionID).Scan(&stats.QuestionID, &stats.TimesAnswered, &stats.TimesCorrect, &stats.TotalScore, &stats.LatestScore, &stats.Mastery, &lastAnswered)
 
 	if err == sql.ErrNoRows {
 		return &questionbank.QuestionStats{QuestionID: questionID}, nil
@@ -807,17 +2115,114 @@ func (s *SQLiteStore) GetQuestionStats(ctx context.Context, questionID string) (
 	if err != nil {
 		return nil, err
 	}
+
+	streak, err := s.questionStreak(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Streak = streak
+	if lastAnswered > 0 {
+		stats.LastAnswered = time.Unix(0, lastAnswered)
+	}
 	return &stats, nil
 }
 
+// RestoreQuestionStats overwrites a question's aggregate stats, e.g. when
+// importing a backup. Streak is derived from grades and is not restored.
+func (s *SQLiteStore) RestoreQuestionStats(ctx context.Context, stats questionbank.QuestionStats) error {
+	var lastAnswered int64
+	if !stats.LastAnswered.IsZero() {
+		lastAnswered = stats.LastAnswered.UnixNano()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO question_stats (question_id, times_answered, times_correct, total_score, latest_score, last_answered_at, mastery)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(question_id) DO UPDATE SET
+		    times_answered   = excluded.times_answered,
+		    times_correct    = excluded.times_correct,
+		    total_score      = excluded.total_score,
+		    latest_score     = excluded.latest_score,
+		    last_answered_at = excluded.last_answered_at,
+		    mastery          = excluded.mastery
+	`, stats.QuestionID, stats.TimesAnswered, stats.TimesCorrect, stats.TotalScore, stats.LatestScore, lastAnswered, stats.Mastery)
+	return err
+}
+
+// ResetQuestionStats zeroes a question's aggregate stats so its mastery
+// starts over. Grades are kept, so its answer history is not lost.
+func (s *SQLiteStore) ResetQuestionStats(ctx context.Context, questionID string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM question_stats WHERE question_id = ?", questionID)
+	return err
+}
+
+// ResetBankStats zeroes the aggregate stats of every question of a bank.
+func (s *SQLiteStore) ResetBankStats(ctx context.Context, bankID string) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM question_stats WHERE question_id IN (SELECT id FROM questions WHERE bank_id = ?)",
+		bankID,
+	)
+	return err
+}
+
+// DecayMastery decays the stored mastery of every answered question by
+// the time since it was last answered, halving it every halfLife. The next
+// answer recomputes mastery as usual.
+func (s *SQLiteStore) DecayMastery(ctx context.Context, halfLife time.Duration, now time.Time) (int, error) {
+	return decayMastery(ctx, s.db, "UPDATE question_stats SET mastery = ? WHERE question_id = ?", halfLife, now)
+}
+
+// SetPassThreshold sets the minimum score counted as correct in
+// times_correct and streaks. Values outside 1-100 are ignored. Stats
+// recorded under a previous threshold are not recomputed.
+func (s *SQLiteStore) SetPassThreshold(score int) {
+	if score >= 1 && score <= 100 {
+		s.passThreshold = score
+	}
+}
+
+// PassThreshold returns the minimum score counted as correct.
+func (s *SQLiteStore) PassThreshold() int {
+	return s.passThreshold
+}
+
+// questionStreak counts consecutive correct grades (see
+// SetPassThreshold) for a question, walking back from the most recent
+// one. Failed gradings are skipped.
+func (s *SQLiteStore) questionStreak(ctx context.Context, questionID string) (int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT score FROM grades WHERE question_id = ? AND status = ? ORDER BY id DESC",
+		questionID, GradeStatusSuccess,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	streak := 0
+	for rows.Next() {
+		var score int
+		if err := rows.Scan(&score); err != nil {
+			return 0, err
+		}
+		if score < s.passThreshold {
+			break
+		}
+		streak++
+	}
+	return streak, rows.Err()
+}
+
+const sqliteStatsByBankSQL = `
+	SELECT q.id, COALESCE(qs.times_answered, 0), COALESCE(qs.times_correct, 0),
+	       COALESCE(qs.total_score, 0), COALESCE(qs.latest_score, 0), COALESCE(qs.mastery, 0),
+	       COALESCE(qs.last_answered_at, 0)
+	FROM questions q
+	LEFT JOIN question_stats qs ON q.id = qs.question_id
+	WHERE q.bank_id = ? AND q.deleted_at IS NULL
+`
+
 func (s *SQLiteStore) GetQuestionStatsByBank(ctx context.Context, bankID string) ([]questionbank.QuestionStats, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT q.id, COALESCE(qs.times_answered, 0), COALESCE(qs.times_correct, 0), 
-		       COALESCE(qs.total_score, 0), COALESCE(qs.latest_score, 0), COALESCE(qs.mastery, 0)
-		FROM questions q
-		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE q.bank_id = ?
-	`, bankID)
+	rows, err := s.stmts.query(ctx, s.db, sqliteStatsByBankSQL, bankID)
 	if err != nil {
 		return nil, err
 	}
@@ -826,22 +2231,29 @@ func (s *SQLiteStore) GetQuestionStatsByBank(ctx context.Context, bankID string)
 	var stats []questionbank.QuestionStats
 	for rows.Next() {
 		var s questionbank.QuestionStats
-		if err := rows.Scan(&s.QuestionID, &s.TimesAnswered, &s.TimesCorrect, &s.TotalScore, &s.LatestScore, &s.Mastery); err != nil {
+		var lastAnswered int64
+		if err := rows.Scan(&s.QuestionID, &s.TimesAnswered, &s.TimesCorrect, &s.TotalScore, &s.LatestScore, &s.Mastery, &lastAnswered); err != nil {
 			return nil, err
 		}
+		if lastAnswered > 0 {
+			s.LastAnswered = time.Unix(0, lastAnswered)
+		}
 		stats = append(stats, s)
 	}
 	return stats, nil
 }
 
 func (s *SQLiteStore) GetBankMastery(ctx context.Context, bankID string) (int, error) {
+	return s.GetBankMasteryScoped(ctx, bankID, MasteryScopeAll)
+}
+
+func (s *SQLiteStore) GetBankMasteryScoped(ctx context.Context, bankID string, scope MasteryScope) (int, error) {
 	var mastery sql.NullFloat64
 	err := s.db.QueryRowContext(ctx, `
-		SELECT AVG(qs.mastery) * COUNT(qs.mastery) / COUNT(q.id)
+		SELECT `+bankMasterySQL(scope)+`
 		FROM questions q
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE q.bank_id = ?
-	`, bankID).Scan(&mastery)
+		WHERE q.bank_id = ? AND q.deleted_at IS NULL`, bankID).Scan(&mastery)
 
 	if err != nil {
 		return 0, err
@@ -852,7 +2264,7 @@ func (s *SQLiteStore) GetBankMastery(ctx context.Context, bankID string) (int, e
 	return int(mastery.Float64), nil
 }
 
-func (s *SQLiteStore) GetBankMasteryBatch(ctx context.Context, bankIDs []string) (map[string]int, error) {
+func (s *SQLiteStore) GetBankMasteryBatch(ctx context.Context, bankIDs []string, scope MasteryScope) (map[string]int, error) {
 	result := make(map[string]int, len(bankIDs))
 	if len(bankIDs) == 0 {
 		return result, nil
@@ -866,10 +2278,10 @@ func (s *SQLiteStore) GetBankMasteryBatch(ctx context.Context, bankIDs []string)
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT q.bank_id, CAST(AVG(qs.mastery) * COUNT(qs.mastery) / COUNT(q.id) AS INTEGER)
+		SELECT q.bank_id, COALESCE(CAST(`+bankMasterySQL(scope)+` AS INTEGER), 0)
 		FROM questions q
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE q.bank_id IN (`+strings.Join(placeholders, ",")+`)
+		WHERE q.bank_id IN (`+strings.Join(placeholders, ",")+`) AND q.deleted_at IS NULL
 		GROUP BY q.bank_id
 	`, args...)
 	if err != nil {
@@ -888,12 +2300,52 @@ func (s *SQLiteStore) GetBankMasteryBatch(ctx context.Context, bankIDs []string)
 	return result, nil
 }
 
+// GetUnansweredCountBatch returns, per bank, how many questions have never
+// been answered (no question_stats row). Banks with none are omitted.
+func (s *SQLiteStore) GetUnansweredCountBatch(ctx context.Context, bankIDs []string) (map[string]int, error) {
+	result := make(map[string]int, len(bankIDs))
+	if len(bankIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(bankIDs))
+	args := make([]interface{}, len(bankIDs))
+	for i, id := range bankIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.bank_id, COUNT(q.id)
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id IN (`+strings.Join(placeholders, ",")+`) AND q.deleted_at IS NULL
+		  AND qs.question_id IS NULL
+		GROUP BY q.bank_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var count int
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		result[id] = count
+	}
+	return result, rows.Err()
+}
+
 func (s *SQLiteStore) GetOverallMastery(ctx context.Context) (int, error) {
 	var mastery sql.NullFloat64
 	err := s.db.QueryRowContext(ctx, `
 		SELECT AVG(qs.mastery) * COUNT(qs.mastery) / COUNT(q.id)
 		FROM questions q
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.deleted_at IS NULL
 	`).Scan(&mastery)
 	if err != nil {
 		return 0, err
@@ -904,14 +2356,166 @@ func (s *SQLiteStore) GetOverallMastery(ctx context.Context) (int, error) {
 	return int(mastery.Float64), nil
 }
 
+// GetLibraryOverview counts the library's folders, categories, banks and
+// questions and averages mastery over all questions in one query.
+func (s *SQLiteStore) GetLibraryOverview(ctx context.Context) (*LibraryOverview, error) {
+	var o LibraryOverview
+	var totalMastery int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM folders WHERE NOT is_system),
+		       (SELECT COUNT(*) FROM categories),
+		       (SELECT COUNT(*) FROM banks WHERE deleted_at IS NULL),
+		       COUNT(q.id),
+		       COUNT(q.id) - COUNT(CASE WHEN qs.times_answered > 0 THEN 1 END),
+		       COALESCE(SUM(qs.mastery), 0)
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.deleted_at IS NULL
+	`).Scan(&o.Folders, &o.Categories, &o.Banks, &o.Questions, &o.UnansweredQuestions, &totalMastery)
+	if err != nil {
+		return nil, err
+	}
+	if o.Questions > 0 {
+		o.Mastery = totalMastery / o.Questions
+	}
+	return &o, nil
+}
+
+func (s *SQLiteStore) StreamQuestionStats(ctx context.Context, filter QuestionStatsFilter, fn func(QuestionStatsRow) error) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer, b.id, b.subject,
+		       COALESCE(c.id, ''), COALESCE(c.name, ''),
+		       COALESCE(f.id, ''), COALESCE(f.name, ''),
+		       COALESCE(qs.times_answered, 0), COALESCE(qs.times_correct, 0),
+		       COALESCE(qs.latest_score, 0), COALESCE(qs.mastery, 0)
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		LEFT JOIN categories c ON b.category_id = c.id
+		LEFT JOIN folders f ON c.folder_id = f.id
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		WHERE q.deleted_at IS NULL AND (? = '' OR f.id = ?) AND (? = '' OR c.id = ?)
+		ORDER BY f.name, c.name, b.subject, q.id`,
+		filter.FolderID, filter.FolderID, filter.CategoryID, filter.CategoryID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r QuestionStatsRow
+		if err := rows.Scan(
+			&r.QuestionID, &r.Question, &r.ExpectedAnswer, &r.BankID, &r.Bank,
+			&r.CategoryID, &r.Category, &r.FolderID, &r.Folder,
+			&r.TimesAnswered, &r.TimesCorrect, &r.LatestScore, &r.Mastery,
+		); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetMasteryByBankType returns one entry per bank type that has questions,
+// ordered by type.
+func (s *SQLiteStore) GetMasteryByBankType(ctx context.Context, filter QuestionStatsFilter) ([]BankTypeMastery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.bank_type, COUNT(q.id), COUNT(qs.question_id),
+		       CAST(AVG(COALESCE(qs.mastery, 0)) AS INTEGER)
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		LEFT JOIN categories c ON b.category_id = c.id
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		WHERE q.deleted_at IS NULL AND (? = '' OR c.folder_id = ?) AND (? = '' OR c.id = ?)
+		GROUP BY b.bank_type
+		ORDER BY b.bank_type`,
+		filter.FolderID, filter.FolderID, filter.CategoryID, filter.CategoryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []BankTypeMastery{}
+	for rows.Next() {
+		var m BankTypeMastery
+		if err := rows.Scan(&m.BankType, &m.QuestionCount, &m.AnsweredCount, &m.Mastery); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+// ListCategoriesWithMastery returns every category with its mastery,
+// computed in a single aggregate query with the same averaging as
+// GetCategoryMastery. Categories without questions get 0.
+func (s *SQLiteStore) ListCategoriesWithMastery(ctx context.Context) ([]*CategoryWithMastery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.name, c.folder_id, c.sort_order, c.version,
+		       COALESCE(CAST(`+bankMasterySQL(MasteryScopeAll)+` AS INTEGER), 0)
+		FROM categories c
+		LEFT JOIN banks b ON b.category_id = c.id
+		LEFT JOIN questions q ON q.bank_id = b.id AND q.deleted_at IS NULL
+		LEFT JOIN question_stats qs ON qs.question_id = q.id
+		GROUP BY c.id
+		ORDER BY c.sort_order ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*CategoryWithMastery
+	for rows.Next() {
+		var cat CategoryWithMastery
+		var folderID sql.NullString
+		if err := rows.Scan(&cat.ID, &cat.Name, &folderID, &cat.SortOrder, &cat.Version, &cat.Mastery); err != nil {
+			return nil, err
+		}
+		if folderID.Valid {
+			cat.FolderID = &folderID.String
+		}
+		categories = append(categories, &cat)
+	}
+	return categories, rows.Err()
+}
+
 func (s *SQLiteStore) GetCategoryMastery(ctx context.Context, categoryID string) (int, error) {
 	var mastery sql.NullFloat64
 	err := s.db.QueryRowContext(ctx, `
-		SELECT AVG(COALESCE(qs.mastery, 0))
+		SELECT `+bankMasterySQL(MasteryScopeAll)+`
 		FROM questions q
 		JOIN banks b ON q.bank_id = b.id
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE b.category_id = ?
+		WHERE b.category_id = ? AND q.deleted_at IS NULL
+	`, categoryID).Scan(&mastery)
+
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+// GetCategoryMasteryWeighted returns the category mastery aggregated with
+// the given weighting.
+func (s *SQLiteStore) GetCategoryMasteryWeighted(ctx context.Context, categoryID string, weighting MasteryWeighting) (int, error) {
+	if weighting != MasteryWeightingAttempts {
+		return s.GetCategoryMastery(ctx, categoryID)
+	}
+
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+attemptsMasterySQL+`
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		JOIN question_stats qs ON q.id = qs.question_id
+		WHERE b.category_id = ? AND q.deleted_at IS NULL AND qs.times_answered > 0
 	`, categoryID).Scan(&mastery)
 
 	if err != nil {
@@ -937,11 +2541,11 @@ func (s *SQLiteStore) GetCategoryMasteryBatch(ctx context.Context, categoryIDs [
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT b.category_id, CAST(AVG(COALESCE(qs.mastery, 0)) AS INTEGER)
+		SELECT b.category_id, CAST(`+bankMasterySQL(MasteryScopeAll)+` AS INTEGER)
 		FROM questions q
 		JOIN banks b ON q.bank_id = b.id
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE b.category_id IN (`+strings.Join(placeholders, ",")+`)
+		WHERE b.category_id IN (`+strings.Join(placeholders, ",")+`) AND q.deleted_at IS NULL
 		GROUP BY b.category_id
 	`, args...)
 	if err != nil {
@@ -980,7 +2584,7 @@ func (s *SQLiteStore) GetWeakQuestionsAcrossBanks(ctx context.Context, bankIDs [
 			       ROW_NUMBER() OVER (PARTITION BY q.bank_id ORDER BY COALESCE(qs.mastery, 0) ASC) as rn
 			FROM questions q
 			LEFT JOIN question_stats qs ON q.id = qs.question_id
-			WHERE q.bank_id IN (` + strings.Join(placeholders, ",") + `)
+			WHERE q.bank_id IN (` + strings.Join(placeholders, ",") + `) AND q.deleted_at IS NULL
 		)
 		SELECT id, subject, expected_answer, bank_id, mastery
 		FROM ranked
@@ -1006,6 +2610,64 @@ func (s *SQLiteStore) GetWeakQuestionsAcrossBanks(ctx context.Context, bankIDs [
 	return results, nil
 }
 
+// GetWeakestQuestionsInCategory returns up to limit questions from all banks
+// in a category: never-answered questions first, then by ascending mastery.
+func (s *SQLiteStore) GetWeakestQuestionsInCategory(ctx context.Context, categoryID string, limit int) ([]QuestionWithBank, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer, q.bank_id, b.subject,
+		       COALESCE(qs.mastery, 0), COALESCE(qs.times_answered, 0)
+		FROM questions q
+		JOIN banks b ON b.id = q.bank_id
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE b.category_id = ? AND q.deleted_at IS NULL
+		ORDER BY COALESCE(qs.times_answered, 0) > 0, COALESCE(qs.mastery, 0), b.subject, q.rowid
+		LIMIT ?
+	`, categoryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []QuestionWithBank
+	for rows.Next() {
+		var q QuestionWithBank
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &q.BankID, &q.BankSubject, &q.Mastery, &q.TimesAnswered); err != nil {
+			return nil, err
+		}
+		results = append(results, q)
+	}
+	return results, rows.Err()
+}
+
+// GetWeakestAnsweredQuestions returns up to limit answered questions from
+// every bank, lowest mastery first, each with its bank's subject.
+// Never-answered questions are left out; GetLibraryOverview counts them.
+func (s *SQLiteStore) GetWeakestAnsweredQuestions(ctx context.Context, limit int) ([]QuestionWithBank, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer, q.bank_id, b.subject, qs.mastery, qs.times_answered
+		FROM questions q
+		JOIN banks b ON b.id = q.bank_id
+		JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.deleted_at IS NULL AND qs.times_answered > 0
+		ORDER BY qs.mastery, b.subject, q.rowid
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []QuestionWithBank
+	for rows.Next() {
+		var q QuestionWithBank
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer, &q.BankID, &q.BankSubject, &q.Mastery, &q.TimesAnswered); err != nil {
+			return nil, err
+		}
+		results = append(results, q)
+	}
+	return results, rows.Err()
+}
+
 // GetSessionQuestionBankID returns the bank_id for a specific question in a session
 func (s *SQLiteStore) GetSessionQuestionBankID(ctx context.Context, sessionID, questionID string) (string, error) {
 	var bankID sql.NullString
@@ -1036,7 +2698,7 @@ func (s *SQLiteStore) GetQuestionsOrderedByMastery(ctx context.Context, bankID s
 		SELECT q.id, q.subject, q.expected_answer, COALESCE(qs.mastery, 0) as mastery
 		FROM questions q
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE q.bank_id = ?
+		WHERE q.bank_id = ? AND q.deleted_at IS NULL
 		ORDER BY mastery `+order, bankID)
 	if err != nil {
 		return nil, err
@@ -1054,3 +2716,29 @@ func (s *SQLiteStore) GetQuestionsOrderedByMastery(ctx context.Context, bankID s
 	}
 	return questions, nil
 }
+
+// GetQuestionsUnansweredFirst returns questions that have never been answered
+// (no question_stats row) first, followed by answered ones sorted by mastery
+// ascending. Used to surface new questions added to a mature bank.
+func (s *SQLiteStore) GetQuestionsUnansweredFirst(ctx context.Context, bankID string) ([]questionbank.Question, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.expected_answer
+		FROM questions q
+		LEFT JOIN question_stats qs ON q.id = qs.question_id
+		WHERE q.bank_id = ? AND q.deleted_at IS NULL
+		ORDER BY qs.question_id IS NOT NULL, COALESCE(qs.mastery, 0) ASC`, bankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []questionbank.Question
+	for rows.Next() {
+		var q questionbank.Question
+		if err := rows.Scan(&q.ID, &q.Subject, &q.ExpectedAnswer); err != nil {
+			return nil, err
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
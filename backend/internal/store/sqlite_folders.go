@@ -25,6 +25,7 @@ func migrateForFolders(db *sql.DB) error {
 	}
 	_ = addColumnIfNotExists(db, "categories", "folder_id", "TEXT REFERENCES folders(id) ON DELETE SET NULL")
 	_ = addColumnIfNotExists(db, "folders", "is_system", "BOOLEAN NOT NULL DEFAULT FALSE")
+	_ = addColumnIfNotExists(db, "folders", "version", "INTEGER NOT NULL DEFAULT 1")
 	// Clean up old is_deleted column if it exists from previous iteration
 	// (no-op if it doesn't exist — SQLite doesn't support DROP COLUMN before 3.35)
 	return nil
@@ -45,8 +46,8 @@ func (s *SQLiteStore) SaveFolder(ctx context.Context, f *folder.Folder) error {
 func (s *SQLiteStore) GetFolder(ctx context.Context, id string) (*folder.Folder, error) {
 	var f folder.Folder
 	err := s.db.QueryRowContext(ctx,
-		"SELECT id, name, COALESCE(is_system, FALSE) FROM folders WHERE id = ?", id,
-	).Scan(&f.ID, &f.Name, &f.IsSystem)
+		"SELECT id, name, COALESCE(is_system, FALSE), version FROM folders WHERE id = ?", id,
+	).Scan(&f.ID, &f.Name, &f.IsSystem, &f.Version)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -61,7 +62,7 @@ func (s *SQLiteStore) GetFolder(ctx context.Context, id string) (*folder.Folder,
 func (s *SQLiteStore) ListFolders(ctx context.Context) ([]*folder.Folder, error) {
 	// Query folders and count categories for system folders
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT f.id, f.name, COALESCE(f.is_system, FALSE),
+		SELECT f.id, f.name, COALESCE(f.is_system, FALSE), f.version,
 		       (SELECT COUNT(*) FROM categories c WHERE c.folder_id = f.id) as cat_count
 		FROM folders f
 	`)
@@ -74,7 +75,7 @@ func (s *SQLiteStore) ListFolders(ctx context.Context) ([]*folder.Folder, error)
 	for rows.Next() {
 		var f folder.Folder
 		var catCount int
-		if err := rows.Scan(&f.ID, &f.Name, &f.IsSystem, &catCount); err != nil {
+		if err := rows.Scan(&f.ID, &f.Name, &f.IsSystem, &f.Version, &catCount); err != nil {
 			return nil, err
 		}
 		// Only include system folders if they have categories
@@ -97,20 +98,13 @@ func (s *SQLiteStore) UpdateFolder(ctx context.Context, f *folder.Folder) error
 	}
 
 	result, err := s.db.ExecContext(ctx,
-		"UPDATE folders SET name = ? WHERE id = ? AND COALESCE(is_system, FALSE) = FALSE",
-		f.Name, f.ID,
+		"UPDATE folders SET name = ?, version = version + 1 WHERE id = ? AND COALESCE(is_system, FALSE) = FALSE AND (? = 0 OR version = ?)",
+		f.Name, f.ID, f.Version, f.Version,
 	)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return s.versionedUpdateResult(ctx, result, "folders", f.ID)
 }
 
 // DeleteFolder handles folder deletion:
@@ -140,7 +134,7 @@ func (s *SQLiteStore) DeleteFolder(ctx context.Context, id string) error {
 
 	// Move all categories from this folder to the Deleted folder
 	_, err = tx.ExecContext(ctx,
-		"UPDATE categories SET folder_id = ? WHERE folder_id = ?",
+		"UPDATE categories SET folder_id = ?, version = version + 1 WHERE folder_id = ?",
 		deletedFolder.ID, id,
 	)
 	if err != nil {
@@ -171,9 +165,9 @@ func (s *SQLiteStore) DeleteFolder(ctx context.Context, id string) error {
 func (s *SQLiteStore) GetOrCreateDeletedFolder(ctx context.Context) (*folder.Folder, error) {
 	var f folder.Folder
 	err := s.db.QueryRowContext(ctx,
-		"SELECT id, name, is_system FROM folders WHERE is_system = TRUE AND name = ?",
+		"SELECT id, name, is_system, version FROM folders WHERE is_system = TRUE AND name = ?",
 		folder.SystemDeletedFolderName,
-	).Scan(&f.ID, &f.Name, &f.IsSystem)
+	).Scan(&f.ID, &f.Name, &f.IsSystem, &f.Version)
 
 	if err == nil {
 		return &f, nil
@@ -211,7 +205,7 @@ func (s *SQLiteStore) EmptyDeletedFolder(ctx context.Context) error {
 	folderID := deletedFolder.ID
 
 	// 1. Move all categories back to "All" (folder_id = NULL)
-	_, err = tx.ExecContext(ctx, "UPDATE categories SET folder_id = NULL WHERE folder_id = ?", folderID)
+	_, err = tx.ExecContext(ctx, "UPDATE categories SET folder_id = NULL, version = version + 1 WHERE folder_id = ?", folderID)
 	if err != nil {
 		return err
 	}
@@ -235,7 +229,33 @@ func (s *SQLiteStore) GetFolderMastery(ctx context.Context, folderID string) (in
 		JOIN banks b ON q.bank_id = b.id
 		JOIN categories c ON b.category_id = c.id
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE c.folder_id = ?
+		WHERE c.folder_id = ? AND q.deleted_at IS NULL
+	`, folderID).Scan(&mastery)
+
+	if err != nil {
+		return 0, err
+	}
+	if !mastery.Valid {
+		return 0, nil
+	}
+	return int(mastery.Float64), nil
+}
+
+// GetFolderMasteryWeighted returns the folder mastery aggregated with the
+// given weighting.
+func (s *SQLiteStore) GetFolderMasteryWeighted(ctx context.Context, folderID string, weighting MasteryWeighting) (int, error) {
+	if weighting != MasteryWeightingAttempts {
+		return s.GetFolderMastery(ctx, folderID)
+	}
+
+	var mastery sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT `+attemptsMasterySQL+`
+		FROM questions q
+		JOIN banks b ON q.bank_id = b.id
+		JOIN categories c ON b.category_id = c.id
+		JOIN question_stats qs ON q.id = qs.question_id
+		WHERE c.folder_id = ? AND q.deleted_at IS NULL AND qs.times_answered > 0
 	`, folderID).Scan(&mastery)
 
 	if err != nil {
@@ -266,7 +286,7 @@ func (s *SQLiteStore) GetFolderMasteryBatch(ctx context.Context, folderIDs []str
 		JOIN banks b ON q.bank_id = b.id
 		JOIN categories c ON b.category_id = c.id
 		LEFT JOIN question_stats qs ON q.id = qs.question_id
-		WHERE c.folder_id IN (`+strings.Join(placeholders, ",")+`)
+		WHERE c.folder_id IN (`+strings.Join(placeholders, ",")+`) AND q.deleted_at IS NULL
 		GROUP BY c.folder_id
 	`, args...)
 	if err != nil {
@@ -291,7 +311,7 @@ func (s *SQLiteStore) GetFolderMasteryBatch(ctx context.Context, folderIDs []str
 
 // ListCategoriesByFolder returns all categories belonging to a folder.
 func (s *SQLiteStore) ListCategoriesByFolder(ctx context.Context, folderID string) ([]*category.Category, error) {
-	rows, err := s.db.QueryContext(ctx, "SELECT id, name, folder_id, sort_order FROM categories WHERE folder_id = ? ORDER BY sort_order ASC", folderID)
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, folder_id, sort_order, version FROM categories WHERE folder_id = ? ORDER BY sort_order ASC", folderID)
 	if err != nil {
 		return nil, err
 	}
@@ -301,7 +321,7 @@ func (s *SQLiteStore) ListCategoriesByFolder(ctx context.Context, folderID strin
 	for rows.Next() {
 		var cat category.Category
 		var fID sql.NullString
-		if err := rows.Scan(&cat.ID, &cat.Name, &fID, &cat.SortOrder); err != nil {
+		if err := rows.Scan(&cat.ID, &cat.Name, &fID, &cat.SortOrder, &cat.Version); err != nil {
 			return nil, err
 		}
 		if fID.Valid {
@@ -313,17 +333,14 @@ func (s *SQLiteStore) ListCategoriesByFolder(ctx context.Context, folderID strin
 }
 
 // UpdateCategoryFolder moves a category to a different folder (or removes it with nil).
-func (s *SQLiteStore) UpdateCategoryFolder(ctx context.Context, categoryID string, folderID *string) error {
-	result, err := s.db.ExecContext(ctx, "UPDATE categories SET folder_id = ? WHERE id = ?", folderID, categoryID)
-	if err != nil {
-		return err
-	}
-	rowsAffected, err := result.RowsAffected()
+// A non-zero expectedVersion must match the category's current version.
+func (s *SQLiteStore) UpdateCategoryFolder(ctx context.Context, categoryID string, folderID *string, expectedVersion int) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE categories SET folder_id = ?, version = version + 1 WHERE id = ? AND (? = 0 OR version = ?)",
+		folderID, categoryID, expectedVersion, expectedVersion,
+	)
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return ErrNotFound
-	}
-	return nil
+	return s.versionedUpdateResult(ctx, result, "categories", categoryID)
 }
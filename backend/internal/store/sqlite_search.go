@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// searchSchema creates FTS5 indexes over questions and banks. The FTS rowid
+// mirrors the source table's rowid, and triggers keep them in sync.
+const searchSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS questions_fts USING fts5(
+    question_id UNINDEXED,
+    bank_id UNINDEXED,
+    subject,
+    expected_answer,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS banks_fts USING fts5(
+    bank_id UNINDEXED,
+    subject,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_insert AFTER INSERT ON questions BEGIN
+    INSERT INTO questions_fts (rowid, question_id, bank_id, subject, expected_answer)
+    VALUES (new.rowid, new.id, new.bank_id, new.subject, new.expected_answer);
+END;
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_update AFTER UPDATE ON questions BEGIN
+    UPDATE questions_fts
+    SET bank_id = new.bank_id, subject = new.subject, expected_answer = new.expected_answer
+    WHERE rowid = old.rowid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS questions_fts_delete AFTER DELETE ON questions BEGIN
+    DELETE FROM questions_fts WHERE rowid = old.rowid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS banks_fts_insert AFTER INSERT ON banks BEGIN
+    INSERT INTO banks_fts (rowid, bank_id, subject) VALUES (new.rowid, new.id, new.subject);
+END;
+
+CREATE TRIGGER IF NOT EXISTS banks_fts_update AFTER UPDATE ON banks BEGIN
+    UPDATE banks_fts SET subject = new.subject WHERE rowid = old.rowid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS banks_fts_delete AFTER DELETE ON banks BEGIN
+    DELETE FROM banks_fts WHERE rowid = old.rowid;
+END;
+`
+
+// migrateForSearch creates the search indexes and fills them from existing
+// rows when they are out of step, e.g. on a database that predates them.
+func migrateForSearch(db *sql.DB) error {
+	if _, err := db.Exec(searchSchema); err != nil {
+		return err
+	}
+	if err := rebuildIfStale(db, "questions_fts", "questions",
+		"INSERT INTO questions_fts (rowid, question_id, bank_id, subject, expected_answer) SELECT rowid, id, bank_id, subject, expected_answer FROM questions",
+	); err != nil {
+		return err
+	}
+	return rebuildIfStale(db, "banks_fts", "banks",
+		"INSERT INTO banks_fts (rowid, bank_id, subject) SELECT rowid, id, subject FROM banks",
+	)
+}
+
+func rebuildIfStale(db *sql.DB, index, source, fill string) error {
+	var indexed, total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + index).Scan(&indexed); err != nil {
+		return err
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + source).Scan(&total); err != nil {
+		return err
+	}
+	if indexed == total {
+		return nil
+	}
+	if _, err := db.Exec("DELETE FROM " + index); err != nil {
+		return err
+	}
+	_, err := db.Exec(fill)
+	return err
+}
+
+func (s *SQLiteStore) Search(ctx context.Context, query SearchQuery) ([]SearchHit, error) {
+	terms := searchTerms(query.Text)
+	if len(terms) == 0 {
+		return []SearchHit{}, nil
+	}
+	// Every term must match; the phrase form only matches the terms
+	// adjacent and in order.
+	tokens := `"` + strings.Join(terms, `" "`) + `"`
+	phrase := `"` + strings.Join(terms, " ") + `"`
+	limit := query.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT bank_id, bank_subject, bank_type, question_id, subject_snippet, answer_snippet, exact
+		FROM (
+			SELECT questions_fts.bank_id, b.subject AS bank_subject, b.bank_type, questions_fts.question_id,
+			       snippet(questions_fts, 2, char(2), char(3), '…', 12) AS subject_snippet,
+			       snippet(questions_fts, 3, char(2), char(3), '…', 12) AS answer_snippet,
+			       questions_fts.rowid IN (SELECT rowid FROM questions_fts WHERE questions_fts MATCH ?) AS exact,
+			       bm25(questions_fts) AS rank
+			FROM questions_fts
+			JOIN questions q ON q.rowid = questions_fts.rowid
+			JOIN banks b ON b.id = questions_fts.bank_id
+			WHERE questions_fts MATCH ? AND q.deleted_at IS NULL AND (? = '' OR b.bank_type = ?)
+			UNION ALL
+			SELECT banks_fts.bank_id, b.subject, b.bank_type, '',
+			       snippet(banks_fts, 1, char(2), char(3), '…', 12), '',
+			       banks_fts.rowid IN (SELECT rowid FROM banks_fts WHERE banks_fts MATCH ?),
+			       bm25(banks_fts)
+			FROM banks_fts
+			JOIN banks b ON b.id = banks_fts.bank_id
+			WHERE banks_fts MATCH ? AND b.deleted_at IS NULL AND (? = '' OR b.bank_type = ?)
+		)
+		ORDER BY exact DESC, rank
+		LIMIT ?`,
+		phrase, tokens, query.BankType, query.BankType,
+		phrase, tokens, query.BankType, query.BankType,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []SearchHit{}
+	for rows.Next() {
+		var h SearchHit
+		var subjectSnippet, answerSnippet string
+		if err := rows.Scan(&h.BankID, &h.BankSubject, &h.BankType, &h.QuestionID, &subjectSnippet, &answerSnippet, &h.Exact); err != nil {
+			return nil, err
+		}
+		if h.QuestionID == "" {
+			h.Field, h.Snippet = "bank_subject", highlightSnippet(subjectSnippet)
+		} else {
+			h.Field, h.Snippet = searchField([]string{"subject", "expected_answer"}, []string{subjectSnippet, answerSnippet})
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
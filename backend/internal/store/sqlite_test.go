@@ -2,7 +2,13 @@ package store_test
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/remaimber-it/backend/internal/domain/category"
 	"github.com/remaimber-it/backend/internal/domain/folder"
@@ -103,6 +109,42 @@ func TestUpdateCategory_NotFound(t *testing.T) {
 	}
 }
 
+func TestUpdateCategory_Version(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	cat := category.New("Go")
+	s.SaveCategory(ctx, cat)
+
+	cat.Name = "Golang"
+	if err := s.UpdateCategory(ctx, cat); err != nil {
+		t.Fatalf("UpdateCategory: %v", err)
+	}
+	got, _ := s.GetCategory(ctx, cat.ID)
+	if got.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", got.Version)
+	}
+
+	// cat still carries version 1, so a second update is stale.
+	cat.Name = "Stale"
+	if err := s.UpdateCategory(ctx, cat); err != store.ErrVersionConflict {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	got, _ = s.GetCategory(ctx, cat.ID)
+	if got.Name != "Golang" {
+		t.Errorf("stale update must not apply, got name %q", got.Name)
+	}
+
+	cat.Version = 0
+	if err := s.UpdateCategory(ctx, cat); err != nil {
+		t.Fatalf("unversioned UpdateCategory: %v", err)
+	}
+	got, _ = s.GetCategory(ctx, cat.ID)
+	if got.Name != "Stale" || got.Version != 3 {
+		t.Errorf("expected unversioned update to apply as version 3, got %q v%d", got.Name, got.Version)
+	}
+}
+
 func TestDeleteCategory_CascadesBank(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
@@ -177,6 +219,93 @@ func TestDeleteBank_NotFound(t *testing.T) {
 	}
 }
 
+func TestUpdateBankTags(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	a := questionbank.New("A")
+	a.Tags = []string{"draft"}
+	b := questionbank.New("B")
+	s.SaveBank(ctx, a)
+	s.SaveBank(ctx, b)
+
+	update, err := s.UpdateBankTags(ctx, []string{a.ID, b.ID}, []string{"go", "draft"}, []string{"draft"})
+	if err != nil {
+		t.Fatalf("UpdateBankTags: %v", err)
+	}
+	// "draft" is removed from A and then added back; B gains both tags.
+	if update.Added != 4 || update.Removed != 1 {
+		t.Errorf("expected 4 added and 1 removed, got %d and %d", update.Added, update.Removed)
+	}
+	if got := strings.Join(update.Tags[b.ID], ","); got != "draft,go" {
+		t.Errorf("expected B tags draft,go, got %q", got)
+	}
+
+	got, _ := s.GetBank(ctx, a.ID)
+	if strings.Join(got.Tags, ",") != "draft,go" || got.Version != 2 {
+		t.Errorf("expected A tags draft,go at version 2, got %v at %d", got.Tags, got.Version)
+	}
+
+	// A missing bank rolls back the whole update.
+	_, err = s.UpdateBankTags(ctx, []string{a.ID, "ghost"}, nil, []string{"go"})
+	if err != store.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	got, _ = s.GetBank(ctx, a.ID)
+	if strings.Join(got.Tags, ",") != "draft,go" {
+		t.Errorf("expected tags unchanged after a failed update, got %v", got.Tags)
+	}
+
+	if err := s.DeleteBank(ctx, a.ID); err != nil {
+		t.Fatalf("DeleteBank: %v", err)
+	}
+	update, _ = s.UpdateBankTags(ctx, []string{b.ID}, []string{"go"}, nil)
+	if update.Added != 0 {
+		t.Errorf("expected re-adding an existing tag to be a no-op, got %d added", update.Added)
+	}
+}
+
+func TestSetQuestionTags(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
+	bank.Questions[0].Tags = []string{"go"}
+	s.SaveBank(ctx, bank)
+	if err := s.AddQuestions(ctx, bank.ID, bank.Questions); err != nil {
+		t.Fatalf("AddQuestions: %v", err)
+	}
+	q1, q2 := bank.Questions[0].ID, bank.Questions[1].ID
+
+	if err := s.SetQuestionTags(ctx, bank.ID, q2, []string{"go", "channels"}); err != nil {
+		t.Fatalf("SetQuestionTags: %v", err)
+	}
+	got, _ := s.GetBank(ctx, bank.ID)
+	for _, q := range got.Questions {
+		want := "go"
+		if q.ID == q2 {
+			want = "channels,go"
+		}
+		if strings.Join(q.Tags, ",") != want {
+			t.Errorf("question %s: expected tags %s, got %v", q.Subject, want, q.Tags)
+		}
+	}
+
+	if err := s.SetQuestionTags(ctx, bank.ID, q1, nil); err != nil {
+		t.Fatalf("SetQuestionTags: %v", err)
+	}
+	q, _ := s.GetQuestion(ctx, bank.ID, q1)
+	if q.Tags == nil || len(q.Tags) != 0 {
+		t.Errorf("expected no tags, got %#v", q.Tags)
+	}
+
+	if err := s.SetQuestionTags(ctx, "other", q1, []string{"go"}); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound for a question of another bank, got %v", err)
+	}
+}
+
 
 // ============================================================================
 // Questions
@@ -210,6 +339,240 @@ func TestAddAndDeleteQuestion(t *testing.T) {
 	}
 }
 
+func TestTrash_DeleteRestorePurge(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Go")
+	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
+	s.SaveBank(ctx, bank)
+	q1, q2 := bank.Questions[0], bank.Questions[1]
+	s.AddQuestions(ctx, bank.ID, bank.Questions)
+	s.SaveGrade(ctx, "s1", q2.ID, 80, nil, nil, nil, nil, "answer", "", 0, nil)
+
+	// A question deleted on its own stays in the trash when its bank is
+	// restored.
+	if err := s.DeleteQuestion(ctx, q1.ID); err != nil {
+		t.Fatalf("DeleteQuestion: %v", err)
+	}
+	if err := s.DeleteQuestion(ctx, q1.ID); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound deleting a trashed question, got %v", err)
+	}
+	if err := s.DeleteBank(ctx, bank.ID); err != nil {
+		t.Fatalf("DeleteBank: %v", err)
+	}
+	if _, err := s.GetBank(ctx, bank.ID); err != store.ErrNotFound {
+		t.Errorf("expected trashed bank to be hidden, got %v", err)
+	}
+	if banks, _ := s.ListBanks(ctx); len(banks) != 0 {
+		t.Errorf("expected no listed banks, got %d", len(banks))
+	}
+
+	trash, err := s.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash: %v", err)
+	}
+	if len(trash.Banks) != 1 || trash.Banks[0].ID != bank.ID || trash.Banks[0].QuestionCount != 1 {
+		t.Errorf("expected the bank with 1 question in the trash, got %+v", trash.Banks)
+	}
+	if len(trash.Questions) != 1 || trash.Questions[0].ID != q1.ID || trash.Questions[0].BankSubject != "Go" {
+		t.Errorf("expected Q1 in the trash, got %+v", trash.Questions)
+	}
+
+	if err := s.RestoreBank(ctx, bank.ID); err != nil {
+		t.Fatalf("RestoreBank: %v", err)
+	}
+	if err := s.RestoreBank(ctx, bank.ID); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound restoring a live bank, got %v", err)
+	}
+	got, err := s.GetBank(ctx, bank.ID)
+	if err != nil || len(got.Questions) != 1 || got.Questions[0].ID != q2.ID {
+		t.Fatalf("expected the bank back with Q2 only, got %+v, %v", got, err)
+	}
+	if stats, _ := s.GetQuestionStats(ctx, q2.ID); stats.TimesAnswered != 1 {
+		t.Errorf("expected stats to survive the trash, got %+v", stats)
+	}
+
+	if err := s.PurgeQuestion(ctx, q1.ID); err != nil {
+		t.Fatalf("PurgeQuestion: %v", err)
+	}
+	if err := s.PurgeBank(ctx, bank.ID); err != nil {
+		t.Fatalf("PurgeBank: %v", err)
+	}
+	if trash, _ := s.ListTrash(ctx); len(trash.Banks) != 0 || len(trash.Questions) != 0 {
+		t.Errorf("expected an empty trash after purging, got %+v", trash)
+	}
+	if err := s.RestoreBank(ctx, bank.ID); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound restoring a purged bank, got %v", err)
+	}
+}
+
+func TestBankReviewLog(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Go")
+	s.SaveBank(ctx, bank)
+
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		if err := s.LogBankReview(ctx, bank.ID, base.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("LogBankReview: %v", err)
+		}
+	}
+
+	reviews, err := s.ListBankReviews(ctx, bank.ID, 2)
+	if err != nil {
+		t.Fatalf("ListBankReviews: %v", err)
+	}
+	if len(reviews) != 2 || !reviews[0].Equal(base.Add(2*time.Hour)) || !reviews[1].Equal(base.Add(time.Hour)) {
+		t.Errorf("expected the 2 latest reviews newest first, got %v", reviews)
+	}
+
+	if err := s.PurgeBank(ctx, bank.ID); err != nil {
+		t.Fatalf("PurgeBank: %v", err)
+	}
+	if reviews, _ := s.ListBankReviews(ctx, bank.ID, 10); len(reviews) != 0 {
+		t.Errorf("expected the log to go with the bank, got %v", reviews)
+	}
+}
+
+func TestAddQuestions_AllOrNothing(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
+
+	if err := s.AddQuestions(ctx, bank.ID, bank.Questions); err != nil {
+		t.Fatalf("AddQuestions: %v", err)
+	}
+	got, _ := s.GetBank(ctx, bank.ID)
+	if len(got.Questions) != 2 {
+		t.Fatalf("expected 2 questions, got %d", len(got.Questions))
+	}
+
+	// A duplicate ID fails the insert, so the new question before it is
+	// rolled back too.
+	bank.AddQuestion("Q3", "A3")
+	batch := []questionbank.Question{bank.Questions[2], bank.Questions[0]}
+	if err := s.AddQuestions(ctx, bank.ID, batch); err == nil {
+		t.Fatal("expected error for duplicate question ID")
+	}
+	got, _ = s.GetBank(ctx, bank.ID)
+	if len(got.Questions) != 2 {
+		t.Errorf("expected failed batch to save nothing, got %d questions", len(got.Questions))
+	}
+}
+
+func TestSaveBankWithQuestions_AllOrNothing(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
+	if err := s.SaveBankWithQuestions(ctx, bank); err != nil {
+		t.Fatalf("SaveBankWithQuestions: %v", err)
+	}
+	got, _ := s.GetBank(ctx, bank.ID)
+	if len(got.Questions) != 2 || got.Questions[0].Subject != "Q1" || got.Questions[1].Subject != "Q2" {
+		t.Fatalf("expected Q1 and Q2 in order, got %+v", got.Questions)
+	}
+
+	// A duplicate question ID fails the insert, so the bank is rolled back too.
+	broken := questionbank.New("Broken")
+	broken.AddQuestion("Q1", "A1")
+	broken.Questions = append(broken.Questions, broken.Questions[0])
+	if err := s.SaveBankWithQuestions(ctx, broken); err == nil {
+		t.Fatal("expected error for duplicate question ID")
+	}
+	if _, err := s.GetBank(ctx, broken.ID); err != store.ErrNotFound {
+		t.Errorf("expected failed save to leave no bank, got %v", err)
+	}
+}
+
+func TestRubricRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bankRubric, questionRubric := "- names the race", "- proposes a mutex"
+	bank := questionbank.New("Review")
+	bank.Rubric = &bankRubric
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Q1", "A1")
+	q := bank.Questions[0]
+	q.Rubric = &questionRubric
+	if err := s.AddQuestion(ctx, bank.ID, q); err != nil {
+		t.Fatalf("AddQuestion: %v", err)
+	}
+
+	got, err := s.GetBank(ctx, bank.ID)
+	if err != nil {
+		t.Fatalf("GetBank: %v", err)
+	}
+	if got.Rubric == nil || *got.Rubric != bankRubric {
+		t.Errorf("expected bank rubric %q, got %v", bankRubric, got.Rubric)
+	}
+	if r := got.RubricFor(got.Questions[0]); r == nil || *r != questionRubric {
+		t.Errorf("expected question rubric %q, got %v", questionRubric, r)
+	}
+
+	q.Rubric = nil
+	if err := s.UpdateQuestion(ctx, q); err != nil {
+		t.Fatalf("UpdateQuestion: %v", err)
+	}
+	gotQ, _ := s.GetQuestion(ctx, bank.ID, q.ID)
+	if gotQ.Rubric != nil {
+		t.Errorf("expected rubric cleared, got %q", *gotQ.Rubric)
+	}
+	if r := got.RubricFor(*gotQ); r == nil || *r != bankRubric {
+		t.Errorf("expected fallback to bank rubric, got %v", r)
+	}
+}
+
+func TestAlternativeAnswersRoundTrip(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Signals")
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Which signal stops a process?", "SIGTERM")
+	q := bank.Questions[0]
+	q.AlternativeAnswers = []string{"SIGINT", "SIGKILL"}
+	if err := s.AddQuestion(ctx, bank.ID, q); err != nil {
+		t.Fatalf("AddQuestion: %v", err)
+	}
+
+	got, err := s.GetBank(ctx, bank.ID)
+	if err != nil {
+		t.Fatalf("GetBank: %v", err)
+	}
+	if want := []string{"SIGTERM", "SIGINT", "SIGKILL"}; !reflect.DeepEqual(got.Questions[0].ExpectedAnswers(), want) {
+		t.Errorf("expected answers %q, got %q", want, got.Questions[0].ExpectedAnswers())
+	}
+
+	if err := s.CopyQuestion(ctx, q.ID, bank.ID, "copy"); err != nil {
+		t.Fatalf("CopyQuestion: %v", err)
+	}
+	copied, _ := s.GetQuestion(ctx, bank.ID, "copy")
+	if !reflect.DeepEqual(copied.AlternativeAnswers, q.AlternativeAnswers) {
+		t.Errorf("expected the copy to keep its alternatives, got %q", copied.AlternativeAnswers)
+	}
+
+	q.AlternativeAnswers = nil
+	if err := s.UpdateQuestion(ctx, q); err != nil {
+		t.Fatalf("UpdateQuestion: %v", err)
+	}
+	gotQ, _ := s.GetQuestion(ctx, bank.ID, q.ID)
+	if gotQ.AlternativeAnswers != nil {
+		t.Errorf("expected alternatives cleared, got %q", gotQ.AlternativeAnswers)
+	}
+}
+
 func TestDeleteQuestion_NotFound(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
@@ -348,6 +711,17 @@ func TestSaveAndGetSession(t *testing.T) {
 	if len(got.Questions) != 1 {
 		t.Errorf("expected 1 question in session, got %d", len(got.Questions))
 	}
+	if got.MaxDuration != nil || got.StartedAt.IsZero() || !got.StartedAt.Equal(session.StartedAt) {
+		t.Errorf("expected no limit and the start time to round-trip, got %v at %v", got.MaxDuration, got.StartedAt)
+	}
+
+	limit := 15 * time.Minute
+	timed := practicesession.NewWithConfig(full, practicesession.SessionConfig{MaxDuration: &limit, FocusOnWeak: true}, full.Questions)
+	s.SaveSession(ctx, timed)
+	got, _ = s.GetSession(ctx, timed.ID)
+	if got.MaxDuration == nil || *got.MaxDuration != 15 || !got.FocusOnWeak {
+		t.Errorf("expected a 15 minute focus-on-weak session, got %v, %v", got.MaxDuration, got.FocusOnWeak)
+	}
 }
 
 func TestCompleteSession(t *testing.T) {
@@ -374,227 +748,1598 @@ func TestCompleteSession(t *testing.T) {
 	}
 }
 
-func TestCompleteSession_NotFound(t *testing.T) {
+func TestListIncompleteSessions(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	err := s.CompleteSession(ctx, "ghost")
-	if err != store.ErrNotFound {
-		t.Errorf("expected ErrNotFound, got %v", err)
+	bank := questionbank.New("Test")
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
+	for _, q := range bank.Questions {
+		s.AddQuestion(ctx, bank.ID, q)
+	}
+	full, _ := s.GetBank(ctx, bank.ID)
+
+	now := time.Now()
+	newSession := func(startedAt time.Time) *practicesession.PracticeSession {
+		session := practicesession.New(full)
+		session.StartedAt = startedAt
+		s.SaveSession(ctx, session)
+		return session
+	}
+	stale := newSession(now.Add(-48 * time.Hour))
+	staler := newSession(now.Add(-72 * time.Hour))
+	newSession(now.Add(-time.Minute))
+	completed := newSession(now.Add(-96 * time.Hour))
+	s.CompleteSession(ctx, completed.ID)
+	s.SaveGrade(ctx, stale.ID, stale.Questions[0].ID, 80, nil, nil, nil, nil, "a", "", 0, nil)
+
+	sessions, err := s.ListIncompleteSessions(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("ListIncompleteSessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != staler.ID || sessions[1].ID != stale.ID {
+		t.Fatalf("expected the two stale active sessions, oldest first, got %+v", sessions)
+	}
+	got := sessions[1]
+	if got.BankID != bank.ID || got.QuestionCount != 2 || got.AnsweredCount != 1 || !got.StartedAt.Equal(stale.StartedAt) {
+		t.Errorf("unexpected summary: %+v", got)
 	}
 }
 
-func TestSaveGradeAndGetGrades(t *testing.T) {
+func TestListSessionsByBank(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
 	bank := questionbank.New("Test")
-	bank.AddQuestion("Q1", "A1")
 	s.SaveBank(ctx, bank)
-	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
-
-	full, _ := s.GetBank(ctx, bank.ID)
-	session := practicesession.New(full)
-	s.SaveSession(ctx, session)
-
-	q := session.Questions[0]
-	err := s.SaveGrade(ctx, session.ID, q.ID, 80, []string{"concept A"}, []string{"concept B"}, "my answer")
+	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
+	s.AddQuestions(ctx, bank.ID, bank.Questions)
+	other := questionbank.New("Other")
+	s.SaveBank(ctx, other)
+
+	now := time.Now()
+	newSession := func(b *questionbank.QuestionBank, startedAt time.Time) *practicesession.PracticeSession {
+		session := practicesession.New(b)
+		session.StartedAt = startedAt
+		s.SaveSession(ctx, session)
+		return session
+	}
+	older := newSession(bank, now.Add(-2*time.Hour))
+	newer := newSession(bank, now.Add(-time.Hour))
+	newSession(other, now)
+
+	s.SaveGrade(ctx, older.ID, older.Questions[0].ID, 80, nil, nil, nil, nil, "a", "", 0, nil)
+	s.SaveGrade(ctx, older.ID, older.Questions[1].ID, 50, nil, nil, nil, nil, "b", "", 0, nil)
+	s.CompleteSession(ctx, older.ID)
+
+	// Restarting moves started_at but not the creation time.
+	newer.StartedAt = now
+	s.RestartSession(ctx, newer)
+
+	sessions, err := s.ListSessionsByBank(ctx, bank.ID)
 	if err != nil {
-		t.Fatalf("SaveGrade: %v", err)
+		t.Fatalf("ListSessionsByBank: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != newer.ID || sessions[1].ID != older.ID {
+		t.Fatalf("expected the bank's two sessions, newest first, got %+v", sessions)
+	}
+	if got := sessions[0]; got.Status != practicesession.SessionStatusActive || got.AnsweredCount != 0 || !got.CreatedAt.Equal(now.Add(-time.Hour)) {
+		t.Errorf("unexpected active summary: %+v", got)
+	}
+	if got := sessions[1]; got.Status != practicesession.SessionStatusCompleted || got.QuestionCount != 2 || got.AnsweredCount != 2 || got.TotalScore != 130 {
+		t.Errorf("unexpected completed summary: %+v", got)
 	}
 
-	grades, err := s.GetGrades(ctx, session.ID)
-	if err != nil {
-		t.Fatalf("GetGrades: %v", err)
+	if sessions, _ := s.ListSessionsByBank(ctx, "ghost"); sessions == nil || len(sessions) != 0 {
+		t.Errorf("expected an empty list for an unknown bank, got %#v", sessions)
 	}
-	if len(grades) != 1 {
-		t.Fatalf("expected 1 grade, got %d", len(grades))
+}
+
+func TestListSessionActivity(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
+	s.AddQuestions(ctx, bank.ID, bank.Questions)
+
+	now := time.Now()
+	newer := practicesession.New(bank)
+	newer.StartedAt = now
+	s.SaveSession(ctx, newer)
+	older := practicesession.New(bank)
+	older.StartedAt = now.Add(-48 * time.Hour)
+	s.SaveSession(ctx, older)
+
+	s.SaveGrade(ctx, older.ID, older.Questions[0].ID, 80, nil, nil, nil, nil, "a", "", 0, nil)
+	s.SaveGradeFailure(ctx, older.ID, older.Questions[1].ID, "b", "timeout", "")
+	s.CompleteSession(ctx, older.ID)
+
+	activity, err := s.ListSessionActivity(ctx)
+	if err != nil {
+		t.Fatalf("ListSessionActivity: %v", err)
 	}
-	g := grades[0]
-	if g.Score != 80 {
-		t.Errorf("expected score 80, got %d", g.Score)
+	if len(activity) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", activity)
 	}
-	if g.UserAnswer != "my answer" {
-		t.Errorf("expected user answer %q, got %q", "my answer", g.UserAnswer)
+	if got := activity[0]; !got.StartedAt.Equal(older.StartedAt) || got.Status != practicesession.SessionStatusCompleted || got.Answered != 1 {
+		t.Errorf("expected the older, completed session first with only its successful grade counted, got %+v", got)
 	}
-	if g.Status != store.GradeStatusSuccess {
-		t.Errorf("expected status success, got %v", g.Status)
+	if got := activity[1]; !got.StartedAt.Equal(newer.StartedAt) || got.Status != practicesession.SessionStatusActive || got.Answered != 0 {
+		t.Errorf("unexpected activity for the active session: %+v", got)
 	}
 }
 
-func TestSaveGrade_Upsert(t *testing.T) {
+func TestPendingAnswers(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
 	bank := questionbank.New("Test")
-	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q1", "A1. B1")
+	bank.AddQuestion("Q2", "A2")
 	s.SaveBank(ctx, bank)
-	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
-
+	s.AddQuestions(ctx, bank.ID, bank.Questions)
 	full, _ := s.GetBank(ctx, bank.ID)
 	session := practicesession.New(full)
 	s.SaveSession(ctx, session)
+	q1, q2 := bank.Questions[0].ID, bank.Questions[1].ID
 
-	q := session.Questions[0]
-	s.SaveGrade(ctx, session.ID, q.ID, 60, nil, nil, "first")
-	s.SaveGrade(ctx, session.ID, q.ID, 90, nil, nil, "second")
+	now := time.Now()
+	s.SavePendingAnswer(ctx, store.PendingAnswer{SessionID: session.ID, QuestionID: q1, UserAnswer: "first", SubmittedAt: now})
+	s.SavePendingAnswer(ctx, store.PendingAnswer{SessionID: session.ID, QuestionID: q2, UserAnswer: "other", SubmittedAt: now.Add(time.Second)})
+	// Answering again replaces the first answer.
+	s.SavePendingAnswer(ctx, store.PendingAnswer{SessionID: session.ID, QuestionID: q1, UserAnswer: "second", SelfCovered: []int{1}, AnswerLanguage: "French", SubmittedAt: now.Add(2 * time.Second)})
 
-	grades, _ := s.GetGrades(ctx, session.ID)
-	if len(grades) != 1 {
-		t.Fatalf("expected upsert to keep 1 grade, got %d", len(grades))
+	pending, err := s.ListPendingAnswers(ctx)
+	if err != nil {
+		t.Fatalf("ListPendingAnswers: %v", err)
+	}
+	if len(pending) != 2 || pending[0].QuestionID != q2 || pending[1].UserAnswer != "second" ||
+		!reflect.DeepEqual(pending[1].SelfCovered, []int{1}) || pending[1].AnswerLanguage != "French" || pending[0].SelfCovered != nil {
+		t.Fatalf("expected both answers, the replaced one last, got %+v", pending)
 	}
-	if grades[0].Score != 90 {
-		t.Errorf("expected updated score 90, got %d", grades[0].Score)
+
+	// The grading of the replaced answer does not settle the newer one.
+	s.SaveGrade(ctx, session.ID, q1, 60, nil, nil, nil, nil, "first", "", 0, nil)
+	if pending, _ := s.ListPendingAnswers(ctx); len(pending) != 2 {
+		t.Errorf("expected the newer answer to stay pending, got %+v", pending)
+	}
+	s.SaveGrade(ctx, session.ID, q1, 90, nil, nil, nil, nil, "second", "", 0, nil)
+	s.SaveGradeFailure(ctx, session.ID, q2, "other", "timeout", "")
+	if pending, _ := s.ListPendingAnswers(ctx); len(pending) != 0 {
+		t.Errorf("expected graded and failed answers to be settled, got %+v", pending)
+	}
+
+	s.SavePendingAnswer(ctx, store.PendingAnswer{SessionID: session.ID, QuestionID: q2, UserAnswer: "retry", SubmittedAt: now})
+	if err := s.RestartSession(ctx, session); err != nil {
+		t.Fatalf("RestartSession: %v", err)
+	}
+	if pending, _ := s.ListPendingAnswers(ctx); len(pending) != 0 {
+		t.Errorf("expected a restart to drop pending answers, got %+v", pending)
 	}
 }
 
-func TestSaveGradeFailure(t *testing.T) {
+func TestRestartSession(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
 	bank := questionbank.New("Test")
 	bank.AddQuestion("Q1", "A1")
+	bank.AddQuestion("Q2", "A2")
 	s.SaveBank(ctx, bank)
-	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+	for _, q := range bank.Questions {
+		s.AddQuestion(ctx, bank.ID, q)
+	}
 
 	full, _ := s.GetBank(ctx, bank.ID)
 	session := practicesession.New(full)
 	s.SaveSession(ctx, session)
+	first := session.Questions[0]
+	s.SaveGrade(ctx, session.ID, first.ID, 90, nil, nil, nil, nil, "answer", "", 0, nil)
 
-	q := session.Questions[0]
-	if err := s.SaveGradeFailure(ctx, session.ID, q.ID, "my answer", "LLM timeout"); err != nil {
-		t.Fatalf("SaveGradeFailure: %v", err)
+	session.Reshuffle()
+	if err := s.RestartSession(ctx, session); err != nil {
+		t.Fatalf("RestartSession: %v", err)
 	}
 
 	grades, _ := s.GetGrades(ctx, session.ID)
-	if len(grades) != 1 {
-		t.Fatalf("expected 1 grade, got %d", len(grades))
+	if len(grades) != 0 {
+		t.Errorf("expected grades cleared, got %d", len(grades))
 	}
-	if grades[0].Status != store.GradeStatusFailed {
-		t.Errorf("expected status failed, got %v", grades[0].Status)
+	got, _ := s.GetSession(ctx, session.ID)
+	if got.Questions[0].ID != session.Questions[0].ID || got.Questions[1].ID != first.ID {
+		t.Errorf("expected the new order to be stored, got %v", got.Questions)
 	}
-	if grades[0].Score != 0 {
-		t.Errorf("expected score 0 for failure, got %d", grades[0].Score)
+	stats, _ := s.GetQuestionStats(ctx, first.ID)
+	if stats.TimesAnswered != 1 {
+		t.Errorf("expected question stats kept, got %+v", stats)
+	}
+
+	s.CompleteSession(ctx, session.ID)
+	if err := s.RestartSession(ctx, session); err != store.ErrSessionCompleted {
+		t.Errorf("expected ErrSessionCompleted, got %v", err)
+	}
+}
+
+func TestCompleteSession_NotFound(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	err := s.CompleteSession(ctx, "ghost")
+	if err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSaveGradeAndGetGrades(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+
+	q := session.Questions[0]
+	err := s.SaveGrade(ctx, session.ID, q.ID, 80, []string{"concept A"}, []string{"concept B"}, []int{0}, []int{1}, "my answer", "", 0, nil)
+	if err != nil {
+		t.Fatalf("SaveGrade: %v", err)
+	}
+
+	grades, err := s.GetGrades(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetGrades: %v", err)
+	}
+	if len(grades) != 1 {
+		t.Fatalf("expected 1 grade, got %d", len(grades))
+	}
+	g := grades[0]
+	if g.Score != 80 {
+		t.Errorf("expected score 80, got %d", g.Score)
+	}
+	if g.UserAnswer != "my answer" {
+		t.Errorf("expected user answer %q, got %q", "my answer", g.UserAnswer)
+	}
+	if g.Status != store.GradeStatusSuccess {
+		t.Errorf("expected status success, got %v", g.Status)
+	}
+	if len(g.CoveredIndices) != 1 || g.CoveredIndices[0] != 0 {
+		t.Errorf("expected covered indices [0], got %v", g.CoveredIndices)
+	}
+	if len(g.MissedIndices) != 1 || g.MissedIndices[0] != 1 {
+		t.Errorf("expected missed indices [1], got %v", g.MissedIndices)
+	}
+}
+
+func TestSaveGrade_Upsert(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+
+	q := session.Questions[0]
+	s.SaveGrade(ctx, session.ID, q.ID, 60, nil, nil, nil, nil, "first", "", 0, nil)
+	firstAnswered := mustQuestionStats(t, s, q.ID).LastAnswered
+	s.SaveGrade(ctx, session.ID, q.ID, 90, nil, nil, nil, nil, "second", "", 0, nil)
+
+	grades, _ := s.GetGrades(ctx, session.ID)
+	if len(grades) != 1 {
+		t.Fatalf("expected upsert to keep 1 grade, got %d", len(grades))
+	}
+	if grades[0].Score != 90 || grades[0].UserAnswer != "second" {
+		t.Errorf("expected the second answer to win, got %+v", grades[0])
+	}
+
+	stats, _ := s.GetQuestionStats(ctx, q.ID)
+	if stats.TimesAnswered != 1 || stats.TimesCorrect != 1 || stats.TotalScore != 90 || stats.LatestScore != 90 || stats.Mastery != 90 {
+		t.Errorf("expected a re-answer to replace its attempt, got %+v", stats)
+	}
+	if firstAnswered.IsZero() || stats.LastAnswered.Before(firstAnswered) {
+		t.Errorf("expected the re-answer to move last answered on from %v, got %v", firstAnswered, stats.LastAnswered)
+	}
+
+	// A failed re-grade retracts the attempt; the next success counts once.
+	s.SaveGradeFailure(ctx, session.ID, q.ID, "third", "LLM timeout", "")
+	if stats, _ := s.GetQuestionStats(ctx, q.ID); stats.TimesAnswered != 0 {
+		t.Errorf("expected the failed re-grade to retract the attempt, got %+v", stats)
+	}
+	s.SaveGrade(ctx, session.ID, q.ID, 50, nil, nil, nil, nil, "fourth", "", 0, nil)
+	if stats, _ := s.GetQuestionStats(ctx, q.ID); stats.TimesAnswered != 1 || stats.TotalScore != 50 {
+		t.Errorf("expected a single attempt after the failure, got %+v", stats)
+	}
+}
+
+func TestSaveGrade_DropsReplacedAnswer(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBankWithQuestions(ctx, bank)
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	q := session.Questions[0]
+
+	// The question is answered again while the first answer is grading, and
+	// the newer answer's grading finishes first.
+	now := time.Now()
+	s.SavePendingAnswer(ctx, store.PendingAnswer{SessionID: session.ID, QuestionID: q.ID, UserAnswer: "first", SubmittedAt: now})
+	s.SavePendingAnswer(ctx, store.PendingAnswer{SessionID: session.ID, QuestionID: q.ID, UserAnswer: "second", SubmittedAt: now.Add(time.Second)})
+	if err := s.SaveGrade(ctx, session.ID, q.ID, 90, nil, nil, nil, nil, "second", "", 0, nil); err != nil {
+		t.Fatalf("SaveGrade: %v", err)
+	}
+	if err := s.SaveGrade(ctx, session.ID, q.ID, 10, nil, nil, nil, nil, "first", "", 0, nil); err != nil {
+		t.Fatalf("SaveGrade: %v", err)
+	}
+	if err := s.SaveGradeFailure(ctx, session.ID, q.ID, "first", "LLM timeout", ""); err != nil {
+		t.Fatalf("SaveGradeFailure: %v", err)
+	}
+
+	grades, _ := s.GetGrades(ctx, session.ID)
+	if len(grades) != 1 || grades[0].Score != 90 || grades[0].UserAnswer != "second" || grades[0].Status != store.GradeStatusSuccess {
+		t.Errorf("expected the latest submission to keep its grade, got %+v", grades)
+	}
+	if stats := mustQuestionStats(t, s, q.ID); stats.TimesAnswered != 1 || stats.TotalScore != 90 || stats.LatestScore != 90 {
+		t.Errorf("expected the stale gradings to leave the stats alone, got %+v", stats)
+	}
+}
+
+func mustQuestionStats(t *testing.T, s *store.SQLiteStore, questionID string) *questionbank.QuestionStats {
+	t.Helper()
+	stats, err := s.GetQuestionStats(context.Background(), questionID)
+	if err != nil {
+		t.Fatalf("GetQuestionStats: %v", err)
+	}
+	return stats
+}
+
+func TestSaveGradeFailure(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+
+	q := session.Questions[0]
+	if err := s.SaveGradeFailure(ctx, session.ID, q.ID, "my answer", "LLM timeout", ""); err != nil {
+		t.Fatalf("SaveGradeFailure: %v", err)
+	}
+
+	grades, _ := s.GetGrades(ctx, session.ID)
+	if len(grades) != 1 {
+		t.Fatalf("expected 1 grade, got %d", len(grades))
+	}
+	if grades[0].Status != store.GradeStatusFailed {
+		t.Errorf("expected status failed, got %v", grades[0].Status)
+	}
+	if grades[0].Score != 0 {
+		t.Errorf("expected score 0 for failure, got %d", grades[0].Score)
+	}
+}
+
+func TestSaveGradeFailure_RestoresLatestScore(t *testing.T) {
+	for _, tt := range []struct {
+		scores      []int // one session each; the last one's grade fails on re-grade
+		wantLatest  int
+		wantMastery int
+	}{
+		{[]int{80, 90}, 80, 80},
+		{[]int{80, 60, 90}, 60, 68}, // 60*0.6 + 80*0.4
+	} {
+		s := newTestStore(t)
+		ctx := context.Background()
+
+		bank := questionbank.New("Test")
+		bank.AddQuestion("Q1", "A1")
+		s.SaveBank(ctx, bank)
+		q := bank.Questions[0]
+
+		var sessionID string
+		for _, score := range tt.scores {
+			session := practicesession.New(bank)
+			s.SaveSession(ctx, session)
+			sessionID = session.ID
+			if err := s.SaveGrade(ctx, session.ID, q.ID, score, nil, nil, nil, nil, "answer", "", 0, nil); err != nil {
+				t.Fatalf("SaveGrade: %v", err)
+			}
+		}
+		if err := s.SaveGradeFailure(ctx, sessionID, q.ID, "answer", "LLM timeout", ""); err != nil {
+			t.Fatalf("SaveGradeFailure: %v", err)
+		}
+
+		stats, err := s.GetQuestionStats(ctx, q.ID)
+		if err != nil {
+			t.Fatalf("GetQuestionStats: %v", err)
+		}
+		if stats.TimesAnswered != len(tt.scores)-1 || stats.LatestScore != tt.wantLatest || stats.Mastery != tt.wantMastery {
+			t.Errorf("scores %v with the last retracted: expected %d answers, latest %d and mastery %d, got %d, %d and %d",
+				tt.scores, len(tt.scores)-1, tt.wantLatest, tt.wantMastery, stats.TimesAnswered, stats.LatestScore, stats.Mastery)
+		}
+	}
+}
+
+// orphansByTable indexes orphan counts by table.
+func orphansByTable(counts []store.OrphanCount) map[string]int {
+	byTable := make(map[string]int, len(counts))
+	for _, c := range counts {
+		byTable[c.Table] = c.Count
+	}
+	return byTable
+}
+
+func TestFindAndDeleteOrphans(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	// Healthy rows that must survive the cleanup.
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	s.AddQuestions(ctx, bank.ID, bank.Questions)
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, bank.Questions[0].ID, 80, nil, nil, nil, nil, "a", "", 0, nil)
+
+	// A question of a missing bank, with tags and stats of its own, and
+	// rows pointing at a missing session and bank.
+	orphan := questionbank.Question{ID: "orphan-q", Subject: "Q", ExpectedAnswer: "A", Tags: []string{"go"}}
+	if err := s.AddQuestions(ctx, "ghost-bank", []questionbank.Question{orphan}); err != nil {
+		t.Fatalf("AddQuestions: %v", err)
+	}
+	s.SavePendingAnswer(ctx, store.PendingAnswer{SessionID: "ghost-session", QuestionID: orphan.ID, UserAnswer: "a", SubmittedAt: time.Now()})
+	s.SaveGrade(ctx, "ghost-session", orphan.ID, 50, nil, nil, nil, nil, "a", "", 0, nil)
+	s.LogBankReview(ctx, "ghost-bank", time.Now())
+
+	found, err := s.FindOrphans(ctx)
+	if err != nil {
+		t.Fatalf("FindOrphans: %v", err)
+	}
+	want := map[string]int{
+		"questions": 1, "question_stats": 0, "question_tags": 0, "bank_tags": 0,
+		"review_log": 1, "session_questions": 0, "grades": 1, "session_answers": 1,
+	}
+	if got := orphansByTable(found); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected orphans %v, got %v", want, got)
+	}
+
+	// Deleting the orphaned question orphans its stats and tags too.
+	removed, err := s.DeleteOrphans(ctx)
+	if err != nil {
+		t.Fatalf("DeleteOrphans: %v", err)
+	}
+	want["question_stats"], want["question_tags"] = 1, 1
+	if got := orphansByTable(removed); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected removed %v, got %v", want, got)
+	}
+
+	found, _ = s.FindOrphans(ctx)
+	for _, c := range found {
+		if c.Count != 0 {
+			t.Errorf("expected no orphans left, got %d in %s", c.Count, c.Table)
+		}
+	}
+	got, _ := s.GetBank(ctx, bank.ID)
+	grades, _ := s.GetGrades(ctx, session.ID)
+	stats, _ := s.GetQuestionStats(ctx, bank.Questions[0].ID)
+	if len(got.Questions) != 1 || len(grades) != 1 || stats.TimesAnswered != 1 {
+		t.Errorf("expected healthy rows kept, got %d questions, %d grades and %d answers", len(got.Questions), len(grades), stats.TimesAnswered)
+	}
+}
+
+func TestSaveGrade_Provenance(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	q := session.Questions[0]
+
+	before := time.Now()
+	if err := s.SaveGrade(ctx, session.ID, q.ID, 80, nil, nil, nil, nil, "a", "qwen3:8b", 2350*time.Millisecond, nil); err != nil {
+		t.Fatalf("SaveGrade: %v", err)
+	}
+	grades, _ := s.GetGrades(ctx, session.ID)
+	g := grades[0]
+	if g.Model != "qwen3:8b" || g.Duration != 2350*time.Millisecond || g.GradedAt.Before(before.Add(-time.Second)) {
+		t.Errorf("expected model, duration and grading time recorded, got %q, %v, %v", g.Model, g.Duration, g.GradedAt)
+	}
+	history, _ := s.GetGradesByQuestion(ctx, q.ID, 10)
+	if history[0].Model != "qwen3:8b" || history[0].Duration != 2350*time.Millisecond {
+		t.Errorf("expected provenance in the question history, got %+v", history[0])
+	}
+
+	// A failed regrade does not keep the earlier grade's model.
+	s.SaveGradeFailure(ctx, session.ID, q.ID, "a", "LLM timeout", "")
+	grades, _ = s.GetGrades(ctx, session.ID)
+	if grades[0].Model != "" || grades[0].Duration != 0 {
+		t.Errorf("expected no provenance for a failure, got %q, %v", grades[0].Model, grades[0].Duration)
+	}
+}
+
+func TestSaveGrade_MatchedAnswer(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	matched := 1
+	s.SaveGrade(ctx, "s1", "q1", 100, nil, nil, nil, nil, "sigint", "exact", 0, &matched)
+	grades, _ := s.GetGrades(ctx, "s1")
+	if m := grades[0].MatchedAnswer; m == nil || *m != 1 {
+		t.Errorf("expected matched answer 1, got %v", m)
+	}
+
+	// A regrade replaces it along with the rest of the grade.
+	s.SaveGrade(ctx, "s1", "q1", 0, nil, nil, nil, nil, "sigstop", "exact", 0, nil)
+	grades, _ = s.GetGrades(ctx, "s1")
+	if m := grades[0].MatchedAnswer; m != nil {
+		t.Errorf("expected the matched answer cleared by a regrade, got %d", *m)
+	}
+}
+
+func TestListGradeFailures_NewestFirst(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	s.SaveBank(ctx, bank)
+	for _, subject := range []string{"Q1", "Q2", "Q3"} {
+		bank.AddQuestion(subject, "A")
+		s.AddQuestion(ctx, bank.ID, bank.Questions[len(bank.Questions)-1])
+	}
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+
+	qs := session.Questions
+	s.SaveGradeFailure(ctx, session.ID, qs[0].ID, "a1", "LLM timeout", "")
+	s.SaveGrade(ctx, session.ID, qs[1].ID, 80, nil, nil, nil, nil, "a2", "", 0, nil)
+	s.SaveGradeFailure(ctx, session.ID, qs[2].ID, "a3", "failed to parse grading response", "not json")
+
+	failures, err := s.ListGradeFailures(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListGradeFailures: %v", err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures, got %d", len(failures))
+	}
+	if failures[0].QuestionID != qs[2].ID || failures[1].QuestionID != qs[0].ID {
+		t.Errorf("expected newest first, got %s then %s", failures[0].QuestionID, failures[1].QuestionID)
+	}
+	if failures[0].RawResponse != "not json" || failures[0].Reason != "failed to parse grading response" {
+		t.Errorf("unexpected failure details: %+v", failures[0])
+	}
+	if failures[0].FailedAt.IsZero() {
+		t.Error("expected failed_at to be set")
+	}
+
+	limited, _ := s.ListGradeFailures(ctx, 1)
+	if len(limited) != 1 || limited[0].QuestionID != qs[2].ID {
+		t.Errorf("expected only the newest failure with limit 1, got %+v", limited)
+	}
+}
+
+func TestGetGradesByQuestion(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A")
+	s.SaveBank(ctx, bank)
+	s.AddQuestions(ctx, bank.ID, bank.Questions)
+	full, _ := s.GetBank(ctx, bank.ID)
+	qID := full.Questions[0].ID
+
+	var sessionIDs []string
+	for range 3 {
+		session := practicesession.New(full)
+		s.SaveSession(ctx, session)
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+	s.SaveGrade(ctx, sessionIDs[0], qID, 40, nil, []string{"detail"}, nil, nil, "first", "", 0, nil)
+	s.SaveGrade(ctx, sessionIDs[1], qID, 90, []string{"detail"}, nil, nil, nil, "second", "", 0, nil)
+	s.SaveGradeFailure(ctx, sessionIDs[2], qID, "third", "LLM timeout", "")
+
+	grades, err := s.GetGradesByQuestion(ctx, qID, 10)
+	if err != nil {
+		t.Fatalf("GetGradesByQuestion: %v", err)
+	}
+	if len(grades) != 3 {
+		t.Fatalf("expected 3 grades, got %d", len(grades))
+	}
+	for i, want := range []string{"third", "second", "first"} {
+		if grades[i].UserAnswer != want || grades[i].SessionID != sessionIDs[2-i] {
+			t.Errorf("grade %d: expected %q, got %+v", i, want, grades[i])
+		}
+		if grades[i].GradedAt.IsZero() {
+			t.Errorf("grade %d: expected graded_at to be set", i)
+		}
+	}
+	if grades[0].Status != store.GradeStatusFailed || grades[1].Score != 90 || len(grades[1].Covered) != 1 {
+		t.Errorf("unexpected grade details: %+v", grades[:2])
+	}
+
+	limited, _ := s.GetGradesByQuestion(ctx, qID, 1)
+	if len(limited) != 1 || limited[0].UserAnswer != "third" {
+		t.Errorf("expected only the newest grade with limit 1, got %+v", limited)
+	}
+}
+
+func TestGetQuestionsUnansweredFirst(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	s.SaveBank(ctx, bank)
+	for _, subject := range []string{"Strong", "Weak", "New A", "Zero", "New B"} {
+		bank.AddQuestion(subject, "A")
+		s.AddQuestion(ctx, bank.ID, bank.Questions[len(bank.Questions)-1])
+	}
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+
+	scores := map[string]int{"Strong": 90, "Weak": 40, "Zero": 0}
+	for _, q := range full.Questions {
+		if score, ok := scores[q.Subject]; ok {
+			s.SaveGrade(ctx, session.ID, q.ID, score, nil, nil, nil, nil, "answer", "", 0, nil)
+		}
+	}
+
+	questions, err := s.GetQuestionsUnansweredFirst(ctx, bank.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionsUnansweredFirst: %v", err)
+	}
+	if len(questions) != 5 {
+		t.Fatalf("expected 5 questions, got %d", len(questions))
+	}
+
+	// Never-answered questions come first, even ahead of an answered 0-mastery one.
+	for _, q := range questions[:2] {
+		if q.Subject != "New A" && q.Subject != "New B" {
+			t.Errorf("expected unanswered questions first, got %q", q.Subject)
+		}
+	}
+	want := []string{"Zero", "Weak", "Strong"}
+	for i, q := range questions[2:] {
+		if q.Subject != want[i] {
+			t.Errorf("position %d: expected %q, got %q", i+2, want[i], q.Subject)
+		}
+	}
+}
+
+func TestGetWeakestQuestionsInCategory(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	cat := category.New("Go")
+	s.SaveCategory(ctx, cat)
+	other := category.New("Rust")
+	s.SaveCategory(ctx, other)
+
+	// subject -> score; questions without a score are never answered.
+	scores := map[string]int{"Strong": 90, "Weak": 40, "Zero": 0, "Elsewhere": 0}
+	var sessionQuestions []practicesession.QuestionWithBankID
+	for _, b := range []struct {
+		bank     *questionbank.QuestionBank
+		subjects []string
+	}{
+		{questionbank.NewWithCategory("Channels", cat.ID), []string{"Strong", "New A"}},
+		{questionbank.NewWithCategory("Goroutines", cat.ID), []string{"Weak", "Zero", "New B"}},
+		{questionbank.NewWithCategory("Ownership", other.ID), []string{"Elsewhere"}},
+	} {
+		s.SaveBank(ctx, b.bank)
+		for _, subject := range b.subjects {
+			b.bank.AddQuestion(subject, "A")
+			q := b.bank.Questions[len(b.bank.Questions)-1]
+			s.AddQuestion(ctx, b.bank.ID, q)
+			sessionQuestions = append(sessionQuestions, practicesession.QuestionWithBankID{Question: q, BankID: b.bank.ID})
+		}
+	}
+	session := practicesession.NewMultiBankSession(sessionQuestions, practicesession.DefaultConfig())
+	s.SaveSession(ctx, session)
+	for _, q := range sessionQuestions {
+		if score, ok := scores[q.Question.Subject]; ok {
+			s.SaveGrade(ctx, session.ID, q.Question.ID, score, nil, nil, nil, nil, "answer", "", 0, nil)
+		}
+	}
+
+	questions, err := s.GetWeakestQuestionsInCategory(ctx, cat.ID, 10)
+	if err != nil {
+		t.Fatalf("GetWeakestQuestionsInCategory: %v", err)
+	}
+	var got []string
+	for _, q := range questions {
+		got = append(got, q.BankSubject+"/"+q.Subject)
+	}
+	// Never-answered first (by bank subject), then by ascending mastery.
+	want := []string{"Channels/New A", "Goroutines/New B", "Goroutines/Zero", "Goroutines/Weak", "Channels/Strong"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if questions[2].TimesAnswered != 1 || questions[0].TimesAnswered != 0 {
+		t.Errorf("expected times answered 0 then 1, got %+v", questions)
+	}
+
+	limited, _ := s.GetWeakestQuestionsInCategory(ctx, cat.ID, 3)
+	if len(limited) != 3 {
+		t.Errorf("expected limit 3 to be honoured, got %d", len(limited))
+	}
+	if none, _ := s.GetWeakestQuestionsInCategory(ctx, "ghost", 10); len(none) != 0 {
+		t.Errorf("expected no questions for an unknown category, got %d", len(none))
+	}
+}
+
+func TestGetQuestionStats_Streak(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	q := bank.Questions[0]
+	s.AddQuestion(ctx, bank.ID, q)
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	for _, score := range []int{90, 40, 80, 100} {
+		session := practicesession.New(full)
+		s.SaveSession(ctx, session)
+		s.SaveGrade(ctx, session.ID, q.ID, score, nil, nil, nil, nil, "answer", "", 0, nil)
+	}
+
+	stats, err := s.GetQuestionStats(ctx, q.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionStats: %v", err)
+	}
+	if stats.TimesAnswered != 4 || stats.TimesCorrect != 3 {
+		t.Errorf("expected 4 answered / 3 correct, got %d / %d", stats.TimesAnswered, stats.TimesCorrect)
+	}
+	if stats.Streak != 2 {
+		t.Errorf("expected streak 2, got %d", stats.Streak)
+	}
+	if stats.Accuracy() != 75 {
+		t.Errorf("expected accuracy 75, got %d", stats.Accuracy())
+	}
+}
+
+func TestQuestionStats_MasteryMatchesDomain(t *testing.T) {
+	testMasteryMatchesDomain(t, newTestStore(t))
+}
+
+// testMasteryMatchesDomain feeds a score sequence through s and checks the
+// stored mastery against QuestionStats.CalculateMastery after every grade,
+// including a re-grade that replaces the latest score.
+func testMasteryMatchesDomain(t *testing.T, s store.Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	q := bank.Questions[0]
+	s.AddQuestion(ctx, bank.ID, q)
+	full, _ := s.GetBank(ctx, bank.ID)
+
+	var want questionbank.QuestionStats
+	check := func(step string) {
+		t.Helper()
+		got, err := s.GetQuestionStats(ctx, q.ID)
+		if err != nil {
+			t.Fatalf("GetQuestionStats: %v", err)
+		}
+		if got.TimesAnswered != want.TimesAnswered || got.TotalScore != want.TotalScore || got.LatestScore != want.LatestScore {
+			t.Fatalf("%s: expected %d answered / total %d / latest %d, got %+v", step, want.TimesAnswered, want.TotalScore, want.LatestScore, got)
+		}
+		if m := want.CalculateMastery(); got.Mastery != m {
+			t.Errorf("%s: expected mastery %d, got %d", step, m, got.Mastery)
+		}
+	}
+
+	var session *practicesession.PracticeSession
+	for _, score := range []int{40, 95, 63, 100, 71} {
+		session = practicesession.New(full)
+		s.SaveSession(ctx, session)
+		if err := s.SaveGrade(ctx, session.ID, q.ID, score, nil, nil, nil, nil, "answer", "", 0, nil); err != nil {
+			t.Fatalf("SaveGrade: %v", err)
+		}
+		want.TimesAnswered++
+		want.TotalScore += score
+		want.LatestScore = score
+		check(fmt.Sprintf("score %d", score))
+	}
+
+	if err := s.SaveGrade(ctx, session.ID, q.ID, 12, nil, nil, nil, nil, "answer", "", 0, nil); err != nil {
+		t.Fatalf("SaveGrade: %v", err)
+	}
+	want.TotalScore += 12 - 71
+	want.LatestScore = 12
+	check("re-grade")
+}
+
+func TestSetPassThreshold(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	s.SetPassThreshold(85)
+	s.SetPassThreshold(0) // out of range, ignored
+	if got := s.PassThreshold(); got != 85 {
+		t.Fatalf("expected pass threshold 85, got %d", got)
+	}
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	q := bank.Questions[0]
+	s.AddQuestion(ctx, bank.ID, q)
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	for _, score := range []int{90, 80, 100} {
+		session := practicesession.New(full)
+		s.SaveSession(ctx, session)
+		s.SaveGrade(ctx, session.ID, q.ID, score, nil, nil, nil, nil, "answer", "", 0, nil)
+	}
+
+	stats, err := s.GetQuestionStats(ctx, q.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionStats: %v", err)
+	}
+	if stats.TimesCorrect != 2 {
+		t.Errorf("expected 80 not to count as correct at threshold 85, got %d correct", stats.TimesCorrect)
+	}
+	if stats.Streak != 1 {
+		t.Errorf("expected streak 1, got %d", stats.Streak)
+	}
+}
+
+func TestResetBankStats(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	var bankIDs, questionIDs []string
+	for _, subject := range []string{"Reset", "Kept"} {
+		bank := questionbank.New(subject)
+		bank.AddQuestion("Q1", "A1")
+		s.SaveBank(ctx, bank)
+		s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+		full, _ := s.GetBank(ctx, bank.ID)
+		session := practicesession.New(full)
+		s.SaveSession(ctx, session)
+		s.SaveGrade(ctx, session.ID, bank.Questions[0].ID, 80, nil, nil, nil, nil, "answer", "", 0, nil)
+		bankIDs = append(bankIDs, bank.ID)
+		questionIDs = append(questionIDs, bank.Questions[0].ID)
+	}
+
+	if err := s.ResetBankStats(ctx, bankIDs[0]); err != nil {
+		t.Fatalf("ResetBankStats: %v", err)
+	}
+
+	if stats, _ := s.GetQuestionStats(ctx, questionIDs[0]); stats.TimesAnswered != 0 || stats.Mastery != 0 {
+		t.Errorf("expected zeroed stats, got %+v", stats)
+	}
+	if stats, _ := s.GetQuestionStats(ctx, questionIDs[1]); stats.TimesAnswered != 1 || stats.Mastery != 80 {
+		t.Errorf("expected the other bank's stats to be kept, got %+v", stats)
+	}
+	if grades, _ := s.GetGradesByQuestion(ctx, questionIDs[0], 10); len(grades) != 1 {
+		t.Errorf("expected the grade to be kept, got %d", len(grades))
+	}
+}
+
+func TestRestoreQuestionStats(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	s.SaveBank(ctx, bank)
+	q := bank.Questions[0]
+	s.AddQuestion(ctx, bank.ID, q)
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, q.ID, 20, nil, nil, nil, nil, "answer", "", 0, nil)
+
+	answeredAt := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	want := questionbank.QuestionStats{QuestionID: q.ID, TimesAnswered: 5, TimesCorrect: 4, TotalScore: 400, LatestScore: 90, Mastery: 86, LastAnswered: answeredAt}
+	if err := s.RestoreQuestionStats(ctx, want); err != nil {
+		t.Fatalf("RestoreQuestionStats: %v", err)
+	}
+
+	got, err := s.GetQuestionStats(ctx, q.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionStats: %v", err)
+	}
+	if got.TimesAnswered != 5 || got.TimesCorrect != 4 || got.TotalScore != 400 || got.LatestScore != 90 || got.Mastery != 86 {
+		t.Errorf("existing stats not overwritten: %+v", got)
+	}
+	if !got.LastAnswered.Equal(answeredAt) {
+		t.Errorf("expected last answered %v, got %v", answeredAt, got.LastAnswered)
+	}
+}
+
+// ============================================================================
+// Search
+// ============================================================================
+
+func TestSearch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	theory := questionbank.New("Go concurrency")
+	s.SaveBank(ctx, theory)
+	theory.AddQuestion("What is a goroutine?", "A lightweight thread managed by the Go runtime")
+	theory.AddQuestion("Why prefer channels?", "A thread that is lightweight on memory can still race")
+	theory.AddQuestion("What is a mutex?", "A lock")
+	for _, q := range theory.Questions {
+		s.AddQuestion(ctx, theory.ID, q)
+	}
+	code := questionbank.NewWithOptions("Threads in Rust", nil, questionbank.BankTypeCode, nil)
+	s.SaveBank(ctx, code)
+	code.AddQuestion("Spawn a lightweight thread", "std::thread::spawn")
+	s.AddQuestion(ctx, code.ID, code.Questions[0])
+
+	hits, err := s.Search(ctx, store.SearchQuery{Text: "Lightweight thread"})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 3 {
+		t.Fatalf("expected 3 hits, got %+v", hits)
+	}
+	if !hits[0].Exact || !hits[1].Exact || hits[2].Exact {
+		t.Errorf("expected the two phrase matches before the token match, got %+v", hits)
+	}
+	if hits[2].QuestionID != theory.Questions[1].ID || hits[2].Field != "expected_answer" {
+		t.Errorf("expected the token match last, got %+v", hits[2])
+	}
+	if !strings.Contains(hits[2].Snippet, "<mark>lightweight</mark>") {
+		t.Errorf("expected a highlighted snippet, got %q", hits[2].Snippet)
+	}
+
+	hits, _ = s.Search(ctx, store.SearchQuery{Text: "lightweight thread", BankType: "code"})
+	if len(hits) != 1 || hits[0].BankID != code.ID || hits[0].Field != "subject" {
+		t.Errorf("expected only the code question, got %+v", hits)
+	}
+
+	hits, _ = s.Search(ctx, store.SearchQuery{Text: "lightweight thread", Limit: 1})
+	if len(hits) != 1 {
+		t.Errorf("expected the limit to apply, got %d hits", len(hits))
+	}
+
+	hits, _ = s.Search(ctx, store.SearchQuery{Text: "concurrency"})
+	if len(hits) != 1 || hits[0].QuestionID != "" || hits[0].Field != "bank_subject" {
+		t.Errorf("expected a bank subject hit, got %+v", hits)
+	}
+
+	// The index follows edits and deletes.
+	mutex := theory.Questions[2]
+	mutex.ExpectedAnswer = "A mutual exclusion lock"
+	s.UpdateQuestion(ctx, mutex)
+	if hits, _ := s.Search(ctx, store.SearchQuery{Text: "exclusion"}); len(hits) != 1 {
+		t.Errorf("expected the edited answer to be found, got %+v", hits)
+	}
+	s.DeleteQuestion(ctx, mutex.ID)
+	if hits, _ := s.Search(ctx, store.SearchQuery{Text: "exclusion"}); len(hits) != 0 {
+		t.Errorf("expected the deleted question to be gone, got %+v", hits)
+	}
+
+	if hits, err := s.Search(ctx, store.SearchQuery{Text: `"*`}); err != nil || len(hits) != 0 {
+		t.Errorf("expected query syntax to be ignored, got %+v, %v", hits, err)
+	}
+}
+
+func TestSearch_EscapesSnippets(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Web <b>basics</b>")
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("What does <script>alert(1)</script> do?", "Runs alert & friends")
+	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+
+	hits, err := s.Search(ctx, store.SearchQuery{Text: "alert"})
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("expected 1 hit, got %+v, %v", hits, err)
+	}
+	if want := "What does &lt;script&gt;<mark>alert</mark>(1)&lt;/script&gt; do?"; hits[0].Snippet != want {
+		t.Errorf("expected an escaped snippet %q, got %q", want, hits[0].Snippet)
+	}
+
+	hits, _ = s.Search(ctx, store.SearchQuery{Text: "basics"})
+	if len(hits) != 1 || hits[0].Snippet != "Web &lt;b&gt;<mark>basics</mark>&lt;/b&gt;" {
+		t.Errorf("expected an escaped bank subject snippet, got %+v", hits)
+	}
+}
+
+// ============================================================================
+// Mastery batch queries
+// ============================================================================
+
+func seedBankWithScore(t *testing.T, s store.Store, ctx context.Context, catID string, score int) string {
+	t.Helper()
+	bank := questionbank.NewWithCategory("Bank", catID)
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Q", "A")
+	q := bank.Questions[0]
+	s.AddQuestion(ctx, bank.ID, q)
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, q.ID, score, nil, nil, nil, nil, "answer", "", 0, nil)
+	return bank.ID
+}
+
+func TestGetBankMasteryBatch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	cat := category.New("Go")
+	s.SaveCategory(ctx, cat)
+
+	id1 := seedBankWithScore(t, s, ctx, cat.ID, 80)
+	id2 := seedBankWithScore(t, s, ctx, cat.ID, 40)
+
+	result, err := s.GetBankMasteryBatch(ctx, []string{id1, id2}, store.MasteryScopeAll)
+	if err != nil {
+		t.Fatalf("GetBankMasteryBatch: %v", err)
+	}
+	if _, ok := result[id1]; !ok {
+		t.Errorf("expected mastery entry for bank %s", id1)
+	}
+	if _, ok := result[id2]; !ok {
+		t.Errorf("expected mastery entry for bank %s", id2)
+	}
+}
+
+func TestGetBankMasteryBatch_Empty(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	result, err := s.GetBankMasteryBatch(ctx, []string{}, store.MasteryScopeAll)
+	if err != nil {
+		t.Fatalf("GetBankMasteryBatch empty: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("expected empty map, got %d entries", len(result))
+	}
+}
+
+func TestGetUnansweredCountBatch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	cat := category.New("Go")
+	s.SaveCategory(ctx, cat)
+
+	answered := seedBankWithScore(t, s, ctx, cat.ID, 80)
+
+	partial := questionbank.NewWithCategory("Partial", cat.ID)
+	s.SaveBank(ctx, partial)
+	partial.AddQuestion("Q1", "A1")
+	partial.AddQuestion("Q2", "A2")
+	for _, q := range partial.Questions {
+		s.AddQuestion(ctx, partial.ID, q)
+	}
+	full, _ := s.GetBank(ctx, partial.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, partial.Questions[0].ID, 50, nil, nil, nil, nil, "answer", "", 0, nil)
+
+	fresh := questionbank.NewWithCategory("Fresh", cat.ID)
+	s.SaveBank(ctx, fresh)
+	fresh.AddQuestion("Q1", "A1")
+	fresh.AddQuestion("Q2", "A2")
+	fresh.AddQuestion("Q3", "A3")
+	for _, q := range fresh.Questions {
+		s.AddQuestion(ctx, fresh.ID, q)
+	}
+
+	result, err := s.GetUnansweredCountBatch(ctx, []string{answered, partial.ID, fresh.ID})
+	if err != nil {
+		t.Fatalf("GetUnansweredCountBatch: %v", err)
+	}
+	if result[answered] != 0 {
+		t.Errorf("fully answered bank: expected 0, got %d", result[answered])
+	}
+	if result[partial.ID] != 1 {
+		t.Errorf("partially answered bank: expected 1, got %d", result[partial.ID])
+	}
+	if result[fresh.ID] != 3 {
+		t.Errorf("brand-new bank: expected 3, got %d", result[fresh.ID])
+	}
+}
+
+func TestGetCategoryMasteryBatch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	cat1 := category.New("Go")
+	cat2 := category.New("Rust")
+	s.SaveCategory(ctx, cat1)
+	s.SaveCategory(ctx, cat2)
+
+	seedBankWithScore(t, s, ctx, cat1.ID, 100)
+	seedBankWithScore(t, s, ctx, cat2.ID, 20)
+
+	result, err := s.GetCategoryMasteryBatch(ctx, []string{cat1.ID, cat2.ID})
+	if err != nil {
+		t.Fatalf("GetCategoryMasteryBatch: %v", err)
+	}
+	if _, ok := result[cat1.ID]; !ok {
+		t.Errorf("expected mastery for category %s", cat1.ID)
+	}
+	if _, ok := result[cat2.ID]; !ok {
+		t.Errorf("expected mastery for category %s", cat2.ID)
+	}
+}
+
+func TestGetFolderMasteryBatch(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	f1 := folder.New("Work")
+	f2 := folder.New("Personal")
+	s.SaveFolder(ctx, f1)
+	s.SaveFolder(ctx, f2)
+
+	cat1 := category.NewWithFolder("Go", f1.ID)
+	cat2 := category.NewWithFolder("Rust", f2.ID)
+	s.SaveCategory(ctx, cat1)
+	s.SaveCategory(ctx, cat2)
+
+	seedBankWithScore(t, s, ctx, cat1.ID, 75)
+	seedBankWithScore(t, s, ctx, cat2.ID, 50)
+
+	result, err := s.GetFolderMasteryBatch(ctx, []string{f1.ID, f2.ID})
+	if err != nil {
+		t.Fatalf("GetFolderMasteryBatch: %v", err)
+	}
+	if _, ok := result[f1.ID]; !ok {
+		t.Errorf("expected mastery for folder %s", f1.ID)
+	}
+	if _, ok := result[f2.ID]; !ok {
+		t.Errorf("expected mastery for folder %s", f2.ID)
+	}
+}
+
+func TestGetFolderMasteryBatch_Empty(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	result, err := s.GetFolderMasteryBatch(ctx, []string{})
+	if err != nil {
+		t.Fatalf("GetFolderMasteryBatch empty: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("expected empty map, got %d entries", len(result))
+	}
+}
+
+func TestGetBankMastery_WeightedByDifficulty(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Weighted")
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Easy", "A")
+	bank.AddQuestion("Hard", "A")
+	bank.Questions[1].Difficulty = questionbank.DifficultyHard
+	for _, q := range bank.Questions {
+		s.AddQuestion(ctx, bank.ID, q)
+	}
+	easy, hard := bank.Questions[0], bank.Questions[1]
+	if err := s.UpdateQuestionDifficulty(ctx, easy.ID, questionbank.DifficultyEasy); err != nil {
+		t.Fatalf("UpdateQuestionDifficulty: %v", err)
+	}
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	if full.Questions[0].Difficulty != questionbank.DifficultyEasy || full.Questions[1].Difficulty != questionbank.DifficultyHard {
+		t.Fatalf("expected stored difficulties, got %+v", full.Questions)
+	}
+
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, easy.ID, 100, nil, nil, nil, nil, "a", "", 0, nil)
+
+	// (100*1 + 0*3) / (1 + 3)
+	if mastery, _ := s.GetBankMastery(ctx, bank.ID); mastery != 25 {
+		t.Errorf("expected weighted mastery 25, got %d", mastery)
+	}
+	if batch, _ := s.GetBankMasteryBatch(ctx, []string{bank.ID}, store.MasteryScopeAll); batch[bank.ID] != 25 {
+		t.Errorf("expected batch mastery 25, got %d", batch[bank.ID])
+	}
+	if banks, _ := s.ListBanksWithMastery(ctx, store.MasteryScopeAll); len(banks) != 1 || banks[0].Mastery != 25 {
+		t.Errorf("expected listed mastery 25, got %+v", banks)
+	}
+
+	// Only the easy question has been answered.
+	if mastery, _ := s.GetBankMasteryScoped(ctx, bank.ID, store.MasteryScopeAnswered); mastery != 100 {
+		t.Errorf("expected answered-scope mastery 100, got %d", mastery)
+	}
+	if batch, _ := s.GetBankMasteryBatch(ctx, []string{bank.ID}, store.MasteryScopeAnswered); batch[bank.ID] != 100 {
+		t.Errorf("expected answered-scope batch mastery 100, got %d", batch[bank.ID])
+	}
+	if banks, _ := s.ListBanksWithMastery(ctx, store.MasteryScopeAnswered); len(banks) != 1 || banks[0].Mastery != 100 || banks[0].QuestionCount != 2 {
+		t.Errorf("expected answered-scope listed mastery 100 over 2 questions, got %+v", banks)
+	}
+	untouched := questionbank.New("Untouched")
+	s.SaveBank(ctx, untouched)
+	untouched.AddQuestion("Q", "A")
+	s.AddQuestion(ctx, untouched.ID, untouched.Questions[0])
+	if batch, err := s.GetBankMasteryBatch(ctx, []string{untouched.ID}, store.MasteryScopeAnswered); err != nil || batch[untouched.ID] != 0 {
+		t.Errorf("expected an unanswered bank to have answered-scope mastery 0, got %v, %v", batch, err)
+	}
+
+	// Editing content keeps the difficulty.
+	hard.Subject = "Harder"
+	s.UpdateQuestion(ctx, hard)
+	if q, _ := s.GetQuestion(ctx, bank.ID, hard.ID); q.Difficulty != questionbank.DifficultyHard {
+		t.Errorf("expected difficulty kept after update, got %q", q.Difficulty)
+	}
+
+	if err := s.UpdateQuestionDifficulty(ctx, "ghost", questionbank.DifficultyEasy); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
 	}
 }
 
-// ============================================================================
-// Mastery batch queries
-// ============================================================================
+func TestGetCategoryMastery_WeightedByDifficulty(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
 
-func seedBankWithScore(t *testing.T, s *store.SQLiteStore, ctx context.Context, catID string, score int) string {
-	t.Helper()
-	bank := questionbank.NewWithCategory("Bank", catID)
+	cat := category.New("Weighted")
+	s.SaveCategory(ctx, cat)
+	bank := questionbank.NewWithCategory("Weighted", cat.ID)
 	s.SaveBank(ctx, bank)
-	bank.AddQuestion("Q", "A")
-	q := bank.Questions[0]
-	s.AddQuestion(ctx, bank.ID, q)
+	bank.AddQuestion("Easy", "A")
+	bank.AddQuestion("Hard", "A")
+	bank.Questions[0].Difficulty = questionbank.DifficultyEasy
+	bank.Questions[1].Difficulty = questionbank.DifficultyHard
+	for _, q := range bank.Questions {
+		s.AddQuestion(ctx, bank.ID, q)
+	}
+	// A bank without questions adds nothing to the category.
+	s.SaveBank(ctx, questionbank.NewWithCategory("Empty", cat.ID))
 
 	full, _ := s.GetBank(ctx, bank.ID)
 	session := practicesession.New(full)
 	s.SaveSession(ctx, session)
-	s.SaveGrade(ctx, session.ID, q.ID, score, nil, nil, "answer")
-	return bank.ID
+	s.SaveGrade(ctx, session.ID, bank.Questions[0].ID, 100, nil, nil, nil, nil, "a", "", 0, nil)
+
+	// (100*1 + 0*3) / (1 + 3)
+	if mastery, _ := s.GetCategoryMastery(ctx, cat.ID); mastery != 25 {
+		t.Errorf("expected weighted category mastery 25, got %d", mastery)
+	}
+	if batch, _ := s.GetCategoryMasteryBatch(ctx, []string{cat.ID}); batch[cat.ID] != 25 {
+		t.Errorf("expected batch category mastery 25, got %d", batch[cat.ID])
+	}
+	if cats, _ := s.ListCategoriesWithMastery(ctx); len(cats) != 1 || cats[0].Mastery != 25 {
+		t.Errorf("expected listed category mastery 25, got %+v", cats)
+	}
 }
 
-func TestGetBankMasteryBatch(t *testing.T) {
+func TestGetBank_QuestionPositions(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	cat := category.New("Go")
-	s.SaveCategory(ctx, cat)
+	bank := questionbank.New("Ordered")
+	bank.PreserveOrder = true
+	s.SaveBank(ctx, bank)
+	for _, subject := range []string{"First", "Second", "Third", "Fourth"} {
+		bank.AddQuestion(subject, "A")
+	}
+	s.AddQuestion(ctx, bank.ID, bank.Questions[0])
+	s.AddQuestions(ctx, bank.ID, bank.Questions[1:3])
+	s.AddQuestion(ctx, bank.ID, bank.Questions[3])
 
-	id1 := seedBankWithScore(t, s, ctx, cat.ID, 80)
-	id2 := seedBankWithScore(t, s, ctx, cat.ID, 40)
+	// Deleting a question leaves a gap that does not disturb the order.
+	s.DeleteQuestion(ctx, bank.Questions[1].ID)
 
-	result, err := s.GetBankMasteryBatch(ctx, []string{id1, id2})
+	got, err := s.GetBank(ctx, bank.ID)
 	if err != nil {
-		t.Fatalf("GetBankMasteryBatch: %v", err)
+		t.Fatalf("GetBank: %v", err)
 	}
-	if _, ok := result[id1]; !ok {
-		t.Errorf("expected mastery entry for bank %s", id1)
+	if !got.PreserveOrder {
+		t.Error("expected preserve_order to round-trip")
 	}
-	if _, ok := result[id2]; !ok {
-		t.Errorf("expected mastery entry for bank %s", id2)
+	var subjects []string
+	for _, q := range got.Questions {
+		subjects = append(subjects, q.Subject)
+	}
+	if want := []string{"First", "Third", "Fourth"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("expected questions in insertion order %v, got %v", want, subjects)
 	}
 }
 
-func TestGetBankMasteryBatch_Empty(t *testing.T) {
+func TestGetBankMasteryScoped(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Scoped")
+	s.SaveBank(ctx, bank)
+	bank.AddQuestion("Answered", "A")
+	for _, q := range bank.Questions {
+		s.AddQuestion(ctx, bank.ID, q)
+	}
+
+	for _, scope := range []store.MasteryScope{store.MasteryScopeAll, store.MasteryScopeAnswered} {
+		if mastery, err := s.GetBankMasteryScoped(ctx, bank.ID, scope); err != nil || mastery != 0 {
+			t.Errorf("%s: expected 0 before any answer, got %d (%v)", scope, mastery, err)
+		}
+	}
+
+	session := practicesession.New(bank)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, bank.Questions[0].ID, 80, nil, nil, nil, nil, "a", "", 0, nil)
+
+	// A new question drags "all" down but leaves "answered" alone.
+	bank.AddQuestion("New", "A")
+	s.AddQuestion(ctx, bank.ID, bank.Questions[1])
+
+	if mastery, _ := s.GetBankMasteryScoped(ctx, bank.ID, store.MasteryScopeAll); mastery != 40 {
+		t.Errorf("expected mastery 40 counting the new question as 0, got %d", mastery)
+	}
+	if mastery, _ := s.GetBankMastery(ctx, bank.ID); mastery != 40 {
+		t.Errorf("expected GetBankMastery to count all questions, got %d", mastery)
+	}
+	if mastery, _ := s.GetBankMasteryScoped(ctx, bank.ID, store.MasteryScopeAnswered); mastery != 80 {
+		t.Errorf("expected mastery 80 over answered questions, got %d", mastery)
+	}
+}
+
+func TestListWithMastery_MatchesPerRowMastery(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	result, err := s.GetBankMasteryBatch(ctx, []string{})
+	cat := category.New("Go")
+	s.SaveCategory(ctx, cat)
+	emptyCat := category.New("Empty")
+	s.SaveCategory(ctx, emptyCat)
+
+	seedBankWithScore(t, s, ctx, cat.ID, 85)
+	seedBankWithScore(t, s, ctx, cat.ID, 40)
+
+	// Three questions, one answered: mastery is averaged over all of them.
+	partial := questionbank.NewWithCategory("Partial", cat.ID)
+	s.SaveBank(ctx, partial)
+	for _, q := range []string{"Q1", "Q2", "Q3"} {
+		partial.AddQuestion(q, "A")
+	}
+	for _, q := range partial.Questions {
+		s.AddQuestion(ctx, partial.ID, q)
+	}
+	full, _ := s.GetBank(ctx, partial.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, partial.Questions[0].ID, 71, nil, nil, nil, nil, "answer", "", 0, nil)
+
+	empty := questionbank.NewWithCategory("No questions", cat.ID)
+	s.SaveBank(ctx, empty)
+
+	banks, err := s.ListBanksWithMastery(ctx, store.MasteryScopeAll)
 	if err != nil {
-		t.Fatalf("GetBankMasteryBatch empty: %v", err)
+		t.Fatalf("ListBanksWithMastery: %v", err)
 	}
-	if len(result) != 0 {
-		t.Errorf("expected empty map, got %d entries", len(result))
+	if len(banks) != 4 {
+		t.Fatalf("expected 4 banks, got %d", len(banks))
+	}
+	for _, b := range banks {
+		want, _ := s.GetBankMastery(ctx, b.ID)
+		if b.Mastery != want {
+			t.Errorf("bank %q: expected mastery %d, got %d", b.Subject, want, b.Mastery)
+		}
+	}
+	if banks[2].ID != partial.ID || banks[2].QuestionCount != 3 || banks[2].Mastery != 23 {
+		t.Errorf("expected partial bank third with 3 questions and mastery 23, got %+v", banks[2])
+	}
+	if banks[3].Mastery != 0 || banks[3].QuestionCount != 0 {
+		t.Errorf("expected empty bank to have mastery 0, got %+v", banks[3])
+	}
+
+	byCat, err := s.ListBanksByCategoryWithMastery(ctx, emptyCat.ID, store.MasteryScopeAll)
+	if err != nil || len(byCat) != 0 {
+		t.Errorf("expected no banks in the empty category, got %v, %v", byCat, err)
+	}
+
+	cats, err := s.ListCategoriesWithMastery(ctx)
+	if err != nil {
+		t.Fatalf("ListCategoriesWithMastery: %v", err)
+	}
+	if len(cats) != 2 {
+		t.Fatalf("expected 2 categories, got %d", len(cats))
+	}
+	for _, c := range cats {
+		want, _ := s.GetCategoryMastery(ctx, c.ID)
+		if c.Mastery != want {
+			t.Errorf("category %q: expected mastery %d, got %d", c.Name, want, c.Mastery)
+		}
 	}
 }
 
-func TestGetCategoryMasteryBatch(t *testing.T) {
+func TestGetMasteryByBankType(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	cat1 := category.New("Go")
-	cat2 := category.New("Rust")
-	s.SaveCategory(ctx, cat1)
-	s.SaveCategory(ctx, cat2)
+	f := folder.New("Work")
+	s.SaveFolder(ctx, f)
+	cat := category.NewWithFolder("Go", f.ID)
+	s.SaveCategory(ctx, cat)
+	other := category.New("Unfiled")
+	s.SaveCategory(ctx, other)
+
+	seed := func(catID string, bankType questionbank.BankType, scores ...int) {
+		bank := questionbank.NewWithOptions(string(bankType), &catID, bankType, nil)
+		s.SaveBank(ctx, bank)
+		for range scores {
+			bank.AddQuestion("Q", "A")
+		}
+		for _, q := range bank.Questions {
+			s.AddQuestion(ctx, bank.ID, q)
+		}
+		full, _ := s.GetBank(ctx, bank.ID)
+		session := practicesession.New(full)
+		s.SaveSession(ctx, session)
+		for i, score := range scores {
+			if score >= 0 {
+				s.SaveGrade(ctx, session.ID, bank.Questions[i].ID, score, nil, nil, nil, nil, "answer", "", 0, nil)
+			}
+		}
+	}
+	// -1 leaves a question unanswered.
+	seed(cat.ID, questionbank.BankTypeTheory, 90, 70)
+	seed(cat.ID, questionbank.BankTypeCode, 40, -1)
+	seed(other.ID, questionbank.BankTypeCLI, 100)
 
-	seedBankWithScore(t, s, ctx, cat1.ID, 100)
-	seedBankWithScore(t, s, ctx, cat2.ID, 20)
+	got, err := s.GetMasteryByBankType(ctx, store.QuestionStatsFilter{})
+	if err != nil {
+		t.Fatalf("GetMasteryByBankType: %v", err)
+	}
+	want := []store.BankTypeMastery{
+		{BankType: "cli", QuestionCount: 1, AnsweredCount: 1, Mastery: 100},
+		{BankType: "code", QuestionCount: 2, AnsweredCount: 1, Mastery: 20},
+		{BankType: "theory", QuestionCount: 2, AnsweredCount: 2, Mastery: 80},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
 
-	result, err := s.GetCategoryMasteryBatch(ctx, []string{cat1.ID, cat2.ID})
+	got, err = s.GetMasteryByBankType(ctx, store.QuestionStatsFilter{FolderID: f.ID})
 	if err != nil {
-		t.Fatalf("GetCategoryMasteryBatch: %v", err)
+		t.Fatalf("GetMasteryByBankType scoped: %v", err)
 	}
-	if _, ok := result[cat1.ID]; !ok {
-		t.Errorf("expected mastery for category %s", cat1.ID)
+	if len(got) != 2 || got[0].BankType != "code" || got[1].BankType != "theory" {
+		t.Errorf("expected only code and theory in the folder, got %+v", got)
 	}
-	if _, ok := result[cat2.ID]; !ok {
-		t.Errorf("expected mastery for category %s", cat2.ID)
+}
+
+func TestMasteryWeighting_AttemptsVsQuestions(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	f := folder.New("Work")
+	s.SaveFolder(ctx, f)
+	cat := category.NewWithFolder("Go", f.ID)
+	s.SaveCategory(ctx, cat)
+
+	// One question mastered in a single attempt...
+	seedBankWithScore(t, s, ctx, cat.ID, 100)
+
+	// ...one failed three times...
+	failedBankID := seedBankWithScore(t, s, ctx, cat.ID, 0)
+	failed, _ := s.GetBank(ctx, failedBankID)
+	for i := 0; i < 2; i++ {
+		session := practicesession.New(failed)
+		s.SaveSession(ctx, session)
+		s.SaveGrade(ctx, session.ID, failed.Questions[0].ID, 0, nil, nil, nil, nil, "wrong", "", 0, nil)
+	}
+
+	// ...and one never answered.
+	unanswered := questionbank.NewWithCategory("Unanswered", cat.ID)
+	s.SaveBank(ctx, unanswered)
+	unanswered.AddQuestion("Q", "A")
+	s.AddQuestion(ctx, unanswered.ID, unanswered.Questions[0])
+
+	tests := []struct {
+		weighting store.MasteryWeighting
+		want      int
+	}{
+		{store.MasteryWeightingQuestions, 33}, // (100 + 0 + 0) / 3
+		{store.MasteryWeightingAttempts, 25},  // (100*1 + 0*3) / 4
+	}
+	for _, tt := range tests {
+		got, err := s.GetCategoryMasteryWeighted(ctx, cat.ID, tt.weighting)
+		if err != nil {
+			t.Fatalf("GetCategoryMasteryWeighted(%s): %v", tt.weighting, err)
+		}
+		if got != tt.want {
+			t.Errorf("category %s mastery: expected %d, got %d", tt.weighting, tt.want, got)
+		}
+
+		got, err = s.GetFolderMasteryWeighted(ctx, f.ID, tt.weighting)
+		if err != nil {
+			t.Fatalf("GetFolderMasteryWeighted(%s): %v", tt.weighting, err)
+		}
+		if got != tt.want {
+			t.Errorf("folder %s mastery: expected %d, got %d", tt.weighting, tt.want, got)
+		}
+	}
+
+	simple, _ := s.GetCategoryMastery(ctx, cat.ID)
+	if simple != 33 {
+		t.Errorf("expected default category mastery to stay the simple average, got %d", simple)
 	}
 }
 
-func TestGetFolderMasteryBatch(t *testing.T) {
+func TestMasteryWeighting_AttemptsAreCapped(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	f1 := folder.New("Work")
-	f2 := folder.New("Personal")
-	s.SaveFolder(ctx, f1)
-	s.SaveFolder(ctx, f2)
+	cat := category.New("Go")
+	s.SaveCategory(ctx, cat)
 
-	cat1 := category.NewWithFolder("Go", f1.ID)
-	cat2 := category.NewWithFolder("Rust", f2.ID)
-	s.SaveCategory(ctx, cat1)
-	s.SaveCategory(ctx, cat2)
+	// answer grades a bank's only question with score, times more times.
+	answer := func(bankID string, score, times int) {
+		bank, _ := s.GetBank(ctx, bankID)
+		for i := 0; i < times; i++ {
+			session := practicesession.New(bank)
+			s.SaveSession(ctx, session)
+			s.SaveGrade(ctx, session.ID, bank.Questions[0].ID, score, nil, nil, nil, nil, "answer", "", 0, nil)
+		}
+	}
 
-	seedBankWithScore(t, s, ctx, cat1.ID, 75)
-	seedBankWithScore(t, s, ctx, cat2.ID, 50)
+	// A question failed ten times and one aced three times weigh the same,
+	// so the drilled failure does not drag the category down to its level.
+	answer(seedBankWithScore(t, s, ctx, cat.ID, 0), 0, 9)
+	answer(seedBankWithScore(t, s, ctx, cat.ID, 100), 100, 2)
 
-	result, err := s.GetFolderMasteryBatch(ctx, []string{f1.ID, f2.ID})
+	got, err := s.GetCategoryMasteryWeighted(ctx, cat.ID, store.MasteryWeightingAttempts)
 	if err != nil {
-		t.Fatalf("GetFolderMasteryBatch: %v", err)
+		t.Fatalf("GetCategoryMasteryWeighted: %v", err)
 	}
-	if _, ok := result[f1.ID]; !ok {
-		t.Errorf("expected mastery for folder %s", f1.ID)
-	}
-	if _, ok := result[f2.ID]; !ok {
-		t.Errorf("expected mastery for folder %s", f2.ID)
+	if got != 50 { // (0*3 + 100*3) / 6; uncapped it would be (0*10 + 100*3) / 13 = 23
+		t.Errorf("expected the capped weighting to give 50, got %d", got)
 	}
 }
 
-func TestGetFolderMasteryBatch_Empty(t *testing.T) {
+func TestMasteryWeighting_AttemptsWithoutAnswers(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
 
-	result, err := s.GetFolderMasteryBatch(ctx, []string{})
+	cat := category.New("Empty")
+	s.SaveCategory(ctx, cat)
+
+	got, err := s.GetCategoryMasteryWeighted(ctx, cat.ID, store.MasteryWeightingAttempts)
 	if err != nil {
-		t.Fatalf("GetFolderMasteryBatch empty: %v", err)
+		t.Fatalf("GetCategoryMasteryWeighted: %v", err)
 	}
-	if len(result) != 0 {
-		t.Errorf("expected empty map, got %d entries", len(result))
+	if got != 0 {
+		t.Errorf("expected 0 for a category without answers, got %d", got)
 	}
 }
 
@@ -611,3 +2356,209 @@ func TestValidIdentifier_Panics(t *testing.T) {
 	// Passing an identifier with a semicolon should panic before touching the DB
 	store.ExposedAddColumnIfNotExists(nil, "bad;table", "col", "TEXT")
 }
+
+// ============================================================================
+// Audit log
+// ============================================================================
+
+func TestAuditLog_TrimsToNewest(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for i := range 5 {
+		entry := store.AuditEntry{EntityType: store.AuditEntityBank, EntityID: fmt.Sprintf("b%d", i), Action: store.AuditActionCreate, At: time.Now()}
+		if err := s.AppendAuditEntry(ctx, entry, 3); err != nil {
+			t.Fatalf("AppendAuditEntry: %v", err)
+		}
+	}
+
+	entries, err := s.ListAuditEntries(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListAuditEntries: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries kept, got %d", len(entries))
+	}
+	for i, want := range []string{"b4", "b3", "b2"} {
+		if entries[i].EntityID != want {
+			t.Errorf("entry %d: expected %s, got %s", i, want, entries[i].EntityID)
+		}
+	}
+}
+
+func TestAuditingStore_RecordsSuccessfulMutations(t *testing.T) {
+	s := newTestStore(t)
+	ctx := store.WithAuditActor(context.Background(), "alice")
+	audited := store.NewAuditingStore(s, 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Q1", "A1")
+	audited.SaveBank(ctx, bank)
+	audited.AddQuestions(ctx, bank.ID, bank.Questions)
+	audited.DeleteBank(ctx, bank.ID)
+	if err := audited.DeleteBank(ctx, "ghost"); err == nil {
+		t.Fatal("expected deleting an unknown bank to fail")
+	}
+
+	entries, _ := s.ListAuditEntries(ctx, 10)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if e := entries[0]; e.Action != store.AuditActionDelete || e.EntityID != bank.ID || e.Actor != "alice" {
+		t.Errorf("unexpected delete entry: %+v", e)
+	}
+	if e := entries[1]; e.EntityType != store.AuditEntityQuestion || e.EntityID != bank.Questions[0].ID {
+		t.Errorf("unexpected question entry: %+v", e)
+	}
+}
+
+func TestDecayMastery(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	bank := questionbank.New("Test")
+	bank.AddQuestion("Aced long ago", "A1")
+	bank.AddQuestion("Weak but recent", "A2")
+	s.SaveBank(ctx, bank)
+	s.AddQuestions(ctx, bank.ID, bank.Questions)
+	stale, recent := bank.Questions[0], bank.Questions[1]
+
+	full, _ := s.GetBank(ctx, bank.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, stale.ID, 95, nil, nil, nil, nil, "a", "", 0, nil)
+	s.SaveGrade(ctx, session.ID, recent.ID, 60, nil, nil, nil, nil, "b", "", 0, nil)
+
+	const halfLife = 30 * 24 * time.Hour
+	now := time.Now()
+	stats, _ := s.GetQuestionStats(ctx, stale.ID)
+	stats.LastAnswered = now.Add(-90 * 24 * time.Hour)
+	s.RestoreQuestionStats(ctx, *stats)
+
+	n, err := s.DecayMastery(ctx, halfLife, now)
+	if err != nil {
+		t.Fatalf("DecayMastery: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 question to decay, got %d", n)
+	}
+	if got, _ := s.GetQuestionStats(ctx, stale.ID); got.Mastery != 12 {
+		t.Errorf("expected mastery 95 to decay to 12 after three half-lives, got %d", got.Mastery)
+	}
+	if got, _ := s.GetQuestionStats(ctx, recent.ID); got.Mastery != 60 {
+		t.Errorf("expected a recent answer to keep mastery 60, got %d", got.Mastery)
+	}
+
+	// Decay starts from the answered mastery, so a second sweep changes nothing.
+	if n, _ := s.DecayMastery(ctx, halfLife, now); n != 0 {
+		t.Errorf("expected a repeated sweep to change nothing, got %d", n)
+	}
+
+	ordered, err := s.GetQuestionsOrderedByMastery(ctx, bank.ID, true)
+	if err != nil {
+		t.Fatalf("GetQuestionsOrderedByMastery: %v", err)
+	}
+	if len(ordered) != 2 || ordered[0].ID != stale.ID {
+		t.Errorf("expected the decayed question first in weakest-first order, got %+v", ordered)
+	}
+
+	// Answering again recomputes mastery from the scores.
+	retry := practicesession.New(full)
+	s.SaveSession(ctx, retry)
+	s.SaveGrade(ctx, retry.ID, stale.ID, 95, nil, nil, nil, nil, "a again", "", 0, nil)
+	if got, _ := s.GetQuestionStats(ctx, stale.ID); got.Mastery != 95 {
+		t.Errorf("expected mastery 95 after answering again, got %d", got.Mastery)
+	}
+}
+
+func TestMoveAndCopyQuestion(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	source := questionbank.New("Source")
+	source.AddQuestion("Q1", "A1")
+	target := questionbank.New("Target")
+	target.AddQuestion("Existing", "A")
+	s.SaveBank(ctx, source)
+	s.SaveBank(ctx, target)
+	q := source.Questions[0]
+	q.Tags = []string{"loops"}
+	s.AddQuestions(ctx, source.ID, []questionbank.Question{q})
+	s.AddQuestion(ctx, target.ID, target.Questions[0])
+
+	full, _ := s.GetBank(ctx, source.ID)
+	session := practicesession.New(full)
+	s.SaveSession(ctx, session)
+	s.SaveGrade(ctx, session.ID, q.ID, 80, nil, nil, nil, nil, "a", "", 0, nil)
+
+	if err := s.MoveQuestion(ctx, q.ID, target.ID); err != nil {
+		t.Fatalf("MoveQuestion: %v", err)
+	}
+	moved, _ := s.GetBank(ctx, target.ID)
+	if len(moved.Questions) != 2 || moved.Questions[1].ID != q.ID {
+		t.Fatalf("expected the question appended to the target bank, got %+v", moved.Questions)
+	}
+	if stats, _ := s.GetQuestionStats(ctx, q.ID); stats.TimesAnswered != 1 {
+		t.Errorf("expected stats to follow the question, got %+v", stats)
+	}
+
+	if err := s.CopyQuestion(ctx, q.ID, source.ID, "copy-id"); err != nil {
+		t.Fatalf("CopyQuestion: %v", err)
+	}
+	copied, err := s.GetQuestion(ctx, source.ID, "copy-id")
+	if err != nil {
+		t.Fatalf("GetQuestion: %v", err)
+	}
+	if copied.Subject != "Q1" || len(copied.Tags) != 1 || copied.Tags[0] != "loops" {
+		t.Errorf("expected content and tags copied, got %+v", copied)
+	}
+	if stats, _ := s.GetQuestionStats(ctx, "copy-id"); stats.TimesAnswered != 0 {
+		t.Errorf("expected the copy to start without stats, got %+v", stats)
+	}
+
+	if err := s.MoveQuestion(ctx, "ghost", target.ID); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound for an unknown question, got %v", err)
+	}
+	if err := s.CopyQuestion(ctx, q.ID, "ghost", "other-id"); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound for an unknown bank, got %v", err)
+	}
+}
+
+// BenchmarkSaveGrade grades the questions of a bank over and over,
+// exercising the question stats statements of every SaveGrade and reading
+// the bank's stats back after each round. The in-memory database leaves out
+// disk syncs, which dominate on disk.
+func BenchmarkSaveGrade(b *testing.B) {
+	b.Run("memory", func(b *testing.B) { benchmarkSaveGrade(b, ":memory:") })
+	b.Run("disk", func(b *testing.B) { benchmarkSaveGrade(b, b.TempDir()+"/bench.db") })
+}
+
+func benchmarkSaveGrade(b *testing.B, dbPath string) {
+	s, err := store.NewSQLite(dbPath)
+	if err != nil {
+		b.Fatalf("NewSQLite: %v", err)
+	}
+	b.Cleanup(func() { s.Close() })
+	ctx := context.Background()
+
+	bank := questionbank.New("Bench")
+	for i := range 20 {
+		bank.AddQuestion(fmt.Sprintf("Q%d", i), "A")
+	}
+	s.SaveBank(ctx, bank)
+	session := practicesession.New(bank)
+	s.SaveSession(ctx, session)
+
+	b.ResetTimer()
+	for i := range b.N {
+		q := session.Questions[i%len(session.Questions)]
+		if err := s.SaveGrade(ctx, session.ID, q.ID, i%101, nil, nil, nil, nil, "answer", "", 0, nil); err != nil {
+			b.Fatalf("SaveGrade: %v", err)
+		}
+		if i%len(session.Questions) == 0 {
+			if _, err := s.GetQuestionStatsByBank(ctx, bank.ID); err != nil {
+				b.Fatalf("GetQuestionStatsByBank: %v", err)
+			}
+		}
+	}
+}
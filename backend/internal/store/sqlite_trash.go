@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ============================================================================
+// Trash
+// ============================================================================
+
+// DeleteBank moves a bank to the trash. It and its questions are hidden from
+// every read until RestoreBank; their stats are kept. PurgeBank deletes for
+// good.
+func (s *SQLiteStore) DeleteBank(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixNano()
+	result, err := tx.ExecContext(ctx, "UPDATE banks SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", now, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	// The questions share the bank's timestamp, so RestoreBank brings back
+	// these and not the ones that were deleted on their own.
+	_, err = tx.ExecContext(ctx, "UPDATE questions SET deleted_at = ? WHERE bank_id = ? AND deleted_at IS NULL", now, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreBank takes a bank and the questions deleted with it out of the
+// trash. Returns ErrNotFound if the bank is not in the trash.
+func (s *SQLiteStore) RestoreBank(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletedAt int64
+	err = tx.QueryRowContext(ctx, "SELECT deleted_at FROM banks WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE questions SET deleted_at = NULL WHERE bank_id = ? AND deleted_at = ?", id, deletedAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE banks SET deleted_at = NULL WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteQuestion moves a question to the trash, keeping its stats.
+// PurgeQuestion deletes for good.
+func (s *SQLiteStore) DeleteQuestion(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE questions SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL",
+		time.Now().UnixNano(), id,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListTrash(ctx context.Context) (*Trash, error) {
+	trash := &Trash{Banks: []TrashedBank{}, Questions: []TrashedQuestion{}}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT b.id, b.subject, b.category_id,
+		       (SELECT COUNT(*) FROM questions q WHERE q.bank_id = b.id AND q.deleted_at = b.deleted_at),
+		       b.deleted_at
+		FROM banks b
+		WHERE b.deleted_at IS NOT NULL
+		ORDER BY b.deleted_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b TrashedBank
+		var categoryID sql.NullString
+		var deletedAt int64
+		if err := rows.Scan(&b.ID, &b.Subject, &categoryID, &b.QuestionCount, &deletedAt); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			b.CategoryID = &categoryID.String
+		}
+		b.DeletedAt = time.Unix(0, deletedAt)
+		trash.Banks = append(trash.Banks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT q.id, q.subject, q.bank_id, b.subject, q.deleted_at
+		FROM questions q
+		JOIN banks b ON b.id = q.bank_id
+		WHERE q.deleted_at IS NOT NULL AND (b.deleted_at IS NULL OR b.deleted_at <> q.deleted_at)
+		ORDER BY q.deleted_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q TrashedQuestion
+		var deletedAt int64
+		if err := rows.Scan(&q.ID, &q.Subject, &q.BankID, &q.BankSubject, &deletedAt); err != nil {
+			return nil, err
+		}
+		q.DeletedAt = time.Unix(0, deletedAt)
+		trash.Questions = append(trash.Questions, q)
+	}
+	return trash, rows.Err()
+}
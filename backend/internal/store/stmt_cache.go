@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// stmtCache holds statements prepared once when a store opens, keyed by
+// their SQL, so hot paths skip parsing and planning on every call. Queries
+// that are not cached run unprepared, so one-off queries need no
+// registering; a nil cache runs everything unprepared.
+type stmtCache map[string]*sql.Stmt
+
+// prepareStmts prepares queries on db. On failure the statements prepared
+// so far are closed.
+func prepareStmts(ctx context.Context, db *sql.DB, queries ...string) (stmtCache, error) {
+	c := make(stmtCache, len(queries))
+	for _, query := range queries {
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		c[query] = stmt
+	}
+	return c, nil
+}
+
+// close closes every cached statement.
+func (c stmtCache) close() error {
+	var errs []error
+	for _, stmt := range c {
+		errs = append(errs, stmt.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// query runs query on db, with its prepared statement when cached.
+func (c stmtCache) query(ctx context.Context, db *sql.DB, query string, args ...any) (*sql.Rows, error) {
+	if stmt, ok := c[query]; ok {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// txQueryRow runs query in tx, with its prepared statement when cached.
+func (c stmtCache) txQueryRow(ctx context.Context, tx *sql.Tx, query string, args ...any) *sql.Row {
+	if stmt, ok := c[query]; ok {
+		return tx.StmtContext(ctx, stmt).QueryRowContext(ctx, args...)
+	}
+	return tx.QueryRowContext(ctx, query, args...)
+}
+
+// txExec runs query in tx, with its prepared statement when cached.
+func (c stmtCache) txExec(ctx context.Context, tx *sql.Tx, query string, args ...any) (sql.Result, error) {
+	if stmt, ok := c[query]; ok {
+		return tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	}
+	return tx.ExecContext(ctx, query, args...)
+}
@@ -2,7 +2,9 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/remaimber-it/backend/internal/domain/category"
 	"github.com/remaimber-it/backend/internal/domain/folder"
@@ -14,6 +16,7 @@ var (
 	ErrNotFound         = errors.New("not found")
 	ErrSessionCompleted = errors.New("session already completed")
 	ErrSystemFolder     = errors.New("cannot modify system folder")
+	ErrVersionConflict  = errors.New("version conflict")
 )
 
 // Store defines the persistence contract for the application.
@@ -22,10 +25,11 @@ type Store interface {
 	SaveFolder(ctx context.Context, f *folder.Folder) error
 	GetFolder(ctx context.Context, id string) (*folder.Folder, error)
 	ListFolders(ctx context.Context) ([]*folder.Folder, error)
-	UpdateFolder(ctx context.Context, f *folder.Folder) error
+	UpdateFolder(ctx context.Context, f *folder.Folder) error // f.Version is the expected version; 0 skips the check
 	DeleteFolder(ctx context.Context, id string) error
 	GetFolderMastery(ctx context.Context, folderID string) (int, error)
 	GetFolderMasteryBatch(ctx context.Context, folderIDs []string) (map[string]int, error)
+	GetFolderMasteryWeighted(ctx context.Context, folderID string, weighting MasteryWeighting) (int, error)
 
 	// System "Deleted" folder
 	GetOrCreateDeletedFolder(ctx context.Context) (*folder.Folder, error)
@@ -36,64 +40,311 @@ type Store interface {
 	GetCategory(ctx context.Context, id string) (*category.Category, error)
 	ListCategories(ctx context.Context) ([]*category.Category, error)
 	ListCategoriesByFolder(ctx context.Context, folderID string) ([]*category.Category, error)
-	UpdateCategory(ctx context.Context, cat *category.Category) error
-	UpdateCategoryFolder(ctx context.Context, categoryID string, folderID *string) error
+	UpdateCategory(ctx context.Context, cat *category.Category) error // cat.Version is the expected version; 0 skips the check
+	UpdateCategoryFolder(ctx context.Context, categoryID string, folderID *string, expectedVersion int) error
+	SaveCategoryWithBanks(ctx context.Context, cat *category.Category, banks []*questionbank.QuestionBank) error // Atomically insert a category with its banks and questions
 	ReorderCategories(ctx context.Context, ids []string) error
 	DeleteCategory(ctx context.Context, id string) error
+	DeleteCategoryReassigning(ctx context.Context, id, targetID string) error      // Move banks to targetID, then delete
+	ListCategoriesWithMastery(ctx context.Context) ([]*CategoryWithMastery, error) // One aggregate query; mastery matches GetCategoryMastery
 	GetCategoryMastery(ctx context.Context, categoryID string) (int, error)
 	GetCategoryMasteryBatch(ctx context.Context, categoryIDs []string) (map[string]int, error)
+	GetCategoryMasteryWeighted(ctx context.Context, categoryID string, weighting MasteryWeighting) (int, error)
 
 	// Global stats
 	GetOverallMastery(ctx context.Context) (int, error)
+	GetLibraryOverview(ctx context.Context) (*LibraryOverview, error)
+	StreamQuestionStats(ctx context.Context, filter QuestionStatsFilter, fn func(QuestionStatsRow) error) error // Calls fn once per question; stops at fn's first error
+	GetMasteryByBankType(ctx context.Context, filter QuestionStatsFilter) ([]BankTypeMastery, error)
+	Search(ctx context.Context, query SearchQuery) ([]SearchHit, error) // Best matches first: exact phrases, then by relevance
 
 	// Banks
 	SaveBank(ctx context.Context, bank *questionbank.QuestionBank) error
+	SaveBankWithQuestions(ctx context.Context, bank *questionbank.QuestionBank) error // Atomically insert a bank with its questions
 	GetBank(ctx context.Context, id string) (*questionbank.QuestionBank, error)
 	ListBanks(ctx context.Context) ([]*questionbank.QuestionBank, error)
 	ListBanksWithCounts(ctx context.Context) ([]*BankWithCount, error)
 	ListBanksByCategory(ctx context.Context, categoryID string) ([]*questionbank.QuestionBank, error)
-	UpdateBankCategory(ctx context.Context, bankID string, categoryID *string) error
+	ListBanksWithMastery(ctx context.Context, scope MasteryScope) ([]*BankWithMastery, error) // One aggregate query; mastery matches GetBankMasteryScoped
+	ListBanksByCategoryWithMastery(ctx context.Context, categoryID string, scope MasteryScope) ([]*BankWithMastery, error)
+	UpdateBankCategory(ctx context.Context, bankID string, categoryID *string, expectedVersion int) error
 	DeleteBank(ctx context.Context, id string) error
-	GetBankMastery(ctx context.Context, bankID string) (int, error)
-	GetBankMasteryBatch(ctx context.Context, bankIDs []string) (map[string]int, error)
+	UpdateBankTags(ctx context.Context, bankIDs []string, add, remove []string) (*BankTagUpdate, error)
+	LogBankReview(ctx context.Context, bankID string, at time.Time) error
+	ListBankReviews(ctx context.Context, bankID string, limit int) ([]time.Time, error)
+	GetBankMastery(ctx context.Context, bankID string) (int, error) // Weighted by question difficulty; see bankMasterySQL
+	GetBankMasteryScoped(ctx context.Context, bankID string, scope MasteryScope) (int, error)
+	GetBankMasteryBatch(ctx context.Context, bankIDs []string, scope MasteryScope) (map[string]int, error)
+	GetUnansweredCountBatch(ctx context.Context, bankIDs []string) (map[string]int, error)
 
 	// Questions
+	GetQuestion(ctx context.Context, bankID, questionID string) (*questionbank.Question, error)
+	SetQuestionTags(ctx context.Context, bankID, questionID string, tags []string) error
+	GetQuestionStats(ctx context.Context, questionID string) (*questionbank.QuestionStats, error)
+	RestoreQuestionStats(ctx context.Context, stats questionbank.QuestionStats) error     // Overwrites the aggregate; streak is not stored
+	ResetQuestionStats(ctx context.Context, questionID string) error                      // Zeroes the aggregate; grade history is kept
+	ResetBankStats(ctx context.Context, bankID string) error                              // ResetQuestionStats for every question of the bank
+	DecayMastery(ctx context.Context, halfLife time.Duration, now time.Time) (int, error) // Decays stored mastery by time since last answered; returns questions changed
+	SetPassThreshold(score int)                                                           // Minimum score counted in times_correct and streaks; not applied retroactively
+	PassThreshold() int
 	AddQuestion(ctx context.Context, bankID string, question questionbank.Question) error
-	UpdateQuestion(ctx context.Context, question questionbank.Question) error
+	AddQuestions(ctx context.Context, bankID string, questions []questionbank.Question) error
+	MoveQuestion(ctx context.Context, questionID, targetBankID string) error        // Keeps the ID, so stats and grades follow
+	CopyQuestion(ctx context.Context, questionID, targetBankID, newID string) error // Copies content and tags; the copy has no stats
+	UpdateQuestion(ctx context.Context, question questionbank.Question) error       // Content only; difficulty is kept
+	UpdateQuestionDifficulty(ctx context.Context, questionID string, difficulty questionbank.Difficulty) error
 	DeleteQuestion(ctx context.Context, id string) error
 	GetQuestionStatsByBank(ctx context.Context, bankID string) ([]questionbank.QuestionStats, error)
 	GetQuestionsOrderedByMastery(ctx context.Context, bankID string, ascending bool) ([]questionbank.Question, error)
+	GetQuestionsUnansweredFirst(ctx context.Context, bankID string) ([]questionbank.Question, error)
 	GetWeakQuestionsAcrossBanks(ctx context.Context, bankIDs []string, maxPerBank int) ([]QuestionWithBank, error)
+	GetWeakestQuestionsInCategory(ctx context.Context, categoryID string, limit int) ([]QuestionWithBank, error)
+	GetWeakestAnsweredQuestions(ctx context.Context, limit int) ([]QuestionWithBank, error) // Answered questions of every bank, lowest mastery first
+
+	// Trash: DeleteBank and DeleteQuestion move items here
+	RestoreBank(ctx context.Context, id string) error
+	PurgeBank(ctx context.Context, id string) error
+	PurgeQuestion(ctx context.Context, id string) error
+	ListTrash(ctx context.Context) (*Trash, error)
 
 	// Sessions
-	SaveSession(ctx context.Context, session *practicesession.PracticeSession) error
+	SaveSession(ctx context.Context, session *practicesession.PracticeSession) error // Sets a zero StartedAt to now
 	GetSession(ctx context.Context, id string) (*practicesession.PracticeSession, error)
 	CompleteSession(ctx context.Context, id string) error
+	RestartSession(ctx context.Context, session *practicesession.PracticeSession) error // Clear an active session's grades and answers, store its question order and restart its timer
 	GetSessionQuestionBankID(ctx context.Context, sessionID, questionID string) (string, error)
+	ListIncompleteSessions(ctx context.Context, startedBefore time.Time) ([]IncompleteSession, error) // Active sessions started before the cutoff, oldest first
+	ListSessionsByBank(ctx context.Context, bankID string) ([]SessionSummary, error)                  // Newest first
+	AverageTimePerQuestion(ctx context.Context, bankID string) (time.Duration, int, error)
+	ListSessionActivity(ctx context.Context) ([]SessionActivity, error) // Every session with a known start time, oldest first
 
 	// Grades
-	SaveGrade(ctx context.Context, sessionID string, questionID string, score int, covered, missed []string, userAnswer string) error
-	SaveGradeFailure(ctx context.Context, sessionID string, questionID string, userAnswer string, reason string) error
+	SaveGrade(ctx context.Context, sessionID string, questionID string, score int, covered, missed []string, coveredIndices, missedIndices []int, userAnswer string, model string, duration time.Duration, matchedAnswer *int) error // matchedAnswer is the index of the expected answer graded against, nil when the question has only one
+	SaveGradeFailure(ctx context.Context, sessionID string, questionID string, userAnswer string, reason string, rawResponse string) error
+	ListGradeFailures(ctx context.Context, limit int) ([]GradeFailure, error)
 	GetGrades(ctx context.Context, sessionID string) ([]StoredGrade, error)
+	SavePendingAnswer(ctx context.Context, answer PendingAnswer) error // Replaces an earlier answer to the same question; SaveGrade and SaveGradeFailure settle it, and drop results for an answer it replaced
+	ListPendingAnswers(ctx context.Context) ([]PendingAnswer, error)   // Answers not graded yet, oldest first
+	DeletePendingAnswer(ctx context.Context, sessionID string, questionID string) error
+	GetGradesByQuestion(ctx context.Context, questionID string, limit int) ([]QuestionGrade, error)
+
+	// Audit log: see AuditingStore
+	AppendAuditEntry(ctx context.Context, entry AuditEntry, keep int) error // keep > 0 trims the log to the newest keep entries
+	ListAuditEntries(ctx context.Context, limit int) ([]AuditEntry, error)  // Newest first
+
+	// Integrity
+	FindOrphans(ctx context.Context) ([]OrphanCount, error)   // rows whose parent row is gone, per table
+	DeleteOrphans(ctx context.Context) ([]OrphanCount, error) // remove them in one transaction; counts removed rows
 
 	// Lifecycle
 	Close() error
 }
 
+// MasteryWeighting selects how question masteries are aggregated into a
+// category or folder mastery.
+type MasteryWeighting string
+
+const (
+	// MasteryWeightingQuestions averages over every question, answered or
+	// not. A category weights its questions by difficulty like a bank does;
+	// a folder counts each once. This is the default.
+	MasteryWeightingQuestions MasteryWeighting = "questions"
+	// MasteryWeightingAttempts weights each answered question by its answer
+	// count capped at 3: a question gains weight over its first three
+	// answers, as its mastery becomes trustworthy, then stays flat, so a
+	// question answered fifty times counts no more than one answered three
+	// times. Never-answered questions do not count.
+	MasteryWeightingAttempts MasteryWeighting = "attempts"
+)
+
+// IsValid reports whether w is a known weighting.
+func (w MasteryWeighting) IsValid() bool {
+	return w == MasteryWeightingQuestions || w == MasteryWeightingAttempts
+}
+
+// MasteryScope selects which questions of a bank count towards its mastery.
+type MasteryScope string
+
+const (
+	// MasteryScopeAll counts every question, never-answered ones as 0, so
+	// adding questions lowers the bank's mastery until they are practised.
+	// This is the default.
+	MasteryScopeAll MasteryScope = "all"
+	// MasteryScopeAnswered counts only questions that have been answered at
+	// least once; a bank with no answers has mastery 0.
+	MasteryScopeAnswered MasteryScope = "answered"
+)
+
+// IsValid reports whether s is a known scope.
+func (s MasteryScope) IsValid() bool {
+	return s == MasteryScopeAll || s == MasteryScopeAnswered
+}
+
+// BankTagUpdate reports the effect of UpdateBankTags, which applies to all
+// banks or none and fails with ErrNotFound if any bank is missing: how many
+// tags were actually added and removed, and the resulting tags of each bank.
+type BankTagUpdate struct {
+	Added   int
+	Removed int
+	Tags    map[string][]string
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, for helpers that run
+// inside or outside a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
 type GradeStatus string
 
 const (
 	GradeStatusSuccess GradeStatus = "success"
 	GradeStatusFailed  GradeStatus = "failed"
+	GradeStatusPending GradeStatus = "pending" // only for session answers: submitted, not graded yet
 )
 
+// PendingAnswer is a submitted answer whose grading has not finished. It is
+// persisted before grading starts, so it can be graded again after a
+// restart.
+type PendingAnswer struct {
+	SessionID      string
+	QuestionID     string
+	UserAnswer     string
+	SelfCovered    []int  // nil when not self-checked
+	AnswerLanguage string // empty assumes the expected answer's language
+	SubmittedAt    time.Time
+}
+
 type StoredGrade struct {
-	QuestionID string
-	Score      int
-	Covered    []string
-	Missed     []string
-	UserAnswer string
-	Status     GradeStatus
+	QuestionID     string
+	Score          int
+	Covered        []string
+	Missed         []string
+	CoveredIndices []int // positions in the expected answer's key points
+	MissedIndices  []int
+	UserAnswer     string
+	Status         GradeStatus
+	GradedAt       time.Time     // zero for grades saved before grading times were recorded
+	Model          string        // model that graded the answer, "exact" for exact grading; empty for failures and older grades
+	Duration       time.Duration // how long grading took, in whole milliseconds; meaningful only when Model is set
+	MatchedAnswer  *int          // index into the question's expected answers the grade matched; nil unless it had alternatives
+}
+
+// QuestionGrade is a grade of a question in one of its sessions.
+type QuestionGrade struct {
+	StoredGrade
+	SessionID string
+}
+
+// GradeFailure is a failed grade with the reason it failed. RawResponse
+// holds the model output when it could not be parsed, and is empty
+// otherwise.
+type GradeFailure struct {
+	SessionID   string
+	QuestionID  string
+	UserAnswer  string
+	Reason      string
+	RawResponse string
+	FailedAt    time.Time
+}
+
+// QuestionStatsFilter scopes StreamQuestionStats and GetMasteryByBankType.
+// Empty fields match everything.
+type QuestionStatsFilter struct {
+	FolderID   string
+	CategoryID string
+}
+
+// QuestionStatsRow is one question with its place in the hierarchy and its
+// stats. Never-answered questions have zero stats; banks without a category
+// and categories without a folder have empty names.
+type QuestionStatsRow struct {
+	QuestionID     string
+	Question       string
+	ExpectedAnswer string
+	BankID         string
+	Bank           string
+	CategoryID     string
+	Category       string
+	FolderID       string
+	Folder         string
+	TimesAnswered  int
+	TimesCorrect   int
+	LatestScore    int
+	Mastery        int
+}
+
+// BankTypeMastery aggregates the questions of every bank of one type.
+// Mastery averages all questions, counting never-answered ones as 0.
+type BankTypeMastery struct {
+	BankType      string
+	QuestionCount int
+	AnsweredCount int
+	Mastery       int
+}
+
+// SearchQuery describes a full-text search over question subjects, expected
+// answers and bank subjects. BankType, when set, restricts hits to banks of
+// that type; a non-positive Limit returns every hit.
+type SearchQuery struct {
+	Text     string
+	BankType string
+	Limit    int
+}
+
+// SearchHit is one search match. QuestionID is empty when the bank subject
+// itself matched. Field names the matched text ("subject", "expected_answer"
+// or "bank_subject") and Snippet is an HTML-escaped excerpt of it with
+// matched terms wrapped in <mark></mark>. Exact reports whether the whole query matched
+// as a phrase.
+type SearchHit struct {
+	BankID      string
+	BankSubject string
+	BankType    string
+	QuestionID  string
+	Field       string
+	Snippet     string
+	Exact       bool
+}
+
+// IncompleteSession summarises an active session. StartedAt is zero for
+// sessions saved before start times were recorded.
+type IncompleteSession struct {
+	ID            string
+	BankID        string
+	StartedAt     time.Time
+	MaxDuration   *int // minutes
+	QuestionCount int
+	AnsweredCount int // questions with a stored grade, successful or failed
+}
+
+// SessionSummary summarises a session of a bank. CreatedAt is zero for
+// sessions saved before start times were recorded.
+type SessionSummary struct {
+	ID            string
+	Status        practicesession.SessionStatus
+	CreatedAt     time.Time
+	QuestionCount int
+	AnsweredCount int // questions with a stored grade, successful or failed
+	TotalScore    int // sum of the stored grades' scores
+}
+
+// SessionActivity is what one session contributes to practice activity.
+type SessionActivity struct {
+	StartedAt time.Time
+	Status    practicesession.SessionStatus
+	Answered  int // successfully graded answers
+}
+
+// LibraryOverview counts everything in the library, trashed banks and
+// questions and the system "Deleted" folder excluded.
+type LibraryOverview struct {
+	Folders             int
+	Categories          int
+	Banks               int
+	Questions           int
+	UnansweredQuestions int
+	Mastery             int // average over all questions, never-answered ones counting as 0
 }
 
 // QuestionWithBank holds a question along with its bank ID and mastery score
@@ -102,7 +353,33 @@ type QuestionWithBank struct {
 	Subject        string
 	ExpectedAnswer string
 	BankID         string
+	BankSubject    string // only set by GetWeakestQuestionsInCategory and GetWeakestAnsweredQuestions
 	Mastery        int
+	TimesAnswered  int // only set by GetWeakestQuestionsInCategory and GetWeakestAnsweredQuestions
+}
+
+// Trash lists what DeleteBank and DeleteQuestion moved to the trash, most
+// recently deleted first. Questions trashed along with their bank are
+// counted in the bank's QuestionCount rather than listed.
+type Trash struct {
+	Banks     []TrashedBank
+	Questions []TrashedQuestion
+}
+
+type TrashedBank struct {
+	ID            string
+	Subject       string
+	CategoryID    *string
+	QuestionCount int
+	DeletedAt     time.Time
+}
+
+type TrashedQuestion struct {
+	ID          string
+	Subject     string
+	BankID      string
+	BankSubject string
+	DeletedAt   time.Time
 }
 
 // BankWithCount holds a question bank with its question count
@@ -113,4 +390,40 @@ type BankWithCount struct {
 	BankType      string
 	Language      *string
 	QuestionCount int
+	Version       int
+}
+
+// BankWithMastery holds a bank with its question count and mastery.
+type BankWithMastery struct {
+	BankWithCount
+	Mastery int
+}
+
+// CategoryWithMastery holds a category with its mastery.
+type CategoryWithMastery struct {
+	category.Category
+	Mastery int
+}
+
+// questionWeightSQL is questionbank.Difficulty.Weight for the questions
+// table aliased q, and NULL on a row where an outer join found no question.
+const questionWeightSQL = "CASE WHEN q.id IS NULL THEN NULL WHEN q.difficulty = 'easy' THEN 1 WHEN q.difficulty = 'hard' THEN 3 ELSE 2 END"
+
+// attemptsWeightSQL is a question's weight under MasteryWeightingAttempts
+// for the question_stats table aliased qs: times_answered, capped at 3.
+const attemptsWeightSQL = "CASE WHEN qs.times_answered < 3 THEN qs.times_answered ELSE 3 END"
+
+// attemptsMasterySQL aggregates answered questions into a mastery weighted
+// by attemptsWeightSQL. It is the same in both SQL dialects.
+const attemptsMasterySQL = "CAST(SUM(qs.mastery * " + attemptsWeightSQL + ") AS DOUBLE PRECISION) / SUM(" + attemptsWeightSQL + ")"
+
+// bankMasterySQL aggregates the questions of a bank, or of all the banks of
+// a category, into its mastery: the average question mastery weighted by
+// difficulty, over the questions scope counts. It is the same in both SQL
+// dialects and yields a floating-point value (NULL when no question counts).
+func bankMasterySQL(scope MasteryScope) string {
+	if scope == MasteryScopeAnswered {
+		return "CAST(SUM(qs.mastery * " + questionWeightSQL + ") AS DOUBLE PRECISION) / SUM(CASE WHEN qs.question_id IS NOT NULL THEN " + questionWeightSQL + " END)"
+	}
+	return "CAST(SUM(COALESCE(qs.mastery, 0) * " + questionWeightSQL + ") AS DOUBLE PRECISION) / SUM(" + questionWeightSQL + ")"
 }
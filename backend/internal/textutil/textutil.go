@@ -0,0 +1,18 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WordSet lowercases s and returns its distinct words, ignoring punctuation.
+func WordSet(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
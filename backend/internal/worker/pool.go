@@ -0,0 +1,64 @@
+// Package worker bounds how much work runs at once.
+package worker
+
+import "sync"
+
+// Pool bounds how many tasks run at once. Its size is fixed when it is
+// created, and it is safe for concurrent use.
+type Pool struct {
+	slots chan struct{} // one token per running task; nil when unbounded
+}
+
+// NewPool returns a Pool running at most size tasks at once. A size below 1
+// leaves it unbounded.
+func NewPool(size int) *Pool {
+	if size < 1 {
+		return &Pool{}
+	}
+	return &Pool{slots: make(chan struct{}, size)}
+}
+
+// Do runs task on the calling goroutine once the pool has room for it, and
+// returns when it is done.
+func (p *Pool) Do(task func()) {
+	p.acquire()
+	defer p.release()
+	task()
+}
+
+// Run calls fn(i) for every i in [0, n) through the pool and returns once
+// all calls have. A pool of size 1 makes the calls in order on the calling
+// goroutine; otherwise each runs on its own goroutine, started once the pool
+// has room for it.
+func (p *Pool) Run(n int, fn func(i int)) {
+	if cap(p.slots) == 1 {
+		for i := range n {
+			p.Do(func() { fn(i) })
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range n {
+		p.acquire()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer p.release()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *Pool) acquire() {
+	if p.slots != nil {
+		p.slots <- struct{}{}
+	}
+}
+
+func (p *Pool) release() {
+	if p.slots != nil {
+		<-p.slots
+	}
+}
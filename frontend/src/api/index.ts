@@ -44,11 +44,18 @@ export async function createFolder(name: string): Promise<Folder> {
   return res.json();
 }
 
-export async function updateFolder(id: string, name: string): Promise<Folder> {
+// Updates send the version the change is based on; the server rejects them
+// with 409 if the entity changed since.
+
+export async function updateFolder(
+  id: string,
+  name: string,
+  version: number
+): Promise<Folder> {
   const res = await fetch(`${API_BASE}/folders/${id}`, {
     method: "PUT",
     headers: { "Content-Type": "application/json" },
-    body: JSON.stringify({ name }),
+    body: JSON.stringify({ name, version }),
   });
   if (!res.ok) throw new Error("Failed to rename folder");
   return res.json();
@@ -97,12 +104,13 @@ export async function createCategory(
 
 export async function updateCategory(
   id: string,
-  name: string
+  name: string,
+  version: number
 ): Promise<Category> {
   const res = await fetch(`${API_BASE}/categories/${id}`, {
     method: "PUT",
     headers: { "Content-Type": "application/json" },
-    body: JSON.stringify({ name }),
+    body: JSON.stringify({ name, version }),
   });
   if (!res.ok) throw new Error("Failed to update category");
   return res.json();
@@ -110,12 +118,13 @@ export async function updateCategory(
 
 export async function updateCategoryFolder(
   categoryId: string,
-  folderId: string | null
+  folderId: string | null,
+  version: number
 ): Promise<Category> {
   const res = await fetch(`${API_BASE}/categories/${categoryId}/folder`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
-    body: JSON.stringify({ folder_id: folderId }),
+    body: JSON.stringify({ folder_id: folderId, version }),
   });
   if (!res.ok) throw new Error("Failed to update category folder");
   return res.json();
@@ -173,12 +182,13 @@ export async function createBank(
 
 export async function updateBankCategory(
   bankId: string,
-  categoryId: string | null
+  categoryId: string | null,
+  version: number
 ): Promise<Bank> {
   const res = await fetch(`${API_BASE}/banks/${bankId}/category`, {
     method: "PATCH",
     headers: { "Content-Type": "application/json" },
-    body: JSON.stringify({ category_id: categoryId }),
+    body: JSON.stringify({ category_id: categoryId, version }),
   });
   if (!res.ok) throw new Error("Failed to update bank category");
   return res.json();
@@ -44,9 +44,12 @@ export function LibraryActionsProvider({
   selectedFolderIdRef,
   selectedCategoryIdRef,
 }: Props) {
-  const { setFolders, setCategories, setBanks, refreshAll, categories } = useLibraryData();
+  const { setFolders, setCategories, setBanks, refreshAll, folders, categories } = useLibraryData();
 
-  // Ref so deleteFolder/deleteCategory can read categories without deps
+  // Refs so actions can read folders and categories (and their versions)
+  // without deps
+  const foldersRef = useRef(folders);
+  foldersRef.current = folders;
   const categoriesRef = useRef(categories);
   categoriesRef.current = categories;
 
@@ -59,7 +62,8 @@ export function LibraryActionsProvider({
   }, [setFolders, setSelectedFolderId, setSelectedCategoryId]);
 
   const updateFolder = useCallback(async (folderId: string, name: string) => {
-    const updated = await api.updateFolder(folderId, name);
+    const version = foldersRef.current.find((f) => f.id === folderId)?.version ?? 0;
+    const updated = await api.updateFolder(folderId, name, version);
     setFolders((prev) => prev.map((f) => (f.id === folderId ? updated : f)));
     return updated;
   }, [setFolders]);
@@ -67,12 +71,12 @@ export function LibraryActionsProvider({
   const deleteFolder = useCallback(async (folderId: string) => {
     const currentCategories = categoriesRef.current;
     const folderCategories = currentCategories.filter((c) => c.folder_id === folderId);
-    await Promise.all(folderCategories.map((c) => api.updateCategoryFolder(c.id, null)));
-
-    setCategories((prev) =>
-      prev.map((c) => (c.folder_id === folderId ? { ...c, folder_id: null } : c))
+    const unfiled = await Promise.all(
+      folderCategories.map((c) => api.updateCategoryFolder(c.id, null, c.version))
     );
 
+    setCategories((prev) => prev.map((c) => unfiled.find((u) => u.id === c.id) ?? c));
+
     await api.deleteFolder(folderId);
     setFolders((prev) => prev.filter((f) => f.id !== folderId));
 
@@ -88,13 +92,15 @@ export function LibraryActionsProvider({
   }, [setCategories]);
 
   const updateCategory = useCallback(async (categoryId: string, name: string) => {
-    const updated = await api.updateCategory(categoryId, name);
+    const version = categoriesRef.current.find((c) => c.id === categoryId)?.version ?? 0;
+    const updated = await api.updateCategory(categoryId, name, version);
     setCategories((prev) => prev.map((c) => (c.id === categoryId ? updated : c)));
     return updated;
   }, [setCategories]);
 
   const moveCategory = useCallback(async (categoryId: string, folderId: string | null) => {
-    const updated = await api.updateCategoryFolder(categoryId, folderId);
+    const version = categoriesRef.current.find((c) => c.id === categoryId)?.version ?? 0;
+    const updated = await api.updateCategoryFolder(categoryId, folderId, version);
     setCategories((prev) => prev.map((c) => (c.id === categoryId ? updated : c)));
     return updated;
   }, [setCategories]);
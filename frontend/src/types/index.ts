@@ -5,6 +5,7 @@ export type Folder = {
   name: string;
   is_system?: boolean;
   mastery: number;
+  version: number;
 };
 
 export type Category = {
@@ -14,6 +15,7 @@ export type Category = {
   folder_id?: string | null;
   sort_order?: number;
   banks?: Bank[];
+  version: number;
 };
 
 export type BankType = "theory" | "code" | "cli";
@@ -27,6 +29,7 @@ export type Bank = {
   mastery: number;
   question_count?: number;
   questions?: Question[];
+  version: number;
 };
 
 export type Question = {